@@ -16,12 +16,14 @@ package pmax
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/dell/gopowermax/api"
 	types "github.com/dell/gopowermax/types/v90"
 	log "github.com/sirupsen/logrus"
 )
@@ -29,6 +31,8 @@ import (
 // The following constants are for internal use within the pmax library.
 const (
 	XRDFGroup = "/rdf_group"
+	XRDFDir   = "/rdf_director"
+	XPort     = "/port"
 	ASYNC     = "ASYNC"
 	METRO     = "METRO"
 	SYNC      = "SYNC"
@@ -40,7 +44,7 @@ func (c *Client) GetRDFGroup(ctx context.Context, symID, rdfGroupNo string) (*ty
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	URL := c.urlPrefix() + ReplicationX + SymmetrixX + symID + XRDFGroup + "/" + rdfGroupNo
 	resp, err := c.api.DoAndGetResponseBody(ctx, http.MethodGet, URL, c.getDefaultHeaders(), nil)
@@ -54,19 +58,173 @@ func (c *Client) GetRDFGroup(ctx context.Context, symID, rdfGroupNo string) (*ty
 	}
 
 	rdfGrpInfo := new(types.RDFGroup)
-	if err := json.NewDecoder(resp.Body).Decode(rdfGrpInfo); err != nil {
+	if err := api.DecodeJSON(resp, rdfGrpInfo); err != nil {
 		return nil, err
 	}
 	return rdfGrpInfo, nil
 }
 
+// SRDFProtectionSpec describes the SRDF protection of a storage group, see CreateSGReplica.
+// RemoteStorageGroupID defaults to the name of the local storage group.
+type SRDFProtectionSpec struct {
+	RemoteSymID          string
+	RDFMode              string
+	RDFGroupNo           string
+	RemoteStorageGroupID string
+	RemoteServiceLevel   string
+	Bias                 bool
+}
+
+// ProtectionSpec describes the protection CreateProtectedStorageGroup applies to a new storage group:
+// the snapshot policies to associate and, if SRDF is set, the SRDF protection.
+type ProtectionSpec struct {
+	SnapshotPolicies []string
+	SRDF             *SRDFProtectionSpec
+}
+
+// ProtectionRollbackError is returned by CreateProtectedStorageGroup when it failed and could not undo
+// all the steps already done. LeftBehind describes what is left on the arrays, for the caller to remove.
+type ProtectionRollbackError struct {
+	StorageGroupID string
+	Err            error
+	LeftBehind     []string
+}
+
+func (e *ProtectionRollbackError) Error() string {
+	return fmt.Sprintf("%s, and the rollback left behind %s", e.Err.Error(), strings.Join(e.LeftBehind, ", "))
+}
+
+// Unwrap returns the error which caused the rollback
+func (e *ProtectionRollbackError) Unwrap() error {
+	return e.Err
+}
+
+// CreateProtectedStorageGroup creates a storage group, associates the snapshot policies of spec with it
+// and protects it with SRDF if requested. If any step fails, the steps already done are undone: the
+// storage group is deleted, as well as the remote storage group if the failed SRDF protection created it,
+// so that a failed call leaves nothing behind. Anything the rollback could not remove is listed
+// in the ProtectionRollbackError returned.
+func (c *Client) CreateProtectedStorageGroup(ctx context.Context, symID, storageGroupID, srpID, serviceLevel string, thickVolumes bool, spec ProtectionSpec) (*types.StorageGroup, error) {
+	defer c.TimeSpent("CreateProtectedStorageGroup", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	sg, err := c.CreateStorageGroup(ctx, symID, storageGroupID, srpID, serviceLevel, thickVolumes)
+	if err != nil {
+		return nil, err
+	}
+
+	policiesAssociated := false
+	// remoteSymID and remoteStorageGroupID are set while the remote storage group may have been created by this call
+	remoteSymID, remoteStorageGroupID := "", ""
+	rollback := func(cause error) error {
+		// undo the changes even if ctx is the reason of the failure
+		rollbackCtx := ctx
+		if ctx.Err() != nil {
+			rollbackCtx = context.Background()
+		}
+		leftBehind := make([]string, 0)
+		if remoteStorageGroupID != "" {
+			_, err := c.GetStorageGroup(rollbackCtx, remoteSymID, remoteStorageGroupID)
+			if err == nil {
+				err = c.DeleteStorageGroup(rollbackCtx, remoteSymID, remoteStorageGroupID)
+			}
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				log.Error(fmt.Sprintf("Failed to delete remote storage group %s: %s", remoteStorageGroupID, err.Error()))
+				leftBehind = append(leftBehind, fmt.Sprintf("storage group %s on array %s", remoteStorageGroupID, remoteSymID))
+			}
+		}
+		if policiesAssociated {
+			if err := c.DisassociateSnapshotPolicies(rollbackCtx, symID, storageGroupID, spec.SnapshotPolicies); err != nil {
+				log.Error(fmt.Sprintf("Failed to disassociate snapshot policies %v from storage group %s: %s", spec.SnapshotPolicies, storageGroupID, err.Error()))
+			}
+		}
+		if err := c.DeleteStorageGroup(rollbackCtx, symID, storageGroupID); err != nil {
+			log.Error(fmt.Sprintf("Failed to delete storage group %s: %s", storageGroupID, err.Error()))
+			leftBehind = append(leftBehind, fmt.Sprintf("storage group %s on array %s", storageGroupID, symID))
+		}
+		if len(leftBehind) > 0 {
+			return &ProtectionRollbackError{StorageGroupID: storageGroupID, Err: cause, LeftBehind: leftBehind}
+		}
+		return cause
+	}
+
+	if len(spec.SnapshotPolicies) > 0 {
+		if err = c.AssociateSnapshotPolicies(ctx, symID, storageGroupID, spec.SnapshotPolicies); err != nil {
+			log.Error("CreateProtectedStorageGroup failed: " + err.Error())
+			return nil, rollback(fmt.Errorf("failed to associate snapshot policies %v with storage group %s: %w", spec.SnapshotPolicies, storageGroupID, err))
+		}
+		policiesAssociated = true
+	}
+	if srdf := spec.SRDF; srdf != nil {
+		remoteSGID := srdf.RemoteStorageGroupID
+		if remoteSGID == "" {
+			remoteSGID = storageGroupID
+		}
+		// a remote storage group which already exists is not ours to delete
+		if _, err = c.GetStorageGroup(ctx, srdf.RemoteSymID, remoteSGID); errors.Is(err, ErrNotFound) {
+			remoteSymID, remoteStorageGroupID = srdf.RemoteSymID, remoteSGID
+		}
+		_, err = c.CreateSGReplica(ctx, symID, srdf.RemoteSymID, srdf.RDFMode, srdf.RDFGroupNo, storageGroupID, remoteSGID, srdf.RemoteServiceLevel, srdf.Bias)
+		if err != nil {
+			log.Error("CreateProtectedStorageGroup failed: " + err.Error())
+			return nil, rollback(fmt.Errorf("failed to protect storage group %s with SRDF: %w", storageGroupID, err))
+		}
+	}
+	log.Info(fmt.Sprintf("Successfully created protected storage group %s", storageGroupID))
+	return sg, nil
+}
+
+// GetRDFDirectorList returns the RDF directors of the array, only those that are online if online is true.
+func (c *Client) GetRDFDirectorList(ctx context.Context, symID string, online bool) (*types.RDFDirList, error) {
+	defer c.TimeSpent("GetRDFDirectorList", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	URL := c.urlPrefix() + ReplicationX + SymmetrixX + symID + XRDFDir
+	if online {
+		URL += "?online=true"
+	}
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
+	defer cancel()
+	rdfDirList := &types.RDFDirList{}
+	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), rdfDirList)
+	if err != nil {
+		log.Error("GetRDFDirectorList failed: " + err.Error())
+		return nil, err
+	}
+	return rdfDirList, nil
+}
+
+// GetRDFPortList returns the RDF capable ports of an RDF director, only those that are online if online is true.
+// Together with GetRDFDirectorList it gives the local ports to choose from when creating an RDF group.
+func (c *Client) GetRDFPortList(ctx context.Context, symID, directorID string, online bool) (*types.RDFPortList, error) {
+	defer c.TimeSpent("GetRDFPortList", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	URL := c.urlPrefix() + ReplicationX + SymmetrixX + symID + XRDFDir + "/" + directorID + XPort
+	if online {
+		URL += "?online=true"
+	}
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
+	defer cancel()
+	rdfPortList := &types.RDFPortList{}
+	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), rdfPortList)
+	if err != nil {
+		log.Error("GetRDFPortList failed: " + err.Error())
+		return nil, err
+	}
+	return rdfPortList, nil
+}
+
 // GetProtectedStorageGroup returns protected storage group given the storage group ID
 func (c *Client) GetProtectedStorageGroup(ctx context.Context, symID, storageGroup string) (*types.RDFStorageGroup, error) {
 	defer c.TimeSpent("GetProtectedStorageGroup", time.Now())
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	URL := c.urlPrefix() + ReplicationX + SymmetrixX + symID + XStorageGroup + "/" + storageGroup
 	resp, err := c.api.DoAndGetResponseBody(ctx, http.MethodGet, URL, c.getDefaultHeaders(), nil)
@@ -80,7 +238,7 @@ func (c *Client) GetProtectedStorageGroup(ctx context.Context, symID, storageGro
 	}
 
 	rdfSgInfo := new(types.RDFStorageGroup)
-	if err := json.NewDecoder(resp.Body).Decode(rdfSgInfo); err != nil {
+	if err := api.DecodeJSON(resp, rdfSgInfo); err != nil {
 		return nil, err
 	}
 	return rdfSgInfo, nil
@@ -195,7 +353,7 @@ func (c *Client) ExecuteReplicationActionOnSG(ctx context.Context, symID, action
 	fields := map[string]interface{}{
 		http.MethodPut: URL,
 	}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, longJobOperation)
 	defer cancel()
 	err := c.api.Put(
 		ctx, URL, c.getDefaultHeaders(), modifyParam, nil)
@@ -255,11 +413,10 @@ func (c *Client) CreateSGReplica(ctx context.Context, symID, remoteSymID, rdfMod
 	}
 	rdfgNo, _ := strconv.Atoi(rdfGroupNo)
 	createSGReplicaPayload := c.GetCreateSGReplicaPayload(remoteSymID, rdfMode, rdfgNo, remoteSGName, remoteServiceLevel, true, bias)
-	Debug = true
-	ifDebugLogPayload(createSGReplicaPayload)
+	c.ifDebugLogPayload(createSGReplicaPayload)
 	URL := c.urlPrefix() + ReplicationX + SymmetrixX + symID + XStorageGroup + "/" + sourceSG + XRDFGroup
 
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, longJobOperation)
 	defer cancel()
 	resp, err := c.api.DoAndGetResponseBody(
 		ctx, http.MethodPost, URL, c.getDefaultHeaders(), createSGReplicaPayload)
@@ -268,8 +425,7 @@ func (c *Client) CreateSGReplica(ctx context.Context, symID, remoteSymID, rdfMod
 	}
 	defer resp.Body.Close()
 	rdfSG := &types.SGRDFInfo{}
-	decoder := json.NewDecoder(resp.Body)
-	if err = decoder.Decode(rdfSG); err != nil {
+	if err = api.DecodeJSON(resp, rdfSG); err != nil {
 		return nil, err
 	}
 	log.Info(fmt.Sprintf("Successfully created SG replica for %s", sourceSG))
@@ -325,11 +481,10 @@ func (c *Client) CreateRDFPair(ctx context.Context, symID, rdfGroupNo, deviceID,
 		LocalDeviceList: deviceList,
 	}
 	createPairPayload := c.GetCreateRDFPairPayload(devList, rdfMode, rdfType, establish, exemptConsistency)
-	Debug = true
-	ifDebugLogPayload(createPairPayload)
+	c.ifDebugLogPayload(createPairPayload)
 	URL := c.urlPrefix() + ReplicationX + SymmetrixX + symID + XRDFGroup + "/" + rdfGroupNo + XVolume + "/" + deviceID
 
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
 	resp, err := c.api.DoAndGetResponseBody(
 		ctx, http.MethodPost, URL, c.getDefaultHeaders(), createPairPayload)
@@ -338,8 +493,7 @@ func (c *Client) CreateRDFPair(ctx context.Context, symID, rdfGroupNo, deviceID,
 	}
 	defer resp.Body.Close()
 	rdfPairList := &types.RDFDevicePairList{}
-	decoder := json.NewDecoder(resp.Body)
-	if err = decoder.Decode(rdfPairList); err != nil {
+	if err = api.DecodeJSON(resp, rdfPairList); err != nil {
 		return nil, err
 	}
 	log.Info(fmt.Sprintf("Successfully created volume replica for %s", deviceID))
@@ -353,7 +507,7 @@ func (c *Client) GetRDFDevicePairInfo(ctx context.Context, symID, rdfGroup, volu
 		return nil, err
 	}
 
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	URL := c.urlPrefix() + ReplicationX + SymmetrixX + symID + XRDFGroup + "/" + rdfGroup + XVolume + "/" + volumeID
 	resp, err := c.api.DoAndGetResponseBody(ctx, http.MethodGet, URL, c.getDefaultHeaders(), nil)
@@ -367,7 +521,7 @@ func (c *Client) GetRDFDevicePairInfo(ctx context.Context, symID, rdfGroup, volu
 	}
 
 	rdfDevPairInfo := new(types.RDFDevicePair)
-	if err := json.NewDecoder(resp.Body).Decode(rdfDevPairInfo); err != nil {
+	if err := api.DecodeJSON(resp, rdfDevPairInfo); err != nil {
 		return nil, err
 	}
 	return rdfDevPairInfo, nil
@@ -380,7 +534,7 @@ func (c *Client) GetStorageGroupRDFInfo(ctx context.Context, symID, sgName, rdfG
 		return nil, err
 	}
 
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	URL := c.urlPrefix() + ReplicationX + SymmetrixX + symID + XStorageGroup + "/" + sgName + XRDFGroup + "/" + rdfGroupNo
 	resp, err := c.api.DoAndGetResponseBody(ctx, http.MethodGet, URL, c.getDefaultHeaders(), nil)
@@ -394,8 +548,222 @@ func (c *Client) GetStorageGroupRDFInfo(ctx context.Context, symID, sgName, rdfG
 	}
 
 	sgRdfInfo := new(types.StorageGroupRDFG)
-	if err := json.NewDecoder(resp.Body).Decode(sgRdfInfo); err != nil {
+	if err := api.DecodeJSON(resp, sgRdfInfo); err != nil {
 		return nil, err
 	}
 	return sgRdfInfo, nil
 }
+
+// RDFPairOptions holds the options of CreateRDFPairInGroup
+type RDFPairOptions struct {
+	// Establish starts the synchronisation of the new pairs once they are created
+	Establish bool
+	// InvalidateR2 invalidates all the tracks of the remote volumes, so that the establish
+	// copies all the data of the local volumes instead of only the changed tracks
+	InvalidateR2 bool
+	// ExemptConsistency adds the pairs to an ASYNC or METRO group without suspending its other pairs
+	ExemptConsistency bool
+}
+
+// CreateRDFPairInGroup pairs the local volumes with new volumes of the remote array of an existing RDF group,
+// in the given RDF mode, and adds the remote volumes to remoteSGID if it is not empty.
+// The pairs are created by an asynchronous job, which is returned, also if it failed, so that its tasks
+// and result can be inspected.
+func (c *Client) CreateRDFPairInGroup(ctx context.Context, localSymID, rdfGroupNo string, localVolIDs []string, remoteSGID, mode string, options RDFPairOptions) (*types.Job, error) {
+	defer c.TimeSpent("CreateRDFPairInGroup", time.Now())
+	if _, err := c.IsAllowedArray(localSymID); err != nil {
+		return nil, err
+	}
+	if len(localVolIDs) == 0 {
+		return nil, fmt.Errorf("at least one volume id has to be specified")
+	}
+	payload := c.GetCreateRDFPairPayload(types.LocalDeviceListCriteria{LocalDeviceList: localVolIDs}, mode, "RDF1", options.Establish, options.ExemptConsistency)
+	if payload == nil {
+		return nil, fmt.Errorf("not a supported RDF mode: %s", mode)
+	}
+	payload.InvalidateR2 = options.InvalidateR2
+	payload.ExecutionOption = types.ExecutionOptionAsynchronous
+	c.ifDebugLogPayload(payload)
+	URL := c.urlPrefix() + ReplicationX + SymmetrixX + localSymID + XRDFGroup + "/" + rdfGroupNo + XVolume
+
+	ctx, cancel := c.getTimeoutContext(ctx, longJobOperation)
+	defer cancel()
+	job := &types.Job{}
+	if err := c.api.Post(ctx, URL, c.getDefaultHeaders(), payload, job); err != nil {
+		log.Error("CreateRDFPairInGroup failed: " + err.Error())
+		return nil, err
+	}
+	job, err := c.WaitOnJobCompletion(ctx, localSymID, job.JobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status == types.JobStatusFailed {
+		return job, fmt.Errorf("The CreateRDFPairInGroup job failed: " + c.JobToString(job))
+	}
+	log.Info(fmt.Sprintf("Successfully created RDF pairs for %v in RDF group %s", localVolIDs, rdfGroupNo))
+	if remoteSGID == "" {
+		return job, nil
+	}
+
+	remoteSymID := ""
+	remoteVolIDs := make([]string, 0, len(localVolIDs))
+	for _, volID := range localVolIDs {
+		pair, err := c.GetRDFDevicePairInfo(ctx, localSymID, rdfGroupNo, volID)
+		if err != nil {
+			return job, err
+		}
+		remoteSymID = pair.RemoteSymmID
+		remoteVolIDs = append(remoteVolIDs, pair.RemoteVolumeName)
+	}
+	if err = c.AddVolumesToStorageGroupS(ctx, remoteSymID, remoteSGID, true, remoteVolIDs...); err != nil {
+		return job, fmt.Errorf("failed to add the remote volumes %v to storage group %s: %w", remoteVolIDs, remoteSGID, err)
+	}
+	return job, nil
+}
+
+// DeleteRDFPair deletes the RDF pair of a local volume of the RDF group, suspending the pair first if it is not
+func (c *Client) DeleteRDFPair(ctx context.Context, symID, rdfGroupNo, volumeID string) error {
+	defer c.TimeSpent("DeleteRDFPair", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return err
+	}
+	pair, err := c.GetRDFDevicePairInfo(ctx, symID, rdfGroupNo, volumeID)
+	if err != nil {
+		return err
+	}
+	URL := c.urlPrefix() + ReplicationX + SymmetrixX + symID + XRDFGroup + "/" + rdfGroupNo + XVolume + "/" + volumeID
+	ctx, cancel := c.getTimeoutContext(ctx, longJobOperation)
+	defer cancel()
+	if pair.RdfpairState != "Suspended" {
+		suspendParam := &types.ModifySGRDFGroup{
+			Action:          "Suspend",
+			Suspend:         &types.Suspend{ConsExempt: true},
+			ExecutionOption: types.ExecutionOptionSynchronous,
+		}
+		c.ifDebugLogPayload(suspendParam)
+		if err = c.api.Put(ctx, URL, c.getDefaultHeaders(), suspendParam, nil); err != nil {
+			log.Error("DeleteRDFPair failed to suspend the pair: " + err.Error())
+			return err
+		}
+	}
+	if err = c.api.Delete(ctx, URL, c.getDefaultHeaders(), nil); err != nil {
+		log.Error("DeleteRDFPair failed: " + err.Error())
+		return err
+	}
+	log.Info(fmt.Sprintf("Successfully deleted the RDF pair of %s in RDF group %s", volumeID, rdfGroupNo))
+	return nil
+}
+
+// rdfModeNames are the names Unisphere gives to the SRDF modes
+var rdfModeNames = map[string]string{
+	ASYNC: "Asynchronous",
+	SYNC:  "Synchronous",
+	METRO: "Active",
+}
+
+// rdfModeOf returns the SRDF mode (ASYNC, SYNC or METRO) of the name Unisphere gives to it, or the name itself
+func rdfModeOf(name string) string {
+	for mode, modeName := range rdfModeNames {
+		if name == modeName {
+			return mode
+		}
+	}
+	return name
+}
+
+// RDFModeTransitionError is returned by SetRDFMode for a change of SRDF mode which the array does not allow
+// from the current mode or state of the storage group. RequiredStep names what must be done first.
+type RDFModeTransitionError struct {
+	StorageGroupID string
+	From           string
+	To             string
+	RequiredStep   string
+}
+
+func (e *RDFModeTransitionError) Error() string {
+	return fmt.Sprintf("cannot change the SRDF mode of storage group %s from %s to %s: %s first",
+		e.StorageGroupID, e.From, e.To, e.RequiredStep)
+}
+
+// SetRDFMode changes the SRDF mode of the pairs of a protected storage group in an RDF group to targetMode,
+// one of ASYNC and SYNC. The pairs are suspended, their mode set and they are resumed, unless they were
+// already suspended, in which case they are left suspended. Transitions the array does not allow, to or from
+// METRO or while the storage group is failed over or partitioned, are rejected with an RDFModeTransitionError.
+func (c *Client) SetRDFMode(ctx context.Context, symID, storageGroup, rdfGroup, targetMode string) error {
+	defer c.TimeSpent("SetRDFMode", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return err
+	}
+	if _, ok := rdfModeNames[targetMode]; !ok {
+		return fmt.Errorf("not a supported SRDF mode: %s", targetMode)
+	}
+	info, err := c.GetStorageGroupRDFInfo(ctx, symID, storageGroup, rdfGroup)
+	if err != nil {
+		return err
+	}
+
+	from := targetMode
+	for _, name := range info.Modes {
+		if mode := rdfModeOf(name); mode != targetMode {
+			from = mode
+			break
+		}
+	}
+	if from == targetMode {
+		log.Info(fmt.Sprintf("StorageGroup (%s) with RDF group (%s) is already in %s mode", storageGroup, rdfGroup, targetMode))
+		return nil
+	}
+	transitionError := &RDFModeTransitionError{StorageGroupID: storageGroup, From: from, To: targetMode}
+	switch {
+	case from == METRO:
+		transitionError.RequiredStep = fmt.Sprintf("delete the SRDF/Metro pairs and create them in %s mode", targetMode)
+		return transitionError
+	case targetMode == METRO:
+		transitionError.RequiredStep = "delete the pairs and create them in METRO mode"
+		return transitionError
+	}
+	suspended := true
+	for _, state := range info.States {
+		switch state {
+		case "Failed Over", "FailedOver":
+			transitionError.RequiredStep = "Failback"
+			return transitionError
+		case "Partitioned":
+			transitionError.RequiredStep = "restore the SRDF links"
+			return transitionError
+		case "Suspended":
+		default:
+			suspended = false
+		}
+	}
+
+	if !suspended {
+		if err := c.ExecuteReplicationActionOnSG(ctx, symID, "Suspend", storageGroup, rdfGroup, false, false, false); err != nil {
+			return err
+		}
+	}
+	setModeParam := &types.ModifySGRDFGroup{
+		Action:          "SetMode",
+		SetMode:         &types.SetMode{Mode: rdfModeNames[targetMode]},
+		ExecutionOption: types.ExecutionOptionSynchronous,
+	}
+	URL := c.urlPrefix() + ReplicationX + SymmetrixX + symID + XStorageGroup + "/" + storageGroup + XRDFGroup + "/" + rdfGroup
+	c.ifDebugLogPayload(setModeParam)
+	putCtx, cancel := c.getTimeoutContext(ctx, longJobOperation)
+	defer cancel()
+	if err := c.api.Put(putCtx, URL, c.getDefaultHeaders(), setModeParam, nil); err != nil {
+		log.Error("SetRDFMode failed: " + err.Error())
+		if !suspended {
+			return fmt.Errorf("setting the SRDF mode of storage group %s to %s failed, its pairs are left suspended: %w",
+				storageGroup, targetMode, err)
+		}
+		return err
+	}
+	if !suspended {
+		if err := c.ExecuteReplicationActionOnSG(ctx, symID, "Resume", storageGroup, rdfGroup, false, false, false); err != nil {
+			return err
+		}
+	}
+	log.Info(fmt.Sprintf("SRDF mode of StorageGroup (%s) with RDF group (%s) changed from %s to %s", storageGroup, rdfGroup, from, targetMode))
+	return nil
+}
@@ -16,13 +16,17 @@ package pmax
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/dell/gopowermax/api"
 	types "github.com/dell/gopowermax/types/v90"
 	log "github.com/sirupsen/logrus"
 )
@@ -36,17 +40,75 @@ const (
 	XGenereation = "/generation"
 )
 
+var (
+	// SnapshotLinkPollInterval is the initial wait between polls in WaitForSnapshotLinkDefined and VolumeClone.Finalize.
+	// It doubles after every poll up to MaxSnapshotLinkPollInterval.
+	SnapshotLinkPollInterval = 1 * time.Second
+	// MaxSnapshotLinkPollInterval is the longest wait between polls in WaitForSnapshotLinkDefined.
+	MaxSnapshotLinkPollInterval = 15 * time.Second
+)
+
+// ErrSnapshotLimitReached is returned, wrapped with the volume or array concerned, by CreateSnapshot
+// if a source volume or the array already has the maximum number of snapshots.
+var ErrSnapshotLimitReached = errors.New("snapshot limit reached")
+
+// DefaultMaxSnapshotsPerVolume is the number of SnapVX snapshots a source volume can have
+// if the array does not report it
+const DefaultMaxSnapshotsPerVolume = 256
+
+// SnapshotLimits are the maximum numbers of SnapVX snapshots of a source volume and of an array,
+// as reported in the replication capabilities of the array. A PerArray limit of 0 is not checked.
+type SnapshotLimits struct {
+	PerVolume int
+	PerArray  int
+}
+
+// SnapshotHeadroom is the number of snapshots of a volume and of its array, and the limits they are
+// checked against.
+type SnapshotHeadroom struct {
+	VolumeSnapshots int
+	ArraySnapshots  int
+	Limits          SnapshotLimits
+}
+
+// Available returns the number of snapshots which can still be created of the volume
+func (h *SnapshotHeadroom) Available() int {
+	available := h.Limits.PerVolume - h.VolumeSnapshots
+	if h.Limits.PerArray > 0 && h.Limits.PerArray-h.ArraySnapshots < available {
+		available = h.Limits.PerArray - h.ArraySnapshots
+	}
+	if available < 0 {
+		return 0
+	}
+	return available
+}
+
 func (c *Client) privURLPrefix() string {
 	return RESTPrefix + PrivateX + c.version + "/"
 }
 
 // GetSnapVolumeList returns a list of all snapshot volumes on the array.
+// From API version 91 the public endpoint is used, falling back to the private one if Unisphere does not
+// have it; the client then keeps to the private endpoint. Version 90 only has the private endpoint.
 func (c *Client) GetSnapVolumeList(ctx context.Context, symID string, queryParams types.QueryParams) (*types.SymVolumeList, error) {
 	defer c.TimeSpent("GetSnapVolumeList", time.Now())
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
-	URL := c.privURLPrefix() + ReplicationX + SymmetrixX + symID + XVolume
+	if c.version != APIVersion90 && atomic.LoadInt32(&c.noPublicSnapVolumeList) == 0 {
+		snapVolList, err := c.getSnapVolumeList(ctx, c.urlPrefix(), symID, queryParams)
+		if !isRouteNotFound(err) {
+			return snapVolList, err
+		}
+		log.Info("Unisphere does not list snapshot volumes publicly, using the private endpoint")
+		atomic.StoreInt32(&c.noPublicSnapVolumeList, 1)
+	}
+	return c.getSnapVolumeList(ctx, c.privURLPrefix(), symID, queryParams)
+}
+
+// getSnapVolumeList lists the snapshot volumes of the array under the given URL prefix
+func (c *Client) getSnapVolumeList(ctx context.Context, prefix string, symID string, queryParams types.QueryParams) (*types.SymVolumeList, error) {
+	URL := prefix + ReplicationX + SymmetrixX + symID + XVolume
 	if queryParams != nil {
 		URL += "?"
 		for key, val := range queryParams {
@@ -60,7 +122,7 @@ func (c *Client) GetSnapVolumeList(ctx context.Context, symID string, queryParam
 		}
 		URL = URL[:len(URL)-1]
 	}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	resp, err := c.api.DoAndGetResponseBody(ctx, http.MethodGet, URL, c.getDefaultHeaders(), nil)
 	if err != nil {
@@ -73,13 +135,22 @@ func (c *Client) GetSnapVolumeList(ctx context.Context, symID string, queryParam
 	}
 
 	snapVolList := &types.SymVolumeList{}
-	decoder := json.NewDecoder(resp.Body)
-	if err = decoder.Decode(snapVolList); err != nil {
+	if err = api.DecodeJSON(resp, snapVolList); err != nil {
 		return nil, err
 	}
 	return snapVolList, nil
 }
 
+// isRouteNotFound returns true if err is a 404 answer to an unknown route, which carries no Unisphere
+// message, unlike the 404 answered for an unknown array or volume
+func isRouteNotFound(err error) bool {
+	var apiErr *types.Error
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusNotFound {
+		return false
+	}
+	return apiErr.BodySnippet != "" || apiErr.Message == "HTTP 404 Not Found" || apiErr.Message == "404 Not Found"
+}
+
 // GetVolumeSnapInfo returns snapVx information associated with a volume.
 func (c *Client) GetVolumeSnapInfo(ctx context.Context, symID string, volumeID string) (*types.SnapshotVolumeGeneration, error) {
 	defer c.TimeSpent("GetVolumeSnapInfo", time.Now())
@@ -87,7 +158,7 @@ func (c *Client) GetVolumeSnapInfo(ctx context.Context, symID string, volumeID s
 		return nil, err
 	}
 	URL := c.privURLPrefix() + ReplicationX + SymmetrixX + symID + XVolume + "/" + volumeID + XSnapshot
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	resp, err := c.api.DoAndGetResponseBody(ctx, http.MethodGet, URL, c.getDefaultHeaders(), nil)
 	if err != nil {
@@ -100,8 +171,7 @@ func (c *Client) GetVolumeSnapInfo(ctx context.Context, symID string, volumeID s
 	}
 
 	snapinfo := &types.SnapshotVolumeGeneration{}
-	decoder := json.NewDecoder(resp.Body)
-	if err = decoder.Decode(snapinfo); err != nil {
+	if err = api.DecodeJSON(resp, snapinfo); err != nil {
 		return nil, err
 	}
 	return snapinfo, nil
@@ -114,7 +184,7 @@ func (c *Client) GetSnapshotInfo(ctx context.Context, symID, volumeID, snapID st
 		return nil, err
 	}
 	URL := c.privURLPrefix() + ReplicationX + SymmetrixX + symID + XVolume + "/" + volumeID + XSnapshot + "/" + snapID
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	resp, err := c.api.DoAndGetResponseBody(ctx, http.MethodGet, URL, c.getDefaultHeaders(), nil)
 	if err != nil {
@@ -127,7 +197,7 @@ func (c *Client) GetSnapshotInfo(ctx context.Context, symID, volumeID, snapID st
 	}
 
 	snapshotInfo := new(types.VolumeSnapshot)
-	if err := json.NewDecoder(resp.Body).Decode(snapshotInfo); err != nil {
+	if err := api.DecodeJSON(resp, snapshotInfo); err != nil {
 		return nil, err
 	}
 	return snapshotInfo, nil
@@ -138,11 +208,19 @@ func (c *Client) GetSnapshotInfo(ctx context.Context, symID, volumeID, snapID st
 // Star flag is used if the source device is participating in SRDF star mode
 // Use the Force flag to automate some scenarios to succeed
 // TimeToLive value ins hour is set on the snapshot to automatically delete the snapshot after target is unlinked
+// If the client was created with ClientOptions.CheckSnapshotLimits, the snapshot headroom of the source
+// volumes is checked first, and ErrSnapshotLimitReached returned without creating any snapshot.
 func (c *Client) CreateSnapshot(ctx context.Context, symID string, snapID string, sourceVolumeList []types.VolumeList, ttl int64) error {
 	defer c.TimeSpent("CreateSnapshot", time.Now())
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return err
 	}
+	if c.checkSnapshotLimits {
+		if err := c.checkSnapshotHeadroom(ctx, symID, sourceVolumeList); err != nil {
+			log.Error("CreateSnapshot failed: " + err.Error())
+			return err
+		}
+	}
 	snapParam := &types.CreateVolumesSnapshot{
 		SourceVolumeList: sourceVolumeList,
 		BothSides:        false,
@@ -151,17 +229,77 @@ func (c *Client) CreateSnapshot(ctx context.Context, symID string, snapID string
 		TimeToLive:       ttl,
 		ExecutionOption:  types.ExecutionOptionSynchronous,
 	}
-	ifDebugLogPayload(snapParam)
+	c.ifDebugLogPayload(snapParam)
 	URL := c.privURLPrefix() + ReplicationX + SymmetrixX + symID + XSnapshot + "/" + snapID
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
 	err := c.api.Post(ctx, URL, c.getDefaultHeaders(), snapParam, nil)
 	if err != nil {
 		log.Error("CreateSnapshot failed: " + err.Error())
+		if strings.Contains(strings.ToLower(err.Error()), "maximum number of snapshots") {
+			return fmt.Errorf("%s: %w", err.Error(), ErrSnapshotLimitReached)
+		}
 	}
 	return err
 }
 
+// GetSnapshotHeadroom returns the number of snapshots of the volume and of the array, and the snapshot
+// limits the array reports in its replication capabilities
+func (c *Client) GetSnapshotHeadroom(ctx context.Context, symID, volumeID string) (*SnapshotHeadroom, error) {
+	defer c.TimeSpent("GetSnapshotHeadroom", time.Now())
+	capabilities, err := c.GetArrayCapabilities(ctx, symID)
+	if err != nil {
+		return nil, err
+	}
+	volumeSnapshots, arraySnapshots, err := c.getSnapshotCounts(ctx, symID)
+	if err != nil {
+		return nil, err
+	}
+	return &SnapshotHeadroom{
+		VolumeSnapshots: volumeSnapshots[volumeID],
+		ArraySnapshots:  arraySnapshots,
+		Limits:          capabilities.SnapshotLimits,
+	}, nil
+}
+
+// getSnapshotCounts returns the number of snapshots of each source volume of the array, and of the whole
+// array, counted from the same listing of the snapshot volumes of the array
+func (c *Client) getSnapshotCounts(ctx context.Context, symID string) (map[string]int, int, error) {
+	symVolumeList, err := c.GetSnapVolumeList(ctx, symID, types.QueryParams{types.IncludeDetails: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	volumeSnapshots := make(map[string]int, len(symVolumeList.SymDevice))
+	arraySnapshots := 0
+	for _, device := range symVolumeList.SymDevice {
+		volumeSnapshots[device.Name] += len(device.Snapshot)
+		arraySnapshots += len(device.Snapshot)
+	}
+	return volumeSnapshots, arraySnapshots, nil
+}
+
+// checkSnapshotHeadroom returns ErrSnapshotLimitReached if a snapshot cannot be created of every source volume
+func (c *Client) checkSnapshotHeadroom(ctx context.Context, symID string, sourceVolumeList []types.VolumeList) error {
+	capabilities, err := c.GetArrayCapabilities(ctx, symID)
+	if err != nil {
+		return err
+	}
+	limits := capabilities.SnapshotLimits
+	volumeSnapshots, arraySnapshots, err := c.getSnapshotCounts(ctx, symID)
+	if err != nil {
+		return err
+	}
+	if limits.PerArray > 0 && arraySnapshots+len(sourceVolumeList) > limits.PerArray {
+		return fmt.Errorf("array %s has %d of %d snapshots: %w", symID, arraySnapshots, limits.PerArray, ErrSnapshotLimitReached)
+	}
+	for _, source := range sourceVolumeList {
+		if count := volumeSnapshots[source.Name]; count >= limits.PerVolume {
+			return fmt.Errorf("volume %s has %d of %d snapshots: %w", source.Name, count, limits.PerVolume, ErrSnapshotLimitReached)
+		}
+	}
+	return nil
+}
+
 // DeleteSnapshot deletes a snapshot from a volume
 // DeviceNameListSource is a list which contains the names of source volumes
 // Symforce flag is used to automate some internal establish scenarios
@@ -185,10 +323,10 @@ func (c *Client) DeleteSnapshot(ctx context.Context, symID, snapID string, sourc
 		ExecutionOption:      types.ExecutionOptionAsynchronous,
 	}
 	job := &types.Job{}
-	ifDebugLogPayload(deleteSnapshot)
+	c.ifDebugLogPayload(deleteSnapshot)
 	URL := c.privURLPrefix() + ReplicationX + SymmetrixX + symID + XSnapshot + "/" + snapID
 	URL = strings.Replace(URL, "/90/", "/91/", 1)
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
 	err := c.api.DoWithHeaders(ctx, http.MethodDelete, URL, c.getDefaultHeaders(), deleteSnapshot, job)
 	if err != nil {
@@ -225,8 +363,8 @@ func (c *Client) DeleteSnapshotS(ctx context.Context, symID, snapID string, sour
 	fields := map[string]interface{}{
 		http.MethodPut: URL,
 	}
-	ifDebugLogPayload(deleteSnapshot)
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	c.ifDebugLogPayload(deleteSnapshot)
+	ctx, cancel := c.getTimeoutContext(ctx, longJobOperation)
 	defer cancel()
 	err := c.api.DoWithHeaders(ctx, http.MethodDelete, URL, c.getDefaultHeaders(), deleteSnapshot, nil)
 	if err != nil {
@@ -251,6 +389,8 @@ func (c *Client) DeleteSnapshotS(ctx context.Context, symID, snapID string, sour
 // NewSnapshotName specifies the new snapshot name to which the old snapshot will be renamed
 // ExecutionOption tells the Unisphere to perform the operation either in Synchronous mode or Asynchronous mode
 // Action defined the operation which will be performed on the given snapshot
+//
+// Deprecated: use LinkSnapshot, UnlinkSnapshot, RelinkSnapshot, RenameSnapshot or SetSnapshotMode instead
 func (c *Client) ModifySnapshot(ctx context.Context, symID string, sourceVol []types.VolumeList,
 	targetVol []types.VolumeList, snapID string, action string,
 	newSnapID string, generation int64) error {
@@ -293,7 +433,7 @@ func (c *Client) ModifySnapshot(ctx context.Context, symID string, sourceVol []t
 	fields := map[string]interface{}{
 		http.MethodPut: URL,
 	}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
 	err := c.api.Put(
 		ctx, URL, c.getDefaultHeaders(), snapParam, job)
@@ -313,6 +453,8 @@ func (c *Client) ModifySnapshot(ctx context.Context, symID string, sourceVol []t
 }
 
 // ModifySnapshotS executes actions on snapshots synchronously
+//
+// Deprecated: use LinkSnapshot, UnlinkSnapshot, RelinkSnapshot, RenameSnapshot or SetSnapshotMode instead
 func (c *Client) ModifySnapshotS(ctx context.Context, symID string, sourceVol []types.VolumeList,
 	targetVol []types.VolumeList, snapID string, action string,
 	newSnapID string, generation int64) error {
@@ -350,21 +492,167 @@ func (c *Client) ModifySnapshotS(ctx context.Context, symID string, sourceVol []
 	default:
 		return fmt.Errorf("not a supported action on Snapshots")
 	}
+	return c.modifySnapshotS(ctx, symID, snapID, snapParam)
+}
+
+// modifySnapshotS sends the snapParam to Unisphere to synchronously execute its action on the snapshot
+func (c *Client) modifySnapshotS(ctx context.Context, symID string, snapID string, snapParam *types.ModifyVolumeSnapshot) error {
 	URL := c.privURLPrefix() + ReplicationX + SymmetrixX + symID + XSnapshot + "/" + snapID
 	fields := map[string]interface{}{
 		http.MethodPut: URL,
 	}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, longJobOperation)
 	defer cancel()
 	err := c.api.Put(ctx, URL, c.getDefaultHeaders(), snapParam, nil)
 	if err != nil {
 		log.WithFields(fields).Error("Error in ModifySnapshotS: " + err.Error())
 		return err
 	}
-	log.Info(fmt.Sprintf("Action (%s) on Snapshot (%s) is successful", action, snapID))
+	log.Info(fmt.Sprintf("Action (%s) on Snapshot (%s) is successful", snapParam.Action, snapID))
 	return nil
 }
 
+// validateSnapshotAction checks the arguments common to all the snapshot actions.
+// If targetVol is required, it must pair one target volume with each source volume.
+func validateSnapshotAction(action string, snapID string, sourceVol []types.VolumeList,
+	targetVol []types.VolumeList, targetRequired bool, generation int64) error {
+	if snapID == "" {
+		return fmt.Errorf("%s snapshot: snapshot name must be supplied", action)
+	}
+	if generation < 0 {
+		return fmt.Errorf("%s snapshot %s: invalid generation %d", action, snapID, generation)
+	}
+	if len(sourceVol) == 0 {
+		return fmt.Errorf("%s snapshot %s: at least one source volume must be supplied", action, snapID)
+	}
+	for _, vol := range sourceVol {
+		if vol.Name == "" {
+			return fmt.Errorf("%s snapshot %s: source volume name must not be empty", action, snapID)
+		}
+	}
+	if !targetRequired {
+		return nil
+	}
+	if len(targetVol) != len(sourceVol) {
+		return fmt.Errorf("%s snapshot %s: %d target volumes given for %d source volumes", action, snapID, len(targetVol), len(sourceVol))
+	}
+	for _, vol := range targetVol {
+		if vol.Name == "" {
+			return fmt.Errorf("%s snapshot %s: target volume name must not be empty", action, snapID)
+		}
+	}
+	return nil
+}
+
+// LinkSnapshot synchronously links the given generation of a snapshot of each source volume to the target volume
+// in the same position of targetVol. If copy is set, the data is copied to the targets in the background.
+func (c *Client) LinkSnapshot(ctx context.Context, symID string, snapID string, sourceVol []types.VolumeList,
+	targetVol []types.VolumeList, generation int64, copy bool) error {
+	defer c.TimeSpent("LinkSnapshot", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return err
+	}
+	if err := validateSnapshotAction(types.SnapshotActionLink, snapID, sourceVol, targetVol, true, generation); err != nil {
+		return err
+	}
+	return c.modifySnapshotS(ctx, symID, snapID, &types.ModifyVolumeSnapshot{
+		VolumeNameListSource: sourceVol,
+		VolumeNameListTarget: targetVol,
+		Exact:                true,
+		Copy:                 copy,
+		Action:               types.SnapshotActionLink,
+		Generation:           generation,
+		ExecutionOption:      types.ExecutionOptionSynchronous,
+	})
+}
+
+// UnlinkSnapshot synchronously unlinks the given generation of a snapshot of each source volume from its target volume
+func (c *Client) UnlinkSnapshot(ctx context.Context, symID string, snapID string, sourceVol []types.VolumeList,
+	targetVol []types.VolumeList, generation int64) error {
+	defer c.TimeSpent("UnlinkSnapshot", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return err
+	}
+	if err := validateSnapshotAction(types.SnapshotActionUnlink, snapID, sourceVol, targetVol, true, generation); err != nil {
+		return err
+	}
+	return c.modifySnapshotS(ctx, symID, snapID, &types.ModifyVolumeSnapshot{
+		VolumeNameListSource: sourceVol,
+		VolumeNameListTarget: targetVol,
+		Exact:                true,
+		Action:               types.SnapshotActionUnlink,
+		Generation:           generation,
+		ExecutionOption:      types.ExecutionOptionSynchronous,
+	})
+}
+
+// RelinkSnapshot synchronously re-links already linked target volumes to the given generation of the snapshot,
+// refreshing the targets with the point in time data of that generation
+func (c *Client) RelinkSnapshot(ctx context.Context, symID string, snapID string, sourceVol []types.VolumeList,
+	targetVol []types.VolumeList, generation int64, copy bool) error {
+	defer c.TimeSpent("RelinkSnapshot", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return err
+	}
+	if err := validateSnapshotAction(types.SnapshotActionRelink, snapID, sourceVol, targetVol, true, generation); err != nil {
+		return err
+	}
+	return c.modifySnapshotS(ctx, symID, snapID, &types.ModifyVolumeSnapshot{
+		VolumeNameListSource: sourceVol,
+		VolumeNameListTarget: targetVol,
+		Exact:                true,
+		Copy:                 copy,
+		Action:               types.SnapshotActionRelink,
+		Generation:           generation,
+		ExecutionOption:      types.ExecutionOptionSynchronous,
+	})
+}
+
+// RenameSnapshot synchronously renames the given generation of a snapshot of the source volumes to newSnapID
+func (c *Client) RenameSnapshot(ctx context.Context, symID string, snapID string, newSnapID string,
+	sourceVol []types.VolumeList, generation int64) error {
+	defer c.TimeSpent("RenameSnapshot", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return err
+	}
+	if err := validateSnapshotAction(types.SnapshotActionRename, snapID, sourceVol, nil, false, generation); err != nil {
+		return err
+	}
+	if newSnapID == "" || newSnapID == snapID {
+		return fmt.Errorf("Rename snapshot %s: a new, different snapshot name must be supplied", snapID)
+	}
+	return c.modifySnapshotS(ctx, symID, snapID, &types.ModifyVolumeSnapshot{
+		VolumeNameListSource: sourceVol,
+		NewSnapshotName:      newSnapID,
+		Action:               types.SnapshotActionRename,
+		Generation:           generation,
+		ExecutionOption:      types.ExecutionOptionSynchronous,
+	})
+}
+
+// SetSnapshotMode synchronously changes the links of the given generation of a snapshot to the target volumes
+// to copy mode (the data is copied to the targets in the background) or, if copy is false, to nocopy mode
+func (c *Client) SetSnapshotMode(ctx context.Context, symID string, snapID string, sourceVol []types.VolumeList,
+	targetVol []types.VolumeList, generation int64, copy bool) error {
+	defer c.TimeSpent("SetSnapshotMode", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return err
+	}
+	if err := validateSnapshotAction(types.SnapshotActionSetMode, snapID, sourceVol, targetVol, true, generation); err != nil {
+		return err
+	}
+	return c.modifySnapshotS(ctx, symID, snapID, &types.ModifyVolumeSnapshot{
+		VolumeNameListSource: sourceVol,
+		VolumeNameListTarget: targetVol,
+		Exact:                true,
+		Copy:                 copy,
+		NoCopy:               !copy,
+		Action:               types.SnapshotActionSetMode,
+		Generation:           generation,
+		ExecutionOption:      types.ExecutionOptionSynchronous,
+	})
+}
+
 // GetPrivVolumeByID returns a Volume structure given the symmetrix and volume ID
 func (c *Client) GetPrivVolumeByID(ctx context.Context, symID string, volumeID string) (*types.VolumeResultPrivate, error) {
 	defer c.TimeSpent("GetPrivVolumeByID", time.Now())
@@ -397,13 +685,45 @@ func (c *Client) GetPrivVolumeByID(ctx context.Context, symID string, volumeID s
 
 	//volume := &types.VolumeResultPrivate{}
 	privateVolumeIterator := new(types.PrivVolumeIterator)
-	decoder := json.NewDecoder(resp.Body)
-	if err = decoder.Decode(privateVolumeIterator); err != nil {
+	if err = api.DecodeJSON(resp, privateVolumeIterator); err != nil {
 		return nil, err
 	}
+	if len(privateVolumeIterator.ResultList.PrivVolumeList) == 0 {
+		return nil, fmt.Errorf("Volume %s cannot be found in the private volume list", volumeID)
+	}
 	return &privateVolumeIterator.ResultList.PrivVolumeList[0], nil
 }
 
+// GetPrivVolumeList returns the private view of the volumes whose WWN matches wwnMatch exactly, or contains it
+// if like is set; all the volumes if wwnMatch is empty. All the pages of the private volume iterator are read.
+func (c *Client) GetPrivVolumeList(ctx context.Context, symID string, wwnMatch string, like bool) ([]types.VolumeResultPrivate, error) {
+	defer c.TimeSpent("GetPrivVolumeList", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	if like {
+		query.Set("wwn", "<like>"+wwnMatch)
+	} else if wwnMatch != "" {
+		query.Set("wwn", wwnMatch)
+	}
+	URL := c.privURLPrefix() + SLOProvisioningX + SymmetrixX + symID + XVolume
+	if len(query) > 0 {
+		URL += "?" + query.Encode()
+	}
+	iter := new(types.PrivVolumeIterator)
+	if err := c.getJSON(ctx, URL, iter); err != nil {
+		log.Error("GetPrivVolumeList failed: " + err.Error())
+		return nil, err
+	}
+	volumes, err := c.privVolumeIterator(iter).All(ctx)
+	if err != nil {
+		log.Error("GetPrivVolumeList failed: " + err.Error())
+		return nil, err
+	}
+	return volumes, nil
+}
+
 // GetSnapshotGenerations returns a list of all the snapshot generation on a specific snapshot
 func (c *Client) GetSnapshotGenerations(ctx context.Context, symID, volumeID, snapID string) (*types.VolumeSnapshotGenerations, error) {
 	defer c.TimeSpent("GetSnapshotGenerations", time.Now())
@@ -412,7 +732,7 @@ func (c *Client) GetSnapshotGenerations(ctx context.Context, symID, volumeID, sn
 	}
 	URL := c.privURLPrefix() + ReplicationX + SymmetrixX + symID + XVolume + "/" + volumeID + XSnapshot + "/" + snapID + XGenereation
 	volumeSnapshotGenerations := new(types.VolumeSnapshotGenerations)
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), volumeSnapshotGenerations)
 	if err != nil {
@@ -429,7 +749,7 @@ func (c *Client) GetSnapshotGenerationInfo(ctx context.Context, symID, volumeID,
 	}
 	URL := c.privURLPrefix() + ReplicationX + SymmetrixX + symID + XVolume + "/" + volumeID + XSnapshot + "/" + snapID + XGenereation + "/" + strconv.FormatInt(generation, 10)
 	volumeSnapshotGeneration := new(types.VolumeSnapshotGeneration)
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), volumeSnapshotGeneration)
 	if err != nil {
@@ -438,13 +758,444 @@ func (c *Client) GetSnapshotGenerationInfo(ctx context.Context, symID, volumeID,
 	return volumeSnapshotGeneration, nil
 }
 
+// WaitForSnapshotLinkDefined polls the generations of the snapshot snapID of srcVolID, with a growing interval,
+// until its link to targetVolID is defined, i.e. the target can be used. An error is returned if the target is not
+// linked to the snapshot or if it is still not defined after timeout. A zero timeout uses the client's timeout.
+func (c *Client) WaitForSnapshotLinkDefined(ctx context.Context, symID, srcVolID, snapID, targetVolID string, timeout time.Duration) error {
+	defer c.TimeSpent("WaitForSnapshotLinkDefined", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return err
+	}
+	return c.waitForSnapshotLink(ctx, symID, srcVolID, snapID, targetVolID, timeout, "defined",
+		func(linkedVol types.LinkedVolumes) bool { return linkedVol.Defined })
+}
+
+// waitForSnapshotLink polls the generations of the snapshot snapID of srcVolID, with a growing interval,
+// until its link to targetVolID satisfies done. state describes done in the messages.
+func (c *Client) waitForSnapshotLink(ctx context.Context, symID, srcVolID, snapID, targetVolID string, timeout time.Duration,
+	state string, done func(linkedVol types.LinkedVolumes) bool) error {
+	if timeout > 0 {
+		ctx = WithTimeout(ctx, timeout)
+	}
+	ctx, cancel := c.getTimeoutContext(ctx, longJobOperation)
+	defer cancel()
+
+	interval := SnapshotLinkPollInterval
+	for {
+		generations, err := c.GetSnapshotGenerations(ctx, symID, srcVolID, snapID)
+		if err != nil {
+			return err
+		}
+		linked := false
+		for _, snapSrc := range generations.VolumeSnapshotSource {
+			if snapSrc.SnapshotName != snapID {
+				continue
+			}
+			for _, linkedVol := range snapSrc.LinkedVolumes {
+				if linkedVol.TargetDevice != targetVolID {
+					continue
+				}
+				if done(linkedVol) {
+					log.Info(fmt.Sprintf("Link of snapshot (%s) of volume %s to volume %s is %s", snapID, srcVolID, targetVolID, state))
+					return nil
+				}
+				linked = true
+			}
+		}
+		if !linked {
+			return fmt.Errorf("snapshot (%s) of volume %s is not linked to volume %s", snapID, srcVolID, targetVolID)
+		}
+		log.Debug(fmt.Sprintf("Link of snapshot (%s) to volume %s is not %s yet, retrying in %v", snapID, targetVolID, state, interval))
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for link of snapshot (%s) of volume %s to volume %s to be %s: %w", snapID, srcVolID, targetVolID, state, ctx.Err())
+		case <-time.After(interval):
+		}
+		interval = interval * 2
+		if interval > MaxSnapshotLinkPollInterval {
+			interval = MaxSnapshotLinkPollInterval
+		}
+	}
+}
+
+// CreateVolumeFromSnapshotOptions are the settings of CreateVolumeFromSnapshot
+type CreateVolumeFromSnapshotOptions struct {
+	// Copy links the snapshot in copy mode, copying its data to the new volume in the background.
+	// Otherwise the new volume only references the data of the snapshot (nocopy mode).
+	Copy bool
+	// Generation is the generation of the snapshot to link, 0 being the most recent
+	Generation int64
+	// Timeout is how long to wait for the link to be defined, zero uses the client's timeout
+	Timeout time.Duration
+}
+
+// CreateVolumeFromSnapshot materializes the snapshot snapID of snapSrcVolID as a new volume named name in
+// targetSGID: it creates the volume, links the snapshot to it and waits for the link to be defined, and returns
+// the new volume. A sizeInCylinders of 0 gives the volume the size of the source volume; a size smaller than the
+// source volume is an error. If a volume with the same name and size already exists in targetSGID, it is reused
+// and only linked if it is not already linked to the snapshot, so the call can be retried.
+func (c *Client) CreateVolumeFromSnapshot(ctx context.Context, symID, snapSrcVolID, snapID, targetSGID, name string,
+	sizeInCylinders int, opts CreateVolumeFromSnapshotOptions) (*types.Volume, error) {
+	defer c.TimeSpent("CreateVolumeFromSnapshot", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	if snapID == "" {
+		return nil, fmt.Errorf("snapshot name must be supplied")
+	}
+	srcVol, err := c.GetVolumeByID(ctx, symID, snapSrcVolID)
+	if err != nil {
+		return nil, err
+	}
+	if sizeInCylinders == 0 {
+		sizeInCylinders = srcVol.CapacityCYL
+	} else if sizeInCylinders < srcVol.CapacityCYL {
+		return nil, fmt.Errorf("requested size %d CYL is smaller than the size %d CYL of the snapshot source volume %s",
+			sizeInCylinders, srcVol.CapacityCYL, snapSrcVolID)
+	}
+
+	vol, created, err := c.CreateVolumeIfNotExists(ctx, symID, targetSGID, name, sizeInCylinders)
+	if err != nil {
+		return nil, err
+	}
+	linked := false
+	if !created {
+		linked, err = c.isLinkedToSnapshot(ctx, symID, snapSrcVolID, snapID, vol.VolumeID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !linked {
+		sourceVol := []types.VolumeList{{Name: snapSrcVolID}}
+		targetVol := []types.VolumeList{{Name: vol.VolumeID}}
+		if err = c.LinkSnapshot(ctx, symID, snapID, sourceVol, targetVol, opts.Generation, opts.Copy); err != nil {
+			return nil, fmt.Errorf("couldn't link snapshot (%s) of volume %s to volume %s: %w", snapID, snapSrcVolID, vol.VolumeID, err)
+		}
+	}
+	if err = c.WaitForSnapshotLinkDefined(ctx, symID, snapSrcVolID, snapID, vol.VolumeID, opts.Timeout); err != nil {
+		return nil, err
+	}
+	log.Info(fmt.Sprintf("Created volume %s (%s) from snapshot (%s) of volume %s", name, vol.VolumeID, snapID, snapSrcVolID))
+	return c.GetVolumeByID(ctx, symID, vol.VolumeID)
+}
+
+// StorageGroupSnapshotLink is a volume of the source storage group of LinkSnapshotToNewStorageGroup and the
+// volume of the target storage group its snapshot is linked to
+type StorageGroupSnapshotLink struct {
+	SourceVolumeID string
+	TargetVolumeID string
+}
+
+// LinkSnapshotToNewStorageGroupOptions are the settings of LinkSnapshotToNewStorageGroup
+type LinkSnapshotToNewStorageGroupOptions struct {
+	// SRPID is the storage resource pool of the target storage group, that of the source storage group if empty
+	SRPID string
+	// ServiceLevel is the service level of the target storage group, that of the source storage group if empty
+	ServiceLevel string
+	// Link are the settings of the link of the snapshot of every source volume to its target volume
+	Link CreateVolumeFromSnapshotOptions
+}
+
+// LinkSnapshotToNewStorageGroup links the snapshot snapID of every volume of sourceSGID to a new volume of the
+// same size in targetSGID, creating targetSGID unless it exists, in which case it must be empty. The target of
+// source volume ID is named "<targetSGID>-<ID>" and the snapshot is linked with the link action of the storage
+// group snapshot, which pairs the source and target volumes in volume ID order; every link is then waited for
+// as in CreateVolumeFromSnapshot. The targets are returned in source volume ID order; after an error, the
+// targets linked before it are returned with the error.
+func (c *Client) LinkSnapshotToNewStorageGroup(ctx context.Context, symID, snapID, sourceSGID, targetSGID string,
+	opts LinkSnapshotToNewStorageGroupOptions) ([]StorageGroupSnapshotLink, error) {
+	defer c.TimeSpent("LinkSnapshotToNewStorageGroup", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	if snapID == "" {
+		return nil, fmt.Errorf("snapshot name must be supplied")
+	}
+	if targetSGID == "" || targetSGID == sourceSGID {
+		return nil, fmt.Errorf("the target storage group must be supplied and differ from the source storage group %s", sourceSGID)
+	}
+	srcSG, err := c.GetStorageGroup(ctx, symID, sourceSGID)
+	if err != nil {
+		return nil, err
+	}
+	volIDs, err := c.GetVolumeIDListInStorageGroup(ctx, symID, sourceSGID)
+	if err != nil {
+		return nil, err
+	}
+	if len(volIDs) == 0 {
+		return nil, fmt.Errorf("storage group %s has no volumes to link snapshot (%s) of", sourceSGID, snapID)
+	}
+	sort.Strings(volIDs)
+	names := make([]string, len(volIDs))
+	sources := make(map[string]string, len(volIDs))
+	for i, volID := range volIDs {
+		names[i] = TruncateResourceName(targetSGID+"-"+volID, MaxVolIdentifierLength)
+		if other, ok := sources[names[i]]; ok {
+			return nil, fmt.Errorf("the targets of volumes %s and %s would both be named %s", other, volID, names[i])
+		}
+		sources[names[i]] = volID
+	}
+
+	targetSG, err := c.GetStorageGroup(ctx, symID, targetSGID)
+	if errors.Is(err, ErrNotFound) {
+		sgOpts := StorageGroupOptions{SRPID: opts.SRPID, ServiceLevel: opts.ServiceLevel}
+		if sgOpts.SRPID == "" {
+			sgOpts.SRPID = srcSG.SRP
+		}
+		if sgOpts.ServiceLevel == "" {
+			sgOpts.ServiceLevel = srcSG.SLO
+		}
+		if _, err = c.CreateStorageGroupWithOptions(ctx, symID, targetSGID, sgOpts); err != nil {
+			return nil, fmt.Errorf("couldn't create the target storage group %s: %w", targetSGID, err)
+		}
+	} else if err != nil {
+		return nil, err
+	} else if targetSG.NumOfVolumes != 0 {
+		return nil, fmt.Errorf("the target storage group %s is not empty, it has %d volumes", targetSGID, targetSG.NumOfVolumes)
+	}
+
+	targetVolIDs := make([]string, len(volIDs))
+	for i, volID := range volIDs {
+		srcVol, err := c.GetVolumeByID(ctx, symID, volID)
+		if err != nil {
+			return nil, err
+		}
+		vol, _, err := c.CreateVolumeIfNotExists(ctx, symID, targetSGID, names[i], srcVol.CapacityCYL)
+		if err != nil {
+			return nil, err
+		}
+		targetVolIDs[i] = vol.VolumeID
+	}
+	sort.Strings(targetVolIDs)
+	if err = c.linkStorageGroupSnapshot(ctx, symID, sourceSGID, snapID, targetSGID, opts.Link.Generation, opts.Link.Copy); err != nil {
+		return nil, fmt.Errorf("couldn't link snapshot (%s) of storage group %s to storage group %s: %w", snapID, sourceSGID, targetSGID, err)
+	}
+
+	targets := make([]StorageGroupSnapshotLink, 0, len(volIDs))
+	for i, volID := range volIDs {
+		if err = c.WaitForSnapshotLinkDefined(ctx, symID, volID, snapID, targetVolIDs[i], opts.Link.Timeout); err != nil {
+			return targets, err
+		}
+		targets = append(targets, StorageGroupSnapshotLink{SourceVolumeID: volID, TargetVolumeID: targetVolIDs[i]})
+	}
+	log.Info(fmt.Sprintf("Linked snapshot (%s) of the %d volumes of storage group %s to storage group %s", snapID, len(targets), sourceSGID, targetSGID))
+	return targets, nil
+}
+
+// linkStorageGroupSnapshot synchronously links the given generation of the snapshot snapID of storage group
+// sgID to the storage group targetSGID
+func (c *Client) linkStorageGroupSnapshot(ctx context.Context, symID, sgID, snapID, targetSGID string, generation int64, copy bool) error {
+	URL := c.urlPrefix() + ReplicationX + SymmetrixX + symID + XStorageGroup + "/" + sgID + XSnapshot + "/" + snapID +
+		XGenereation + "/" + strconv.FormatInt(generation, 10)
+	param := &types.ModifyStorageGroupSnapshot{
+		Action:          types.SnapshotActionLink,
+		Link:            &types.LinkStorageGroupSnapshotParam{LinkStorageGroupName: targetSGID, Copy: copy},
+		ExecutionOption: types.ExecutionOptionSynchronous,
+	}
+	ctx, cancel := c.getTimeoutContext(ctx, longJobOperation)
+	defer cancel()
+	if err := c.api.Put(ctx, URL, c.getDefaultHeaders(), param, nil); err != nil {
+		log.Error("linkStorageGroupSnapshot failed: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+// isLinkedToSnapshot returns whether targetVolID is linked to a generation of the snapshot snapID of srcVolID
+func (c *Client) isLinkedToSnapshot(ctx context.Context, symID, srcVolID, snapID, targetVolID string) (bool, error) {
+	generations, err := c.GetSnapshotGenerations(ctx, symID, srcVolID, snapID)
+	if err != nil {
+		return false, err
+	}
+	for _, snapSrc := range generations.VolumeSnapshotSource {
+		if snapSrc.SnapshotName != snapID {
+			continue
+		}
+		for _, linkedVol := range snapSrc.LinkedVolumes {
+			if linkedVol.TargetDevice == targetVolID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// SnapshotTopology is the graph of the SnapVX snapshots of volumes of an array: the snapshot
+// generations of each source volume with their linked targets, and the snapshot each target is linked to.
+type SnapshotTopology struct {
+	SymID   string
+	Volumes map[string]*SnapshotTopologyVolume
+}
+
+// SnapshotTopologyVolume is a volume of a SnapshotTopology
+type SnapshotTopologyVolume struct {
+	VolumeID string
+	// Snapshots are the snapshot generations of which the volume is the source,
+	// sorted by snapshot name and generation
+	Snapshots []SnapshotGeneration
+	// LinkedFrom is the snapshot the volume is linked to as a target, if any
+	LinkedFrom *SnapshotLinkSource
+}
+
+// SnapshotGeneration is a generation of a snapshot and the targets linked to it
+type SnapshotGeneration struct {
+	SnapshotName string
+	Generation   int64
+	Timestamp    int64
+	Expired      bool
+	Secured      bool
+	Targets      []SnapshotLinkTarget
+}
+
+// SnapshotLinkTarget is a volume linked to a snapshot generation
+type SnapshotLinkTarget struct {
+	VolumeID string
+	State    string
+	Defined  bool
+}
+
+// SnapshotLinkSource is the snapshot generation a target volume is linked to
+type SnapshotLinkSource struct {
+	SourceVolumeID string
+	SnapshotName   string
+	Generation     int64
+	State          string
+}
+
+// Origin returns the snapshots volumeID was cloned from, the one it is linked to first,
+// then the one its source is linked to, and so on.
+func (t *SnapshotTopology) Origin(volumeID string) []SnapshotLinkSource {
+	origin := make([]SnapshotLinkSource, 0)
+	visited := map[string]bool{volumeID: true}
+	for vol := t.Volumes[volumeID]; vol != nil && vol.LinkedFrom != nil; vol = t.Volumes[vol.LinkedFrom.SourceVolumeID] {
+		origin = append(origin, *vol.LinkedFrom)
+		if visited[vol.LinkedFrom.SourceVolumeID] {
+			break
+		}
+		visited[vol.LinkedFrom.SourceVolumeID] = true
+	}
+	return origin
+}
+
+// BuildSnapshotTopology returns the snapshot topology of the volumes volIDs and of the volumes they are
+// related to, directly or not, as snapshot source or linked target. The volumes are read from the
+// private volume endpoint, up to ClientOptions.MaxConcurrentRequests at a time. If some of the volumes
+// can not be read, or ctx is done, the topology of the others is returned together with an error.
+func (c *Client) BuildSnapshotTopology(ctx context.Context, symID string, volIDs []string) (*SnapshotTopology, error) {
+	defer c.TimeSpent("BuildSnapshotTopology", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	topology := &SnapshotTopology{SymID: symID, Volumes: make(map[string]*SnapshotTopologyVolume)}
+	queued := make(map[string]bool)
+	pending := make([]string, 0, len(volIDs))
+	for _, volID := range volIDs {
+		if !queued[volID] {
+			queued[volID] = true
+			pending = append(pending, volID)
+		}
+	}
+	msgs := make([]string, 0)
+	for len(pending) != 0 {
+		// read the pending volumes, then queue the volumes they are related to
+		volumes := make([]*SnapshotTopologyVolume, len(pending))
+		errs := make([]error, len(pending))
+		err := c.forEachConcurrently(ctx, "BuildSnapshotTopology", len(pending), func(ctx context.Context, i int) error {
+			vol, err := c.GetPrivVolumeByID(ctx, symID, pending[i])
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %s", pending[i], err.Error())
+				return nil
+			}
+			volumes[i] = newSnapshotTopologyVolume(pending[i], &vol.TimeFinderInfo)
+			return nil
+		})
+		if err != nil {
+			log.Error("BuildSnapshotTopology failed: " + err.Error())
+			return topology, err
+		}
+
+		related := make([]string, 0)
+		for i, vol := range volumes {
+			if errs[i] != nil {
+				msgs = append(msgs, errs[i].Error())
+				continue
+			}
+			topology.Volumes[vol.VolumeID] = vol
+			if vol.LinkedFrom != nil {
+				related = append(related, vol.LinkedFrom.SourceVolumeID)
+			}
+			for _, snapshot := range vol.Snapshots {
+				for _, target := range snapshot.Targets {
+					related = append(related, target.VolumeID)
+				}
+			}
+		}
+		pending = pending[:0]
+		for _, volID := range related {
+			if volID != "" && !queued[volID] {
+				queued[volID] = true
+				pending = append(pending, volID)
+			}
+		}
+	}
+	if len(msgs) != 0 {
+		err := fmt.Errorf("BuildSnapshotTopology failed to read %d volumes: %s", len(msgs), strings.Join(msgs, "; "))
+		log.Error(err.Error())
+		return topology, err
+	}
+	return topology, nil
+}
+
+// newSnapshotTopologyVolume returns the snapshot sessions of a volume
+func newSnapshotTopologyVolume(volID string, info *types.TimeFinderInfo) *SnapshotTopologyVolume {
+	vol := &SnapshotTopologyVolume{VolumeID: volID, Snapshots: make([]SnapshotGeneration, 0)}
+	for _, session := range info.SnapVXSession {
+		for _, src := range session.SourceSnapshotGenInfo {
+			snapshot := SnapshotGeneration{
+				SnapshotName: src.SnapshotHeader.SnapshotName,
+				Generation:   src.SnapshotHeader.Generation,
+				Timestamp:    src.SnapshotHeader.Timestamp,
+				Expired:      src.SnapshotHeader.Expired,
+				Secured:      src.SnapshotHeader.Secured,
+				Targets:      make([]SnapshotLinkTarget, 0),
+			}
+			for _, link := range src.LinkSnapshotGenInfo {
+				snapshot.Targets = append(snapshot.Targets, SnapshotLinkTarget{
+					VolumeID: link.TargetDevice,
+					State:    link.State,
+					Defined:  link.Defined,
+				})
+			}
+			sort.Slice(snapshot.Targets, func(i, j int) bool {
+				return snapshot.Targets[i].VolumeID < snapshot.Targets[j].VolumeID
+			})
+			vol.Snapshots = append(vol.Snapshots, snapshot)
+		}
+		if tgt := session.TargetSourceSnapshotGenInfo; tgt != nil && tgt.SourceDevice != "" {
+			vol.LinkedFrom = &SnapshotLinkSource{
+				SourceVolumeID: tgt.SourceDevice,
+				SnapshotName:   tgt.SnapshotName,
+				Generation:     tgt.Generation,
+				State:          tgt.Defined,
+			}
+		}
+	}
+	sort.Slice(vol.Snapshots, func(i, j int) bool {
+		if vol.Snapshots[i].SnapshotName != vol.Snapshots[j].SnapshotName {
+			return vol.Snapshots[i].SnapshotName < vol.Snapshots[j].SnapshotName
+		}
+		return vol.Snapshots[i].Generation < vol.Snapshots[j].Generation
+	})
+	return vol
+}
+
 // GetReplicationCapabilities returns details about SnapVX and SRDF
 // execution capabilities on the Symmetrix array
 func (c *Client) GetReplicationCapabilities(ctx context.Context) (*types.SymReplicationCapabilities, error) {
 	defer c.TimeSpent("GetReplicationCapabilities", time.Now())
 	URL := c.urlPrefix() + ReplicationX + "capabilities/symmetrix"
 	symReplicationCapabilities := new(types.SymReplicationCapabilities)
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), symReplicationCapabilities)
 	if err != nil {
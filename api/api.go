@@ -23,9 +23,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"mime"
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -110,13 +115,20 @@ type Client interface {
 }
 
 type client struct {
-	http     *http.Client
-	host     string
-	token    string
-	showHTTP bool
-	debug    bool
+	http          *http.Client
+	host          string
+	token         string
+	showHTTP      bool
+	debug         bool
+	maxRetries    int
+	retryInterval time.Duration
+	warningHook   WarningHook
 }
 
+// defaultRetryInterval is the wait between retries of a transient failure
+// when ClientOptions.RetryInterval is not set
+const defaultRetryInterval = 1 * time.Second
+
 // ClientOptions are options for the API client.
 type ClientOptions struct {
 	// Insecure is a flag that indicates whether or not to supress SSL errors.
@@ -131,6 +143,64 @@ type ClientOptions struct {
 	// ShowHTTP is a flag that indicates whether or not HTTP requests and
 	// responses should be logged to stdout
 	ShowHTTP bool
+
+	// MaxRetries is the number of times a request is re-sent after a
+	// transient failure (connection error, 502, 503 or 504). Zero disables retries.
+	// POST and PUT requests, which may have been applied before the failure,
+	// are only re-sent after a 503 or a failure to send them.
+	MaxRetries int
+
+	// RetryInterval is the time to wait between retries.
+	// Defaults to one second.
+	RetryInterval time.Duration
+
+	// Transport tunes the HTTP transport used to reach Unisphere.
+	Transport TransportOptions
+
+	// WarningHook, if set, is called with the responses which carry a
+	// Warning, Deprecation or Sunset header.
+	WarningHook WarningHook
+}
+
+// WarningHook is called with the method and path of a request and the headers
+// of its response, which warn about the request, e.g. that the API version is deprecated.
+type WarningHook func(method, path string, header http.Header)
+
+// TransportOptions control the protocol and connection reuse of the HTTP transport.
+// The zero value keeps the Go defaults, except that HTTP/2 is only negotiated when EnableHTTP2 is set.
+type TransportOptions struct {
+	// ForceHTTP1 disables HTTP/2 negotiation, for load balancers which mishandle it.
+	ForceHTTP1 bool
+
+	// EnableHTTP2 negotiates HTTP/2 with servers which support it. Ignored if ForceHTTP1 is set.
+	EnableHTTP2 bool
+
+	// TLSSessionCacheSize is the number of TLS sessions kept for resumption. Zero disables resumption.
+	TLSSessionCacheSize int
+
+	// DisableKeepAlives closes the connection after every request.
+	DisableKeepAlives bool
+
+	// KeepAlive is the interval between TCP keep-alive probes. Negative disables them.
+	KeepAlive time.Duration
+
+	// IdleConnTimeout is how long an idle connection is kept open for reuse.
+	IdleConnTimeout time.Duration
+
+	// MaxIdleConnsPerHost is the number of idle connections kept open to Unisphere.
+	MaxIdleConnsPerHost int
+
+	// FIPSCompatibleTLS restricts TLS to version 1.2 with the FIPS 140-2 approved
+	// AES-GCM cipher suites and NIST curves.
+	FIPSCompatibleTLS bool
+}
+
+// fipsCipherSuites are the FIPS 140-2 approved cipher suites used when FIPSCompatibleTLS is set
+var fipsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
 }
 
 // New returns a new API client.
@@ -155,11 +225,10 @@ func New(
 	}
 
 	if opts.Insecure {
-		c.http.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		}
+		c.http.Transport = newTransport(opts.Transport, &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: true, // #nosec G402 -- only if the caller asked for it
+		})
 	} else {
 		// Loading system certs by default if insecure is set to false
 		// TODO: Check if we need to remove references to UseCerts from the code
@@ -167,12 +236,11 @@ func New(
 		if err != nil {
 			return nil, errSysCerts
 		}
-		c.http.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				RootCAs:            pool,
-				InsecureSkipVerify: false,
-			},
-		}
+		c.http.Transport = newTransport(opts.Transport, &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			RootCAs:            pool,
+			InsecureSkipVerify: false,
+		})
 	}
 
 	if opts.ShowHTTP {
@@ -181,9 +249,48 @@ func New(
 
 	c.debug = debug
 
+	if opts.MaxRetries > 0 {
+		c.maxRetries = opts.MaxRetries
+	}
+	c.retryInterval = defaultRetryInterval
+	if opts.RetryInterval > 0 {
+		c.retryInterval = opts.RetryInterval
+	}
+	c.warningHook = opts.WarningHook
+
 	return c, nil
 }
 
+// newTransport returns an http.Transport using tlsConfig and tuned by opts
+func newTransport(opts TransportOptions, tlsConfig *tls.Config) *http.Transport {
+	if opts.TLSSessionCacheSize > 0 {
+		tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(opts.TLSSessionCacheSize)
+	}
+	if opts.FIPSCompatibleTLS {
+		tlsConfig.MinVersion = tls.VersionTLS12
+		tlsConfig.MaxVersion = tls.VersionTLS12
+		tlsConfig.CipherSuites = fipsCipherSuites
+		tlsConfig.CurvePreferences = []tls.CurveID{tls.CurveP256, tls.CurveP384}
+	}
+	dialer := &net.Dialer{
+		KeepAlive: opts.KeepAlive,
+	}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSClientConfig:     tlsConfig,
+		DisableKeepAlives:   opts.DisableKeepAlives,
+		IdleConnTimeout:     opts.IdleConnTimeout,
+		MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
+	}
+	if opts.ForceHTTP1 {
+		// A non-nil empty map stops the transport from upgrading to HTTP/2
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	} else if opts.EnableHTTP2 {
+		transport.ForceAttemptHTTP2 = true
+	}
+	return transport
+}
+
 func (c *client) GetHTTPClient() *http.Client {
 	return c.http
 }
@@ -265,8 +372,7 @@ func (c *client) DoWithHeaders(
 		if resp == nil {
 			return nil
 		}
-		dec := json.NewDecoder(res.Body)
-		if err = dec.Decode(resp); err != nil && err != io.EOF {
+		if err = DecodeJSON(res, resp); err != nil && err != io.EOF {
 			c.doLog(log.WithError(err).Error,
 				fmt.Sprintf("Unable to decode response into %+v",
 					resp))
@@ -295,6 +401,7 @@ func (c *client) DoAndGetResponseBody(
 		uriBeginsWithSlash = beginsWithSlash(uri)
 	)
 
+	ubf.Grow(len(c.host) + 1 + luri)
 	ubf.WriteString(c.host)
 
 	if !hostEndsWithSlash && (luri > 0) {
@@ -314,46 +421,115 @@ func (c *client) DoAndGetResponseBody(
 		return nil, err
 	}
 
-	var isContentTypeSet bool
-
-	// marshal the message body (assumes json format)
+	// marshal the message body (assumes json format) into a byte slice
+	// so that it can be re-sent if the request has to be retried
+	var (
+		payload     []byte
+		contentType string
+	)
 	if r, ok := body.(io.ReadCloser); ok {
-		req, err = http.NewRequest(method, u.String(), r)
-		defer r.Close()
-		if v, ok := headers[HeaderKeyContentType]; ok {
-			req.Header.Set(HeaderKeyContentType, v)
-		} else {
-			req.Header.Set(
-				HeaderKeyContentType, headerValContentTypeBinaryOctetStream)
+		payload, err = ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, err
 		}
-		isContentTypeSet = true
+		contentType = headerValContentTypeBinaryOctetStream
 	} else if body != nil {
 		buf := &bytes.Buffer{}
 		enc := json.NewEncoder(buf)
 		if err = enc.Encode(body); err != nil {
 			return nil, err
 		}
-		req, err = http.NewRequest(method, u.String(), buf)
-		if v, ok := headers[HeaderKeyContentType]; ok {
-			req.Header.Set(HeaderKeyContentType, v)
+		payload = buf.Bytes()
+		contentType = HeaderValContentTypeJSON
+	}
+	if v, ok := headers[HeaderKeyContentType]; ok && contentType != "" {
+		contentType = v
+	}
+
+	// add headers to the request
+	headers = requestHeaders(ctx, headers, body)
+
+	for attempt := 0; ; attempt++ {
+		if req, err = c.newRequest(ctx, method, u.String(), headers, payload, contentType); err != nil {
+			return nil, err
+		}
+
+		if c.showHTTP {
+			logRequest(ctx, req, c.doLog)
+		}
+
+		// send the request, noting whether any of it reached the connection
+		var sent int32
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+			WroteHeaders: func() { atomic.StoreInt32(&sent, 1) },
+		}))
+		res, err = c.http.Do(req)
+		if attempt >= c.maxRetries || !isTransient(ctx, method, atomic.LoadInt32(&sent) == 1, res, err) {
+			break
+		}
+		if err != nil {
+			c.doLog(log.WithError(err).Warn,
+				fmt.Sprintf("%s %s failed, retrying (%d/%d)", method, u.Path, attempt+1, c.maxRetries))
 		} else {
-			req.Header.Set(HeaderKeyContentType, HeaderValContentTypeJSON)
+			c.doLog(log.Warn,
+				fmt.Sprintf("%s %s returned %d, retrying (%d/%d)", method, u.Path, res.StatusCode, attempt+1, c.maxRetries))
+			// drain the body so that the connection can be reused
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%s %s aborted: %w", method, u.Path, ctx.Err())
+		case <-time.After(c.retryInterval):
 		}
-		isContentTypeSet = true
-	} else {
-		req, err = http.NewRequest(method, u.String(), nil)
 	}
-
 	if err != nil {
+		// a canceled or expired context aborts the request in flight
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("%s %s aborted: %w", method, u.Path, ctxErr)
+		}
 		return nil, err
 	}
 
-	if !isContentTypeSet {
-		isContentTypeSet = req.Header.Get(HeaderKeyContentType) != ""
+	if c.showHTTP {
+		logResponse(ctx, res, c.doLog)
+	}
+	recordResponse(ctx, method, u.Path, res)
+	if c.warningHook != nil && hasWarnings(res.Header) {
+		c.warningHook(method, u.Path, res.Header.Clone())
 	}
 
-	// add headers to the request
-	addMetaData(headers, body)
+	return res, err
+}
+
+// newRequest builds a request with a fresh reader over payload, so the
+// same body can be sent once per attempt
+func (c *client) newRequest(
+	ctx context.Context,
+	method, uri string,
+	headers map[string]string,
+	payload []byte,
+	contentType string) (*http.Request, error) {
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, uri, body)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(payload)), nil
+		}
+	}
+
+	isContentTypeSet := contentType != ""
+	if isContentTypeSet {
+		req.Header.Set(HeaderKeyContentType, contentType)
+	}
 	for header, value := range headers {
 		if header == HeaderKeyContentType && isContentTypeSet {
 			continue
@@ -366,21 +542,36 @@ func (c *client) DoAndGetResponseBody(
 		req.SetBasicAuth("", c.token)
 	}
 
-	if c.showHTTP {
-		logRequest(ctx, req, c.doLog)
-	}
+	return req.WithContext(ctx), nil
+}
 
-	// send the request
-	req = req.WithContext(ctx)
-	if res, err = c.http.Do(req); err != nil {
-		return nil, err
+// isTransient returns true if the outcome of a request is worth retrying.
+// A request which is not idempotent may have been applied before a connection error,
+// a 502 or a 504, so it is only retried after a 503, which Unisphere returns without
+// processing the request, or if it failed before anything was sent.
+func isTransient(ctx context.Context, method string, sent bool, res *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
 	}
-
-	if c.showHTTP {
-		logResponse(ctx, res, c.doLog)
+	if err != nil {
+		return !sent || isIdempotent(method)
+	}
+	switch res.StatusCode {
+	case http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return isIdempotent(method)
 	}
+	return false
+}
 
-	return res, err
+// isIdempotent returns true if sending a request with method more than once has the same effect as sending it once
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodDelete:
+		return true
+	}
+	return false
 }
 
 func (c *client) SetToken(token string) {
@@ -392,13 +583,19 @@ func (c *client) GetToken() string {
 }
 
 func (c *client) ParseJSONError(r *http.Response) error {
-	jsonError := &types.Error{}
-	if err := json.NewDecoder(r.Body).Decode(jsonError); err != nil {
-		if err != nil {
-			jsonError.HTTPStatusCode = r.StatusCode
-			jsonError.Message = http.StatusText(r.StatusCode)
-			return jsonError
+	jsonError := &types.Error{
+		RequestID: requestID(r.Header),
+		Header:    r.Header,
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(r.Body, maxErrorBodySize))
+	if err := json.Unmarshal(body, jsonError); err != nil {
+		jsonError.HTTPStatusCode = r.StatusCode
+		jsonError.Message = http.StatusText(r.StatusCode)
+		jsonError.BodySnippet = bodySnippet(body)
+		if jsonError.BodySnippet != "" {
+			jsonError.Message += " (" + contentType(r) + " response: " + jsonError.BodySnippet + ")"
 		}
+		return jsonError
 	}
 
 	jsonError.HTTPStatusCode = r.StatusCode
@@ -409,6 +606,87 @@ func (c *client) ParseJSONError(r *http.Response) error {
 	return jsonError
 }
 
+// MaxBodySnippetSize is the number of bytes of an unexpected response body kept in types.Error.BodySnippet
+const MaxBodySnippetSize = 256
+
+// maxErrorBodySize is the number of bytes of an error response which are read to parse it
+const maxErrorBodySize = 64 * 1024
+
+// DecodeJSON decodes the body of a successful response into v. If the body is not JSON, e.g. the
+// HTML error page of a load balancer, or is truncated, a *types.Error with the start of the body is
+// returned instead of the bare decoding error. An empty body returns io.EOF.
+// The response is taken to be JSON unless its content type says otherwise; text/plain is accepted,
+// as it is sniffed by servers which do not set the content type.
+func DecodeJSON(res *http.Response, v interface{}) error {
+	snippet := &snippetWriter{}
+	// the snippet is kept for every response, so allocate it at once rather than as it grows
+	snippet.Grow(MaxBodySnippetSize + 1)
+	body := io.TeeReader(res.Body, snippet)
+	if !isJSONContentType(res) {
+		ioutil.ReadAll(io.LimitReader(body, MaxBodySnippetSize))
+		return &types.Error{
+			Message:        fmt.Sprintf("unexpected %s response: %s", contentType(res), bodySnippet(snippet.Bytes())),
+			HTTPStatusCode: res.StatusCode,
+			BodySnippet:    bodySnippet(snippet.Bytes()),
+		}
+	}
+	err := json.NewDecoder(body).Decode(v)
+	if err == nil || (err == io.EOF && snippet.Len() == 0) {
+		return err
+	}
+	return &types.Error{
+		Message:        fmt.Sprintf("unable to decode %s response: %s (body: %s)", contentType(res), err.Error(), bodySnippet(snippet.Bytes())),
+		HTTPStatusCode: res.StatusCode,
+		BodySnippet:    bodySnippet(snippet.Bytes()),
+	}
+}
+
+// isJSONContentType returns false if the content type of res is set to something other than JSON or plain text
+func isJSONContentType(res *http.Response) bool {
+	value := res.Header.Get(HeaderKeyContentType)
+	if value == "" || value == HeaderValContentTypeJSON {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/plain" || mediaType == HeaderValContentTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+func contentType(res *http.Response) string {
+	if value := res.Header.Get(HeaderKeyContentType); value != "" {
+		return value
+	}
+	return "untyped"
+}
+
+// bodySnippet returns the start of body, with redacted secrets, for an error message
+func bodySnippet(body []byte) string {
+	snippet := strings.TrimSpace(string(body))
+	if len(snippet) > MaxBodySnippetSize {
+		snippet = snippet[:MaxBodySnippetSize] + "..."
+	}
+	return RedactSecrets(snippet)
+}
+
+// snippetWriter keeps the first bytes written to it, up to one more than MaxBodySnippetSize
+// so that bodySnippet knows whether the body was truncated
+type snippetWriter struct {
+	bytes.Buffer
+}
+
+func (s *snippetWriter) Write(p []byte) (int, error) {
+	if room := MaxBodySnippetSize + 1 - s.Len(); room > 0 {
+		if len(p) > room {
+			s.Buffer.Write(p[:room])
+		} else {
+			s.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
 func (c *client) doLog(
 	l func(args ...interface{}),
 	msg string) {
@@ -418,17 +696,62 @@ func (c *client) doLog(
 	}
 }
 
-func addMetaData(headers map[string]string, body interface{}) {
+// headersKey is the context key under which WithHeaders stores the headers
+type headersKey struct{}
+
+// WithHeaders returns a copy of ctx with which every request is also sent with headers, e.g. those
+// an endpoint needs for asynchronous behavior or payload versioning. They take precedence over the
+// headers of the request, and are merged with those already set on ctx.
+func WithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	merged := make(map[string]string)
+	if previous, ok := ctx.Value(headersKey{}).(map[string]string); ok {
+		for k, v := range previous {
+			merged[k] = v
+		}
+	}
+	for k, v := range headers {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, headersKey{}, merged)
+}
+
+// requestHeaders returns the headers of a request with the metadata of body and the headers set
+// on ctx with WithHeaders. headers is not modified, so the callers can share it between requests.
+func requestHeaders(ctx context.Context, headers map[string]string, body interface{}) map[string]string {
+	headers = addMetaData(headers, body)
+	extra, ok := ctx.Value(headersKey{}).(map[string]string)
+	if !ok || len(extra) == 0 {
+		return headers
+	}
+	merged := make(map[string]string, len(headers)+len(extra))
+	for k, v := range headers {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+// addMetaData returns a copy of headers with the metadata of body, or headers itself if body has none
+func addMetaData(headers map[string]string, body interface{}) map[string]string {
 	if headers == nil || body == nil {
-		return
+		return headers
 	}
 	// If the body contains a MetaData method, extract the data
 	// and add as HTTP headers.
 	if usgp, ok := interface{}(body).(interface {
 		MetaData() http.Header
 	}); ok {
-		for k := range usgp.MetaData() {
-			headers[k] = usgp.MetaData().Get(k)
+		metaData := usgp.MetaData()
+		merged := make(map[string]string, len(headers)+len(metaData))
+		for k, v := range headers {
+			merged[k] = v
+		}
+		for k := range metaData {
+			merged[k] = metaData.Get(k)
 		}
+		return merged
 	}
+	return headers
 }
@@ -24,6 +24,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
+	"regexp"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -53,7 +54,7 @@ func logRequest(
 	WriteIndented(w, buf)
 	fmt.Fprintln(w)
 
-	lf(log.Debug, w.String())
+	lf(log.Debug, RedactSecrets(w.String()))
 }
 
 func logResponse(
@@ -84,7 +85,23 @@ func logResponse(
 		fmt.Fprintln(w, scanner.Text())
 	}
 
-	log.Debug(w.String())
+	log.Debug(RedactSecrets(w.String()))
+}
+
+const redacted = "******"
+
+var (
+	secretJSONRegex   = regexp.MustCompile(`(?i)("[^"]*(password|passwd|secret|token|credential|chap)[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	secretHeaderRegex = regexp.MustCompile(`(?im)^(\s*(authorization|proxy-authorization|cookie|set-cookie|x-auth-token)\s*:\s*).*$`)
+	urlUserRegex      = regexp.MustCompile(`(?i)(https?://)[^/@\s]+@`)
+)
+
+// RedactSecrets masks the values of JSON fields and HTTP headers which carry credentials,
+// such as passwords, tokens and cookies, and the user information of URLs in s.
+func RedactSecrets(s string) string {
+	s = secretJSONRegex.ReplaceAllString(s, `$1"`+redacted+`"`)
+	s = secretHeaderRegex.ReplaceAllString(s, "${1}"+redacted)
+	return urlUserRegex.ReplaceAllString(s, "${1}"+redacted+"@")
 }
 
 // WriteIndentedN indents all lines n spaces.
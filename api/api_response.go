@@ -0,0 +1,128 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Headers of a response which carry the ID of the request, in order of preference
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id", "X-Trace-Id"}
+
+// Headers of a response which warn about the request, e.g. that the API version is deprecated
+var warningHeaders = []string{"Warning", "Deprecation", "Sunset"}
+
+// ResponseInfo describes the last response to the requests made with a context returned by
+// WithResponseInfo, e.g. to log the request ID Unisphere gave a call which returned unexpected data.
+type ResponseInfo struct {
+	mutex      sync.Mutex
+	method     string
+	path       string
+	statusCode int
+	header     http.Header
+}
+
+// responseInfoKey is the context key under which WithResponseInfo stores the ResponseInfo
+type responseInfoKey struct{}
+
+// WithResponseInfo returns a copy of ctx with which every request records its response into info.
+// A call sending several requests, possibly at once, records the last one to complete.
+func WithResponseInfo(ctx context.Context, info *ResponseInfo) context.Context {
+	return context.WithValue(ctx, responseInfoKey{}, info)
+}
+
+// recordResponse records res into the ResponseInfo of ctx, if any
+func recordResponse(ctx context.Context, method, path string, res *http.Response) {
+	info, ok := ctx.Value(responseInfoKey{}).(*ResponseInfo)
+	if !ok || info == nil || res == nil {
+		return
+	}
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+	info.method = method
+	info.path = path
+	info.statusCode = res.StatusCode
+	info.header = res.Header.Clone()
+}
+
+// Method returns the method of the request, e.g. GET
+func (info *ResponseInfo) Method() string {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+	return info.method
+}
+
+// Path returns the path of the request
+func (info *ResponseInfo) Path() string {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+	return info.path
+}
+
+// StatusCode returns the HTTP status of the response, or 0 if no response was received
+func (info *ResponseInfo) StatusCode() int {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+	return info.statusCode
+}
+
+// Header returns the headers of the response
+func (info *ResponseInfo) Header() http.Header {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+	return info.header.Clone()
+}
+
+// RequestID returns the ID the server gave the request, to quote when opening a service request
+func (info *ResponseInfo) RequestID() string {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+	return requestID(info.header)
+}
+
+// Warnings returns the warnings of the response, e.g. that the API version is deprecated
+func (info *ResponseInfo) Warnings() []string {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+	warnings := make([]string, 0)
+	for _, key := range warningHeaders {
+		for _, value := range info.header.Values(key) {
+			warnings = append(warnings, key+": "+value)
+		}
+	}
+	return warnings
+}
+
+// hasWarnings returns true if h has a header which warns about the request
+func hasWarnings(h http.Header) bool {
+	for _, key := range warningHeaders {
+		if h.Get(key) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// requestID returns the first request ID header of h
+func requestID(h http.Header) string {
+	for _, key := range requestIDHeaders {
+		if id := h.Get(key); id != "" {
+			return id
+		}
+	}
+	return ""
+}
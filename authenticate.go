@@ -20,8 +20,11 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/dell/gopowermax/api"
@@ -37,22 +40,181 @@ type Client struct {
 	version        string
 	symmetrixID    string
 	contextTimeout time.Duration
+	readTimeout    time.Duration
+	writeTimeout   time.Duration
+	longJobTimeout time.Duration
+	logPayloads    bool
+	maxPayloadLog  int
+	// maxConcurrentRequests bounds the requests of forEachConcurrently
+	maxConcurrentRequests int
+	// noFieldSelection is set once Unisphere rejected the select query parameter
+	noFieldSelection int32
+	// noVolumeExpansion is set once Unisphere rejected the expansion of the volumes of a storage group
+	noVolumeExpansion int32
+	// noPublicSnapVolumeList is set once Unisphere answered that it has no public snapshot volume list
+	noPublicSnapVolumeList int32
+	// noInitiatorAliasFilter is set once Unisphere rejected the alias query parameter of the initiators
+	noInitiatorAliasFilter int32
+	checkSnapshotLimits    bool
+	locker                 Locker
+	capabilities           *capabilityCache
+	// skipAllowedArrayCheck and arrayAuthorizer replace the allowed arrays in IsAllowedArray
+	skipAllowedArrayCheck bool
+	arrayAuthorizer       ArrayAuthorizer
+	// headerHook customizes the default headers, which are cached in headers
+	headerHook HeaderHook
+	headers    *headerCache
+	// contentType and applicationType are the Accept and Content-Type, and Application-Type headers
+	contentType     string
+	applicationType string
 }
 
+// ClientOptions are the optional settings of a Client created by NewClientWithOptions.
+// A zero timeout falls back to the client's default timeout (see SetContextTimeout).
+type ClientOptions struct {
+	// Insecure is a flag that indicates whether or not to supress SSL errors.
+	Insecure bool
+
+	// UseCerts is a flag that indicates whether system certs should be loaded
+	UseCerts bool
+
+	// ReadTimeout is the time limit for calls which only read from Unisphere.
+	ReadTimeout time.Duration
+
+	// WriteTimeout is the time limit for calls which create, modify or delete objects.
+	WriteTimeout time.Duration
+
+	// LongJobTimeout is the time limit for synchronous storage group and replication
+	// updates, and for waiting on job completion, which may take much longer than a GET
+	// when thousands of devices are involved.
+	LongJobTimeout time.Duration
+
+	// MaxRetries is the number of times a request is re-sent after a transient failure.
+	MaxRetries int
+
+	// Transport selects the HTTP protocol version and tunes TLS session resumption and
+	// keep-alive, e.g. for Unisphere deployments behind a load balancer.
+	Transport api.TransportOptions
+
+	// ReadOnly makes every method that would create, modify or delete an object on the array
+	// fail with ErrReadOnlyClient instead of sending the request to Unisphere.
+	ReadOnly bool
+
+	// AllowHTTP permits an http:// endpoint, e.g. a test server. Credentials are then sent
+	// in clear text, so it must not be used with a real Unisphere.
+	AllowHTTP bool
+
+	// LogPayloads logs the request payloads of the calls which create or modify objects,
+	// with passwords and other secrets redacted.
+	LogPayloads bool
+
+	// MaxPayloadLogSize is the number of bytes of a payload which are logged before it is
+	// truncated; zero selects DefaultMaxPayloadLogSize.
+	MaxPayloadLogSize int
+
+	// MaxConcurrentRequests is the number of requests the client has outstanding at once when it
+	// reads or waits for several objects concurrently; zero selects DefaultMaxConcurrentRequests.
+	MaxConcurrentRequests int
+
+	// CheckSnapshotLimits makes CreateSnapshot fail with ErrSnapshotLimitReached, without sending
+	// the request, if a source volume or the array has as many snapshots as the array allows.
+	CheckSnapshotLimits bool
+
+	// Locker, if set, serializes the requests modifying the same storage group or masking view,
+	// which Unisphere rejects when they run concurrently, and the jobs they start which the client
+	// waits for. See NewLockManager.
+	Locker Locker
+
+	// SkipAllowedArrayCheck allows every array, whatever the allowed arrays set with SetAllowedArrays,
+	// for callers which authorize the access to the arrays themselves.
+	SkipAllowedArrayCheck bool
+
+	// ArrayAuthorizer, if set, decides in place of the allowed arrays whether an array can be
+	// manipulated; the array is rejected with the error it returns.
+	ArrayAuthorizer ArrayAuthorizer
+
+	// CapabilityRefreshInterval is how long GetArrayCapabilities caches the capabilities of an
+	// array; zero selects DefaultCapabilityRefreshInterval.
+	CapabilityRefreshInterval time.Duration
+
+	// HeaderHook, if set, customizes the headers sent with every request. The headers of a single
+	// call can be set with WithHeaders.
+	HeaderHook HeaderHook
+
+	// DeprecationWarnings, if set, receives a DeprecationWarning when Unisphere warns that an endpoint
+	// or the API version in use is deprecated. Each distinct warning is sent once; it is dropped, and
+	// logged, if the channel is full.
+	DeprecationWarnings chan<- DeprecationWarning
+
+	// DeprecationHandler, if set, is called with the same warnings as DeprecationWarnings
+	DeprecationHandler DeprecationHandler
+}
+
+// HeaderHook adds, changes or removes the default headers of the requests of a client. It is called
+// when the headers are built, i.e. on the first request and after the credentials or the default
+// array change, rather than on every request.
+type HeaderHook func(headers map[string]string)
+
+// ArrayAuthorizer returns an error if the array symID must not be manipulated
+type ArrayAuthorizer func(symID string) error
+
+// DefaultMaxPayloadLogSize is the number of bytes of a payload logged when
+// ClientOptions.MaxPayloadLogSize is not set.
+const DefaultMaxPayloadLogSize = 4096
+
+// operationClass selects which of the client's timeouts applies to a call
+type operationClass int
+
+const (
+	defaultOperation operationClass = iota
+	readOperation
+	writeOperation
+	longJobOperation
+)
+
+// timeoutKey is the context key under which WithTimeout stores a per-call timeout
+type timeoutKey struct{}
+
+// ErrReadOnlyClient is returned, wrapped with the rejected request, by a client created with
+// ClientOptions.ReadOnly for any request that would mutate the array.
+var ErrReadOnlyClient = errors.New("client is read-only")
+
 var (
 	errNilReponse    = errors.New("nil response from API")
 	errBodyRead      = errors.New("error reading body")
 	errNoLink        = errors.New("Error: problem finding link")
 	debug, _         = strconv.ParseBool(os.Getenv("X_CSI_POWERMAX_DEBUG"))
-	accHeader        string
-	conHeader        string
-	applicationType  string
 	logResponseTimes bool
 	// PmaxTimeout is the timeout value for pmax calls.
 	// If Unisphere fails to answer within this period, an error will be returned.
 	defaultPmaxTimeout = 10 * time.Minute
 )
 
+// validateEndpoint checks that endpoint is an https URL, or an http URL if allowHTTP is set,
+// without embedded credentials. The errors do not quote the endpoint, as it may contain a password.
+func validateEndpoint(endpoint string, allowHTTP bool) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("Endpoint is not a valid URL, e.g. https://1.2.3.4:8443")
+	}
+	if u.User != nil {
+		return fmt.Errorf("Endpoint must not contain credentials, use ConfigConnect instead")
+	}
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !allowHTTP {
+			return fmt.Errorf("Endpoint must use https unless http is explicitly allowed")
+		}
+	default:
+		return fmt.Errorf("Endpoint must use https, e.g. https://1.2.3.4:8443")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("Endpoint must include a host, e.g. https://1.2.3.4:8443")
+	}
+	return nil
+}
+
 // Authenticate and get API version
 func (c *Client) Authenticate(ctx context.Context, configConnect *ConfigConnect) error {
 	if debug {
@@ -61,22 +223,16 @@ func (c *Client) Authenticate(ctx context.Context, configConnect *ConfigConnect)
 	}
 
 	c.configConnect = configConnect
+	c.headers.reset()
 	c.api.SetToken("")
 	basicAuthString := basicAuth(configConnect.Username, configConnect.Password)
 
 	headers := make(map[string]string, 1)
 	headers["Authorization"] = "Basic " + basicAuthString
 
-	path := "univmax/restapi/" + c.version + "/system/version"
-
-	if c.version != APIVersion90 {
-		// Path for version has been changed from u4p 91 onwards
-		path = "univmax/restapi/" + "version"
-	}
-
 	ctx, cancel := c.GetTimeoutContext(ctx)
 	defer cancel()
-	resp, err := c.api.DoAndGetResponseBody(ctx, http.MethodGet, path, headers, nil)
+	resp, err := c.api.DoAndGetResponseBody(ctx, http.MethodGet, c.getVersionURL(), headers, nil)
 	if err != nil {
 		doLog(log.WithError(err).Error, "")
 		return err
@@ -95,10 +251,54 @@ func (c *Client) Authenticate(ctx context.Context, configConnect *ConfigConnect)
 }
 
 // GetTimeoutContext sets up a timeout of time PmaxTimeout for the returned context.
+// A timeout set on ctx with WithTimeout takes precedence.
 // The user caller should call the cancel function that is returned.
 func (c *Client) GetTimeoutContext(ctx context.Context) (context.Context, context.CancelFunc) {
-	ctx, cancel := context.WithTimeout(ctx, c.contextTimeout)
-	return ctx, cancel
+	return c.getTimeoutContext(ctx, defaultOperation)
+}
+
+// getTimeoutContext is GetTimeoutContext using the timeout configured for the class of operation
+func (c *Client) getTimeoutContext(ctx context.Context, class operationClass) (context.Context, context.CancelFunc) {
+	timeout := c.contextTimeout
+	switch class {
+	case readOperation:
+		if c.readTimeout > 0 {
+			timeout = c.readTimeout
+		}
+	case writeOperation:
+		if c.writeTimeout > 0 {
+			timeout = c.writeTimeout
+		}
+	case longJobOperation:
+		if c.longJobTimeout > 0 {
+			timeout = c.longJobTimeout
+		}
+	}
+	if t, ok := ctx.Value(timeoutKey{}).(time.Duration); ok && t > 0 {
+		timeout = t
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// WithTimeout returns a copy of ctx which overrides the client's timeout
+// for any call made with it, e.g. for an unusually large storage group update.
+func WithTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutKey{}, timeout)
+}
+
+// ResponseInfo describes the last response to the calls made with a context returned by WithResponseInfo
+type ResponseInfo = api.ResponseInfo
+
+// WithResponseInfo returns a copy of ctx with which the calls record the last response they receive
+// into info, e.g. its request ID, to quote when opening a service request.
+func WithResponseInfo(ctx context.Context, info *ResponseInfo) context.Context {
+	return api.WithResponseInfo(ctx, info)
+}
+
+// WithHeaders returns a copy of ctx with which the calls send headers along with the default ones,
+// e.g. those an endpoint needs for asynchronous behavior or payload versioning.
+func WithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	return api.WithHeaders(ctx, headers)
 }
 
 // Generate the base 64 Authorization string from username / password
@@ -142,6 +342,34 @@ func NewClientWithArgs(
 	insecure,
 	useCerts bool) (client Pmax, err error) {
 
+	maxRetries := 0
+	if retriesStr := os.Getenv("X_CSI_UNISPHERE_MAX_RETRIES"); retriesStr != "" {
+		if retries, err := strconv.Atoi(retriesStr); err == nil {
+			maxRetries = retries
+		} else {
+			doLog(log.WithError(err).Error, "Unable to parse Unisphere max retries")
+		}
+	}
+	allowHTTP, _ := strconv.ParseBool(os.Getenv("X_CSI_UNISPHERE_ALLOW_HTTP"))
+
+	return NewClientWithOptions(endpoint, version, applicationName, ClientOptions{
+		Insecure:   insecure,
+		UseCerts:   useCerts,
+		MaxRetries: maxRetries,
+		AllowHTTP:  allowHTTP,
+	})
+}
+
+// NewClientWithOptions is NewClientWithArgs with the optional settings, such as the
+// per operation class timeouts, given as ClientOptions.
+func NewClientWithOptions(
+	endpoint string,
+	version string,
+	applicationName string,
+	options ClientOptions) (client Pmax, err error) {
+
+	insecure, useCerts, maxRetries := options.Insecure, options.UseCerts, options.MaxRetries
+
 	logResponseTimes, _ = strconv.ParseBool(os.Getenv("X_CSI_POWERMAX_RESPONSE_TIMES"))
 
 	contextTimeout := defaultPmaxTimeout
@@ -156,13 +384,16 @@ func NewClientWithArgs(
 		version = DefaultAPIVersion
 	}
 	fields := map[string]interface{}{
-		"endpoint":         endpoint,
+		"endpoint":         api.RedactSecrets(endpoint),
 		"applicationName":  applicationName,
 		"insecure":         insecure,
 		"useCerts":         useCerts,
 		"version":          version,
 		"debug":            debug,
 		"logResponseTimes": logResponseTimes,
+		"maxRetries":       maxRetries,
+		"readOnly":         options.ReadOnly,
+		"logPayloads":      options.LogPayloads,
 	}
 
 	doLog(log.WithFields(fields).Debug, "pmax client init")
@@ -171,24 +402,37 @@ func NewClientWithArgs(
 		doLog(log.WithFields(fields).Error, "endpoint is required")
 		return nil, fmt.Errorf("Endpoint must be supplied, e.g. https://1.2.3.4:8443")
 	}
+	if err := validateEndpoint(endpoint, options.AllowHTTP); err != nil {
+		doLog(log.WithFields(fields).Error, err.Error())
+		return nil, err
+	}
 
 	opts := api.ClientOptions{
-		Insecure: insecure,
-		UseCerts: useCerts,
-		ShowHTTP: debug,
+		Insecure:   insecure,
+		UseCerts:   useCerts,
+		ShowHTTP:   debug,
+		MaxRetries: maxRetries,
+		Transport:  options.Transport,
 	}
-
-	if applicationType != "" {
-		log.Debug(fmt.Sprintf("Application type already set to: %s, Resetting it to: %s",
-			applicationType, applicationName))
+	if notifier := newDeprecationNotifier(options); notifier != nil {
+		opts.WarningHook = notifier.notify
 	}
-	applicationType = applicationName
 
 	ac, err := api.New(endpoint, opts, debug)
 	if err != nil {
 		doLog(log.WithError(err).Error, "Unable to create HTTP client")
 		return nil, err
 	}
+	if options.ReadOnly {
+		ac = &readOnlyAPI{Client: ac}
+	}
+	if options.Locker != nil {
+		ac = &lockingAPI{Client: ac, locker: options.Locker}
+	}
+	maxPayloadLog := options.MaxPayloadLogSize
+	if maxPayloadLog <= 0 {
+		maxPayloadLog = DefaultMaxPayloadLogSize
+	}
 
 	client = &Client{
 		api: ac,
@@ -198,21 +442,113 @@ func NewClientWithArgs(
 		allowedArrays:  []string{},
 		version:        version,
 		contextTimeout: contextTimeout,
+		readTimeout:    options.ReadTimeout,
+		writeTimeout:   options.WriteTimeout,
+		longJobTimeout: options.LongJobTimeout,
+		logPayloads:    options.LogPayloads,
+		maxPayloadLog:  maxPayloadLog,
+
+		maxConcurrentRequests: options.MaxConcurrentRequests,
+
+		checkSnapshotLimits: options.CheckSnapshotLimits,
+		locker:              options.Locker,
+		capabilities:        newCapabilityCache(options.CapabilityRefreshInterval),
+
+		skipAllowedArrayCheck: options.SkipAllowedArrayCheck,
+		arrayAuthorizer:       options.ArrayAuthorizer,
+		headerHook:            options.HeaderHook,
+		headers:               &headerCache{},
+		contentType:           api.HeaderValContentTypeJSON + ";version=" + version,
+		applicationType:       applicationName,
+	}
+
+	return client, nil
+}
+
+// readOnlyAPI wraps an api.Client and rejects every request but GET.
+// Deleting an iterator is allowed, as it only releases the server side state of a listing.
+type readOnlyAPI struct {
+	api.Client
+}
+
+func (r *readOnlyAPI) check(method, path string) error {
+	if method == http.MethodGet || (method == http.MethodDelete && strings.Contains(path, IteratorX)) {
+		return nil
 	}
+	return fmt.Errorf("%s %s rejected: %w", method, path, ErrReadOnlyClient)
+}
 
-	accHeader = api.HeaderValContentTypeJSON
-	if version != "" {
-		accHeader = accHeader + ";version=" + version
+func (r *readOnlyAPI) Do(ctx context.Context, method, path string, body, resp interface{}) error {
+	if err := r.check(method, path); err != nil {
+		return err
 	}
-	conHeader = accHeader
+	return r.Client.Do(ctx, method, path, body, resp)
+}
 
-	return client, nil
+func (r *readOnlyAPI) DoWithHeaders(ctx context.Context, method, path string, headers map[string]string, body, resp interface{}) error {
+	if err := r.check(method, path); err != nil {
+		return err
+	}
+	return r.Client.DoWithHeaders(ctx, method, path, headers, body, resp)
+}
+
+func (r *readOnlyAPI) DoAndGetResponseBody(ctx context.Context, method, path string, headers map[string]string, body interface{}) (*http.Response, error) {
+	if err := r.check(method, path); err != nil {
+		return nil, err
+	}
+	return r.Client.DoAndGetResponseBody(ctx, method, path, headers, body)
+}
+
+func (r *readOnlyAPI) Post(ctx context.Context, path string, headers map[string]string, body, resp interface{}) error {
+	if err := r.check(http.MethodPost, path); err != nil {
+		return err
+	}
+	return r.Client.Post(ctx, path, headers, body, resp)
+}
+
+func (r *readOnlyAPI) Put(ctx context.Context, path string, headers map[string]string, body, resp interface{}) error {
+	if err := r.check(http.MethodPut, path); err != nil {
+		return err
+	}
+	return r.Client.Put(ctx, path, headers, body, resp)
+}
+
+func (r *readOnlyAPI) Delete(ctx context.Context, path string, headers map[string]string, resp interface{}) error {
+	if err := r.check(http.MethodDelete, path); err != nil {
+		return err
+	}
+	return r.Client.Delete(ctx, path, headers, resp)
+}
+
+// GetHTTPClient returns a copy of the HTTP client whose transport rejects the same requests
+func (r *readOnlyAPI) GetHTTPClient() *http.Client {
+	httpClient := *r.Client.GetHTTPClient()
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	httpClient.Transport = &readOnlyTransport{RoundTripper: transport, api: r}
+	return &httpClient
+}
+
+// readOnlyTransport is the transport of the HTTP client of a read-only client
+type readOnlyTransport struct {
+	http.RoundTripper
+	api *readOnlyAPI
+}
+
+func (t *readOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.api.check(req.Method, req.URL.Path); err != nil {
+		return nil, err
+	}
+	return t.RoundTripper.RoundTrip(req)
 }
 
 // WithSymmetrixID sets the default array for the client
 func (c *Client) WithSymmetrixID(symmetrixID string) Pmax {
 	client := *c
 	client.symmetrixID = symmetrixID
+	client.headers = &headerCache{}
 	return &client
 }
 
@@ -222,18 +558,52 @@ func (c *Client) SetContextTimeout(timeout time.Duration) Pmax {
 	return c
 }
 
+// headerCache holds the default headers of a client, so that they are not built for every request
+type headerCache struct {
+	mutex   sync.Mutex
+	headers map[string]string
+}
+
+// reset makes the headers be built again on the next request
+func (hc *headerCache) reset() {
+	if hc == nil {
+		return
+	}
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+	hc.headers = nil
+}
+
+// getDefaultHeaders returns the headers of the requests to Unisphere. The map is shared by the
+// requests and must not be modified; WithHeaders sets the headers of a call.
 func (c *Client) getDefaultHeaders() map[string]string {
+	if c.headers == nil {
+		return c.buildDefaultHeaders()
+	}
+	c.headers.mutex.Lock()
+	defer c.headers.mutex.Unlock()
+	if c.headers.headers == nil {
+		c.headers.headers = c.buildDefaultHeaders()
+	}
+	return c.headers.headers
+}
+
+// buildDefaultHeaders returns the default headers customized by the HeaderHook of the client
+func (c *Client) buildDefaultHeaders() map[string]string {
 	headers := make(map[string]string)
-	headers["Accept"] = accHeader
-	if applicationType != "" {
-		headers["Application-Type"] = applicationType
+	headers["Accept"] = c.contentType
+	if c.applicationType != "" {
+		headers["Application-Type"] = c.applicationType
 	}
-	headers["Content-Type"] = conHeader
+	headers["Content-Type"] = c.contentType
 	basicAuthString := basicAuth(c.configConnect.Username, c.configConnect.Password)
 	headers["Authorization"] = "Basic " + basicAuthString
 	if c.symmetrixID != "" {
 		headers["symid"] = c.symmetrixID
 	}
+	if c.headerHook != nil {
+		c.headerHook(headers)
+	}
 	return headers
 }
 
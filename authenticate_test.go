@@ -0,0 +1,170 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func Test_DefaultHeaders(t *testing.T) {
+	var mutex sync.Mutex
+	received := make([]http.Header, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		received = append(received, r.Header.Clone())
+		mutex.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"symmetrixId": ["000197900046"]}`))
+	}))
+	defer server.Close()
+
+	hookCalls := 0
+	options := ClientOptions{
+		AllowHTTP: true,
+		HeaderHook: func(headers map[string]string) {
+			hookCalls++
+			headers["X-Client"] = "test"
+			delete(headers, "Application-Type")
+		},
+	}
+	client, err := NewClientWithOptions(server.URL, "", "", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Authenticate(context.Background(), &ConfigConnect{Username: "user", Password: "pass"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err = client.GetSymmetrixIDList(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := WithHeaders(context.Background(), map[string]string{"X-Call": "async"})
+	if _, err = client.GetSymmetrixIDList(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if hookCalls != 1 {
+		t.Errorf("expected the headers to be built once, got %d", hookCalls)
+	}
+	if len(received) != 5 {
+		t.Fatalf("expected 5 requests, got %d", len(received))
+	}
+	for i, header := range received[1:] {
+		if header.Get("X-Client") != "test" || header.Get("Authorization") == "" {
+			t.Errorf("request %d is missing the default headers: %v", i+1, header)
+		}
+	}
+	if received[3].Get("X-Call") != "" || received[4].Get("X-Call") != "async" {
+		t.Errorf("expected only the last request to have the call header, got %q and %q",
+			received[3].Get("X-Call"), received[4].Get("X-Call"))
+	}
+
+	// a client for another default array builds its own headers
+	arrayClient := client.WithSymmetrixID("000197900046")
+	if _, err = arrayClient.GetSymmetrixIDList(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if received[5].Get("symid") != "000197900046" || received[4].Get("symid") != "" {
+		t.Errorf("expected only the array client to send its array, got %q and %q",
+			received[5].Get("symid"), received[4].Get("symid"))
+	}
+	if hookCalls != 2 {
+		t.Errorf("expected the headers of the array client to be built, got %d builds", hookCalls)
+	}
+}
+
+func Test_ReadOnlyClientRejectsWrites(t *testing.T) {
+	var mutex sync.Mutex
+	methods := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		methods = append(methods, r.Method)
+		mutex.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(server.URL, "", "", ClientOptions{AllowHTTP: true, ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ac := client.(*Client).api
+	if err = ac.Do(context.Background(), http.MethodPost, "/univmax/restapi/90/sloprovisioning", map[string]string{}, nil); !errors.Is(err, ErrReadOnlyClient) {
+		t.Errorf("expected Do to reject a POST with ErrReadOnlyClient, got %v", err)
+	}
+	if err = ac.Do(context.Background(), http.MethodGet, "/univmax/restapi/90/sloprovisioning", nil, nil); err != nil {
+		t.Errorf("expected Do to send a GET, got %v", err)
+	}
+	httpClient := client.GetHTTPClient()
+	if _, err = httpClient.Post(server.URL+"/univmax/restapi/90/sloprovisioning", "application/json", nil); !errors.Is(err, ErrReadOnlyClient) {
+		t.Errorf("expected the HTTP client to reject a POST with ErrReadOnlyClient, got %v", err)
+	}
+	resp, err := httpClient.Get(server.URL + "/univmax/restapi/90/sloprovisioning")
+	if err != nil {
+		t.Fatalf("expected the HTTP client to send a GET, got %v", err)
+	}
+	resp.Body.Close()
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, method := range methods {
+		if method != http.MethodGet {
+			t.Errorf("expected only GET requests to reach the server, got a %s", method)
+		}
+	}
+}
+
+func Test_HeadersPerClient(t *testing.T) {
+	var mutex sync.Mutex
+	received := make(map[string]http.Header)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		received[r.URL.Query().Get("client")] = r.Header.Clone()
+		mutex.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	// the first client is used after the second one is constructed
+	first, err := NewClientWithOptions(server.URL, APIVersion90, "first", ClientOptions{AllowHTTP: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewClientWithOptions(server.URL, APIVersion91, "second", ClientOptions{AllowHTTP: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, client := range map[string]Pmax{"first": first, "second": second} {
+		c := client.(*Client)
+		if err = c.api.Get(context.Background(), "/test?client="+name, c.getDefaultHeaders(), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, version := range map[string]string{"first": APIVersion90, "second": APIVersion91} {
+		header := received[name]
+		if got := header.Get("Application-Type"); got != name {
+			t.Errorf("expected the %s client to send the application type %s, got %s", name, name, got)
+		}
+		if got, want := header.Get("Accept"), "application/json;version="+version; got != want {
+			t.Errorf("expected the %s client to accept %s, got %s", name, want, got)
+		}
+	}
+}
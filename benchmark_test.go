@@ -0,0 +1,270 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dell/gopowermax/api"
+	"github.com/dell/gopowermax/mock"
+	types "github.com/dell/gopowermax/types/v90"
+)
+
+// The benchmarks run against the mock server with thousands of objects, optionally
+// with a latency added to every request to simulate a slow array, e.g.
+//
+//	go test -run Benchmark -bench . -benchmem
+//
+// Besides the time and allocations, they report the requests sent to Unisphere per
+// operation, which TestPerformanceBudgets keeps within the budgets below.
+
+// slowArrayLatency is the latency of a request to the simulated slow array
+const slowArrayLatency = time.Millisecond
+
+// mockIteratorPageSize is the page size of the volume iterators of the mock
+const mockIteratorPageSize = 10
+
+// benchmarkStorageGroup is the storage group of the volumes added by newBenchmarkClient
+const benchmarkStorageGroup = "bench-sg"
+
+// benchmarkLatencies are the latencies the benchmarks are run with
+var benchmarkLatencies = []struct {
+	name    string
+	latency time.Duration
+}{
+	{"fast", 0},
+	{"slow", slowArrayLatency},
+}
+
+// benchmarkVolumeID returns the ID of the i-th volume added by newBenchmarkClient, from 0,
+// above the IDs of the volumes of the mock
+func benchmarkVolumeID(i int) string {
+	return fmt.Sprintf("%05X", 0x1000+i)
+}
+
+// newBenchmarkClient resets the mock, adds nvols volumes to benchmarkStorageGroup and
+// returns a client of the mock server
+func newBenchmarkClient(tb testing.TB, nvols int) *Client {
+	mock.Reset()
+	if _, err := mock.AddStorageGroup(benchmarkStorageGroup, "SRP_1", "Diamond"); err != nil {
+		tb.Fatal(err)
+	}
+	for i := 0; i < nvols; i++ {
+		id := benchmarkVolumeID(i)
+		if err := mock.AddNewVolume(id, "Vol"+id, 7, benchmarkStorageGroup); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	client, err := NewClientWithOptions(mockServer.URL, "", "", ClientOptions{Insecure: true, AllowHTTP: true})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err = client.Authenticate(context.Background(), &ConfigConnect{
+		Username: defaultUsername,
+		Password: defaultPassword,
+	}); err != nil {
+		tb.Fatal(err)
+	}
+	return client.(*Client)
+}
+
+// setLatency sets the latency of the mock for the rest of the benchmark
+func setLatency(b *testing.B, latency time.Duration) {
+	mock.Data.Latency = latency
+	b.Cleanup(func() { mock.Data.Latency = 0 })
+}
+
+// reportRequests reports the requests sent per operation since the mock served start requests
+func reportRequests(b *testing.B, start int) {
+	b.ReportMetric(float64(mock.RequestCount()-start)/float64(b.N), "requests/op")
+}
+
+func BenchmarkGetVolumeIDList(b *testing.B) {
+	for _, nvols := range []int{1000, 4000} {
+		for _, l := range benchmarkLatencies {
+			b.Run(fmt.Sprintf("%d-volumes-%s", nvols, l.name), func(b *testing.B) {
+				client := newBenchmarkClient(b, nvols)
+				setLatency(b, l.latency)
+				b.ReportAllocs()
+				start := mock.RequestCount()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					ids, err := client.GetVolumeIDList(context.Background(), symID, "", false)
+					if err != nil || len(ids) < nvols {
+						b.Fatalf("expected %d volumes, got %d: %v", nvols, len(ids), err)
+					}
+				}
+				b.StopTimer()
+				reportRequests(b, start)
+			})
+		}
+	}
+}
+
+func BenchmarkGetVolumesByStorageGroupWithDetails(b *testing.B) {
+	for _, l := range benchmarkLatencies {
+		b.Run(l.name, func(b *testing.B) {
+			const nvols = 500
+			client := newBenchmarkClient(b, nvols)
+			setLatency(b, l.latency)
+			b.ReportAllocs()
+			start := mock.RequestCount()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				vols, err := client.GetVolumesByStorageGroupWithDetails(context.Background(), symID, benchmarkStorageGroup, nil)
+				if err != nil || len(vols) != nvols {
+					b.Fatalf("expected %d volumes, got %d: %v", nvols, len(vols), err)
+				}
+			}
+			b.StopTimer()
+			reportRequests(b, start)
+		})
+	}
+}
+
+func BenchmarkUpdateStorageGroup(b *testing.B) {
+	for _, l := range benchmarkLatencies {
+		b.Run(l.name, func(b *testing.B) {
+			const nvols = 2000
+			client := newBenchmarkClient(b, nvols)
+			if _, err := client.CreateStorageGroup(context.Background(), symID, "bench-update-sg", "SRP_1", "Diamond", false); err != nil {
+				b.Fatal(err)
+			}
+			volumeIDs := make([]string, 100)
+			for i := range volumeIDs {
+				volumeIDs[i] = benchmarkVolumeID(i)
+			}
+			setLatency(b, l.latency)
+			b.ReportAllocs()
+			start := mock.RequestCount()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := client.AddVolumesToStorageGroupS(context.Background(), symID, "bench-update-sg", false, volumeIDs...); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := client.RemoveVolumesFromStorageGroup(context.Background(), symID, "bench-update-sg", false, volumeIDs...); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+			reportRequests(b, start)
+		})
+	}
+}
+
+// volumeIteratorBody returns the JSON of a volume iterator listing nvols volumes at once
+func volumeIteratorBody(tb testing.TB, nvols int) []byte {
+	iter := &types.VolumeIterator{ID: "Volume", Count: nvols, MaxPageSize: nvols}
+	iter.ResultList.From = 1
+	iter.ResultList.To = nvols
+	iter.ResultList.VolumeList = make([]types.VolumeIDList, nvols)
+	for i := range iter.ResultList.VolumeList {
+		iter.ResultList.VolumeList[i].VolumeIDs = fmt.Sprintf("%05X", i+1)
+	}
+	body, err := json.Marshal(iter)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return body
+}
+
+// decodeVolumeIterator decodes body as a response of Unisphere
+func decodeVolumeIterator(body []byte) (*types.VolumeIterator, error) {
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{api.HeaderKeyContentType: []string{api.HeaderValContentTypeJSON}},
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+	}
+	iter := &types.VolumeIterator{}
+	return iter, api.DecodeJSON(resp, iter)
+}
+
+func BenchmarkDecodeVolumeIterator(b *testing.B) {
+	body := volumeIteratorBody(b, 1000)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := decodeVolumeIterator(body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestPerformanceBudgets checks the requests sent to Unisphere, and the allocations made, by the
+// operations measured by the benchmarks, which would silently grow with the number of objects.
+func TestPerformanceBudgets(t *testing.T) {
+	const nvols = 1000
+	pages := nvols / mockIteratorPageSize
+	var tests = []struct {
+		name string
+		// budget is the maximum number of requests of the operation
+		budget int
+		run    func(client *Client) error
+	}{
+		// the listing, the following pages and the deletion of the iterator
+		{"GetVolumeIDListInStorageGroup", pages + 1, func(client *Client) error {
+			_, err := client.GetVolumeIDListInStorageGroup(context.Background(), symID, benchmarkStorageGroup)
+			return err
+		}},
+		// the storage group with its volume IDs, then one request per volume
+		{"GetVolumesByStorageGroupWithDetails", 1 + nvols, func(client *Client) error {
+			_, err := client.GetVolumesByStorageGroupWithDetails(context.Background(), symID, benchmarkStorageGroup, nil)
+			return err
+		}},
+		// a single update, whatever the number of volumes
+		{"AddVolumesToStorageGroupS", 1, func(client *Client) error {
+			volumeIDs := make([]string, 100)
+			for i := range volumeIDs {
+				volumeIDs[i] = benchmarkVolumeID(i)
+			}
+			return client.AddVolumesToStorageGroupS(context.Background(), symID, mock.DefaultStorageGroup, false, volumeIDs...)
+		}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			client := newBenchmarkClient(t, nvols)
+			start := mock.RequestCount()
+			if err := tt.run(client); err != nil {
+				t.Fatal(err)
+			}
+			if requests := mock.RequestCount() - start; requests > tt.budget {
+				t.Errorf("%s sent %d requests, the budget is %d", tt.name, requests, tt.budget)
+			}
+		})
+	}
+
+	t.Run("DecodeVolumeIterator", func(t *testing.T) {
+		// about two allocations per volume ID, and a few per response
+		const budget = 2*nvols + 50
+		body := volumeIteratorBody(t, nvols)
+		allocs := testing.AllocsPerRun(10, func() {
+			if _, err := decodeVolumeIterator(body); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs > budget {
+			t.Errorf("decoding %d volume IDs made %.0f allocations, the budget is %d", nvols, allocs, budget)
+		}
+	})
+}
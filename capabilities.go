@@ -0,0 +1,157 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	types "github.com/dell/gopowermax/types/v90"
+	log "github.com/sirupsen/logrus"
+)
+
+// The following constants are for internal use of the pmax library.
+const (
+	VVolX = "vvol/"
+	FileX = "file/"
+)
+
+// DefaultCapabilityRefreshInterval is how long the capabilities of an array are cached when
+// ClientOptions.CapabilityRefreshInterval is not set.
+const DefaultCapabilityRefreshInterval = time.Hour
+
+// ArrayCapabilities are the replication, vVol and file capabilities of an array
+type ArrayCapabilities struct {
+	SymmetrixID           string
+	SnapVxCapable         bool
+	RdfCapable            bool
+	VirtualWitnessCapable bool
+	// VVolCapable is set if the array serves vVol storage containers
+	VVolCapable bool
+	// FileCapable is set if the array serves file systems
+	FileCapable bool
+	// SnapshotLimits are the maximum numbers of SnapVX snapshots of a source volume and of the array
+	SnapshotLimits SnapshotLimits
+	// Refreshed is when the capabilities were read from Unisphere
+	Refreshed time.Time
+}
+
+// capabilityCache holds the capabilities of the arrays read by GetArrayCapabilities. It is
+// shared by the copies of a client made by WithSymmetrixID.
+type capabilityCache struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	arrays   map[string]*ArrayCapabilities
+}
+
+func newCapabilityCache(interval time.Duration) *capabilityCache {
+	if interval <= 0 {
+		interval = DefaultCapabilityRefreshInterval
+	}
+	return &capabilityCache{interval: interval, arrays: make(map[string]*ArrayCapabilities)}
+}
+
+// get returns a copy of the capabilities of the array if they are cached and not older than the interval
+func (cache *capabilityCache) get(symID string, now time.Time) (*ArrayCapabilities, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	capabilities := cache.arrays[symID]
+	if capabilities == nil || now.Sub(capabilities.Refreshed) >= cache.interval {
+		return nil, false
+	}
+	result := *capabilities
+	return &result, true
+}
+
+func (cache *capabilityCache) put(capabilities ArrayCapabilities) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.arrays[capabilities.SymmetrixID] = &capabilities
+}
+
+// GetArrayCapabilities returns the capabilities of an array, which are read from Unisphere once per
+// ClientOptions.CapabilityRefreshInterval, so that they can be checked before each provisioning.
+func (c *Client) GetArrayCapabilities(ctx context.Context, symID string) (*ArrayCapabilities, error) {
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	if capabilities, ok := c.capabilities.get(symID, time.Now()); ok {
+		return capabilities, nil
+	}
+	return c.ForceRefreshArrayCapabilities(ctx, symID)
+}
+
+// ForceRefreshArrayCapabilities reads the capabilities of an array from Unisphere and caches them,
+// e.g. after a license was added to the array.
+func (c *Client) ForceRefreshArrayCapabilities(ctx context.Context, symID string) (*ArrayCapabilities, error) {
+	defer c.TimeSpent("ForceRefreshArrayCapabilities", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	replication, err := c.GetReplicationCapabilities(ctx)
+	if err != nil {
+		log.Error("ForceRefreshArrayCapabilities failed: " + err.Error())
+		return nil, err
+	}
+	capabilities := ArrayCapabilities{SymmetrixID: symID}
+	found := false
+	for _, symCapability := range replication.SymmetrixCapability {
+		if symCapability.SymmetrixID == symID {
+			capabilities.SnapVxCapable = symCapability.SnapVxCapable
+			capabilities.RdfCapable = symCapability.RdfCapable
+			capabilities.VirtualWitnessCapable = symCapability.VirtualWitnessCapable
+			capabilities.SnapshotLimits = SnapshotLimits{
+				PerVolume: symCapability.SnapVxMaxSnapshotsPerVolume,
+				PerArray:  symCapability.SnapVxMaxSnapshotsPerArray,
+			}
+			if capabilities.SnapshotLimits.PerVolume <= 0 {
+				capabilities.SnapshotLimits.PerVolume = DefaultMaxSnapshotsPerVolume
+			}
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("the replication capabilities of array %s are not reported by Unisphere", symID)
+	}
+	if capabilities.VVolCapable, err = c.isArrayListed(ctx, c.urlPrefix()+VVolX+"symmetrix", symID); err != nil {
+		log.Error("ForceRefreshArrayCapabilities failed: " + err.Error())
+		return nil, err
+	}
+	if capabilities.FileCapable, err = c.isArrayListed(ctx, c.urlPrefix()+FileX+"symmetrix", symID); err != nil {
+		log.Error("ForceRefreshArrayCapabilities failed: " + err.Error())
+		return nil, err
+	}
+	capabilities.Refreshed = time.Now()
+	c.capabilities.put(capabilities)
+	return &capabilities, nil
+}
+
+// isArrayListed returns true if the list of arrays at URL holds symID. A Unisphere without the
+// resource at URL answers 404, so none of its arrays is listed.
+func (c *Client) isArrayListed(ctx context.Context, URL string, symID string) (bool, error) {
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
+	defer cancel()
+	symIDList := &types.SymmetrixIDList{}
+	if err := c.api.Get(ctx, URL, c.getDefaultHeaders(), symIDList); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return stringInSlice(symID, symIDList.SymmetrixIDs), nil
+}
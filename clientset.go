@@ -0,0 +1,221 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	types "github.com/dell/gopowermax/types/v90"
+	log "github.com/sirupsen/logrus"
+)
+
+// ArrayEndpoint is the connection configuration of one Unisphere instance managed by a ClientSet.
+type ArrayEndpoint struct {
+	// Endpoint is the Unisphere URL, e.g. https://1.2.3.4:8443
+	Endpoint string
+
+	// Version is the REST API version; an empty version selects DefaultAPIVersion.
+	Version string
+
+	// ApplicationName is sent to Unisphere as the application type of the client.
+	ApplicationName string
+
+	// Username and Password are used to authenticate the client.
+	Username string
+	Password string
+
+	// Options are the optional settings of the client.
+	Options ClientOptions
+
+	// SymmetrixIDs are the arrays reached through this endpoint. The client is restricted
+	// to them; an empty list lets the client manage every array known to Unisphere, which
+	// ForArray then asks for the arrays it does not find in the lists of the endpoints.
+	SymmetrixIDs []string
+}
+
+// ClientSet holds the clients of several Unisphere endpoints. A client is only constructed
+// and authenticated the first time its endpoint is needed, and is reused afterwards.
+type ClientSet struct {
+	mutex     sync.Mutex
+	endpoints []ArrayEndpoint
+	clients   []Pmax
+	arrays    map[string]int
+}
+
+// NewClientSet returns a ClientSet for the given endpoints. An array can be listed by
+// only one endpoint.
+func NewClientSet(endpoints ...ArrayEndpoint) (*ClientSet, error) {
+	cs := &ClientSet{
+		endpoints: endpoints,
+		clients:   make([]Pmax, len(endpoints)),
+		arrays:    make(map[string]int),
+	}
+	for i, ep := range endpoints {
+		if ep.Endpoint == "" {
+			return nil, fmt.Errorf("Endpoint must be supplied, e.g. https://1.2.3.4:8443")
+		}
+		for _, symID := range ep.SymmetrixIDs {
+			if j, ok := cs.arrays[symID]; ok {
+				return nil, fmt.Errorf("array %s is listed by both %s and %s", symID, endpoints[j].Endpoint, ep.Endpoint)
+			}
+			cs.arrays[symID] = i
+		}
+	}
+	return cs, nil
+}
+
+// Arrays returns the sorted list of arrays listed by the endpoints, or found by ForArray
+// behind the endpoints which do not list their arrays.
+func (cs *ClientSet) Arrays() []string {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	arrays := make([]string, 0, len(cs.arrays))
+	for symID := range cs.arrays {
+		arrays = append(arrays, symID)
+	}
+	sort.Strings(arrays)
+	return arrays
+}
+
+// ForArray returns the client of the endpoint which manages the array symID,
+// constructing and authenticating it if this is the first use of the endpoint.
+// An array no endpoint lists is looked up in the arrays known to the Unisphere
+// instances of the endpoints without SymmetrixIDs, and remembered once found.
+func (cs *ClientSet) ForArray(ctx context.Context, symID string) (Pmax, error) {
+	cs.mutex.Lock()
+	i, ok := cs.arrays[symID]
+	cs.mutex.Unlock()
+	if ok {
+		return cs.client(ctx, i)
+	}
+	for i, ep := range cs.endpoints {
+		if len(ep.SymmetrixIDs) != 0 {
+			continue
+		}
+		client, err := cs.client(ctx, i)
+		if err != nil {
+			continue
+		}
+		symIDList, err := client.GetSymmetrixIDList(ctx)
+		if err != nil {
+			log.Error("Listing the arrays of " + ep.Endpoint + " failed: " + err.Error())
+			continue
+		}
+		found := false
+		cs.mutex.Lock()
+		for _, id := range symIDList.SymmetrixIDs {
+			if _, ok := cs.arrays[id]; !ok {
+				cs.arrays[id] = i
+			}
+			found = found || id == symID
+		}
+		cs.mutex.Unlock()
+		if found {
+			return client, nil
+		}
+	}
+	return nil, fmt.Errorf("the requested array (%s) is not managed by any endpoint", symID)
+}
+
+// client returns the client of the i'th endpoint, constructing it if needed
+func (cs *ClientSet) client(ctx context.Context, i int) (Pmax, error) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	if cs.clients[i] != nil {
+		return cs.clients[i], nil
+	}
+	ep := cs.endpoints[i]
+	client, err := NewClientWithOptions(ep.Endpoint, ep.Version, ep.ApplicationName, ep.Options)
+	if err != nil {
+		return nil, err
+	}
+	err = client.Authenticate(ctx, &ConfigConnect{
+		Endpoint: ep.Endpoint,
+		Version:  ep.Version,
+		Username: ep.Username,
+		Password: ep.Password,
+	})
+	if err != nil {
+		log.Error("Authentication to " + ep.Endpoint + " failed: " + err.Error())
+		return nil, err
+	}
+	arrays := make([]string, len(ep.SymmetrixIDs))
+	copy(arrays, ep.SymmetrixIDs)
+	client.SetAllowedArrays(arrays)
+	cs.clients[i] = client
+	return client, nil
+}
+
+// forEachEndpoint calls f concurrently with the client of every endpoint and returns the
+// errors of the endpoints which failed, prefixed with the endpoint URL.
+func (cs *ClientSet) forEachEndpoint(ctx context.Context, f func(client Pmax) error) error {
+	errs := make([]error, len(cs.endpoints))
+	var wg sync.WaitGroup
+	for i := range cs.endpoints {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			client, err := cs.client(ctx, i)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			errs[i] = f(client)
+		}(i)
+	}
+	wg.Wait()
+	msgs := make([]string, 0)
+	for i, err := range errs {
+		if err != nil {
+			msgs = append(msgs, cs.endpoints[i].Endpoint+": "+err.Error())
+		}
+	}
+	if len(msgs) != 0 {
+		return fmt.Errorf("%d of %d endpoints failed: %s", len(msgs), len(cs.endpoints), strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// GetSymmetrixIDList returns the sorted list of arrays reported by all the endpoints. If some
+// endpoints fail, the arrays of the others are returned together with an error.
+func (cs *ClientSet) GetSymmetrixIDList(ctx context.Context) (*types.SymmetrixIDList, error) {
+	var mutex sync.Mutex
+	found := make(map[string]bool)
+	err := cs.forEachEndpoint(ctx, func(client Pmax) error {
+		symIDList, err := client.GetSymmetrixIDList(ctx)
+		if err != nil {
+			return err
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, symID := range symIDList.SymmetrixIDs {
+			found[symID] = true
+		}
+		return nil
+	})
+	symIDList := &types.SymmetrixIDList{SymmetrixIDs: make([]string, 0, len(found))}
+	for symID := range found {
+		symIDList.SymmetrixIDs = append(symIDList.SymmetrixIDs, symID)
+	}
+	sort.Strings(symIDList.SymmetrixIDs)
+	if err != nil {
+		log.Error("GetSymmetrixIDList failed: " + err.Error())
+	}
+	return symIDList, err
+}
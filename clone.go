@@ -0,0 +1,284 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	types "github.com/dell/gopowermax/types/v90"
+	log "github.com/sirupsen/logrus"
+)
+
+// CloneSnapshotPrefix starts the names of the temporary snapshots taken by CloneVolumeFull, so that
+// RecoverCloneSnapshots can find those left behind, e.g. by a crash, and terminate them.
+const CloneSnapshotPrefix = "gpmx_clone_"
+
+// MaxSnapshotNameLength is the maximum length of the name of a snapshot
+const MaxSnapshotNameLength = 32
+
+// CloneVolumeOptions are the settings of CloneVolumeFull
+type CloneVolumeOptions struct {
+	// AutoCleanup finalizes the clone in the background: once the copy completes, the clone is unlinked
+	// and the temporary snapshot terminated. Otherwise VolumeClone.Finalize must be called.
+	AutoCleanup bool
+	// Timeout is how long to wait for the link to be defined, zero uses the client's timeout
+	Timeout time.Duration
+	// CopyTimeout is how long the background finalization waits for the copy to complete,
+	// zero uses the client's timeout of long jobs
+	CopyTimeout time.Duration
+}
+
+// DefaultCloneSnapshotGracePeriod is a safe minimum age for RecoverCloneSnapshots to terminate a clone
+// snapshot: younger ones may belong to a CloneVolumeFull still in progress, e.g. on another host.
+const DefaultCloneSnapshotGracePeriod = time.Hour
+
+// VolumeClone is a full copy of a volume made by CloneVolumeFull. The clone can be used as soon as
+// it is returned, while its data is copied in the background from the temporary snapshot.
+type VolumeClone struct {
+	SymID          string
+	SourceVolumeID string
+	SnapshotName   string
+	Volume         *types.Volume
+
+	client      *Client
+	copyTimeout time.Duration
+	mutex       sync.Mutex
+	finalized   bool
+	done        chan struct{}
+	cancel      context.CancelFunc
+	err         error
+}
+
+// cloneSnapshotName returns the name of the temporary snapshot of the clone named name
+func cloneSnapshotName(name string) string {
+	return TruncateResourceName(CloneSnapshotPrefix+name, MaxSnapshotNameLength)
+}
+
+// CloneVolumeFull creates the volume name in targetSGID as a full copy of srcVolID: it takes a temporary snapshot
+// of srcVolID and links it to the new volume in copy mode, see CreateVolumeFromSnapshot. Once the copy completes,
+// VolumeClone.Finalize unlinks the clone and terminates the snapshot, or it is done in the background with
+// opts.AutoCleanup. The snapshot is named after the clone, so the call can be retried, and
+// RecoverCloneSnapshots cleans up after clones which were never finalized.
+func (c *Client) CloneVolumeFull(ctx context.Context, symID, srcVolID, targetSGID, name string,
+	sizeInCylinders int, opts CloneVolumeOptions) (*VolumeClone, error) {
+	defer c.TimeSpent("CloneVolumeFull", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	snapID := cloneSnapshotName(name)
+	snapInfo, err := c.GetVolumeSnapInfo(ctx, symID, srcVolID)
+	if err != nil {
+		return nil, err
+	}
+	exists := false
+	for _, snapSrc := range snapInfo.VolumeSnapshotSource {
+		if snapSrc.SnapshotName == snapID {
+			exists = true
+			break
+		}
+	}
+	if !exists {
+		if err = c.CreateSnapshot(ctx, symID, snapID, []types.VolumeList{{Name: srcVolID}}, 0); err != nil {
+			return nil, fmt.Errorf("couldn't create the snapshot (%s) of volume %s to clone: %w", snapID, srcVolID, err)
+		}
+	}
+	vol, err := c.CreateVolumeFromSnapshot(ctx, symID, srcVolID, snapID, targetSGID, name, sizeInCylinders,
+		CreateVolumeFromSnapshotOptions{Copy: true, Timeout: opts.Timeout})
+	if err != nil {
+		return nil, err
+	}
+	clone := &VolumeClone{
+		SymID:          symID,
+		SourceVolumeID: srcVolID,
+		SnapshotName:   snapID,
+		Volume:         vol,
+		client:         c,
+		copyTimeout:    opts.CopyTimeout,
+	}
+	if opts.AutoCleanup {
+		// the background finalization outlives ctx, so it gets its own deadline and can be stopped with Cancel
+		cleanupCtx, cancel := c.getTimeoutContext(WithTimeout(context.Background(), opts.CopyTimeout), longJobOperation)
+		clone.done = make(chan struct{})
+		clone.cancel = cancel
+		go func() {
+			defer close(clone.done)
+			defer cancel()
+			err := clone.Finalize(cleanupCtx)
+			if err != nil {
+				log.Error(fmt.Sprintf("Couldn't clean up after clone %s (%s): %s", name, vol.VolumeID, err.Error()))
+			}
+			clone.mutex.Lock()
+			clone.err = err
+			clone.mutex.Unlock()
+		}()
+	}
+	log.Info(fmt.Sprintf("Cloning volume %s to %s (%s)", srcVolID, name, vol.VolumeID))
+	return clone, nil
+}
+
+// Finalize waits for the copy of the clone to complete, then unlinks the clone from the temporary snapshot
+// and terminates it. Finalizing a clone again does nothing.
+func (vc *VolumeClone) Finalize(ctx context.Context) error {
+	vc.mutex.Lock()
+	finalized := vc.finalized
+	vc.mutex.Unlock()
+	if finalized {
+		return nil
+	}
+	// the copy can take hours, so it is waited for without the lock, which Err needs
+	c := vc.client
+	err := c.waitForSnapshotLink(ctx, vc.SymID, vc.SourceVolumeID, vc.SnapshotName, vc.Volume.VolumeID, vc.copyTimeout, "copied", isLinkCopied)
+	if err != nil {
+		return err
+	}
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+	if vc.finalized {
+		return nil
+	}
+	if err = c.terminateCloneSnapshot(ctx, vc.SymID, vc.SourceVolumeID, vc.SnapshotName, []string{vc.Volume.VolumeID}); err != nil {
+		return err
+	}
+	vc.finalized = true
+	return nil
+}
+
+// Done returns a channel which is closed once the background finalization of a clone made with
+// CloneVolumeOptions.AutoCleanup is over, and nil for the other clones
+func (vc *VolumeClone) Done() <-chan struct{} {
+	return vc.done
+}
+
+// Cancel stops the background finalization of a clone made with CloneVolumeOptions.AutoCleanup, which then
+// fails with context.Canceled; the clone is left for Finalize or RecoverCloneSnapshots.
+func (vc *VolumeClone) Cancel() {
+	if vc.cancel != nil {
+		vc.cancel()
+	}
+}
+
+// Err returns the error of the background finalization once Done is closed
+func (vc *VolumeClone) Err() error {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+	return vc.err
+}
+
+// isLinkCopied returns whether all the data of a snapshot has been copied to the linked volume
+func isLinkCopied(linkedVol types.LinkedVolumes) bool {
+	return linkedVol.State == types.SnapshotLinkStateCopied || (linkedVol.Copy && linkedVol.PercentageCopied >= 100)
+}
+
+// terminateCloneSnapshot unlinks the targets from the snapshot snapID of srcVolID and deletes the snapshot
+func (c *Client) terminateCloneSnapshot(ctx context.Context, symID, srcVolID, snapID string, targetVolIDs []string) error {
+	for _, targetVolID := range targetVolIDs {
+		err := c.UnlinkSnapshot(ctx, symID, snapID, []types.VolumeList{{Name: srcVolID}}, []types.VolumeList{{Name: targetVolID}}, 0)
+		if err != nil {
+			return fmt.Errorf("couldn't unlink volume %s from snapshot (%s): %w", targetVolID, snapID, err)
+		}
+	}
+	if err := c.DeleteSnapshotS(ctx, symID, snapID, []types.VolumeList{{Name: srcVolID}}, 0); err != nil {
+		return fmt.Errorf("couldn't terminate snapshot (%s) of volume %s: %w", snapID, srcVolID, err)
+	}
+	log.Info(fmt.Sprintf("Terminated clone snapshot (%s) of volume %s", snapID, srcVolID))
+	return nil
+}
+
+// RecoverCloneSnapshots terminates the temporary snapshots of CloneVolumeFull left on the array: those whose
+// linked clones are all copied are unlinked and deleted, as are those without any link. The snapshots still
+// being copied, and those created less than gracePeriod ago or whose creation time is unknown, are left for
+// a later call, see DefaultCloneSnapshotGracePeriod. It returns the terminated snapshots as "volumeID/snapshotName".
+func (c *Client) RecoverCloneSnapshots(ctx context.Context, symID string, gracePeriod time.Duration) ([]string, error) {
+	defer c.TimeSpent("RecoverCloneSnapshots", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	snapVolumes, err := c.GetSnapVolumeList(ctx, symID, nil)
+	if err != nil {
+		return nil, err
+	}
+	terminated := make([]string, 0)
+	errs := make([]string, 0)
+	for _, volID := range snapVolumes.Name {
+		snapInfo, err := c.GetVolumeSnapInfo(ctx, symID, volID)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, snapSrc := range snapInfo.VolumeSnapshotSource {
+			snapID := snapSrc.SnapshotName
+			if !strings.HasPrefix(snapID, CloneSnapshotPrefix) || seen[snapID] {
+				continue
+			}
+			seen[snapID] = true
+			if gracePeriod > 0 && !isSnapshotOlderThan(snapSrc, gracePeriod) {
+				log.Info(fmt.Sprintf("Clone snapshot (%s) of volume %s may still be in use, skipping it", snapID, volID))
+				continue
+			}
+			done, err := c.recoverCloneSnapshot(ctx, symID, volID, snapID)
+			if err != nil {
+				errs = append(errs, err.Error())
+			} else if done {
+				terminated = append(terminated, volID+"/"+snapID)
+			}
+		}
+	}
+	sort.Strings(terminated)
+	if len(errs) > 0 {
+		return terminated, fmt.Errorf("couldn't recover all clone snapshots of array %s: %s", symID, strings.Join(errs, "; "))
+	}
+	return terminated, nil
+}
+
+// isSnapshotOlderThan returns whether the snapshot was created more than age ago, false if its creation time is unknown
+func isSnapshotOlderThan(snapSrc types.VolumeSnapshotSource, age time.Duration) bool {
+	if snapSrc.TimeStampUTC <= 0 {
+		return false
+	}
+	created := time.Unix(0, snapSrc.TimeStampUTC*int64(time.Millisecond))
+	return time.Since(created) > age
+}
+
+// recoverCloneSnapshot terminates the snapshot snapID of srcVolID unless one of its links is still being
+// copied, and returns whether it was terminated
+func (c *Client) recoverCloneSnapshot(ctx context.Context, symID, srcVolID, snapID string) (bool, error) {
+	generations, err := c.GetSnapshotGenerations(ctx, symID, srcVolID, snapID)
+	if err != nil {
+		return false, err
+	}
+	targetVolIDs := make([]string, 0)
+	for _, snapSrc := range generations.VolumeSnapshotSource {
+		if snapSrc.SnapshotName != snapID {
+			continue
+		}
+		for _, linkedVol := range snapSrc.LinkedVolumes {
+			if !isLinkCopied(linkedVol) {
+				log.Info(fmt.Sprintf("Clone snapshot (%s) of volume %s is still being copied to volume %s", snapID, srcVolID, linkedVol.TargetDevice))
+				return false, nil
+			}
+			targetVolIDs = append(targetVolIDs, linkedVol.TargetDevice)
+		}
+	}
+	if err = c.terminateCloneSnapshot(ctx, symID, srcVolID, snapID, targetVolIDs); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,73 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package compat
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	pmax "github.com/dell/gopowermax"
+	"github.com/dell/gopowermax/mock"
+	"github.com/dell/gopowermax/pmaxtest"
+	types91 "github.com/dell/gopowermax/types/v91"
+)
+
+func Test_Unwrap(t *testing.T) {
+	h := pmaxtest.New(t)
+	client := New(h.Client)
+	if Unwrap(client) != h.Client {
+		t.Errorf("expected Unwrap to return the current client")
+	}
+	if Unwrap(client.WithSymmetrixID(h.SymID)) == nil {
+		t.Errorf("expected WithSymmetrixID to keep the v90 interface")
+	}
+	other := struct{ Pmax }{client}
+	if Unwrap(other) != nil {
+		t.Errorf("expected Unwrap to return nil for another implementation")
+	}
+}
+
+func Test_CreateVolumeInProtectedStorageGroupS(t *testing.T) {
+	h := pmaxtest.New(t)
+	client := New(h.Client)
+	vol, err := client.CreateVolumeInProtectedStorageGroupS(h.Ctx, h.SymID, mock.DefaultRemoteSymID,
+		mock.DefaultProtectedStorageGroup, mock.DefaultProtectedStorageGroup, "compat-vol", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vol.VolumeIdentifier != "compat-vol" {
+		t.Errorf("expected volume compat-vol, got %s", vol.VolumeIdentifier)
+	}
+}
+
+func Test_GetCreateVolInSGPayload(t *testing.T) {
+	current, err := pmax.NewClientWithOptions("https://unisphere:8443", pmax.APIVersion91, "",
+		pmax.ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := New(current).GetCreateVolInSGPayload(10, "vol1", true, "000000000002", "sg2")
+	if _, ok := payload.(*types91.UpdateStorageGroupPayload); !ok {
+		t.Errorf("expected the payload of Unisphere 9.1, got %T", payload)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"force":true`) {
+		t.Errorf("expected a forced payload, got %s", data)
+	}
+}
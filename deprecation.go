@@ -0,0 +1,129 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DeprecationWarning is a warning of Unisphere that a request used a deprecated endpoint or API version,
+// as found in the Warning, Deprecation and Sunset headers of the response.
+type DeprecationWarning struct {
+	// Method is the method of the request, e.g. GET
+	Method string
+
+	// Path is the path of the request
+	Path string
+
+	// APIVersion is the API version of the request, e.g. "90", or empty if the path has none
+	APIVersion string
+
+	// Deprecation is the Deprecation header, e.g. "true" or the date the endpoint was deprecated
+	Deprecation string
+
+	// Sunset is the Sunset header, the date after which the endpoint may be removed
+	Sunset string
+
+	// Messages are the Warning headers, e.g. `299 - "API version 90 is deprecated"`
+	Messages []string
+}
+
+// String returns the warning as it is logged
+func (w DeprecationWarning) String() string {
+	parts := []string{fmt.Sprintf("%s %s", w.Method, w.Path)}
+	if w.Deprecation != "" {
+		parts = append(parts, "deprecation: "+w.Deprecation)
+	}
+	if w.Sunset != "" {
+		parts = append(parts, "sunset: "+w.Sunset)
+	}
+	parts = append(parts, w.Messages...)
+	return strings.Join(parts, "; ")
+}
+
+// DeprecationHandler is called with the DeprecationWarnings of a client. It is called while the
+// request which got the warning completes, so it should not block.
+type DeprecationHandler func(warning DeprecationWarning)
+
+// deprecationNotifier delivers the DeprecationWarnings of a client to its channel and handler,
+// once per method and distinct warning, so that a deprecated API version is reported once
+// rather than on every request.
+type deprecationNotifier struct {
+	channel chan<- DeprecationWarning
+	handler DeprecationHandler
+	mutex   sync.Mutex
+	seen    map[string]bool
+}
+
+// newDeprecationNotifier returns the notifier of the DeprecationWarnings requested by options,
+// or nil if the client does not report them
+func newDeprecationNotifier(options ClientOptions) *deprecationNotifier {
+	if options.DeprecationWarnings == nil && options.DeprecationHandler == nil {
+		return nil
+	}
+	return &deprecationNotifier{
+		channel: options.DeprecationWarnings,
+		handler: options.DeprecationHandler,
+		seen:    make(map[string]bool),
+	}
+}
+
+// notify is the api.WarningHook of the client
+func (n *deprecationNotifier) notify(method, path string, header http.Header) {
+	warning := newDeprecationWarning(method, path, header)
+	key := strings.Join(append([]string{method, warning.Deprecation, warning.Sunset}, warning.Messages...), "\n")
+	n.mutex.Lock()
+	if n.seen[key] {
+		n.mutex.Unlock()
+		return
+	}
+	n.seen[key] = true
+	n.mutex.Unlock()
+
+	log.Warn("Unisphere deprecation warning: " + warning.String())
+	if n.handler != nil {
+		n.handler(warning)
+	}
+	if n.channel != nil {
+		select {
+		case n.channel <- warning:
+		default:
+			log.Warn("Deprecation warning dropped, the channel is full: " + warning.String())
+		}
+	}
+}
+
+// newDeprecationWarning returns the DeprecationWarning of the response headers of a request
+func newDeprecationWarning(method, path string, header http.Header) DeprecationWarning {
+	warning := DeprecationWarning{
+		Method:      method,
+		Path:        path,
+		Deprecation: header.Get("Deprecation"),
+		Sunset:      header.Get("Sunset"),
+		Messages:    header.Values("Warning"),
+	}
+	if i := strings.Index(path, RESTPrefix); i >= 0 {
+		version := strings.SplitN(path[i+len(RESTPrefix):], "/", 2)[0]
+		if version != "" && strings.Trim(version, "0123456789") == "" {
+			warning.APIVersion = version
+		}
+	}
+	return warning
+}
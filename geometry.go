@@ -0,0 +1,75 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The emulations whose geometry is known to CylinderSizeBytes
+const (
+	EmulationFBA     = "FBA"
+	EmulationCKD3390 = "CKD-3390"
+	EmulationCKD3380 = "CKD-3380"
+)
+
+// A PowerMax cylinder has 15 tracks. An FBA track is 128 KiB, so an FBA cylinder is 1.875 MiB;
+// a CKD track is the track capacity of the emulated 3390 or 3380 disk.
+const (
+	tracksPerCylinder = 15
+	fbaTrackSize      = 128 * 1024
+	ckd3390TrackSize  = 56664
+	ckd3380TrackSize  = 47476
+)
+
+// CylinderSizeBytes returns the size of a cylinder of a volume with the given emulation. An empty
+// emulation is FBA, and "CKD" is CKD-3390.
+func CylinderSizeBytes(emulation string) (int64, error) {
+	switch strings.ToUpper(emulation) {
+	case "", EmulationFBA:
+		return tracksPerCylinder * fbaTrackSize, nil
+	case "CKD", EmulationCKD3390:
+		return tracksPerCylinder * ckd3390TrackSize, nil
+	case EmulationCKD3380:
+		return tracksPerCylinder * ckd3380TrackSize, nil
+	}
+	return 0, fmt.Errorf("the geometry of emulation %s is not known", emulation)
+}
+
+// CylindersForSizeBytes returns the number of cylinders of the smallest volume with the given
+// emulation which holds sizeBytes, i.e. the size rounded up to whole cylinders.
+func CylindersForSizeBytes(sizeBytes int64, emulation string) (int, error) {
+	cylinderSize, err := CylinderSizeBytes(emulation)
+	if err != nil {
+		return 0, err
+	}
+	if sizeBytes < 0 {
+		return 0, fmt.Errorf("Invalid size %d, it must not be negative", sizeBytes)
+	}
+	return int((sizeBytes + cylinderSize - 1) / cylinderSize), nil
+}
+
+// BytesForCylinders returns the size in bytes of a volume with the given number of cylinders and emulation.
+func BytesForCylinders(cylinders int, emulation string) (int64, error) {
+	cylinderSize, err := CylinderSizeBytes(emulation)
+	if err != nil {
+		return 0, err
+	}
+	if cylinders < 0 {
+		return 0, fmt.Errorf("Invalid number of cylinders %d, it must not be negative", cylinders)
+	}
+	return int64(cylinders) * cylinderSize, nil
+}
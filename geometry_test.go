@@ -0,0 +1,63 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import "testing"
+
+func Test_CylindersForSizeBytes(t *testing.T) {
+	var tests = []struct {
+		name      string
+		sizeBytes int64
+		emulation string
+		cylinders int
+		bytes     int64
+		wantErr   bool
+	}{
+		{"one FBA cylinder", 1966080, "FBA", 1, 1966080, false},
+		{"FBA rounded up", 1966081, "FBA", 2, 3932160, false},
+		{"1 GiB FBA", 1024 * 1024 * 1024, "", 547, 1075445760, false},
+		{"zero", 0, "FBA", 0, 0, false},
+		{"CKD-3390", 849960, "CKD-3390", 1, 849960, false},
+		{"CKD is 3390", 849961, "ckd", 2, 1699920, false},
+		{"CKD-3380", 712140, "CKD-3380", 1, 712140, false},
+		{"unknown emulation", 1024, "AS/400_D910", 0, 0, true},
+		{"negative size", -1, "FBA", 0, 0, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			cylinders, err := CylindersForSizeBytes(tt.sizeBytes, tt.emulation)
+			if (err != nil) != tt.wantErr || cylinders != tt.cylinders {
+				t.Errorf("CylindersForSizeBytes(%d, %s) = %d, %v; expected %d", tt.sizeBytes, tt.emulation, cylinders, err, tt.cylinders)
+			}
+			if tt.wantErr {
+				return
+			}
+			bytes, err := BytesForCylinders(cylinders, tt.emulation)
+			if err != nil || bytes != tt.bytes {
+				t.Errorf("BytesForCylinders(%d, %s) = %d, %v; expected %d", cylinders, tt.emulation, bytes, err, tt.bytes)
+			}
+		})
+	}
+}
+
+func Test_BytesForCylindersErrors(t *testing.T) {
+	if _, err := BytesForCylinders(-1, EmulationFBA); err == nil {
+		t.Errorf("BytesForCylinders(-1) expected an error")
+	}
+	if _, err := BytesForCylinders(1, "CKD-9345"); err == nil {
+		t.Errorf("BytesForCylinders(1, CKD-9345) expected an error")
+	}
+}
@@ -16,13 +16,17 @@ package pmax
 
 import (
 	"context"
+	"io"
 	"net/http"
+	"time"
 
 	types "github.com/dell/gopowermax/types/v90"
 )
 
-// Debug is a boolean, when enabled, that enables logging of send payloads, and other debug information. Default to false.
+// Debug is a boolean, when enabled, that enables logging of send payloads of every client. Default to false.
 // It is set true by unit testing.
+//
+// Deprecated: use ClientOptions.LogPayloads to enable payload logging per client.
 var Debug = false
 
 // ConfigConnect is an argument structure that can be passed to Authenticate.
@@ -84,18 +88,50 @@ type Pmax interface {
 	// and handles all the details of the iteration for you.
 	GetVolumeIDList(ctx context.Context, symID string, volumeIdentifierMatch string, like bool) ([]string, error)
 
+	// ListVolumesPaged returns a page of the volume IDs of the array and a token to resume the listing with
+	// the next page, e.g. after a timeout or in another process.
+	ListVolumesPaged(ctx context.Context, symID string, pageSize int, resumeToken string) (*VolumePage, error)
+
 	// GetVolumeIDListInStorageGroup returns a list of volume IDs that are associated with the StorageGroup
 	GetVolumeIDListInStorageGroup(ctx context.Context, symID string, storageGroupID string) ([]string, error)
 
+	// GetVolumesByStorageGroupWithDetails returns the volumes of a StorageGroup, read concurrently,
+	// calling progress, if not nil, after each volume is read.
+	GetVolumesByStorageGroupWithDetails(ctx context.Context, symID string, storageGroupID string, progress VolumeProgressFunc) ([]*types.Volume, error)
+
+	// GetVolumeIDsIteratorWithFilter returns an iterator of the volumes matching the filter, e.g. the
+	// volumes of a storage group without allocations.
+	GetVolumeIDsIteratorWithFilter(ctx context.Context, symID string, filter VolumeFilter) (*types.VolumeIterator, error)
+
+	// GetVolumeIDListWithFilter is GetVolumeIDsIteratorWithFilter returning the []string of volume ids.
+	GetVolumeIDListWithFilter(ctx context.Context, symID string, filter VolumeFilter) ([]string, error)
+
 	// GetVolumeById returns a Volume given the volumeID.
 	GetVolumeByID(ctx context.Context, symID string, volumeID string) (*types.Volume, error)
 
+	// GetVolumeByIDWithFields returns only the given fields of a volume, e.g. "cap_cyl", if Unisphere supports
+	// field selection, and the complete volume otherwise.
+	GetVolumeByIDWithFields(ctx context.Context, symID string, volumeID string, fields []string) (*types.Volume, error)
+
+	// FindVolumeAcrossArrays returns the volumes, and the arrays they are on, whose identifier
+	// is identifier. The arrays are queried in parallel.
+	FindVolumeAcrossArrays(ctx context.Context, symIDs []string, identifier string) ([]VolumeMatch, error)
+
 	// GetStorageGroupIDList returns a list of all the StorageGroup ids.
 	GetStorageGroupIDList(ctx context.Context, symID string) (*types.StorageGroupIDList, error)
 
 	// GetStorageGroup returns a storage group given the StorageGroup id.
+	// The error matches ErrNotFound if the storage group does not exist.
 	GetStorageGroup(ctx context.Context, symID string, storageGroupID string) (*types.StorageGroup, error)
 
+	// GetStorageGroupWithFields returns only the given fields of a storage group, e.g. "num_of_vols", if
+	// Unisphere supports field selection, and the complete storage group otherwise.
+	GetStorageGroupWithFields(ctx context.Context, symID string, storageGroupID string, fields []string) (*types.StorageGroup, error)
+
+	// GetStorageGroupWithVolumes returns a storage group along with the IDs of its volumes, in one request
+	// if Unisphere expands the volumes of the storage group
+	GetStorageGroupWithVolumes(ctx context.Context, symID string, storageGroupID string) (*types.StorageGroup, error)
+
 	// GetStoragePool returns a storage pool given the GetStoragePoolID and SymID.
 	GetStoragePool(ctx context.Context, symID string, storagePoolID string) (*types.StoragePool, error)
 
@@ -104,6 +140,11 @@ type Pmax interface {
 	// This is a blocking call and will only return after the storage group has been created
 	CreateStorageGroup(ctx context.Context, symID string, storageGroupID string, srpID string, serviceLevel string, thickVolumes bool) (*types.StorageGroup, error)
 
+	// CreateStorageGroupWithOptions creates a storage group given the Storage group id and the settings
+	// of StorageGroupOptions, such as the workload, emulation and compression, and returns the storage group object.
+	// This is a blocking call and will only return after the storage group has been created
+	CreateStorageGroupWithOptions(ctx context.Context, symID string, storageGroupID string, options StorageGroupOptions) (*types.StorageGroup, error)
+
 	// UpdateStorageGroup updates a storage group (i.e. a PUT operation) and should support all the defined
 	// operations (but many have not been tested).
 	// This is done asynchronously and returns back a job
@@ -114,6 +155,11 @@ type Pmax interface {
 	// This is done synchronously and doesn't create any jobs
 	UpdateStorageGroupS(ctx context.Context, symID string, storageGroupID string, payload interface{}) error
 
+	// AssociateSnapshotPolicies associates snapshot policies with a storage group
+	AssociateSnapshotPolicies(ctx context.Context, symID, storageGroupID string, policyNames []string) error
+	// DisassociateSnapshotPolicies disassociates snapshot policies from a storage group
+	DisassociateSnapshotPolicies(ctx context.Context, symID, storageGroupID string, policyNames []string) error
+
 	// CreateVolumeInStorageGroup takes simplified input arguments to create a volume of a give name and size in a particular storage group.
 	// This method creates a job and waits on the job to complete.
 	CreateVolumeInStorageGroup(ctx context.Context, symID string, storageGroupID string, volumeName string, sizeInCylinders int) (*types.Volume, error)
@@ -122,28 +168,81 @@ type Pmax interface {
 	// This is done synchronously and no jobs are created. HTTP header argument is optional
 	CreateVolumeInStorageGroupS(ctx context.Context, symID, storageGroupID string, volumeName string, sizeInCylinders int, opts ...http.Header) (*types.Volume, error)
 
+	// CreateVolumeIfNotExists returns the volume with the given name if it already exists in the storage group with the
+	// requested size, otherwise it creates it synchronously. The returned flag is true if the volume was created.
+	CreateVolumeIfNotExists(ctx context.Context, symID, storageGroupID string, volumeName string, sizeInCylinders int) (*types.Volume, bool, error)
+
 	// CreateVolumeInProtectedStorageGroup takes simplified input arguments to create a volume of a give name and size in a protected storage group.
 	// This will add volume in both Local and Remote Storage group
 	// This is done synchronously and no jobs are created. HTTP header argument is optional
 	CreateVolumeInProtectedStorageGroupS(ctx context.Context, symID, remoteSymID, storageGroupID string, remoteStorageGroupID string, volumeName string, sizeInCylinders int, opts ...http.Header) (*types.Volume, error)
 
+	// CreateVolumeInProtectedStorageGroupSWithForceOption is CreateVolumeInProtectedStorageGroupS with the force flag of the update,
+	// which CreateVolumeInProtectedStorageGroupS always sets. See ForceOption for what force implies.
+	CreateVolumeInProtectedStorageGroupSWithForceOption(ctx context.Context, symID, remoteSymID, storageGroupID string, remoteStorageGroupID string, volumeName string, sizeInCylinders int, force ForceOption, opts ...http.Header) (*types.Volume, error)
+
 	// DeleteStorageGroup deletes a storage group given a storage group id
 	DeleteStorageGroup(ctx context.Context, symID string, storageGroupID string) error
 
 	// DeleteMaskingView deletes a masking view given a masking view id
 	DeleteMaskingView(ctx context.Context, symID string, maskingViewID string) error
 
+	// UnmapVolumeFromHost removes a volume from the storage groups exposing it to a host through its masking views
+	UnmapVolumeFromHost(ctx context.Context, symID string, volumeID string, hostID string) (*UnmapResult, error)
+
+	// UnmapVolumeFromHostWithOptions unmaps a volume from a host, deleting the masking views and storage groups left empty as set in options
+	UnmapVolumeFromHostWithOptions(ctx context.Context, symID string, volumeID string, hostID string, options UnmapOptions) (*UnmapResult, error)
+
+	// GetSRPDataReduction returns the data reduction status, enabled percentage and ratio of a Storage Pool
+	GetSRPDataReduction(ctx context.Context, symID string, storagePoolID string) (*DataReduction, error)
+
+	// GetStorageGroupDataReduction returns the data reduction status and ratio of a storage group
+	GetStorageGroupDataReduction(ctx context.Context, symID string, storageGroupID string) (*DataReduction, error)
+
+	// GetStorageGroupDemandReport returns the allocated and subscribed capacity of the storage groups in a Storage Pool
+	GetStorageGroupDemandReport(ctx context.Context, symID string, storagePoolID string) (*types.StorageGroupDemandReport, error)
+
+	// GetSRPDemandByServiceLevel returns the usable capacity of a Storage Pool and the demand of its storage groups by service level
+	GetSRPDemandByServiceLevel(ctx context.Context, symID string, storagePoolID string) (*SRPDemandByServiceLevel, error)
+
+	// GetSRPNotificationSettings returns the capacity alert thresholds of a Storage Pool
+	GetSRPNotificationSettings(ctx context.Context, symID string, storagePoolID string) (*types.SRPNotificationSettings, error)
+
+	// SetSRPCapacityAlertThresholds enables the capacity alerts of a Storage Pool and sets their warning and critical thresholds in percent
+	SetSRPCapacityAlertThresholds(ctx context.Context, symID string, storagePoolID string, warningPercent, criticalPercent int) (*types.SRPNotificationSettings, error)
+
+	// ChooseSRP selects the Storage Pool of an array in which to provision according to a placement policy and
+	// returns the data on which the Storage Pools were ranked
+	ChooseSRP(ctx context.Context, symID string, policy SRPPlacementPolicy) (*SRPChoice, error)
+
+	// SetCapacityAlertThresholds sets the capacity alert thresholds of every Storage Pool of an array
+	SetCapacityAlertThresholds(ctx context.Context, symID string, warningPercent, criticalPercent int) ([]types.SRPNotificationSettings, error)
+
+	// GetStorageGroupDemand returns the allocated and subscribed capacity of a storage group in a Storage Pool
+	// The error matches ErrNotFound if the storage group is not in the demand report of the Storage Pool.
+	GetStorageGroupDemand(ctx context.Context, symID string, storagePoolID string, storageGroupID string) (*types.StorageGroupDemand, error)
+
 	// Get the list of Storage Pools
 	GetStoragePoolList(ctx context.Context, symID string) (*types.StoragePoolList, error)
 
 	// Rename a Volume given the volumeID
 	RenameVolume(ctx context.Context, symID string, volumeID string, newName string) (*types.Volume, error)
+	// ClearVolumeIdentifier removes the identifier (name) of a Volume
+	ClearVolumeIdentifier(ctx context.Context, symID string, volumeID string) (*types.Volume, error)
 
 	// Add volume(s) asynchronously to a StorageGroup
 	AddVolumesToStorageGroup(ctx context.Context, symID, storageGroupID string, force bool, volumeIDs ...string) error
+	// UpdateStorageGroupAndGetNewVolumeIDs updates a storage group asynchronously with the given payload, waits for
+	// the job to complete and returns the IDs of the volumes that were added to the storage group
+	UpdateStorageGroupAndGetNewVolumeIDs(ctx context.Context, symID, storageGroupID string, payload interface{}) ([]string, error)
 	// Add volume(s) synchronously to a StorageGroup
 	// This is a blocking call and will only return once the volumes have been added to storage group
 	AddVolumesToStorageGroupS(ctx context.Context, symID, storageGroupID string, force bool, volumeIDs ...string) error
+	// AddVolumesToStorageGroupByCriteria adds pre-existing volumes, which are not in any storage group, to a
+	// StorageGroup by their size and emulation, and returns their IDs. It requires Unisphere 9.1 or later.
+	AddVolumesToStorageGroupByCriteria(ctx context.Context, symID, storageGroupID string, force bool, criteria VolumeCriteria) ([]string, error)
+	// AddVolumeRangeToStorageGroup adds the volumes from firstVolumeID to lastVolumeID, both included, to a StorageGroup.
+	AddVolumeRangeToStorageGroup(ctx context.Context, symID, storageGroupID string, force bool, firstVolumeID, lastVolumeID string) error
 	// Adds one or more volumes (given by their volumeIDs) to a Protected StorageGroup
 	AddVolumesToProtectedStorageGroup(ctx context.Context, symID, storageGroupID, remoteSymID, remoteStorageGroupID string, force bool, volumeIDs ...string) error
 
@@ -159,57 +258,183 @@ type Pmax interface {
 	// Deletes a volume
 	DeleteVolume(ctx context.Context, symID string, volumeID string) error
 
+	// DeleteVolumeWithRetry deletes a volume, retrying for up to window, zero for DefaultDeleteVolumeRetryWindow,
+	// while Unisphere reports it in a storage group.
+	DeleteVolumeWithRetry(ctx context.Context, symID string, volumeID string, window time.Duration) error
+
 	// GetMaskingViewList  returns a list of the MaskingView names.
 	GetMaskingViewList(ctx context.Context, symID string) (*types.MaskingViewList, error)
 
+	// GetMaskingViewListWithFilter returns the names of the masking views of a host or host
+	// group, port group and/or storage group.
+	GetMaskingViewListWithFilter(ctx context.Context, symID string, filter MaskingViewFilter) (*types.MaskingViewList, error)
+
+	// ForEachMaskingView calls fn with the name of every masking view matching filter, paging
+	// through the listing, and stops at the first error of fn.
+	ForEachMaskingView(ctx context.Context, symID string, filter MaskingViewFilter, fn func(mvID string) error) error
+
 	// GetMaskingViewByID returns a masking view given it's identifier (which is the name)
+	// The error matches ErrNotFound if the masking view does not exist.
 	GetMaskingViewByID(ctx context.Context, symID string, maskingViewID string) (*types.MaskingView, error)
 
 	// GetMaskingViewConnections returns the connections of a masking view (optionally for a specific volume id.)
 	// Here volume id is the 5 digit volume ID.
 	GetMaskingViewConnections(ctx context.Context, symID string, maskingViewID string, volumeID string) ([]*types.MaskingViewConnection, error)
 
+	// GetMaskingViewConnectionsWithFilter returns the connections of a masking view of a volume and/or an initiator
+	GetMaskingViewConnectionsWithFilter(ctx context.Context, symID string, maskingViewID string, filter MaskingViewConnectionFilter) ([]*types.MaskingViewConnection, error)
+
+	// GetMaskingViewConnectionsIterator returns an iterator of the connections of a masking view matching the filter, holding the first page of them
+	GetMaskingViewConnectionsIterator(ctx context.Context, symID, maskingViewID string, filter MaskingViewConnectionFilter) (*types.MaskingViewConnectionIterator, error)
+
+	// GetMaskingViewConnectionsIteratorPage returns the connections from..to of a masking view connection iterator
+	GetMaskingViewConnectionsIteratorPage(ctx context.Context, iter *types.MaskingViewConnectionIterator, from, to int) ([]*types.MaskingViewConnection, error)
+
+	// DeleteMaskingViewConnectionsIterator deletes a masking view connection iterator
+	DeleteMaskingViewConnectionsIterator(ctx context.Context, iter *types.MaskingViewConnectionIterator) error
+
+	// ForEachMaskingViewConnection calls fn with every connection of a masking view matching the filter, a page at a time
+	ForEachMaskingViewConnection(ctx context.Context, symID, maskingViewID string, filter MaskingViewConnectionFilter, fn func(*types.MaskingViewConnection) error) error
+
+	// GetConnectionsForInitiator returns the connections of a masking view through an initiator (optionally for a specific volume id.)
+	GetConnectionsForInitiator(ctx context.Context, symID, maskingViewID, initiatorID, volumeID string) ([]*types.MaskingViewConnection, error)
+	// GetVolumeNamespaceID returns the NVMe namespace ID of a volume exposed to an NVMe host by a masking view
+	GetVolumeNamespaceID(ctx context.Context, symID, maskingViewID, volumeID string) (string, error)
+
+	// ValidateMaskingViewPathing returns the volumes of a masking view which are not exposed through
+	// expectedPathsPerVolume director ports with a consistent host LUN address
+	ValidateMaskingViewPathing(ctx context.Context, symID, mvID string, expectedPathsPerVolume int) ([]MaskingViewPathingDiscrepancy, error)
+
 	// CreateMaskingView creates a masking view given the Masking view id, Storage group id,
 	// host id and the port id and returns the masking view object
 	CreateMaskingView(ctx context.Context, symID string, maskingViewID string, storageGroupID string, hostOrhostGroupID string, isHost bool, portGroupID string) (*types.MaskingView, error)
 
+	// CreateMaskingViewWithOptions is CreateMaskingView with optional settings, such as the
+	// starting host LUN address of the volumes
+	CreateMaskingViewWithOptions(ctx context.Context, symID string, maskingViewID string, storageGroupID string, hostOrhostGroupID string, isHost bool, portGroupID string, options MaskingViewOptions) (*types.MaskingView, error)
+
+	// CreateMaskingViewAsync is CreateMaskingViewWithOptions executed asynchronously, it returns the job creating the masking view
+	CreateMaskingViewAsync(ctx context.Context, symID string, maskingViewID string, storageGroupID string, hostOrhostGroupID string, isHost bool, portGroupID string, options MaskingViewOptions) (*types.Job, error)
+
 	// CreatePortGroup creates a port group given the Port Group id and a list of dir/port ids
 	CreatePortGroup(ctx context.Context, symID string, portGroupID string, dirPorts []types.PortKey) (*types.PortGroup, error)
 
+	// CreatePortGroupWithProtocol creates a port group of the given protocol:
+	// types.PortGroupProtocolFibre, types.PortGroupProtocolISCSI or types.PortGroupProtocolNVMeTCP
+	CreatePortGroupWithProtocol(ctx context.Context, symID string, portGroupID string, dirPorts []types.PortKey, protocol string) (*types.PortGroup, error)
+
 	// System
 	GetSymmetrixIDList(ctx context.Context) (*types.SymmetrixIDList, error)
 	GetSymmetrixByID(ctx context.Context, id string) (*types.Symmetrix, error)
 
+	// GetLocalSymmetrixIDList returns the allowed arrays which are local to the Unisphere, not managed remotely
+	GetLocalSymmetrixIDList(ctx context.Context) (*types.SymmetrixIDList, error)
+
+	// ValidateLocalArray returns an error unless the array is allowed and local to the Unisphere
+	ValidateLocalArray(ctx context.Context, symID string) error
+
+	// GetArrayPerfRegistration returns how the array is registered for the collection of performance data
+	GetArrayPerfRegistration(ctx context.Context, symID string) (*types.PerformanceRegistration, error)
+
+	// IsArrayPerfRegistered returns true if the array is registered for the collection of performance data
+	IsArrayPerfRegistered(ctx context.Context, symID string) (bool, error)
+
+	// RegisterArrayForPerformance registers the array for the collection of diagnostic performance data,
+	// and of real time data if realTime is set
+	RegisterArrayForPerformance(ctx context.Context, symID string, realTime bool) error
+
+	// GetArrayTime returns the time of an array and the skew of its clock from the local clock,
+	// to interpret the timestamps of the array and schedule jobs on it
+	GetArrayTime(ctx context.Context, symID string) (*ArrayTime, error)
+
+	// CollectSupportBundle gathers the description, inventory, recent jobs and alerts of an array and client-side
+	// log files into a zip archive of JSON files for Dell support, with secrets redacted
+	CollectSupportBundle(ctx context.Context, symID string, opts SupportBundleOptions) (*SupportBundle, error)
+
+	// ExportInventory writes an inventory of the storage groups, volumes, hosts, masking views and port groups of an array in JSON or CSV
+	ExportInventory(ctx context.Context, symID string, w io.Writer, format InventoryFormat) error
+
+	// GetUnisphereInfo returns the version, build date, supported API versions and,
+	// where available, the API load of the connected Unisphere instance.
+	GetUnisphereInfo(ctx context.Context) (*types.UnisphereInfo, error)
+
+	// GetArrayHealth returns the current health scores of an array by metric
+	GetArrayHealth(ctx context.Context, symID string) (*ArrayHealth, error)
+
+	// GetArrayLastRefresh returns when Unisphere last refreshed its data about an array
+	GetArrayLastRefresh(ctx context.Context, symID string) (time.Time, error)
+
+	// GetSLOComplianceSummary returns the number of storage groups of an array in each service level compliance state
+	GetSLOComplianceSummary(ctx context.Context, symID string) (*types.SLOComplianceSummary, error)
+
+	// CheckAPIVersion checks that the API version of the client is supported by both the library and Unisphere,
+	// failing with an APIVersionError naming the versions to use instead.
+	CheckAPIVersion(ctx context.Context) error
+
 	// GetJobIDList retrieves the list of jobs on a given Symmetrix.
 	// If optional parameter statusQuery is a types.JobStatusRunning or similar string, will search for jobs
 	// with a particular status.
 	GetJobIDList(ctx context.Context, symID string, statusQuery string) ([]string, error)
+
+	// GetJobIDListWithFilter retrieves the IDs of the jobs on a given Symmetrix selected by the status,
+	// the prefix of the name and the resource type of the filter, which are applied by Unisphere
+	GetJobIDListWithFilter(ctx context.Context, symID string, filter JobListFilter) ([]string, error)
 	GetJobByID(ctx context.Context, symID string, jobID string) (*types.Job, error)
 	WaitOnJobCompletion(ctx context.Context, symID string, jobID string) (*types.Job, error)
+
+	// WaitOnJobCompletionWithOptions is WaitOnJobCompletion which can cancel the job if ctx is aborted.
+	WaitOnJobCompletionWithOptions(ctx context.Context, symID string, jobID string, options JobWaitOptions) (*types.Job, error)
+
+	// CancelJob cancels a job which has not started running.
+	CancelJob(ctx context.Context, symID string, jobID string) error
 	JobToString(job *types.Job) string
 
 	// GetPortGroupList returns a list of all the Port Group ids.
+	// portGroupType filters them by protocol: "fibre", "iscsi", "nvme" or "" for all.
 	GetPortGroupList(ctx context.Context, symID string, portGroupType string) (*types.PortGroupList, error)
 	// GetPortGroupByID returns a port group given the PortGroup id.
+	// The error matches ErrNotFound if the port group does not exist.
 	GetPortGroupByID(ctx context.Context, symID string, portGroupID string) (*types.PortGroup, error)
 
 	// GetInitiatorList returns a list of all the Initiator ids based on filters supplied
 	GetInitiatorList(ctx context.Context, symID string, initiatorHBA string, isISCSI bool, inHost bool) (*types.InitiatorList, error)
+	// GetInitiatorSessionStats returns the login statistics of an HBA on every director port it is seen on
+	GetInitiatorSessionStats(ctx context.Context, symID, initiatorHBA string) (*InitiatorSessionStats, error)
+	// GetInitiatorListWithFilter returns the Initiator ids selected by filter, e.g. the NVMe host NQNs
+	GetInitiatorListWithFilter(ctx context.Context, symID string, filter InitiatorListFilter) (*types.InitiatorList, error)
 	// GetInitiatorByID returns an Initiator given the Initiator id.
 	GetInitiatorByID(ctx context.Context, symID string, initID string) (*types.Initiator, error)
+	// GetInitiatorsByAlias returns the Initiators with the given alias, on every director port they are seen on.
+	// The error matches ErrNotFound if no initiator has the alias.
+	GetInitiatorsByAlias(ctx context.Context, symID, alias string) ([]*types.Initiator, error)
 
 	// GetHostList returns a list of all the Host ids.
 	GetHostList(ctx context.Context, symID string) (*types.HostList, error)
 	// GetHostByID returns a Host given the Host id.
+	// The error matches ErrNotFound if the host does not exist.
 	GetHostByID(ctx context.Context, symID string, hostID string) (*types.Host, error)
+	// GetHostForInitiator returns the host owning the initiator with an FC WWN, IQN or NVMe host NQN,
+	// or nil if the initiator is not in a host.
+	GetHostForInitiator(ctx context.Context, symID string, hba string) (*types.Host, error)
 	// CreateHost creates a host from a list of InitiatorIDs (and optional HostFlags) return returns a types.Host.
 	// Initiator IDs do not contain the storage port designations, just the IQN string or FC WWN.
 	// Initiator IDs cannot be a member of more than one host.
 	CreateHost(ctx context.Context, symID string, hostID string, initiatorIDs []string, hostFlags *types.HostFlags) (*types.Host, error)
+	// CreateHostAsync is CreateHost executed asynchronously, it returns the job creating the host.
+	CreateHostAsync(ctx context.Context, symID string, hostID string, initiatorIDs []string, hostFlags *types.HostFlags) (*types.Job, error)
 	// DeleteHost deletes a host given the hostID.
 	DeleteHost(ctx context.Context, symID string, hostID string) error
+	// ImportHostsFromInitiators creates hosts for the logged in initiators which are not in a host, grouped
+	// into hosts by grouping, and returns which hosts were created. An error is returned if some failed.
+	ImportHostsFromInitiators(ctx context.Context, symID string, grouping HostGrouping) (*HostImportReport, error)
+	// CreateOrUpdateHost creates the host with the initiators or, if another node created it, adds the
+	// initiators it lacks, retrying when the host is changed concurrently
+	CreateOrUpdateHost(ctx context.Context, symID string, hostID string, initiatorIDs []string, hostFlags *types.HostFlags) (*types.Host, error)
 	// UpdateHostInitiators will update the inititators
 	UpdateHostInitiators(ctx context.Context, symID string, host *types.Host, initiatorIDs []string) (*types.Host, error)
+	// MoveInitiatorToHost moves an initiator from one host to another, removing it from the
+	// source host before adding it to the target host, and returns the target host.
+	MoveInitiatorToHost(ctx context.Context, symID, initiatorID, fromHostID, toHostID string) (*types.Host, error)
 	UpdateHostName(ctx context.Context, symID, oldHostID, newHostID string) (*types.Host, error)
 	// GetDirectorIDList returns a list of directors
 	GetDirectorIDList(ctx context.Context, symID string) (*types.DirectorIDList, error)
@@ -217,11 +442,32 @@ type Pmax interface {
 	GetPortList(ctx context.Context, symID string, directorID string, query string) (*types.PortList, error)
 	// GetPort returns port details.
 	GetPort(ctx context.Context, symID string, directorID string, portID string) (*types.Port, error)
-	// GetListOfTargetAddresses returns an array of all IP addresses which expose iscsi targets.
+	// GetSymmetrixPortList returns a list of the ports of all the directors of an array.
+	GetSymmetrixPortList(ctx context.Context, symID string, query string) (*types.PortList, error)
+	// GetListOfTargetAddresses returns an array of all IP addresses which expose iscsi targets
+	// on ports which are online.
 	GetListOfTargetAddresses(ctx context.Context, symID string) ([]string, error)
+	// GetListOfTargetAddressesWithOptions returns an array of all IP addresses which expose
+	// iscsi targets, including those of offline ports if requested.
+	GetListOfTargetAddressesWithOptions(ctx context.Context, symID string, options TargetAddressOptions) ([]string, error)
 	// GetISCSITargets returns a list of ISCSI Targets for a given sym id
 	GetISCSITargets(ctx context.Context, symID string) ([]ISCSITarget, error)
 
+	// GetIPInterfaceList returns the IDs of the IP interfaces of a port of an SE director
+	GetIPInterfaceList(ctx context.Context, symID, directorID, portID string) (*types.IPInterfaceList, error)
+
+	// GetIPInterface returns an IP interface of a port of an SE director
+	GetIPInterface(ctx context.Context, symID, directorID, portID, interfaceID string) (*types.IPInterface, error)
+
+	// GetIPInterfaces returns the IP interfaces of a port of an SE director
+	GetIPInterfaces(ctx context.Context, symID, directorID, portID string) ([]types.IPInterface, error)
+
+	// CreateIPInterface creates an IP interface with a VLAN and network ID on a port of an SE director
+	CreateIPInterface(ctx context.Context, symID, directorID, portID string, param types.CreateIPInterfaceParam) error
+
+	// GetISCSIPortals returns the portal IPs of the iSCSI targets of an array with the IP interfaces carrying them
+	GetISCSIPortals(ctx context.Context, symID string) ([]ISCSIPortal, error)
+
 	// SetAllowedArrays sets the list of arrays which can be manipulated
 	// an empty list will allow all arrays to be accessed
 	SetAllowedArrays(arrays []string) error
@@ -238,17 +484,65 @@ type Pmax interface {
 	GetSnapshotInfo(ctx context.Context, symID, volume, SnapID string) (*types.VolumeSnapshot, error)
 	// CreateSnapshot creates a snapVx snapshot of a volume using the input parameters
 	CreateSnapshot(ctx context.Context, symID string, SnapID string, sourceVolumeList []types.VolumeList, ttl int64) error
+	// GetSnapshotHeadroom returns the number of snapshots of a volume and its array, and the snapshot limits
+	GetSnapshotHeadroom(ctx context.Context, symID, volumeID string) (*SnapshotHeadroom, error)
 
 	//ModifySnapshot executes actions on a snapshot asynchronously
 	// This creates a job and waits on its completion
+	//
+	// Deprecated: use LinkSnapshot, UnlinkSnapshot, RelinkSnapshot, RenameSnapshot or SetSnapshotMode instead
 	ModifySnapshot(ctx context.Context, symID string, sourceVol []types.VolumeList,
 		targetVol []types.VolumeList, SnapID string, action string,
 		newSnapID string, generation int64) error
 
 	// ModifySnapshotS executes actions on a snapshot synchronously
+	//
+	// Deprecated: use LinkSnapshot, UnlinkSnapshot, RelinkSnapshot, RenameSnapshot or SetSnapshotMode instead
 	ModifySnapshotS(ctx context.Context, symID string, sourceVol []types.VolumeList,
 		targetVol []types.VolumeList, SnapID string, action string,
 		newSnapID string, generation int64) error
+
+	// WaitForSnapshotLinkDefined waits until the link of a snapshot to a target volume is defined or timeout expires
+	WaitForSnapshotLinkDefined(ctx context.Context, symID, srcVolID, snapID, targetVolID string, timeout time.Duration) error
+
+	// CreateVolumeFromSnapshot creates a volume in a storage group, links a snapshot to it and waits for the link to be defined
+	CreateVolumeFromSnapshot(ctx context.Context, symID, snapSrcVolID, snapID, targetSGID, name string,
+		sizeInCylinders int, opts CreateVolumeFromSnapshotOptions) (*types.Volume, error)
+
+	// LinkSnapshotToNewStorageGroup links a snapshot of every volume of a storage group to a new volume of the same size in a target storage group
+	LinkSnapshotToNewStorageGroup(ctx context.Context, symID, snapID, sourceSGID, targetSGID string,
+		opts LinkSnapshotToNewStorageGroupOptions) ([]StorageGroupSnapshotLink, error)
+
+	// CloneVolumeFull creates a volume as a full copy of another through a temporary snapshot
+	CloneVolumeFull(ctx context.Context, symID, srcVolID, targetSGID, name string,
+		sizeInCylinders int, opts CloneVolumeOptions) (*VolumeClone, error)
+
+	// RecoverCloneSnapshots terminates the temporary snapshots of CloneVolumeFull older than gracePeriod
+	// whose clones are copied or gone
+	RecoverCloneSnapshots(ctx context.Context, symID string, gracePeriod time.Duration) ([]string, error)
+
+	// BuildSnapshotTopology returns the graph of the snapshots and linked targets of volumes and of the volumes related to them
+	BuildSnapshotTopology(ctx context.Context, symID string, volIDs []string) (*SnapshotTopology, error)
+
+	// LinkSnapshot links a generation of a snapshot to target volumes synchronously, optionally in copy mode
+	LinkSnapshot(ctx context.Context, symID string, snapID string, sourceVol []types.VolumeList,
+		targetVol []types.VolumeList, generation int64, copy bool) error
+
+	// UnlinkSnapshot unlinks a generation of a snapshot from target volumes synchronously
+	UnlinkSnapshot(ctx context.Context, symID string, snapID string, sourceVol []types.VolumeList,
+		targetVol []types.VolumeList, generation int64) error
+
+	// RelinkSnapshot re-links target volumes to a generation of a snapshot synchronously
+	RelinkSnapshot(ctx context.Context, symID string, snapID string, sourceVol []types.VolumeList,
+		targetVol []types.VolumeList, generation int64, copy bool) error
+
+	// RenameSnapshot renames a generation of a snapshot synchronously
+	RenameSnapshot(ctx context.Context, symID string, snapID string, newSnapID string,
+		sourceVol []types.VolumeList, generation int64) error
+
+	// SetSnapshotMode sets the links of a generation of a snapshot to copy or nocopy mode synchronously
+	SetSnapshotMode(ctx context.Context, symID string, snapID string, sourceVol []types.VolumeList,
+		targetVol []types.VolumeList, generation int64, copy bool) error
 	// DeleteSnapshot deletes a snapshot from a volume
 	// This is an asynchronous call and waits for the job to complete
 	DeleteSnapshot(ctx context.Context, symID, SnapID string, sourceVolumes []types.VolumeList, generation int64) error
@@ -263,9 +557,19 @@ type Pmax interface {
 	GetSnapshotGenerationInfo(ctx context.Context, symID, volume, SnapID string, generation int64) (*types.VolumeSnapshotGeneration, error)
 	// GetReplicationCapabilities returns details about SnapVX and SRDF execution capabilities on the Symmetrix array
 	GetReplicationCapabilities(ctx context.Context) (*types.SymReplicationCapabilities, error)
+
+	// GetArrayCapabilities returns the SnapVX, SRDF, vVol and file capabilities of an array,
+	// cached for ClientOptions.CapabilityRefreshInterval
+	GetArrayCapabilities(ctx context.Context, symID string) (*ArrayCapabilities, error)
+
+	// ForceRefreshArrayCapabilities reads the capabilities of an array from Unisphere and caches them
+	ForceRefreshArrayCapabilities(ctx context.Context, symID string) (*ArrayCapabilities, error)
 	// GetPrivVolumeByID returns a Volume structure given the symmetrix and volume ID (volume ID is in WWN format)
 	GetPrivVolumeByID(ctx context.Context, symID string, volumeID string) (*types.VolumeResultPrivate, error)
 
+	// GetPrivVolumeList returns the private view of the volumes whose WWN matches, reading all the pages of the iterator
+	GetPrivVolumeList(ctx context.Context, symID string, wwnMatch string, like bool) ([]types.VolumeResultPrivate, error)
+
 	// Delete PortGroup
 	DeletePortGroup(ctx context.Context, symID string, portGroupID string) error
 	// Update PortGroup
@@ -273,19 +577,38 @@ type Pmax interface {
 
 	// Expand the size of an existing volume
 	ExpandVolume(ctx context.Context, symID string, volumeID string, newSizeCYL int) (*types.Volume, error)
-	GetCreateVolInSGPayload(sizeInCylinders int, volumeName string, isSync bool, remoteSymID, storageGroupID string, opts ...http.Header) (payload interface{})
+	GetCreateVolInSGPayload(sizeInCylinders int, volumeName string, isSync bool, remoteSymID, storageGroupID string, opts ...http.Header) *StorageGroupPayload
+	// GetCreateVolInSGPayloadWithForceOption returns the payload for creating a volume in a storage group, see ForceOption for what force implies
+	GetCreateVolInSGPayloadWithForceOption(sizeInCylinders int, volumeName string, isSync bool, force ForceOption, remoteSymID, storageGroupID string, opts ...http.Header) *StorageGroupPayload
+	// GetAddExistingVolumesToSGPayload returns the payload for adding pre-existing volumes to a storage group by their attributes
+	GetAddExistingVolumesToSGPayload(isSync bool, force ForceOption, criteria VolumeCriteria) (*StorageGroupPayload, error)
 	//GetCreateVolInSGPayloadWithMetaDataHeaders(sizeInCylinders int, volumeName string, isSync bool, remoteSymID, remoteStorageGroupID string, metadata http.Header) (payload interface{})
 
 	// Fetches RDF group information
 	GetRDFGroup(ctx context.Context, symID, rdfGroup string) (*types.RDFGroup, error)
+	// CreateProtectedStorageGroup creates a storage group protected by the snapshot policies and/or SRDF
+	// of spec, rolling back all changes if any step fails
+	CreateProtectedStorageGroup(ctx context.Context, symID, storageGroupID, srpID, serviceLevel string, thickVolumes bool, spec ProtectionSpec) (*types.StorageGroup, error)
+	// GetRDFDirectorList returns the RDF directors of the array, optionally only the online ones
+	GetRDFDirectorList(ctx context.Context, symID string, online bool) (*types.RDFDirList, error)
+	// GetRDFPortList returns the RDF capable ports of an RDF director, optionally only the online ones
+	GetRDFPortList(ctx context.Context, symID, directorID string, online bool) (*types.RDFPortList, error)
 	// GetProtectedStorageGroup returns protected storage group given the storage group ID
 	GetProtectedStorageGroup(ctx context.Context, symID, storageGroup string) (*types.RDFStorageGroup, error)
 	// CreateSGReplica creates a storage group on remote array and protect them with given RDF Mode and a given source storage group
 	CreateSGReplica(ctx context.Context, symID, remoteSymID, rdfMode, rdfGroupNo, sourceSG, remoteSGName, remoteServiceLevel string, bias bool) (*types.SGRDFInfo, error)
 	// ExecuteReplicationActionOnSG executes supported replication based actions on the protected SG
 	ExecuteReplicationActionOnSG(ctx context.Context, symID, action, storageGroup, rdfGroup string, force, exemptConsistency, bias bool) error
+	// SetRDFMode changes the SRDF mode of a protected SG between ASYNC and SYNC, suspending and resuming its pairs around
+	// the change. Transitions the array does not allow fail with an RDFModeTransitionError naming the step required first.
+	SetRDFMode(ctx context.Context, symID, storageGroup, rdfGroup, targetMode string) error
 	// Creates a volume replication pair
 	CreateRDFPair(ctx context.Context, symID, rdfGroupNo, deviceID, rdfMode, rdfType string, establish, exemptConsistency bool) (*types.RDFDevicePairList, error)
+	// CreateRDFPairInGroup pairs local volumes with new remote volumes in an existing RDF group and
+	// adds the remote volumes to remoteSGID; the pair creation job is returned
+	CreateRDFPairInGroup(ctx context.Context, localSymID, rdfGroupNo string, localVolIDs []string, remoteSGID, mode string, options RDFPairOptions) (*types.Job, error)
+	// DeleteRDFPair suspends and deletes the RDF pair of a volume
+	DeleteRDFPair(ctx context.Context, symID, rdfGroupNo, volumeID string) error
 	/// GetRDFDevicePairInfo returns RDF volume information
 	GetRDFDevicePairInfo(ctx context.Context, symID, rdfGroup, volumeID string) (*types.RDFDevicePair, error)
 	// GetStorageGroupRDFInfo returns the of RDF info of protected storage group
@@ -0,0 +1,375 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	types "github.com/dell/gopowermax/types/v90"
+	log "github.com/sirupsen/logrus"
+)
+
+// InventoryFormat is the format of the inventory written by ExportInventory
+type InventoryFormat string
+
+// The formats of ExportInventory
+const (
+	// InventoryFormatJSON is a JSON object holding the symmetrixId, the exported time and an array of the
+	// objects of each kind: storageGroups, volumes, hosts, maskingViews and portGroups, one object per line.
+	InventoryFormatJSON InventoryFormat = "json"
+	// InventoryFormatCSV is a CSV file of a line per object, with the columns of InventoryCSVHeader; every line
+	// holds the symmetrixId and the exported time, as in RFC 3339.
+	InventoryFormatCSV InventoryFormat = "csv"
+)
+
+// InventoryCSVHeader are the columns of an inventory in CSV. The symmetrix_id and exported columns are those of
+// the whole inventory, the same on every line. The kind is one of storage_group, volume, host,
+// masking_view and port_group. The identifier is the volume identifier of a volume, the capacity is that of
+// a storage group or volume and the service level that of a storage group. The related objects, separated by
+// ";", are the masking views of a storage group, the storage groups of a volume, the initiators of a host,
+// the host (or host group), port group and storage group of a masking view and the ports of a port group.
+var InventoryCSVHeader = []string{"symmetrix_id", "exported", "kind", "id", "identifier", "capacity_gb", "service_level", "related"}
+
+// inventorySection is the export of the objects of a kind
+type inventorySection struct {
+	// name is the key of the objects in JSON, e.g. "storageGroups"
+	name string
+	// kind is the kind of the objects in CSV, e.g. "storage_group"
+	kind string
+	// each calls fn with the ID of every object, stopping at its first error
+	each func(fn func(id string) error) error
+	// get reads an object
+	get func(ctx context.Context, id string) (interface{}, error)
+	// row returns the CSV columns of an object, from the id
+	row func(object interface{}) []string
+}
+
+// newInventorySection returns the inventorySection of the objects of type T
+func newInventorySection[T any](name, kind string, each func(fn func(id string) error) error,
+	get func(ctx context.Context, id string) (*T, error), row func(object *T) []string) inventorySection {
+	return inventorySection{
+		name: name,
+		kind: kind,
+		each: each,
+		get: func(ctx context.Context, id string) (interface{}, error) {
+			object, err := get(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			return object, nil
+		},
+		row: func(object interface{}) []string { return row(object.(*T)) },
+	}
+}
+
+// eachID returns the each function of an inventorySection listing the IDs returned by list
+func eachID(list func() ([]string, error)) func(fn func(id string) error) error {
+	return func(fn func(id string) error) error {
+		ids, err := list()
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err = fn(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// formatGB formats a capacity in GB for the CSV inventory
+func formatGB(gb float64) string {
+	return strconv.FormatFloat(gb, 'f', -1, 64)
+}
+
+// ExportInventory writes an inventory of the storage groups, volumes, hosts, masking views and port groups of an
+// array to w, e.g. for a nightly backup of its configuration, in the JSON or CSV format. The objects are read
+// ClientOptions.MaxConcurrentRequests at a time, concurrently, and written as soon as they are read; the volumes are listed a page at a time. So the memory used does not grow with the size of the array,
+// but an error may leave a partial inventory in w. The objects deleted while the inventory is exported are left out.
+func (c *Client) ExportInventory(ctx context.Context, symID string, w io.Writer, format InventoryFormat) error {
+	defer c.TimeSpent("ExportInventory", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return err
+	}
+	var writer inventoryWriter
+	switch format {
+	case InventoryFormatJSON:
+		writer = &jsonInventoryWriter{w: w}
+	case InventoryFormatCSV:
+		writer = &csvInventoryWriter{w: csv.NewWriter(w)}
+	default:
+		return fmt.Errorf("Invalid inventory format %s, it must be %s or %s", format, InventoryFormatJSON, InventoryFormatCSV)
+	}
+
+	sections := []inventorySection{
+		newInventorySection("storageGroups", "storage_group",
+			eachID(func() ([]string, error) {
+				list, err := c.GetStorageGroupIDList(ctx, symID)
+				if err != nil {
+					return nil, err
+				}
+				return list.StorageGroupIDs, nil
+			}),
+			func(ctx context.Context, id string) (*types.StorageGroup, error) {
+				return c.GetStorageGroup(ctx, symID, id)
+			},
+			func(sg *types.StorageGroup) []string {
+				return []string{sg.StorageGroupID, "", formatGB(sg.CapacityGB), sg.SLO, strings.Join(sg.MaskingView, ";")}
+			}),
+		newInventorySection("volumes", "volume",
+			func(fn func(id string) error) error {
+				iter, err := c.GetVolumeIDsIterator(ctx, symID, "", false)
+				if err != nil {
+					return err
+				}
+				return c.volumeIterator(iter).ForEach(ctx, func(volume types.VolumeIDList) error {
+					return fn(volume.VolumeIDs)
+				})
+			},
+			func(ctx context.Context, id string) (*types.Volume, error) { return c.GetVolumeByID(ctx, symID, id) },
+			func(vol *types.Volume) []string {
+				return []string{vol.VolumeID, vol.VolumeIdentifier, formatGB(vol.CapacityGB), "", strings.Join(vol.StorageGroupIDList, ";")}
+			}),
+		newInventorySection("hosts", "host",
+			eachID(func() ([]string, error) {
+				list, err := c.GetHostList(ctx, symID)
+				if err != nil {
+					return nil, err
+				}
+				return list.HostIDs, nil
+			}),
+			func(ctx context.Context, id string) (*types.Host, error) { return c.GetHostByID(ctx, symID, id) },
+			func(host *types.Host) []string {
+				return []string{host.HostID, "", "", "", strings.Join(host.Initiators, ";")}
+			}),
+		newInventorySection("maskingViews", "masking_view",
+			eachID(func() ([]string, error) {
+				list, err := c.GetMaskingViewList(ctx, symID)
+				if err != nil {
+					return nil, err
+				}
+				return list.MaskingViewIDs, nil
+			}),
+			func(ctx context.Context, id string) (*types.MaskingView, error) {
+				return c.GetMaskingViewByID(ctx, symID, id)
+			},
+			func(mv *types.MaskingView) []string {
+				hostID := mv.HostID
+				if hostID == "" {
+					hostID = mv.HostGroupID
+				}
+				return []string{mv.MaskingViewID, "", "", "", strings.Join([]string{hostID, mv.PortGroupID, mv.StorageGroupID}, ";")}
+			}),
+		newInventorySection("portGroups", "port_group",
+			eachID(func() ([]string, error) {
+				list, err := c.GetPortGroupList(ctx, symID, "")
+				if err != nil {
+					return nil, err
+				}
+				return list.PortGroupIDs, nil
+			}),
+			func(ctx context.Context, id string) (*types.PortGroup, error) {
+				return c.GetPortGroupByID(ctx, symID, id)
+			},
+			func(pg *types.PortGroup) []string {
+				ports := make([]string, len(pg.SymmetrixPortKey))
+				for i, key := range pg.SymmetrixPortKey {
+					ports[i] = key.DirectorID + ":" + key.PortID
+				}
+				return []string{pg.PortGroupID, "", "", "", strings.Join(ports, ";")}
+			}),
+	}
+
+	err := writer.begin(symID, time.Now().UTC())
+	for i := 0; i < len(sections) && err == nil; i++ {
+		err = c.exportInventorySection(ctx, writer, sections[i])
+	}
+	if err == nil {
+		err = writer.end()
+	}
+	if err != nil {
+		log.Error("ExportInventory failed: " + err.Error())
+		return err
+	}
+	log.Info(fmt.Sprintf("Exported the inventory of array %s in %s", symID, format))
+	return nil
+}
+
+// exportInventorySection writes the objects of a section, reading them ClientOptions.MaxConcurrentRequests at a time
+func (c *Client) exportInventorySection(ctx context.Context, writer inventoryWriter, section inventorySection) error {
+	if err := writer.section(section); err != nil {
+		return err
+	}
+	batchSize := c.maxConcurrentRequests
+	if batchSize <= 0 {
+		batchSize = DefaultMaxConcurrentRequests
+	}
+	batch := make([]string, 0, batchSize)
+	flush := func() error {
+		err := c.exportInventoryObjects(ctx, writer, section, batch)
+		batch = batch[:0]
+		return err
+	}
+	err := section.each(func(id string) error {
+		batch = append(batch, id)
+		if len(batch) < batchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil && len(batch) > 0 {
+		err = flush()
+	}
+	if err != nil {
+		return err
+	}
+	return writer.endSection(section)
+}
+
+// exportInventoryObjects reads the objects of ids concurrently and writes them in the order of ids,
+// leaving out those which no longer exist
+func (c *Client) exportInventoryObjects(ctx context.Context, writer inventoryWriter, section inventorySection, ids []string) error {
+	objects := make([]interface{}, len(ids))
+	err := c.forEachConcurrently(ctx, "ExportInventory", len(ids), func(ctx context.Context, i int) error {
+		object, err := section.get(ctx, ids[i])
+		if errors.Is(err, ErrNotFound) {
+			log.Debug(fmt.Sprintf("ExportInventory left out %s %s, which no longer exists", section.kind, ids[i]))
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't read %s %s: %w", section.kind, ids[i], err)
+		}
+		objects[i] = object
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if object == nil {
+			continue
+		}
+		if err := writer.object(section, object); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inventoryWriter writes an inventory in a format
+type inventoryWriter interface {
+	// begin starts the inventory of an array
+	begin(symID string, exported time.Time) error
+	// section starts the objects of a section
+	section(section inventorySection) error
+	// object writes an object of the current section
+	object(section inventorySection, object interface{}) error
+	// endSection ends the objects of a section
+	endSection(section inventorySection) error
+	// end ends the inventory
+	end() error
+}
+
+// jsonInventoryWriter writes an inventory in InventoryFormatJSON
+type jsonInventoryWriter struct {
+	w io.Writer
+	// objects is the number of objects written in the current section
+	objects int
+}
+
+func (j *jsonInventoryWriter) begin(symID string, exported time.Time) error {
+	header, err := json.Marshal(struct {
+		SymmetrixID string    `json:"symmetrixId"`
+		Exported    time.Time `json:"exported"`
+	}{symID, exported})
+	if err != nil {
+		return err
+	}
+	// the sections follow the fields of the header, within the same object
+	_, err = j.w.Write(header[:len(header)-1])
+	return err
+}
+
+func (j *jsonInventoryWriter) section(section inventorySection) error {
+	j.objects = 0
+	_, err := fmt.Fprintf(j.w, ",\n%q:[", section.name)
+	return err
+}
+
+func (j *jsonInventoryWriter) object(section inventorySection, object interface{}) error {
+	content, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	separator := ",\n"
+	if j.objects == 0 {
+		separator = "\n"
+	}
+	j.objects++
+	if _, err = io.WriteString(j.w, separator); err != nil {
+		return err
+	}
+	_, err = j.w.Write(content)
+	return err
+}
+
+func (j *jsonInventoryWriter) endSection(section inventorySection) error {
+	_, err := io.WriteString(j.w, "\n]")
+	return err
+}
+
+func (j *jsonInventoryWriter) end() error {
+	_, err := io.WriteString(j.w, "}\n")
+	return err
+}
+
+// csvInventoryWriter writes an inventory in InventoryFormatCSV
+type csvInventoryWriter struct {
+	w *csv.Writer
+	// symID and exported are the first columns of every object
+	symID, exported string
+}
+
+func (c *csvInventoryWriter) begin(symID string, exported time.Time) error {
+	c.symID, c.exported = symID, exported.Format(time.RFC3339Nano)
+	return c.w.Write(InventoryCSVHeader)
+}
+
+func (c *csvInventoryWriter) section(section inventorySection) error {
+	return nil
+}
+
+func (c *csvInventoryWriter) object(section inventorySection, object interface{}) error {
+	return c.w.Write(append([]string{c.symID, c.exported, section.kind}, section.row(object)...))
+}
+
+func (c *csvInventoryWriter) endSection(section inventorySection) error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvInventoryWriter) end() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,193 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	types "github.com/dell/gopowermax/types/v90"
+	log "github.com/sirupsen/logrus"
+)
+
+// XIPInterface is the path of the IP interfaces of a port
+const XIPInterface = "/ip_interface"
+
+// MaxVLANID is the highest VLAN ID of an IP interface
+const MaxVLANID = 4094
+
+// ISCSIPortal is a portal IP advertised by an iSCSI target, and the IP interface carrying it
+type ISCSIPortal struct {
+	IQN      string
+	PortalIP string
+	// PortKey is the port of the IP interface
+	PortKey types.PortKey
+	// Interface is nil if no IP interface of the array has the portal IP
+	Interface *types.IPInterface
+}
+
+// getPortURL returns the URL of a port of a director
+func (c *Client) getPortURL(symID, directorID, portID string) string {
+	return c.getSymmetrixIDListURL() + "/" + symID + "/director/" + directorID + "/port/" + portID
+}
+
+// GetIPInterfaceList returns the IDs of the IP interfaces of a port of an SE director
+func (c *Client) GetIPInterfaceList(ctx context.Context, symID, directorID, portID string) (*types.IPInterfaceList, error) {
+	defer c.TimeSpent("GetIPInterfaceList", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	list := &types.IPInterfaceList{}
+	if err := c.getJSON(ctx, c.getPortURL(symID, directorID, portID)+XIPInterface, list); err != nil {
+		log.Error("GetIPInterfaceList failed: " + err.Error())
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetIPInterface returns an IP interface of a port of an SE director
+func (c *Client) GetIPInterface(ctx context.Context, symID, directorID, portID, interfaceID string) (*types.IPInterface, error) {
+	defer c.TimeSpent("GetIPInterface", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	ipInterface := &types.IPInterface{}
+	if err := c.getJSON(ctx, c.getPortURL(symID, directorID, portID)+XIPInterface+"/"+interfaceID, ipInterface); err != nil {
+		log.Error("GetIPInterface failed: " + err.Error())
+		return nil, err
+	}
+	return ipInterface, nil
+}
+
+// GetIPInterfaces returns the IP interfaces of a port of an SE director
+func (c *Client) GetIPInterfaces(ctx context.Context, symID, directorID, portID string) ([]types.IPInterface, error) {
+	list, err := c.GetIPInterfaceList(ctx, symID, directorID, portID)
+	if err != nil {
+		return nil, err
+	}
+	ipInterfaces := make([]types.IPInterface, 0, len(list.IPInterfaceIDs))
+	for _, interfaceID := range list.IPInterfaceIDs {
+		ipInterface, err := c.GetIPInterface(ctx, symID, directorID, portID, interfaceID)
+		if err != nil {
+			return nil, err
+		}
+		ipInterfaces = append(ipInterfaces, *ipInterface)
+	}
+	return ipInterfaces, nil
+}
+
+// CreateIPInterface creates an IP interface on a port of an SE director, on the VLAN and network ID of param.
+// Unisphere versions which cannot configure IP interfaces reject the request.
+func (c *Client) CreateIPInterface(ctx context.Context, symID, directorID, portID string, param types.CreateIPInterfaceParam) error {
+	defer c.TimeSpent("CreateIPInterface", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return err
+	}
+	ip := net.ParseIP(param.IPAddress)
+	if ip == nil {
+		return fmt.Errorf("invalid IP address %s for an IP interface", param.IPAddress)
+	}
+	maxPrefixLength := 128
+	if ip.To4() != nil {
+		maxPrefixLength = 32
+	}
+	if param.IPPrefixLength < 1 || param.IPPrefixLength > maxPrefixLength {
+		return fmt.Errorf("invalid prefix length %d for IP address %s", param.IPPrefixLength, param.IPAddress)
+	}
+	if param.VLANID < 0 || param.VLANID > MaxVLANID {
+		return fmt.Errorf("invalid VLAN ID %d, it must be between 0 and %d", param.VLANID, MaxVLANID)
+	}
+	if param.NetworkID < 0 {
+		return fmt.Errorf("invalid network ID %d", param.NetworkID)
+	}
+	payload := &types.EditPortParam{
+		EditPortActionParam: types.EditPortActionParam{CreateIPInterfaceParam: &param},
+		ExecutionOption:     types.ExecutionOptionSynchronous,
+	}
+	c.ifDebugLogPayload(payload)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
+	defer cancel()
+	if err := c.api.Put(ctx, c.getPortURL(symID, directorID, portID), c.getDefaultHeaders(), payload, nil); err != nil {
+		log.Error("CreateIPInterface failed: " + err.Error())
+		return err
+	}
+	log.Info(fmt.Sprintf("Created IP interface %s/%d on VLAN %d of port %s:%s", param.IPAddress, param.IPPrefixLength, param.VLANID, directorID, portID))
+	return nil
+}
+
+// GetISCSIPortals returns the portal IPs advertised by the iSCSI targets of an array with the IP interfaces
+// carrying them, so that their VLANs and network IDs can be checked. The IP interfaces of the GigE ports
+// are read concurrently, up to ClientOptions.MaxConcurrentRequests at a time.
+func (c *Client) GetISCSIPortals(ctx context.Context, symID string) ([]ISCSIPortal, error) {
+	defer c.TimeSpent("GetISCSIPortals", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	targets, err := c.GetISCSITargets(ctx, symID)
+	if err != nil {
+		return nil, err
+	}
+	ports, err := c.GetSymmetrixPortList(ctx, symID, "type=Gige")
+	if err != nil {
+		return nil, err
+	}
+
+	portInterfaces := make([][]types.IPInterface, len(ports.SymmetrixPortKey))
+	err = c.forEachConcurrently(ctx, "GetISCSIPortals", len(ports.SymmetrixPortKey), func(ctx context.Context, i int) error {
+		p := ports.SymmetrixPortKey[i]
+		ipInterfaces, err := c.GetIPInterfaces(ctx, symID, p.DirectorID, p.PortID)
+		if errors.Is(err, ErrNotFound) {
+			// the port has no IP interface
+			return nil
+		} else if err != nil {
+			return err
+		}
+		portInterfaces[i] = ipInterfaces
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	type portInterface struct {
+		portKey     types.PortKey
+		ipInterface types.IPInterface
+	}
+	interfaceByIP := make(map[string]portInterface)
+	for i, p := range ports.SymmetrixPortKey {
+		for _, ipInterface := range portInterfaces[i] {
+			if _, ok := interfaceByIP[ipInterface.IPAddress]; !ok {
+				interfaceByIP[ipInterface.IPAddress] = portInterface{portKey: p, ipInterface: ipInterface}
+			}
+		}
+	}
+
+	portals := make([]ISCSIPortal, 0)
+	for _, target := range targets {
+		for _, portalIP := range target.PortalIPs {
+			portal := ISCSIPortal{IQN: target.IQN, PortalIP: portalIP}
+			if match, ok := interfaceByIP[portalIP]; ok {
+				ipInterface := match.ipInterface
+				portal.PortKey = match.portKey
+				portal.Interface = &ipInterface
+			}
+			portals = append(portals, portal)
+		}
+	}
+	return portals, nil
+}
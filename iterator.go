@@ -0,0 +1,269 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	types "github.com/dell/gopowermax/types/v90"
+	log "github.com/sirupsen/logrus"
+)
+
+// iteratorPage is a page of the entries of a Unisphere iterator, as returned by common/Iterator/{id}/page
+type iteratorPage[T any] struct {
+	Result []T `json:"result"`
+	From   int `json:"from"`
+	To     int `json:"to"`
+}
+
+// Iterator pages through the entries of a Unisphere listing. Unisphere returns the first page of the
+// listing together with the ID of an iterator which serves the remaining pages; the iterator is kept
+// by Unisphere only if the listing has more than one page, and it expires after a while.
+type Iterator[T any] struct {
+	client *Client
+
+	// kind names the listing in the errors, e.g. "Volume"
+	kind string
+
+	// entries names the entries in the errors, e.g. "volumes"
+	entries string
+
+	// ID is the ID of the Unisphere iterator
+	ID string
+
+	// Count is the number of entries of the whole listing
+	Count int
+
+	// MaxPageSize is the maximum number of entries of a page
+	MaxPageSize int
+
+	// first is the first page, returned with the listing
+	first []T
+
+	// firstTo is the index, from 1, of the last entry of the first page
+	firstTo int
+}
+
+// newIterator returns an Iterator of the listing whose first page, ending at entry firstTo, is first
+func newIterator[T any](c *Client, kind, entries, id string, count, maxPageSize int, first []T, firstTo int) *Iterator[T] {
+	return &Iterator[T]{
+		client:      c,
+		kind:        kind,
+		entries:     entries,
+		ID:          id,
+		Count:       count,
+		MaxPageSize: maxPageSize,
+		first:       first,
+		firstTo:     firstTo,
+	}
+}
+
+// newListIterator returns an Iterator of a listing which Unisphere returned whole, without an iterator
+func newListIterator[T any](c *Client, kind, entries string, list []T) *Iterator[T] {
+	return newIterator(c, kind, entries, "", len(list), len(list), list, len(list))
+}
+
+// Page returns the entries from..to, counted from 1, of the listing. A to of 0, or beyond a page of
+// MaxPageSize entries, reads a whole page. A page which does not hold all the requested entries is an error.
+func (it *Iterator[T]) Page(ctx context.Context, from, to int) ([]T, error) {
+	if to == 0 || to-from+1 > it.MaxPageSize {
+		to = from + it.MaxPageSize - 1
+	}
+	if to > it.Count {
+		to = it.Count
+	}
+	if it.ID == "" {
+		// the listing was returned whole
+		if from < 1 || from > to+1 {
+			return nil, fmt.Errorf("%s listing has no %s from %d to %d", it.kind, it.entries, from, to)
+		}
+		return it.first[from-1 : to], nil
+	}
+	URL := fmt.Sprintf("%s%s%s%s?from=%d&to=%d", RESTPrefix, IteratorX, it.ID, XPage, from, to)
+	page := new(iteratorPage[T])
+	if err := it.client.getJSON(ctx, URL, page); err != nil {
+		return nil, err
+	}
+	if len(page.Result) != to-from+1 {
+		return nil, fmt.Errorf("%s iterator %s returned %d %s from %d to %d", it.kind, it.ID, len(page.Result), it.entries, from, to)
+	}
+	return page.Result, nil
+}
+
+// All returns all the entries of the listing, reading the pages which follow the first one,
+// and deletes the Unisphere iterator afterwards, even if the pagination was aborted.
+func (it *Iterator[T]) All(ctx context.Context) ([]T, error) {
+	result := make([]T, 0, it.Count)
+	err := it.ForEach(ctx, func(entry T) error {
+		result = append(result, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(result) != it.Count {
+		return nil, fmt.Errorf("Expected %d %s but got %d %s", it.Count, it.entries, len(result), it.entries)
+	}
+	return result, nil
+}
+
+// ForEach calls fn with every entry of the listing in order, reading the pages which follow the first
+// one only as they are reached, so that a large listing is never held at once. It stops at the first
+// error of fn, which it returns, and deletes the Unisphere iterator afterwards like All.
+func (it *Iterator[T]) ForEach(ctx context.Context, fn func(T) error) error {
+	if it.MaxPageSize < it.Count {
+		defer func() {
+			// still clean up the iterator if the pagination was aborted
+			if ctx.Err() != nil {
+				it.Delete(context.Background())
+			} else {
+				it.Delete(ctx)
+			}
+		}()
+	}
+
+	for _, entry := range it.first {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	for from := it.firstTo + 1; from <= it.Count; {
+		if ctx.Err() != nil {
+			return abortedError(ctx, fmt.Sprintf("%s iterator %s at %d of %d", it.kind, it.ID, from, it.Count))
+		}
+		page, err := it.Page(ctx, from, 0)
+		if err != nil {
+			return err
+		}
+		for _, entry := range page {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+		from += len(page)
+	}
+	return nil
+}
+
+// Delete deletes the Unisphere iterator.
+func (it *Iterator[T]) Delete(ctx context.Context) error {
+	if it.ID == "" {
+		return nil
+	}
+	URL := RESTPrefix + IteratorX + it.ID
+	ctx, cancel := it.client.getTimeoutContext(ctx, writeOperation)
+	defer cancel()
+	if err := it.client.api.Delete(ctx, URL, it.client.getDefaultHeaders(), nil); err != nil {
+		log.Error(fmt.Sprintf("Deleting %s iterator %s failed: %s", it.kind, it.ID, err.Error()))
+		return err
+	}
+	return nil
+}
+
+// volumeIterator returns the Iterator of the volume IDs of a volume listing
+func (c *Client) volumeIterator(iter *types.VolumeIterator) *Iterator[types.VolumeIDList] {
+	return newIterator(c, "Volume", "ids", iter.ID, iter.Count, iter.MaxPageSize, iter.ResultList.VolumeList, iter.ResultList.To)
+}
+
+// privVolumeIterator returns the Iterator of the private view of the volumes of a private volume listing
+func (c *Client) privVolumeIterator(iter *types.PrivVolumeIterator) *Iterator[types.VolumeResultPrivate] {
+	return newIterator(c, "Private volume", "volumes", iter.ID, iter.Count, iter.MaxPageSize, iter.ResultList.PrivVolumeList, iter.ResultList.To)
+}
+
+// connectionIterator returns the Iterator of the connections of a masking view
+func (c *Client) connectionIterator(iter *types.MaskingViewConnectionIterator) *Iterator[*types.MaskingViewConnection] {
+	return newIterator(c, "Masking view connection", "connections", iter.ID, iter.Count, iter.MaxPageSize, iter.ResultList.MaskingViewConnections, iter.ResultList.To)
+}
+
+// listedID is an entry of an ID listing. Unisphere lists the IDs as strings when it returns the listing
+// whole, and as objects holding only the ID in the pages of an iterator, e.g. {"storageGroupId": "SG1"}.
+type listedID string
+
+// UnmarshalJSON decodes an ID listed either as a string or as an object holding only the ID
+func (id *listedID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*id = listedID(s)
+		return nil
+	}
+	var entry map[string]string
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return err
+	}
+	if len(entry) != 1 {
+		return fmt.Errorf("Expected a single ID but got %s", string(data))
+	}
+	for _, s := range entry {
+		*id = listedID(s)
+	}
+	return nil
+}
+
+// idIterator is the iterator Unisphere returns instead of an ID listing which does not fit in a page
+type idIterator struct {
+	ID          string                  `json:"id"`
+	Count       int                     `json:"count"`
+	MaxPageSize int                     `json:"maxPageSize"`
+	ResultList  *iteratorPage[listedID] `json:"resultList"`
+}
+
+// idListing reads the ID listing at URL, which Unisphere returns either whole, as a list of IDs under key,
+// or as an iterator when it does not fit in a page, and returns the Iterator of the IDs
+func (c *Client) idListing(ctx context.Context, URL, kind, key string) (*Iterator[listedID], error) {
+	var body json.RawMessage
+	if err := c.getJSON(ctx, URL, &body); err != nil {
+		return nil, err
+	}
+	iter := new(idIterator)
+	if err := json.Unmarshal(body, iter); err != nil {
+		return nil, err
+	}
+	if iter.ResultList != nil {
+		return newIterator(c, kind, "ids", iter.ID, iter.Count, iter.MaxPageSize, iter.ResultList.Result, iter.ResultList.To), nil
+	}
+	listing := make(map[string][]listedID)
+	if err := json.Unmarshal(body, &listing); err != nil {
+		return nil, err
+	}
+	return newListIterator(c, kind, "ids", listing[key]), nil
+}
+
+// getIDList returns all the IDs of the ID listing at URL, see idListing
+func (c *Client) getIDList(ctx context.Context, URL, kind, key string) ([]string, error) {
+	iter, err := c.idListing(ctx, URL, kind, key)
+	if err != nil {
+		return nil, err
+	}
+	listed, err := iter.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(listed))
+	for i := range listed {
+		ids[i] = string(listed[i])
+	}
+	return ids, nil
+}
+
+// toVolumeIDs returns the IDs of the volumes of a page of a volume iterator
+func toVolumeIDs(list []types.VolumeIDList) []string {
+	ids := make([]string, len(list))
+	for i := range list {
+		ids[i] = list[i].VolumeIDs
+	}
+	return ids
+}
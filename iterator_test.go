@@ -0,0 +1,195 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// iteratorServer serves the pages of the iterator "ids" over ids, answers 404 for any other
+// iterator as Unisphere does once an iterator has expired, and records the deleted iterators
+type iteratorServer struct {
+	*httptest.Server
+	mutex   sync.Mutex
+	pages   int
+	deleted []string
+}
+
+func newIteratorServer(t *testing.T, ids []string) *iteratorServer {
+	s := &iteratorServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := strings.TrimSuffix(r.URL.Path, XPage)
+		iterID := path[strings.LastIndex(path, "/")+1:]
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if r.Method == http.MethodDelete {
+			s.deleted = append(s.deleted, iterID)
+			return
+		}
+		if iterID != "ids" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"message": "Iterator %s does not exist"}`, iterID)
+			return
+		}
+		s.pages++
+		from, _ := strconv.Atoi(r.URL.Query().Get("from"))
+		to, _ := strconv.Atoi(r.URL.Query().Get("to"))
+		result := make([]map[string]string, 0)
+		for i := from; i <= to && i <= len(ids); i++ {
+			result = append(result, map[string]string{"id": ids[i-1]})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result, "from": from, "to": to})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// newTestIterator returns an Iterator over ids served by s, with pages of pageSize ids
+func newTestIterator(t *testing.T, s *iteratorServer, iterID string, ids []string, pageSize int) *Iterator[listedID] {
+	client, err := NewClientWithOptions(s.URL, "", "", ClientOptions{AllowHTTP: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := make([]listedID, 0, pageSize)
+	for i := 0; i < pageSize && i < len(ids); i++ {
+		first = append(first, listedID(ids[i]))
+	}
+	return newIterator(client.(*Client), "Test", "ids", iterID, len(ids), pageSize, first, len(first))
+}
+
+func Test_IteratorPage(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e"}
+	s := newIteratorServer(t, ids)
+	it := newTestIterator(t, s, "ids", ids, 2)
+
+	page, err := it.Page(context.Background(), 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(page) != "[c d]" {
+		t.Errorf("expected a whole page from 3, got %v", page)
+	}
+	page, err = it.Page(context.Background(), 5, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(page) != "[e]" {
+		t.Errorf("expected the page to stop at the last entry, got %v", page)
+	}
+
+	whole := newListIterator(nil, "Test", "ids", []listedID{"a", "b", "c"})
+	if page, err = whole.Page(context.Background(), 2, 3); err != nil || fmt.Sprint(page) != "[b c]" {
+		t.Errorf("expected a page of the whole listing, got %v, %v", page, err)
+	}
+	if _, err = whole.Page(context.Background(), 5, 6); err == nil {
+		t.Error("expected a page beyond the whole listing to fail")
+	}
+}
+
+func Test_IteratorAll(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e"}
+	s := newIteratorServer(t, ids)
+	it := newTestIterator(t, s, "ids", ids, 2)
+
+	all, err := it.All(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(all) != "[a b c d e]" {
+		t.Errorf("expected all the ids in order, got %v", all)
+	}
+	if s.pages != 2 {
+		t.Errorf("expected the 2 pages after the first one to be read, got %d", s.pages)
+	}
+	if fmt.Sprint(s.deleted) != "[ids]" {
+		t.Errorf("expected the iterator to be deleted once, got %v", s.deleted)
+	}
+
+	// a listing of a single page has no iterator to read or delete
+	s.pages, s.deleted = 0, nil
+	single := newTestIterator(t, s, "ids", ids[:2], 2)
+	if all, err = single.All(context.Background()); err != nil || len(all) != 2 {
+		t.Errorf("expected the first page alone, got %v, %v", all, err)
+	}
+	if s.pages != 0 || len(s.deleted) != 0 {
+		t.Errorf("expected no request for a single page, got %d pages and deletions %v", s.pages, s.deleted)
+	}
+}
+
+func Test_IteratorForEach(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e"}
+	s := newIteratorServer(t, ids)
+	it := newTestIterator(t, s, "ids", ids, 2)
+
+	stop := errors.New("stop")
+	seen := make([]listedID, 0)
+	err := it.ForEach(context.Background(), func(id listedID) error {
+		seen = append(seen, id)
+		if id == "c" {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("expected the error of fn, got %v", err)
+	}
+	if fmt.Sprint(seen) != "[a b c]" {
+		t.Errorf("expected the ids up to the stop, got %v", seen)
+	}
+	if s.pages != 1 {
+		t.Errorf("expected only the page of the stop to be read, got %d", s.pages)
+	}
+	if fmt.Sprint(s.deleted) != "[ids]" {
+		t.Errorf("expected the stopped iterator to be deleted, got %v", s.deleted)
+	}
+
+	// an aborted pagination still deletes the iterator
+	s.pages, s.deleted = 0, nil
+	ctx, cancel := context.WithCancel(context.Background())
+	err = it.ForEach(ctx, func(id listedID) error {
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the pagination to be aborted, got %v", err)
+	}
+	if s.pages != 0 || fmt.Sprint(s.deleted) != "[ids]" {
+		t.Errorf("expected no page and the iterator deleted, got %d pages and deletions %v", s.pages, s.deleted)
+	}
+}
+
+func Test_IteratorExpired(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e"}
+	s := newIteratorServer(t, ids)
+	it := newTestIterator(t, s, "expired", ids, 2)
+
+	if _, err := it.Page(context.Background(), 3, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a page of an expired iterator to be ErrNotFound, got %v", err)
+	}
+	all, err := it.All(context.Background())
+	if !errors.Is(err, ErrNotFound) || all != nil {
+		t.Errorf("expected All to fail with the expired iterator, got %v, %v", all, err)
+	}
+}
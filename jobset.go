@@ -0,0 +1,122 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	types "github.com/dell/gopowermax/types/v90"
+)
+
+// JobSetPolicy decides what JobSet.WaitAll does once a job fails
+type JobSetPolicy int
+
+const (
+	// JobSetContinueOnError waits for every job, whatever the outcome of the others
+	JobSetContinueOnError JobSetPolicy = iota
+	// JobSetFirstError stops waiting for the other jobs as soon as one fails. They are left
+	// to run unless JobSet.Options.CancelOnAbort is set, which cancels those not yet running.
+	JobSetFirstError
+)
+
+// JobResult is the outcome of a job of a JobSet. Err is set if the job could not be waited
+// for or it completed in JobStatusFailed.
+type JobResult struct {
+	JobID string
+	Job   *types.Job
+	Err   error
+}
+
+// JobSet waits for a group of asynchronous jobs of an array, like a sync.WaitGroup:
+// the job IDs are added as the jobs are submitted, then WaitAll waits for all of them.
+type JobSet struct {
+	// Options are used to wait for each job
+	Options JobWaitOptions
+
+	client Pmax
+	symID  string
+	policy JobSetPolicy
+	mutex  sync.Mutex
+	jobIDs []string
+}
+
+// NewJobSet returns an empty JobSet for the jobs of the array symID
+func NewJobSet(client Pmax, symID string, policy JobSetPolicy) *JobSet {
+	return &JobSet{client: client, symID: symID, policy: policy}
+}
+
+// Add adds jobs to the set
+func (s *JobSet) Add(jobIDs ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.jobIDs = append(s.jobIDs, jobIDs...)
+}
+
+// Len returns the number of jobs in the set
+func (s *JobSet) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.jobIDs)
+}
+
+// WaitAll waits for the jobs of the set with WaitOnJobCompletionWithOptions, up to the
+// ClientOptions.MaxConcurrentRequests of the client at a time, and returns their results in the order they were added. If a job failed, the error is the first failure
+// with JobSetFirstError, or lists every failure with JobSetContinueOnError; the results of the jobs which were
+// not waited for hold the error of the aborted wait.
+func (s *JobSet) WaitAll(ctx context.Context) ([]JobResult, error) {
+	s.mutex.Lock()
+	jobIDs := make([]string, len(s.jobIDs))
+	copy(jobIDs, s.jobIDs)
+	s.mutex.Unlock()
+
+	limit := 0
+	if client, ok := s.client.(*Client); ok {
+		limit = client.maxConcurrentRequests
+	}
+	results := make([]JobResult, len(jobIDs))
+	err := forEachConcurrently(ctx, limit, "JobSet.WaitAll", len(jobIDs), func(ctx context.Context, i int) error {
+		job, err := s.client.WaitOnJobCompletionWithOptions(ctx, s.symID, jobIDs[i], s.Options)
+		if err == nil && job.Status == types.JobStatusFailed {
+			err = fmt.Errorf("Symmetrix %s Job %s failed: %s", s.symID, jobIDs[i], job.Result)
+		}
+		results[i] = JobResult{JobID: jobIDs[i], Job: job, Err: err}
+		if s.policy == JobSetFirstError {
+			return err
+		}
+		return nil
+	})
+	for i := range results {
+		if results[i].JobID == "" {
+			// the job was not waited for
+			results[i] = JobResult{JobID: jobIDs[i], Err: err}
+		}
+	}
+	if err != nil && s.policy == JobSetFirstError {
+		return results, err
+	}
+	failed := make([]string, 0)
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result.JobID+": "+result.Err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return results, fmt.Errorf("%d of %d jobs failed: %s", len(failed), len(results), strings.Join(failed, "; "))
+	}
+	return results, nil
+}
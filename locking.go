@@ -0,0 +1,234 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/dell/gopowermax/api"
+)
+
+// Resource types of a LockKey
+const (
+	LockStorageGroup = "storagegroup"
+	LockMaskingView  = "maskingview"
+)
+
+// LockKey identifies an array object whose modifications are serialized by a Locker
+type LockKey struct {
+	SymID        string
+	ResourceType string
+	ResourceID   string
+}
+
+func (k LockKey) String() string {
+	return k.SymID + "/" + k.ResourceType + "/" + k.ResourceID
+}
+
+// Locker serializes the modifications of an object on an array, which Unisphere rejects
+// when they run concurrently. Lock blocks until the caller holds key or ctx is done, and
+// returns the function which releases it.
+// NewLockManager returns an in-process Locker; a controller running several replicas
+// against the same arrays can supply one backed by a distributed lock instead.
+type Locker interface {
+	Lock(ctx context.Context, key LockKey) (unlock func(), err error)
+}
+
+// LockManager is an in-process Locker
+type LockManager struct {
+	mutex sync.Mutex
+	locks map[LockKey]*keyLock
+}
+
+// keyLock is held by whoever sent to held; waiters counts the callers using it,
+// so it can be removed from the LockManager once the last one releases it
+type keyLock struct {
+	held    chan struct{}
+	waiters int
+}
+
+// NewLockManager returns an empty LockManager
+func NewLockManager() *LockManager {
+	return &LockManager{locks: make(map[LockKey]*keyLock)}
+}
+
+// Lock blocks until key is held by the caller or ctx is done
+func (m *LockManager) Lock(ctx context.Context, key LockKey) (func(), error) {
+	m.mutex.Lock()
+	l := m.locks[key]
+	if l == nil {
+		l = &keyLock{held: make(chan struct{}, 1)}
+		m.locks[key] = l
+	}
+	l.waiters++
+	m.mutex.Unlock()
+
+	select {
+	case l.held <- struct{}{}:
+	case <-ctx.Done():
+		m.release(key, l, false)
+		return nil, fmt.Errorf("waiting for the lock of %s: %w", key, ctx.Err())
+	}
+	var once sync.Once
+	return func() { once.Do(func() { m.release(key, l, true) }) }, nil
+}
+
+func (m *LockManager) release(key LockKey, l *keyLock, held bool) {
+	if held {
+		<-l.held
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	l.waiters--
+	if l.waiters == 0 {
+		delete(m.locks, key)
+	}
+}
+
+// lockKeyFromPath returns the key of the storage group or masking view modified by a
+// request, from the symmetrix and resource segments of its path, e.g.
+// /univmax/restapi/91/sloprovisioning/symmetrix/{symID}/storagegroup/{sgID}/...
+func lockKeyFromPath(path string) (LockKey, bool) {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i+3 < len(segments); i++ {
+		if segments[i]+"/" != SymmetrixX {
+			continue
+		}
+		switch segments[i+2] {
+		case LockStorageGroup, LockMaskingView:
+			if segments[i+3] == "" {
+				return LockKey{}, false
+			}
+			return LockKey{SymID: segments[i+1], ResourceType: segments[i+2], ResourceID: segments[i+3]}, true
+		}
+		return LockKey{}, false
+	}
+	return LockKey{}, false
+}
+
+// heldLocksKey is the context key of the locks held by the Client method sending a request
+type heldLocksKey struct{}
+
+// withHeldLock returns a copy of ctx recording that the caller holds key
+func withHeldLock(ctx context.Context, key LockKey) context.Context {
+	held, _ := ctx.Value(heldLocksKey{}).(map[LockKey]bool)
+	locks := make(map[LockKey]bool, len(held)+1)
+	for k := range held {
+		locks[k] = true
+	}
+	locks[key] = true
+	return context.WithValue(ctx, heldLocksKey{}, locks)
+}
+
+// isLockHeld returns true if ctx records that the caller holds key
+func isLockHeld(ctx context.Context, key LockKey) bool {
+	held, _ := ctx.Value(heldLocksKey{}).(map[LockKey]bool)
+	return held[key]
+}
+
+// lockResource holds the lock of a storage group or masking view, if the client has a Locker, for a
+// Client method which modifies it with a job and waits for the job, so that no other modification
+// starts before the job is done. The requests sent with the returned context do not wait for the lock.
+func (c *Client) lockResource(ctx context.Context, symID, resourceType, resourceID string) (context.Context, func(), error) {
+	key := LockKey{SymID: symID, ResourceType: resourceType, ResourceID: resourceID}
+	if c.locker == nil || isLockHeld(ctx, key) {
+		return ctx, func() {}, nil
+	}
+	unlock, err := c.locker.Lock(ctx, key)
+	if err != nil {
+		return ctx, nil, err
+	}
+	return withHeldLock(ctx, key), unlock, nil
+}
+
+// lockingAPI wraps an api.Client and holds the lock of the storage group or masking view
+// a request modifies while it is sent, unless the Client method sending it already holds it.
+// GET requests are not serialized.
+type lockingAPI struct {
+	api.Client
+	locker Locker
+}
+
+func (l *lockingAPI) lock(ctx context.Context, method, path string) (func(), error) {
+	if method == http.MethodGet {
+		return func() {}, nil
+	}
+	key, ok := lockKeyFromPath(path)
+	if !ok || isLockHeld(ctx, key) {
+		return func() {}, nil
+	}
+	return l.locker.Lock(ctx, key)
+}
+
+func (l *lockingAPI) Do(ctx context.Context, method, path string, body, resp interface{}) error {
+	unlock, err := l.lock(ctx, method, path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return l.Client.Do(ctx, method, path, body, resp)
+}
+
+func (l *lockingAPI) DoWithHeaders(ctx context.Context, method, path string, headers map[string]string, body, resp interface{}) error {
+	unlock, err := l.lock(ctx, method, path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return l.Client.DoWithHeaders(ctx, method, path, headers, body, resp)
+}
+
+func (l *lockingAPI) DoAndGetResponseBody(ctx context.Context, method, path string, headers map[string]string, body interface{}) (*http.Response, error) {
+	unlock, err := l.lock(ctx, method, path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return l.Client.DoAndGetResponseBody(ctx, method, path, headers, body)
+}
+
+func (l *lockingAPI) Post(ctx context.Context, path string, headers map[string]string, body, resp interface{}) error {
+	unlock, err := l.lock(ctx, http.MethodPost, path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return l.Client.Post(ctx, path, headers, body, resp)
+}
+
+func (l *lockingAPI) Put(ctx context.Context, path string, headers map[string]string, body, resp interface{}) error {
+	unlock, err := l.lock(ctx, http.MethodPut, path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return l.Client.Put(ctx, path, headers, body, resp)
+}
+
+func (l *lockingAPI) Delete(ctx context.Context, path string, headers map[string]string, resp interface{}) error {
+	unlock, err := l.lock(ctx, http.MethodDelete, path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return l.Client.Delete(ctx, path, headers, resp)
+}
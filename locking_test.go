@@ -0,0 +1,165 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dell/gopowermax/api"
+)
+
+func Test_lockKeyFromPath(t *testing.T) {
+	var tests = []struct {
+		name     string
+		path     string
+		expected LockKey
+		ok       bool
+	}{
+		{"storage group", "/univmax/restapi/91/sloprovisioning/symmetrix/000197900046/storagegroup/sg1",
+			LockKey{"000197900046", LockStorageGroup, "sg1"}, true},
+		{"storage group snapshot", "/univmax/restapi/91/replication/symmetrix/000197900046/storagegroup/sg1/snapshot/snap1",
+			LockKey{"000197900046", LockStorageGroup, "sg1"}, true},
+		{"masking view", "/univmax/restapi/91/sloprovisioning/symmetrix/000197900046/maskingview/mv1?force=true",
+			LockKey{"000197900046", LockMaskingView, "mv1"}, true},
+		{"storage group creation", "/univmax/restapi/91/sloprovisioning/symmetrix/000197900046/storagegroup", LockKey{}, false},
+		{"volume", "/univmax/restapi/91/sloprovisioning/symmetrix/000197900046/volume/00001", LockKey{}, false},
+		{"job", "/univmax/restapi/system/symmetrix/000197900046/job/myjob", LockKey{}, false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := lockKeyFromPath(tt.path)
+			if ok != tt.ok || key != tt.expected {
+				t.Errorf("lockKeyFromPath(%s) = %v, %t; expected %v, %t", tt.path, key, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func Test_LockManagerSerializes(t *testing.T) {
+	m := NewLockManager()
+	key := LockKey{"000197900046", LockStorageGroup, "sg1"}
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	holders, maxHolders := 0, 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := m.Lock(context.Background(), key)
+			if err != nil {
+				t.Errorf("Lock failed: %v", err)
+				return
+			}
+			mutex.Lock()
+			holders++
+			if holders > maxHolders {
+				maxHolders = holders
+			}
+			mutex.Unlock()
+			time.Sleep(time.Millisecond)
+			mutex.Lock()
+			holders--
+			mutex.Unlock()
+			unlock()
+		}()
+	}
+	wg.Wait()
+	if maxHolders != 1 {
+		t.Errorf("Expected the lock to be held by 1 caller at a time but it was held by %d", maxHolders)
+	}
+	if len(m.locks) != 0 {
+		t.Errorf("Expected no locks left but found %d", len(m.locks))
+	}
+}
+
+func Test_LockManagerContextDone(t *testing.T) {
+	m := NewLockManager()
+	key := LockKey{"000197900046", LockMaskingView, "mv1"}
+	unlock, err := m.Lock(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	// another key is not blocked
+	other, err := m.Lock(context.Background(), LockKey{"000197900046", LockMaskingView, "mv2"})
+	if err != nil {
+		t.Fatalf("Lock of another key failed: %v", err)
+	}
+	other()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := m.Lock(ctx, key); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded but got: %v", err)
+	}
+	unlock()
+	unlock()
+	if len(m.locks) != 0 {
+		t.Errorf("Expected no locks left but found %d", len(m.locks))
+	}
+	unlock, err = m.Lock(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Lock after release failed: %v", err)
+	}
+	unlock()
+}
+
+// countingLocker is a LockManager which counts the locks it was asked for
+type countingLocker struct {
+	*LockManager
+	locks int
+}
+
+func (l *countingLocker) Lock(ctx context.Context, key LockKey) (func(), error) {
+	l.locks++
+	return l.LockManager.Lock(ctx, key)
+}
+
+// nopAPI is an api.Client whose Do sends nothing
+type nopAPI struct {
+	api.Client
+}
+
+func (nopAPI) Do(ctx context.Context, method, path string, body, resp interface{}) error {
+	return nil
+}
+
+func Test_lockingAPIDo(t *testing.T) {
+	locker := &countingLocker{LockManager: NewLockManager()}
+	l := &lockingAPI{Client: nopAPI{}, locker: locker}
+	path := "/univmax/restapi/91/sloprovisioning/symmetrix/000197900046/storagegroup/sg1"
+	if err := l.Do(context.Background(), http.MethodPut, path, nil, nil); err != nil || locker.locks != 1 {
+		t.Errorf("Expected Do to lock sg1 once but got %d locks: %v", locker.locks, err)
+	}
+
+	// a Client method holding the lock does not wait for it again
+	c := &Client{locker: locker}
+	ctx, unlock, err := c.lockResource(context.Background(), "000197900046", LockStorageGroup, "sg1")
+	if err != nil {
+		t.Fatalf("lockResource failed: %v", err)
+	}
+	if err := l.Do(ctx, http.MethodPut, path, nil, nil); err != nil || locker.locks != 2 {
+		t.Errorf("Expected Do not to lock sg1 again but got %d locks: %v", locker.locks, err)
+	}
+	unlock()
+	if len(locker.LockManager.locks) != 0 {
+		t.Errorf("Expected no locks left but found %d", len(locker.LockManager.locks))
+	}
+}
@@ -0,0 +1,323 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	types "github.com/dell/gopowermax/types/v90"
+	log "github.com/sirupsen/logrus"
+)
+
+// MaskingConfig is the masking configuration of an array: the storage groups with their volumes,
+// the port groups with their ports, the hosts with their initiators, and the masking views tying them
+type MaskingConfig struct {
+	StorageGroups []StorageGroupConfig
+	PortGroups    []PortGroupConfig
+	Hosts         []HostConfig
+	MaskingViews  []types.MaskingView
+}
+
+// StorageGroupConfig is a storage group of a MaskingConfig.
+// The SRP and service level are only used to create the storage group.
+type StorageGroupConfig struct {
+	StorageGroupID string
+	SRPID          string
+	ServiceLevel   string
+	VolumeIDs      []string
+}
+
+// PortGroupConfig is a port group of a MaskingConfig.
+// The protocol, see types.PortGroupProtocolFibre, is only used to create the port group.
+type PortGroupConfig struct {
+	PortGroupID string
+	Protocol    string
+	Ports       []types.PortKey
+}
+
+// HostConfig is a host of a MaskingConfig
+type HostConfig struct {
+	HostID       string
+	InitiatorIDs []string
+}
+
+// MaskingChangeType is the kind of a MaskingChange
+type MaskingChangeType string
+
+// The changes made by ApplyMaskingChanges
+const (
+	MaskingChangeDeleteMaskingView  MaskingChangeType = "DeleteMaskingView"
+	MaskingChangeCreateStorageGroup MaskingChangeType = "CreateStorageGroup"
+	MaskingChangeAddVolumes         MaskingChangeType = "AddVolumes"
+	MaskingChangeRemoveVolumes      MaskingChangeType = "RemoveVolumes"
+	MaskingChangeCreatePortGroup    MaskingChangeType = "CreatePortGroup"
+	MaskingChangeAddPorts           MaskingChangeType = "AddPorts"
+	MaskingChangeRemovePorts        MaskingChangeType = "RemovePorts"
+	MaskingChangeCreateHost         MaskingChangeType = "CreateHost"
+	MaskingChangeAddInitiators      MaskingChangeType = "AddInitiators"
+	MaskingChangeRemoveInitiators   MaskingChangeType = "RemoveInitiators"
+	MaskingChangeCreateMaskingView  MaskingChangeType = "CreateMaskingView"
+)
+
+// MaskingChange is a change of the masking configuration of an array, see DiffMaskingConfig
+type MaskingChange struct {
+	Type MaskingChangeType
+	// ID is the storage group, port group, host or masking view changed
+	ID string
+	// SRPID and ServiceLevel are those of a new storage group
+	SRPID        string
+	ServiceLevel string
+	// VolumeIDs are the volumes added to or removed from a storage group
+	VolumeIDs []string
+	// Protocol is that of a new port group
+	Protocol string
+	// Ports are the ports of a new port group, or those added to or removed from a port group
+	Ports []types.PortKey
+	// InitiatorIDs are the initiators of a new host, or those added to or removed from a host
+	InitiatorIDs []string
+	// MaskingView is the new masking view
+	MaskingView *types.MaskingView
+}
+
+// String describes the change
+func (change MaskingChange) String() string {
+	switch change.Type {
+	case MaskingChangeAddVolumes, MaskingChangeRemoveVolumes:
+		return fmt.Sprintf("%s %s %v", change.Type, change.ID, change.VolumeIDs)
+	case MaskingChangeCreatePortGroup, MaskingChangeAddPorts, MaskingChangeRemovePorts:
+		return fmt.Sprintf("%s %s %v", change.Type, change.ID, portKeyIDs(change.Ports))
+	case MaskingChangeCreateHost, MaskingChangeAddInitiators, MaskingChangeRemoveInitiators:
+		return fmt.Sprintf("%s %s %v", change.Type, change.ID, change.InitiatorIDs)
+	}
+	return fmt.Sprintf("%s %s", change.Type, change.ID)
+}
+
+// portKeyID returns the "<director>:<port>" ID of a port, with the director in upper case and the port
+// in lower case as UpdatePortGroup compares them. The port ID of the ports of a port group may come
+// as a combination of director and port number.
+func portKeyID(port types.PortKey) string {
+	portID := port.PortID
+	if i := strings.LastIndex(portID, ":"); i >= 0 {
+		portID = portID[i+1:]
+	}
+	return strings.ToUpper(port.DirectorID) + ":" + strings.ToLower(portID)
+}
+
+func portKeyIDs(ports []types.PortKey) []string {
+	ids := make([]string, 0, len(ports))
+	for _, port := range ports {
+		ids = append(ids, portKeyID(port))
+	}
+	return ids
+}
+
+// missingStrings returns the strings of from which are not in in, without duplicates, in the order of from
+func missingStrings(from, in []string) []string {
+	seen := make(map[string]bool)
+	for _, s := range in {
+		seen[s] = true
+	}
+	missing := make([]string, 0)
+	for _, s := range from {
+		if !seen[s] {
+			seen[s] = true
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+// missingPorts returns the ports of from which are not in in, see missingStrings
+func missingPorts(from, in []types.PortKey) []types.PortKey {
+	seen := make(map[string]bool)
+	for _, port := range in {
+		seen[portKeyID(port)] = true
+	}
+	missing := make([]types.PortKey, 0)
+	for _, port := range from {
+		if !seen[portKeyID(port)] {
+			seen[portKeyID(port)] = true
+			missing = append(missing, port)
+		}
+	}
+	return missing
+}
+
+// DiffMaskingConfig returns the changes which turn the masking configuration actual into desired, in the order
+// they can be applied by ApplyMaskingChanges: the masking views which differ from the desired ones are deleted,
+// then the storage groups, port groups and hosts are created or their members added and removed, and finally
+// the masking views are created. Only the objects of desired are compared: those which are only in actual are
+// left as they are. No changes are returned if actual already matches desired.
+func DiffMaskingConfig(actual, desired *MaskingConfig) []MaskingChange {
+	if actual == nil {
+		actual = &MaskingConfig{}
+	}
+	if desired == nil {
+		desired = &MaskingConfig{}
+	}
+	changes := make([]MaskingChange, 0)
+
+	actualMVs := make(map[string]types.MaskingView)
+	for _, mv := range actual.MaskingViews {
+		actualMVs[mv.MaskingViewID] = mv
+	}
+	createMVs := make([]MaskingChange, 0)
+	for _, mv := range desired.MaskingViews {
+		if existing, ok := actualMVs[mv.MaskingViewID]; ok {
+			if existing == mv {
+				continue
+			}
+			changes = append(changes, MaskingChange{Type: MaskingChangeDeleteMaskingView, ID: mv.MaskingViewID})
+		}
+		newMV := mv
+		createMVs = append(createMVs, MaskingChange{Type: MaskingChangeCreateMaskingView, ID: mv.MaskingViewID, MaskingView: &newMV})
+	}
+
+	actualSGs := make(map[string]StorageGroupConfig)
+	for _, sg := range actual.StorageGroups {
+		actualSGs[sg.StorageGroupID] = sg
+	}
+	for _, sg := range desired.StorageGroups {
+		existing, ok := actualSGs[sg.StorageGroupID]
+		if !ok {
+			changes = append(changes, MaskingChange{
+				Type:         MaskingChangeCreateStorageGroup,
+				ID:           sg.StorageGroupID,
+				SRPID:        sg.SRPID,
+				ServiceLevel: sg.ServiceLevel,
+			})
+		}
+		if add := missingStrings(sg.VolumeIDs, existing.VolumeIDs); len(add) > 0 {
+			changes = append(changes, MaskingChange{Type: MaskingChangeAddVolumes, ID: sg.StorageGroupID, VolumeIDs: add})
+		}
+		if remove := missingStrings(existing.VolumeIDs, sg.VolumeIDs); len(remove) > 0 {
+			changes = append(changes, MaskingChange{Type: MaskingChangeRemoveVolumes, ID: sg.StorageGroupID, VolumeIDs: remove})
+		}
+	}
+
+	actualPGs := make(map[string]PortGroupConfig)
+	for _, pg := range actual.PortGroups {
+		actualPGs[pg.PortGroupID] = pg
+	}
+	for _, pg := range desired.PortGroups {
+		existing, ok := actualPGs[pg.PortGroupID]
+		if !ok {
+			changes = append(changes, MaskingChange{
+				Type:     MaskingChangeCreatePortGroup,
+				ID:       pg.PortGroupID,
+				Protocol: pg.Protocol,
+				Ports:    missingPorts(pg.Ports, nil),
+			})
+			continue
+		}
+		if add := missingPorts(pg.Ports, existing.Ports); len(add) > 0 {
+			changes = append(changes, MaskingChange{Type: MaskingChangeAddPorts, ID: pg.PortGroupID, Ports: add})
+		}
+		if remove := missingPorts(existing.Ports, pg.Ports); len(remove) > 0 {
+			changes = append(changes, MaskingChange{Type: MaskingChangeRemovePorts, ID: pg.PortGroupID, Ports: remove})
+		}
+	}
+
+	actualHosts := make(map[string]HostConfig)
+	for _, host := range actual.Hosts {
+		actualHosts[host.HostID] = host
+	}
+	for _, host := range desired.Hosts {
+		existing, ok := actualHosts[host.HostID]
+		if !ok {
+			changes = append(changes, MaskingChange{
+				Type:         MaskingChangeCreateHost,
+				ID:           host.HostID,
+				InitiatorIDs: missingStrings(host.InitiatorIDs, nil),
+			})
+			continue
+		}
+		if add := missingStrings(host.InitiatorIDs, existing.InitiatorIDs); len(add) > 0 {
+			changes = append(changes, MaskingChange{Type: MaskingChangeAddInitiators, ID: host.HostID, InitiatorIDs: add})
+		}
+		if remove := missingStrings(existing.InitiatorIDs, host.InitiatorIDs); len(remove) > 0 {
+			changes = append(changes, MaskingChange{Type: MaskingChangeRemoveInitiators, ID: host.HostID, InitiatorIDs: remove})
+		}
+	}
+
+	return append(changes, createMVs...)
+}
+
+// ApplyMaskingChanges makes the changes, e.g. from DiffMaskingConfig, on the array symID in order.
+// It stops at the first change which fails and returns the number of changes made, so that the
+// remaining ones can be applied again once the cause is fixed.
+func ApplyMaskingChanges(ctx context.Context, client Pmax, symID string, changes []MaskingChange) (int, error) {
+	for i, change := range changes {
+		if err := applyMaskingChange(ctx, client, symID, change); err != nil {
+			log.Error(fmt.Sprintf("Masking change %s failed: %s", change, err.Error()))
+			return i, fmt.Errorf("couldn't apply masking change %d of %d (%s): %w", i+1, len(changes), change, err)
+		}
+		log.Info(fmt.Sprintf("Applied masking change %s", change))
+	}
+	return len(changes), nil
+}
+
+func applyMaskingChange(ctx context.Context, client Pmax, symID string, change MaskingChange) error {
+	var err error
+	switch change.Type {
+	case MaskingChangeDeleteMaskingView:
+		err = client.DeleteMaskingView(ctx, symID, change.ID)
+	case MaskingChangeCreateStorageGroup:
+		_, err = client.CreateStorageGroup(ctx, symID, change.ID, change.SRPID, change.ServiceLevel, false)
+	case MaskingChangeAddVolumes:
+		err = client.AddVolumesToStorageGroupS(ctx, symID, change.ID, false, change.VolumeIDs...)
+	case MaskingChangeRemoveVolumes:
+		_, err = client.RemoveVolumesFromStorageGroup(ctx, symID, change.ID, false, change.VolumeIDs...)
+	case MaskingChangeCreatePortGroup:
+		_, err = client.CreatePortGroupWithProtocol(ctx, symID, change.ID, change.Ports, change.Protocol)
+	case MaskingChangeAddPorts, MaskingChangeRemovePorts:
+		var pg *types.PortGroup
+		if pg, err = client.GetPortGroupByID(ctx, symID, change.ID); err != nil {
+			return err
+		}
+		ports := missingPorts(append(append([]types.PortKey{}, pg.SymmetrixPortKey...), change.Ports...), nil)
+		if change.Type == MaskingChangeRemovePorts {
+			ports = missingPorts(pg.SymmetrixPortKey, change.Ports)
+		}
+		_, err = client.UpdatePortGroup(ctx, symID, change.ID, ports)
+	case MaskingChangeCreateHost:
+		_, err = client.CreateHost(ctx, symID, change.ID, change.InitiatorIDs, nil)
+	case MaskingChangeAddInitiators, MaskingChangeRemoveInitiators:
+		var host *types.Host
+		if host, err = client.GetHostByID(ctx, symID, change.ID); err != nil {
+			return err
+		}
+		initiatorIDs := missingStrings(append(append([]string{}, host.Initiators...), change.InitiatorIDs...), nil)
+		if change.Type == MaskingChangeRemoveInitiators {
+			initiatorIDs = missingStrings(host.Initiators, change.InitiatorIDs)
+		}
+		_, err = client.UpdateHostInitiators(ctx, symID, host, initiatorIDs)
+	case MaskingChangeCreateMaskingView:
+		mv := change.MaskingView
+		if mv == nil {
+			return fmt.Errorf("masking view %s to create is missing", change.ID)
+		}
+		if mv.HostGroupID != "" {
+			_, err = client.CreateMaskingView(ctx, symID, change.ID, mv.StorageGroupID, mv.HostGroupID, false, mv.PortGroupID)
+		} else {
+			_, err = client.CreateMaskingView(ctx, symID, change.ID, mv.StorageGroupID, mv.HostID, true, mv.PortGroupID)
+		}
+	default:
+		err = fmt.Errorf("unknown masking change type %s", change.Type)
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"strings"
+	"testing"
+
+	types "github.com/dell/gopowermax/types/v90"
+)
+
+func Test_DiffMaskingConfig(t *testing.T) {
+	mv := types.MaskingView{MaskingViewID: "mv", StorageGroupID: "sg", HostID: "host", PortGroupID: "pg"}
+	actual := &MaskingConfig{
+		StorageGroups: []StorageGroupConfig{{StorageGroupID: "sg", VolumeIDs: []string{"00001", "00002"}}},
+		PortGroups:    []PortGroupConfig{{PortGroupID: "pg", Ports: []types.PortKey{{DirectorID: "FA-1D", PortID: "FA-1D:5"}}}},
+		Hosts:         []HostConfig{{HostID: "host", InitiatorIDs: []string{"i1"}}},
+		MaskingViews:  []types.MaskingView{mv},
+	}
+	hostGroupMV := mv
+	hostGroupMV.HostID = ""
+	hostGroupMV.HostGroupID = "hg"
+	var tests = []struct {
+		name     string
+		actual   *MaskingConfig
+		desired  *MaskingConfig
+		expected string
+	}{
+		{"in sync", actual, &MaskingConfig{
+			StorageGroups: []StorageGroupConfig{{StorageGroupID: "sg", VolumeIDs: []string{"00002", "00001"}}},
+			PortGroups:    []PortGroupConfig{{PortGroupID: "pg", Ports: []types.PortKey{{DirectorID: "fa-1d", PortID: "5"}}}},
+			Hosts:         []HostConfig{{HostID: "host", InitiatorIDs: []string{"i1"}}},
+			MaskingViews:  []types.MaskingView{mv},
+		}, ""},
+		{"objects only in actual are left", actual, &MaskingConfig{}, ""},
+		{"nil actual", nil, &MaskingConfig{
+			StorageGroups: []StorageGroupConfig{{StorageGroupID: "sg", VolumeIDs: []string{"00001", "00001"}}},
+		}, "CreateStorageGroup sg; AddVolumes sg [00001]"},
+		{"masking view of a host group", actual, &MaskingConfig{MaskingViews: []types.MaskingView{hostGroupMV}},
+			"DeleteMaskingView mv; CreateMaskingView mv"},
+		{"members", actual, &MaskingConfig{
+			StorageGroups: []StorageGroupConfig{{StorageGroupID: "sg", VolumeIDs: []string{"00003"}}},
+			PortGroups:    []PortGroupConfig{{PortGroupID: "pg", Ports: []types.PortKey{{DirectorID: "FA-2D", PortID: "1"}}}},
+			Hosts:         []HostConfig{{HostID: "host", InitiatorIDs: []string{"i1", "i2"}}},
+		}, "AddVolumes sg [00003]; RemoveVolumes sg [00001 00002]; AddPorts pg [FA-2D:1]; RemovePorts pg [FA-1D:5]; AddInitiators host [i2]"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			changes := make([]string, 0)
+			for _, change := range DiffMaskingConfig(tt.actual, tt.desired) {
+				changes = append(changes, change.String())
+			}
+			if strings.Join(changes, "; ") != tt.expected {
+				t.Errorf("DiffMaskingConfig() = %s; expected %s", strings.Join(changes, "; "), tt.expected)
+			}
+		})
+	}
+}
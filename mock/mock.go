@@ -15,13 +15,19 @@
 package mock
 
 import (
-	"encoding/base64"
+	"embed"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -35,6 +41,12 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// jsonFiles are the JSON files served when Data.JSONDir is empty, so that the mock
+// does not depend on the working directory of the tests using it
+//
+//go:embed *.json
+var jsonFiles embed.FS
+
 // constants
 const (
 	APIVersion                   = "{apiversion}"
@@ -55,10 +67,22 @@ const (
 	DefaultFcStoragePortWWN      = "5000000000000001"
 	DefaultRDFGNo                = 13
 	DefaultRemoteRDFGNo          = 13
+	DefaultMaxSnapshotsPerVolume = 256
 	RemoteArrayHeaderKey         = "RemoteArray"
 	RemoteArrayHeaderValue       = "true"
+	// DefaultFirstDeviceID is the first device ID handed out for volumes created through the mock
+	DefaultFirstDeviceID = 0x0A000
+	// MaxDeviceID is the largest 5 hex digit device ID
+	MaxDeviceID = 0xFFFFF
+	// MaxVolumeSizeCYL is the largest volume the mock creates, 64 TiB in cylinders of 1.875 MiB
+	MaxVolumeSizeCYL = 35791394
+	// maxVolumeIdentifierLength is the longest volume identifier Unisphere accepts
+	maxVolumeIdentifierLength = 64
 )
 
+// volumeIdentifierRegexp matches the volume identifiers Unisphere accepts
+var volumeIdentifierRegexp = regexp.MustCompile(`^[A-Za-z0-9_\-.:]+$`)
+
 const (
 	_ = 1 << (10 * iota)
 	KiB
@@ -70,8 +94,8 @@ const (
 
 var mockCacheMutex sync.Mutex
 
-// Data are internal tables the Mock Unisphere uses to provide functionality.
-var Data struct {
+// dataTables is the type of Data
+type dataTables struct {
 	VolumeIDToIdentifier          map[string]string
 	VolumeIDToSize                map[string]int
 	VolumeIDIteratorList          []string
@@ -82,114 +106,300 @@ var Data struct {
 	MaskingViewIDToStorageGroupID map[string]string
 	StorageGroupIDToMaskingViewID map[string]string
 	JobIDToMockJob                map[string]*JobInfo
+	JobExpiry                     time.Duration
 	StorageGroupIDToNVolumes      map[string]int
 	StorageGroupIDToStorageGroup  map[string]*types.StorageGroup
 	StorageGroupIDToVolumes       map[string][]string
 	MaskingViewIDToMaskingView    map[string]*types.MaskingView
+	MaskingViewIDToStartingLUN    map[string]string
+	MaskingViewIDToLUNAddresses   map[string]map[string]string
+	MaskingViewIDToPathLUNs       map[string]map[string]string
 	InitiatorIDToInitiator        map[string]*types.Initiator
+	InitiatorIDToLoginEvents      map[string][]InitiatorLoginState
 	HostIDToHost                  map[string]*types.Host
 	PortGroupIDToPortGroup        map[string]*types.PortGroup
 	PortIDToSymmetrixPortType     map[string]*types.SymmetrixPortType
+	DirectorIDToDirector          map[string]*types.Director
 	VolumeIDToVolume              map[string]*types.Volume
 	JSONDir                       string
 	InitiatorHost                 string
 
+	// WWNToVolumeID indexes the volumes by WWN for the private volume queries
+	WWNToVolumeID map[string]string
+	// PrivVolumeIteratorList is the list of the volume IDs of the private volume iterator
+	PrivVolumeIteratorList []string
+	// ConnectionIteratorList is the list of the connections of the masking view connection iterator
+	ConnectionIteratorList []*types.MaskingViewConnection
+	// ConnectionPageSize is the page size of the masking view connections, which are returned whole if it is 0
+	ConnectionPageSize int
+	// IDListPageSize is the page size of the ID listings, which are returned whole if it is 0
+	IDListPageSize int
+	// IDIteratorLists are the IDs of the iterators of the ID listings, by iterator ID
+	IDIteratorLists map[string][]string
+	// PortIDToIPInterfaces are the IP interfaces of the ports "<director>:<port>"
+	PortIDToIPInterfaces map[string][]*types.IPInterface
+
 	// Snapshots
 	VolIDToSnapshots  map[string]map[string]*types.Snapshot
 	SnapIDToLinkedVol map[string]map[string]*types.LinkedVolumes
+	// MaxSnapshotsPerVolume is the number of snapshots a volume can have
+	MaxSnapshotsPerVolume int
+	// MaxSnapshotsPerArray is the number of snapshots the array can have, unlimited if 0
+	MaxSnapshotsPerArray int
 
 	// SRDF
 	StorageGroupIDToRDFStorageGroup map[string]*types.RDFStorageGroup
 	RDFGroup                        *types.RDFGroup
 	SGRDFInfo                       *types.SGRDFInfo
+	VolumeIDToRDFPair               map[string]*types.RDFDevicePair
+
+	// Snapshot policies
+	StorageGroupIDToSnapshotPolicies map[string][]string
+
+	// NoPublicSnapVolumeList answers 404 to the public listing of snapshot volumes, as Unisphere before 9.2 does
+	NoPublicSnapVolumeList bool
+	// BlockPrivateRoutes answers 403 to every private route, as a hardened deployment does
+	BlockPrivateRoutes bool
+
+	// ProtectMaskingViewVolumes rejects, as the array does, the removal of the last volumes of a masking view
+	ProtectMaskingViewVolumes bool
+
+	// SymmetrixIDToLocal overrides the local attribute of the arrays
+	SymmetrixIDToLocal map[string]bool
+	// SymmetrixIDToHealth overrides the health of the arrays, which are fully healthy by default
+	SymmetrixIDToHealth map[string]*types.ArrayHealth
+	// SymmetrixIDToRefreshTime overrides the time Unisphere last refreshed the arrays, the time of Reset by default
+	SymmetrixIDToRefreshTime map[string]time.Time
+	ResetTime                time.Time
+
+	// VVolSymmetrixIDs and FileSymmetrixIDs are the arrays serving vVols and file systems;
+	// nil answers 404, as a Unisphere without the vVol or file resources does
+	VVolSymmetrixIDs []string
+	FileSymmetrixIDs []string
+	// CapabilityRequests counts the requests for the replication capabilities
+	CapabilityRequests int
+
+	// SymmetrixIDToPerfRegistration are the arrays registered for the collection of performance data
+	SymmetrixIDToPerfRegistration map[string]*types.PerformanceRegistration
+	// AlertIDToAlert are the alerts of the arrays, added with AddAlert
+	AlertIDToAlert map[string]*types.Alert
+
+	// Capacity alert thresholds of the storage resource pools
+	SRPIDToNotificationSettings map[string]*types.SRPNotificationSettings
+	// StoragePoolIDToStoragePool are the storage resource pools of the array, SRP_1 and SRP_2
+	// with DefaultStoragePoolCapacity after Reset, and those added with AddStoragePool
+	StoragePoolIDToStoragePool map[string]*types.StoragePool
+
+	// Device ID allocation for volumes created through the mock
+	NextDeviceID int
+	// VolumeNameToAllocatedID maps the name of each volume created through the mock to its device ID
+	VolumeNameToAllocatedID map[string]string
+
+	// Latency is added before every request is served to simulate a slow Unisphere
+	Latency time.Duration
+	// ClockSkew is added to the system time of the arrays to simulate an array clock out of sync
+	ClockSkew time.Duration
+	// ResponseWarning, if set, is sent in the Warning header of every response
+	ResponseWarning string
+	// ResponseDeprecation and ResponseSunset, if set, are sent in the Deprecation and Sunset
+	// headers of every response
+	ResponseDeprecation string
+	ResponseSunset      string
+	// UnisphereVersion, if set, is the version returned by the version endpoint, with the
+	// API versions in UnisphereAPIVersions
+	UnisphereVersion     string
+	UnisphereAPIVersions []string
+
+	// Authentication
+	Username string
+	Password string
+	// RequireAuth enforces Basic credentials or a session token on every route, not only on version
+	RequireAuth   bool
+	SessionTokens map[string]bool
+}
+
+// Data are internal tables the Mock Unisphere uses to provide functionality.
+var Data dataTables
+
+// authRequestCount is the number of requests seen since UnauthorizedAfterRequests was set
+var authRequestCount int
+
+// sessionTokenCount is used to generate unique session tokens
+var sessionTokenCount int
+
+// requestCount is used to generate the X-Request-Id of each response
+var requestCount int
+
+// inducedErrorTable is the type of InducedErrors
+type inducedErrorTable struct {
+	NoConnection                    bool
+	InvalidJSON                     bool
+	HTMLResponse                    bool
+	HTMLErrorResponse               bool
+	TruncatedJSONResponse           bool
+	BadHTTPStatus                   int
+	TransientHTTPErrorCount         int
+	GetSymmetrixError               bool
+	GetArrayHealthError             bool
+	GetSLOComplianceError           bool
+	GetVersionError                 bool
+	GetAPIUsageError                bool
+	GetVolumeIteratorError          bool
+	GetVolumeError                  bool
+	UpdateVolumeError               bool
+	DeleteVolumeError               bool
+	DeviceInSGError                 bool
+	DeviceInSGErrorCount            int
+	GetStorageGroupError            bool
+	InvalidResponse                 bool
+	GetStoragePoolError             bool
+	UpdateStorageGroupError         bool
+	UpdateRemoteStorageGroupError   bool
+	UpdateLocalAndRemoteSGError     bool
+	FieldSelectionUnsupported       bool
+	VolumeExpansionUnsupported      bool
+	InitiatorAliasFilterUnsupported bool
+	GetJobError                     bool
+	JobFailedError                  bool
+	VolumeNotCreatedError           bool
+	GetJobCannotFindRoleForUser     bool
+	CancelJobError                  bool
+	CreateStorageGroupError         bool
+	StorageGroupAlreadyExists       bool
+	DeleteStorageGroupError         bool
+	GetStoragePoolListError         bool
+	GetPortGroupError               bool
+	GetPortError                    bool
+	GetSpecificPortError            bool
+	GetPortISCSITargetError         bool
+	GetPortGigEError                bool
+	GetIPInterfaceError             bool
+	CreateIPInterfaceError          bool
+	GetDirectorError                bool
+	GetInitiatorError               bool
+	GetInitiatorByIDError           bool
+	GetHostError                    bool
+	CreateHostError                 bool
+	DeleteHostError                 bool
+	UpdateHostError                 bool
+	CreateHostConflict              bool
+	UpdateHostConflict              bool
+	GetMaskingViewError             bool
+	CreateMaskingViewError          bool
+	MaskingViewAlreadyExists        bool
+	DeleteMaskingViewError          bool
+	PortGroupNotFoundError          bool
+	InitiatorGroupNotFoundError     bool
+	StorageGroupNotFoundError       bool
+	VolumeNotAddedError             bool
+	GetMaskingViewConnectionsError  bool
+	ResetAfterFirstError            bool
+	CreateSnapshotError             bool
+	DeleteSnapshotError             bool
+	LinkSnapshotError               bool
+	RenameSnapshotError             bool
+	GetSymVolumeError               bool
+	GetVolSnapsError                bool
+	GetGenerationError              bool
+	GetPrivateVolumeIterator        bool
+	PrivVolumePartialPageError      bool
+	SnapshotNotLicensed             bool
+	UnisphereMismatchError          bool
+	TargetNotDefinedError           bool
+	CopyInProgressError             bool
+	SnapshotExpired                 bool
+	InvalidSnapshotName             bool
+	GetPrivVolumeByIDError          bool
+	CreatePortGroupError            bool
+	UpdatePortGroupError            bool
+	DeletePortGroupError            bool
+	ExpandVolumeError               bool
+	MaxSnapSessionError             bool
+	GetSRDFInfoError                bool
+	VolumeRdfTypesError             bool
+	GetSRDFPairInfoError            bool
+	GetProtectedStorageGroupError   bool
+	CreateSGReplicaError            bool
+	CreateSGReplicaPartialError     bool
+	GetRDFGroupError                bool
+	GetSGOnRemote                   bool
+	GetSGWithVolOnRemote            bool
+	RDFGroupHasPairError            bool
+	GetRemoteVolumeError            bool
+	InvalidLocalVolumeError         bool
+	InvalidRemoteVolumeError        bool
+	FetchResponseError              bool
+	RemoveVolumesFromSG             bool
+	GetSGDemandReportError          bool
+	GetSRPNotificationError         bool
+	UpdateSRPNotificationError      bool
+	GetAlertError                   bool
+	GetPerfRegistrationError        bool
+	RegisterPerformanceError        bool
+	GetRDFDirectorError             bool
+	EditSnapshotPolicyError         bool
+	GetRDFPortError                 bool
+	CreateRDFPairError              bool
+	DeleteRDFPairError              bool
+	SetRDFModeError                 bool
+	// AllowDuplicateVolumeIdentifiers lets volumes be created with the identifier of an existing
+	// volume, as older versions of the mock did, instead of failing with 409 Conflict
+	AllowDuplicateVolumeIdentifiers bool
+	// AllowInvalidVolumes lets volumes be created with invalid sizes or identifiers, as older
+	// versions of the mock did, instead of failing with 400 Bad Request
+	AllowInvalidVolumes bool
+	// TargetDefinedAfterPolls is the number of snapshot generation polls after which
+	// links made undefined by TargetNotDefinedError become defined. Zero keeps them undefined.
+	TargetDefinedAfterPolls int
+	// CopiedAfterPolls is the number of snapshot generation polls after which the links kept
+	// copying by CopyInProgressError are copied. Zero keeps them copying.
+	CopiedAfterPolls int
+	// UnauthorizedAfterRequests, if non zero, is the number of requests that are served
+	// before every following request is rejected with 401 Unauthorized
+	UnauthorizedAfterRequests int
+	// SnapshotAge backdates the creation time of the snapshots created afterwards
+	SnapshotAge time.Duration
 }
 
 // InducedErrors constants
-var InducedErrors struct {
-	NoConnection                   bool
-	InvalidJSON                    bool
-	BadHTTPStatus                  int
-	GetSymmetrixError              bool
-	GetVolumeIteratorError         bool
-	GetVolumeError                 bool
-	UpdateVolumeError              bool
-	DeleteVolumeError              bool
-	DeviceInSGError                bool
-	GetStorageGroupError           bool
-	InvalidResponse                bool
-	GetStoragePoolError            bool
-	UpdateStorageGroupError        bool
-	GetJobError                    bool
-	JobFailedError                 bool
-	VolumeNotCreatedError          bool
-	GetJobCannotFindRoleForUser    bool
-	CreateStorageGroupError        bool
-	StorageGroupAlreadyExists      bool
-	DeleteStorageGroupError        bool
-	GetStoragePoolListError        bool
-	GetPortGroupError              bool
-	GetPortError                   bool
-	GetSpecificPortError           bool
-	GetPortISCSITargetError        bool
-	GetPortGigEError               bool
-	GetDirectorError               bool
-	GetInitiatorError              bool
-	GetInitiatorByIDError          bool
-	GetHostError                   bool
-	CreateHostError                bool
-	DeleteHostError                bool
-	UpdateHostError                bool
-	GetMaskingViewError            bool
-	CreateMaskingViewError         bool
-	MaskingViewAlreadyExists       bool
-	DeleteMaskingViewError         bool
-	PortGroupNotFoundError         bool
-	InitiatorGroupNotFoundError    bool
-	StorageGroupNotFoundError      bool
-	VolumeNotAddedError            bool
-	GetMaskingViewConnectionsError bool
-	ResetAfterFirstError           bool
-	CreateSnapshotError            bool
-	DeleteSnapshotError            bool
-	LinkSnapshotError              bool
-	RenameSnapshotError            bool
-	GetSymVolumeError              bool
-	GetVolSnapsError               bool
-	GetGenerationError             bool
-	GetPrivateVolumeIterator       bool
-	SnapshotNotLicensed            bool
-	UnisphereMismatchError         bool
-	TargetNotDefinedError          bool
-	SnapshotExpired                bool
-	InvalidSnapshotName            bool
-	GetPrivVolumeByIDError         bool
-	CreatePortGroupError           bool
-	UpdatePortGroupError           bool
-	DeletePortGroupError           bool
-	ExpandVolumeError              bool
-	MaxSnapSessionError            bool
-	GetSRDFInfoError               bool
-	VolumeRdfTypesError            bool
-	GetSRDFPairInfoError           bool
-	GetProtectedStorageGroupError  bool
-	CreateSGReplicaError           bool
-	GetRDFGroupError               bool
-	GetSGOnRemote                  bool
-	GetSGWithVolOnRemote           bool
-	RDFGroupHasPairError           bool
-	GetRemoteVolumeError           bool
-	InvalidLocalVolumeError        bool
-	InvalidRemoteVolumeError       bool
-	FetchResponseError             bool
-	RemoveVolumesFromSG            bool
+var InducedErrors inducedErrorTable
+
+// errorScheduleMutex protects errorResetCounts, errorSchedules and scheduledRequestCount
+var errorScheduleMutex sync.Mutex
+
+// errorResetCounts is, for each flag of InducedErrors set by InduceErrorTimes,
+// the number of times hasError still reports it before resetting it
+var errorResetCounts = make(map[*bool]int)
+
+// errorSchedule is a window of requests during which a flag of InducedErrors is set
+type errorSchedule struct {
+	flag        *bool
+	first, last int
 }
 
+// errorSchedules are the windows added by ScheduleError
+var errorSchedules []errorSchedule
+
+// scheduledRequestCount is the number of requests seen since Reset, against which errorSchedules are matched
+var scheduledRequestCount int
+
 // hasError checks to see if the specified error (via pointer)
 // is set. If so it returns true, else false.
+// If the error was set by InduceErrorTimes, it is reset once reported that many times.
 // Additionally if ResetAfterFirstError is set, the first error
 // condition will be reset to no longer be an error condition.
 func hasError(errorType *bool) bool {
 	if *errorType {
+		errorScheduleMutex.Lock()
+		if count, ok := errorResetCounts[errorType]; ok {
+			if count <= 1 {
+				*errorType = false
+				delete(errorResetCounts, errorType)
+			} else {
+				errorResetCounts[errorType] = count - 1
+			}
+		}
+		errorScheduleMutex.Unlock()
 		if InducedErrors.ResetAfterFirstError {
 			*errorType = false
 			InducedErrors.ResetAfterFirstError = false
@@ -199,24 +409,119 @@ func hasError(errorType *bool) bool {
 	return false
 }
 
+// inducedErrorFlag returns the boolean field of InducedErrors with the given name
+func inducedErrorFlag(name string) (*bool, error) {
+	field := reflect.ValueOf(&InducedErrors).Elem().FieldByName(name)
+	if !field.IsValid() || field.Kind() != reflect.Bool {
+		return nil, fmt.Errorf("%s is not an induced error flag", name)
+	}
+	return field.Addr().Interface().(*bool), nil
+}
+
+// InduceErrorTimes sets the flag of InducedErrors with the given name, and resets it
+// once the handlers checking it with hasError have reported it count times.
+// Unlike ResetAfterFirstError, each flag keeps its own count.
+func InduceErrorTimes(name string, count int) error {
+	flag, err := inducedErrorFlag(name)
+	if err != nil {
+		return err
+	}
+	if count <= 0 {
+		return fmt.Errorf("invalid count %d for %s", count, name)
+	}
+	errorScheduleMutex.Lock()
+	defer errorScheduleMutex.Unlock()
+	*flag = true
+	errorResetCounts[flag] = count
+	return nil
+}
+
+// ScheduleError sets the flag of InducedErrors with the given name for the requests numbered
+// first to last, counting from the call to ScheduleError, and clears it for the other requests.
+// Several windows may be scheduled for the same flag.
+func ScheduleError(name string, first, last int) error {
+	flag, err := inducedErrorFlag(name)
+	if err != nil {
+		return err
+	}
+	if first <= 0 || last < first {
+		return fmt.Errorf("invalid window %d to %d for %s", first, last, name)
+	}
+	errorScheduleMutex.Lock()
+	defer errorScheduleMutex.Unlock()
+	errorSchedules = append(errorSchedules, errorSchedule{
+		flag:  flag,
+		first: scheduledRequestCount + first,
+		last:  scheduledRequestCount + last,
+	})
+	return nil
+}
+
+// applyErrorSchedules counts a request and sets or clears the flags of the scheduled errors accordingly
+func applyErrorSchedules() {
+	errorScheduleMutex.Lock()
+	defer errorScheduleMutex.Unlock()
+	scheduledRequestCount++
+	if len(errorSchedules) == 0 {
+		return
+	}
+	inWindow := make(map[*bool]bool)
+	for _, schedule := range errorSchedules {
+		if scheduledRequestCount >= schedule.first && scheduledRequestCount <= schedule.last {
+			inWindow[schedule.flag] = true
+		} else if _, ok := inWindow[schedule.flag]; !ok {
+			inWindow[schedule.flag] = false
+		}
+	}
+	for flag, set := range inWindow {
+		*flag = set
+	}
+}
+
+// resetErrorSchedules forgets the counts of InduceErrorTimes and the windows of ScheduleError
+func resetErrorSchedules() {
+	errorScheduleMutex.Lock()
+	defer errorScheduleMutex.Unlock()
+	errorResetCounts = make(map[*bool]int)
+	errorSchedules = nil
+	scheduledRequestCount = 0
+}
+
 // Reset : re-initializes the variables
 func Reset() {
+	// requests still in flight from an earlier test read the data under the lock
+	mockCacheMutex.Lock()
 	InducedErrors.NoConnection = false
 	InducedErrors.InvalidJSON = false
+	InducedErrors.HTMLResponse = false
+	InducedErrors.HTMLErrorResponse = false
+	InducedErrors.TruncatedJSONResponse = false
 	InducedErrors.BadHTTPStatus = 0
+	InducedErrors.TransientHTTPErrorCount = 0
 	InducedErrors.GetSymmetrixError = false
+	InducedErrors.GetArrayHealthError = false
+	InducedErrors.GetSLOComplianceError = false
+	InducedErrors.GetVersionError = false
+	InducedErrors.GetAPIUsageError = false
 	InducedErrors.GetVolumeIteratorError = false
 	InducedErrors.GetVolumeError = false
 	InducedErrors.UpdateVolumeError = false
 	InducedErrors.DeleteVolumeError = false
 	InducedErrors.DeviceInSGError = false
+	InducedErrors.DeviceInSGErrorCount = 0
 	InducedErrors.GetStorageGroupError = false
 	InducedErrors.InvalidResponse = false
 	InducedErrors.UpdateStorageGroupError = false
+	InducedErrors.UpdateRemoteStorageGroupError = false
+	InducedErrors.UpdateLocalAndRemoteSGError = false
+	InducedErrors.FieldSelectionUnsupported = false
+	InducedErrors.VolumeExpansionUnsupported = false
+	InducedErrors.InitiatorAliasFilterUnsupported = false
 	InducedErrors.GetJobError = false
 	InducedErrors.JobFailedError = false
 	InducedErrors.VolumeNotCreatedError = false
 	InducedErrors.GetJobCannotFindRoleForUser = false
+	InducedErrors.CancelJobError = false
 	InducedErrors.CreateStorageGroupError = false
 	InducedErrors.StorageGroupAlreadyExists = false
 	InducedErrors.DeleteStorageGroupError = false
@@ -227,6 +532,8 @@ func Reset() {
 	InducedErrors.GetSpecificPortError = false
 	InducedErrors.GetPortISCSITargetError = false
 	InducedErrors.GetPortGigEError = false
+	InducedErrors.GetIPInterfaceError = false
+	InducedErrors.CreateIPInterfaceError = false
 	InducedErrors.GetDirectorError = false
 	InducedErrors.GetInitiatorError = false
 	InducedErrors.GetInitiatorByIDError = false
@@ -234,6 +541,8 @@ func Reset() {
 	InducedErrors.CreateHostError = false
 	InducedErrors.DeleteHostError = false
 	InducedErrors.UpdateHostError = false
+	InducedErrors.CreateHostConflict = false
+	InducedErrors.UpdateHostConflict = false
 	InducedErrors.GetMaskingViewError = false
 	InducedErrors.CreateMaskingViewError = false
 	InducedErrors.MaskingViewAlreadyExists = false
@@ -252,9 +561,11 @@ func Reset() {
 	InducedErrors.RenameSnapshotError = false
 	InducedErrors.GetGenerationError = false
 	InducedErrors.GetPrivateVolumeIterator = false
+	InducedErrors.PrivVolumePartialPageError = false
 	InducedErrors.SnapshotNotLicensed = false
 	InducedErrors.UnisphereMismatchError = false
 	InducedErrors.TargetNotDefinedError = false
+	InducedErrors.CopyInProgressError = false
 	InducedErrors.SnapshotExpired = false
 	InducedErrors.InvalidSnapshotName = false
 	InducedErrors.GetPrivVolumeByIDError = false
@@ -268,6 +579,7 @@ func Reset() {
 	InducedErrors.GetSRDFPairInfoError = false
 	InducedErrors.GetProtectedStorageGroupError = false
 	InducedErrors.CreateSGReplicaError = false
+	InducedErrors.CreateSGReplicaPartialError = false
 	InducedErrors.GetRDFGroupError = false
 	InducedErrors.GetSGOnRemote = false
 	InducedErrors.GetSGWithVolOnRemote = false
@@ -277,6 +589,39 @@ func Reset() {
 	InducedErrors.GetRemoteVolumeError = false
 	InducedErrors.FetchResponseError = false
 	InducedErrors.RemoveVolumesFromSG = false
+	InducedErrors.GetSGDemandReportError = false
+	InducedErrors.GetSRPNotificationError = false
+	InducedErrors.UpdateSRPNotificationError = false
+	InducedErrors.GetAlertError = false
+	InducedErrors.GetPerfRegistrationError = false
+	InducedErrors.RegisterPerformanceError = false
+	InducedErrors.GetRDFDirectorError = false
+	InducedErrors.EditSnapshotPolicyError = false
+	InducedErrors.GetRDFPortError = false
+	InducedErrors.CreateRDFPairError = false
+	InducedErrors.DeleteRDFPairError = false
+	InducedErrors.SetRDFModeError = false
+	InducedErrors.AllowDuplicateVolumeIdentifiers = false
+	InducedErrors.AllowInvalidVolumes = false
+	InducedErrors.TargetDefinedAfterPolls = 0
+	InducedErrors.CopiedAfterPolls = 0
+	InducedErrors.UnauthorizedAfterRequests = 0
+	InducedErrors.SnapshotAge = 0
+	authRequestCount = 0
+	requestCount = 0
+	Data.Latency = 0
+	Data.ClockSkew = 0
+	Data.ResponseWarning = ""
+	Data.ResponseDeprecation = ""
+	Data.ResponseSunset = ""
+	Data.UnisphereVersion = ""
+	Data.UnisphereAPIVersions = nil
+	Data.NextDeviceID = DefaultFirstDeviceID
+	Data.VolumeNameToAllocatedID = make(map[string]string)
+	Data.Username = defaultUsername
+	Data.Password = defaultPassword
+	Data.RequireAuth = false
+	Data.SessionTokens = make(map[string]bool)
 	Data.JSONDir = "mock"
 	Data.VolumeIDToIdentifier = make(map[string]string)
 	Data.VolumeIDToSize = make(map[string]int)
@@ -288,19 +633,55 @@ func Reset() {
 	Data.MaskingViewIDToStorageGroupID = make(map[string]string)
 	Data.StorageGroupIDToMaskingViewID = make(map[string]string)
 	Data.JobIDToMockJob = make(map[string]*JobInfo)
+	Data.JobExpiry = 0
 	Data.StorageGroupIDToNVolumes = make(map[string]int)
 	Data.StorageGroupIDToNVolumes[DefaultStorageGroup] = 0
 	Data.StorageGroupIDToStorageGroup = make(map[string]*types.StorageGroup)
 	Data.MaskingViewIDToMaskingView = make(map[string]*types.MaskingView)
+	Data.MaskingViewIDToStartingLUN = make(map[string]string)
+	Data.MaskingViewIDToLUNAddresses = make(map[string]map[string]string)
+	Data.MaskingViewIDToPathLUNs = make(map[string]map[string]string)
 	Data.InitiatorIDToInitiator = make(map[string]*types.Initiator)
+	Data.InitiatorIDToLoginEvents = make(map[string][]InitiatorLoginState)
 	Data.HostIDToHost = make(map[string]*types.Host)
 	Data.PortGroupIDToPortGroup = make(map[string]*types.PortGroup)
 	Data.PortIDToSymmetrixPortType = make(map[string]*types.SymmetrixPortType)
+	Data.DirectorIDToDirector = make(map[string]*types.Director)
 	Data.VolumeIDToVolume = make(map[string]*types.Volume)
+	Data.WWNToVolumeID = make(map[string]string)
+	Data.PrivVolumeIteratorList = make([]string, 0)
+	Data.ConnectionIteratorList = make([]*types.MaskingViewConnection, 0)
+	Data.ConnectionPageSize = 0
+	Data.IDListPageSize = 0
+	Data.IDIteratorLists = make(map[string][]string)
+	Data.PortIDToIPInterfaces = make(map[string][]*types.IPInterface)
 	Data.StorageGroupIDToVolumes = make(map[string][]string)
 	Data.VolIDToSnapshots = make(map[string]map[string]*types.Snapshot)
 	Data.SnapIDToLinkedVol = make(map[string]map[string]*types.LinkedVolumes)
+	Data.MaxSnapshotsPerVolume = DefaultMaxSnapshotsPerVolume
+	Data.MaxSnapshotsPerArray = 0
 	Data.StorageGroupIDToRDFStorageGroup = make(map[string]*types.RDFStorageGroup)
+	Data.StorageGroupIDToSnapshotPolicies = make(map[string][]string)
+	Data.SymmetrixIDToLocal = make(map[string]bool)
+	Data.SymmetrixIDToHealth = make(map[string]*types.ArrayHealth)
+	Data.SymmetrixIDToRefreshTime = make(map[string]time.Time)
+	Data.ResetTime = time.Now()
+	Data.SymmetrixIDToPerfRegistration = make(map[string]*types.PerformanceRegistration)
+	Data.AlertIDToAlert = make(map[string]*types.Alert)
+	Data.VVolSymmetrixIDs = []string{}
+	Data.FileSymmetrixIDs = []string{}
+	Data.CapabilityRequests = 0
+	Data.ProtectMaskingViewVolumes = false
+	Data.NoPublicSnapVolumeList = false
+	Data.BlockPrivateRoutes = false
+	Data.StoragePoolIDToStoragePool = map[string]*types.StoragePool{
+		DefaultStoragePool: newStoragePool(DefaultStoragePool, DefaultStoragePoolCapacity),
+		"SRP_2":            newStoragePool("SRP_2", DefaultStoragePoolCapacity),
+	}
+	Data.SRPIDToNotificationSettings = make(map[string]*types.SRPNotificationSettings)
+	for srpID := range Data.StoragePoolIDToStoragePool {
+		Data.SRPIDToNotificationSettings[srpID] = newSRPNotificationSettings(srpID)
+	}
 	Data.RDFGroup = &types.RDFGroup{
 		RdfgNumber:          DefaultRDFGNo,
 		Label:               "RG_13",
@@ -312,6 +693,7 @@ func Reset() {
 		Type:                "VASA_ASYNC",
 		Async:               true,
 	}
+	Data.VolumeIDToRDFPair = make(map[string]*types.RDFDevicePair)
 	Data.SGRDFInfo = &types.SGRDFInfo{
 		RdfGroupNumber: DefaultRDFGNo,
 		VolumeRdfTypes: []string{"R1"},
@@ -319,9 +701,104 @@ func Reset() {
 		Modes:          []string{"Asynchronous"},
 		LargerRdfSides: []string{"Equal"},
 	}
+	mockCacheMutex.Unlock()
+	resetErrorSchedules()
 	initMockCache()
 }
 
+// State is a copy of the mock data and induced errors made by SaveState
+type State struct {
+	data          dataTables
+	inducedErrors inducedErrorTable
+}
+
+// SaveState returns a copy of the mock data and induced errors, so that a test suite can populate
+// the mock once and restore it with RestoreState before each test case instead of rebuilding it.
+func SaveState() *State {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	state := &State{inducedErrors: InducedErrors}
+	deepCopy(reflect.ValueOf(&state.data).Elem(), reflect.ValueOf(&Data).Elem(), make(map[copied]reflect.Value))
+	return state
+}
+
+// RestoreState replaces the mock data and induced errors with a copy of those saved by SaveState.
+// The state is not modified by the requests served afterwards, so it can be restored again.
+func RestoreState(state *State) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	deepCopy(reflect.ValueOf(&Data).Elem(), reflect.ValueOf(&state.data).Elem(), make(map[copied]reflect.Value))
+	InducedErrors = state.inducedErrors
+	authRequestCount = 0
+}
+
+// copied identifies a pointer or map already copied by deepCopy, so that the copy shares it
+// wherever the original does
+type copied struct {
+	pointer uintptr
+	typ     reflect.Type
+}
+
+// deepCopy sets dst to a copy of src which shares no pointer, map or slice with it. Nil maps and
+// slices stay nil. The unexported fields of structs, e.g. of time.Time, are copied shallowly.
+func deepCopy(dst, src reflect.Value, done map[copied]reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr, reflect.Map:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		key := copied{pointer: src.Pointer(), typ: src.Type()}
+		if value, ok := done[key]; ok {
+			dst.Set(value)
+			return
+		}
+		var value reflect.Value
+		if src.Kind() == reflect.Ptr {
+			value = reflect.New(src.Type().Elem())
+			done[key] = value
+			deepCopy(value.Elem(), src.Elem(), done)
+		} else {
+			value = reflect.MakeMapWithSize(src.Type(), src.Len())
+			done[key] = value
+			iter := src.MapRange()
+			for iter.Next() {
+				element := reflect.New(src.Type().Elem()).Elem()
+				deepCopy(element, iter.Value(), done)
+				value.SetMapIndex(iter.Key(), element)
+			}
+		}
+		dst.Set(value)
+	case reflect.Slice:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		value := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(value.Index(i), src.Index(i), done)
+		}
+		dst.Set(value)
+	case reflect.Struct:
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				deepCopy(dst.Field(i), src.Field(i), done)
+			}
+		}
+	case reflect.Interface:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		value := reflect.New(src.Elem().Type()).Elem()
+		deepCopy(value, src.Elem(), done)
+		dst.Set(value)
+	default:
+		dst.Set(src)
+	}
+}
+
 func initMockCache() {
 	// Initialize SGs
 	AddStorageGroup("CSI-Test-SG-1", "SRP_1", "Diamond")
@@ -343,6 +820,32 @@ func initMockCache() {
 	fcDir2 := "FA-2D"
 	fcDir1PortKey1 := fcDir1 + ":" + "5"
 	fcDir2PortKey1 := fcDir2 + ":" + "1"
+	// Add directors and their ports
+	for _, dir := range []string{"RF-1F", "RF-2F", iscsiDir1, "SE-2E"} {
+		AddDirector(dir, "Online")
+		for _, port := range []string{"0", "1"} {
+			AddSymmetrixPort(&types.SymmetrixPortType{
+				SymmetrixPortKey: &types.PortKey{DirectorID: dir, PortID: port},
+				PortStatus:       "ON",
+				DirectorStatus:   "Online",
+				Type:             "GigE",
+				Identifier:       "iqn.1992-04.com.emc:600009700bcbb70e3287017400000001",
+				ISCSITarget:      true,
+				IPAddresses:      []string{"1.1.1.1"},
+			})
+		}
+	}
+	for _, key := range []string{fcDir1PortKey1, fcDir2PortKey1} {
+		dirPort := strings.Split(key, ":")
+		AddDirector(dirPort[0], "Online")
+		AddSymmetrixPort(&types.SymmetrixPortType{
+			SymmetrixPortKey: &types.PortKey{DirectorID: dirPort[0], PortID: dirPort[1]},
+			PortStatus:       "ON",
+			DirectorStatus:   "Online",
+			Type:             "FibreChannel",
+			Identifier:       DefaultFcStoragePortWWN,
+		})
+	}
 	// Add Port groups
 	AddPortGroup("csi-pg", "Fibre", []string{fcDir1PortKey1, fcDir2PortKey1})
 	// Initialize initiators
@@ -385,6 +888,141 @@ func initMockCache() {
 
 var mockRouter http.Handler
 
+// transientError returns true while InducedErrors.TransientHTTPErrorCount
+// requests remain to be failed, decrementing the count on each call
+func transientError() bool {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	if InducedErrors.TransientHTTPErrorCount > 0 {
+		InducedErrors.TransientHTTPErrorCount--
+		return true
+	}
+	return false
+}
+
+// unauthorizedRequest returns true if the request must be rejected with 401 Unauthorized,
+// either because UnauthorizedAfterRequests is exhausted or because authentication is
+// required and the request carries neither valid credentials nor a valid session token.
+func unauthorizedRequest(r *http.Request) bool {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	if InducedErrors.UnauthorizedAfterRequests > 0 {
+		authRequestCount++
+		if authRequestCount > InducedErrors.UnauthorizedAfterRequests {
+			return true
+		}
+	}
+	if !Data.RequireAuth {
+		return false
+	}
+	return !isAuthorized(r)
+}
+
+// isAuthorized checks the request against the mock credentials and session tokens.
+// Session tokens are sent as Basic authentication with an empty user name.
+func isAuthorized(r *http.Request) bool {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	expectedUsername, expectedPassword := credentials()
+	if username == expectedUsername && password == expectedPassword {
+		return true
+	}
+	return username == "" && Data.SessionTokens[password]
+}
+
+// credentials returns the accepted user name and password, which are the defaults until Reset or SetCredentials is called
+func credentials() (string, string) {
+	if Data.Username == "" {
+		return defaultUsername, defaultPassword
+	}
+	return Data.Username, Data.Password
+}
+
+// SetCredentials sets the user name and password accepted by the mock
+func SetCredentials(username, password string) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	Data.Username = username
+	Data.Password = password
+}
+
+// RequireAuthentication enables or disables the credential check on every route
+func RequireAuthentication(require bool) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	Data.RequireAuth = require
+}
+
+// SetLatency sets the delay added before every request is served
+func SetLatency(latency time.Duration) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	Data.Latency = latency
+}
+
+// SetUnisphereVersion sets the version returned by the version endpoint and the API versions it lists,
+// which older Unisphere releases leave empty
+func SetUnisphereVersion(version string, apiVersions []string) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	Data.UnisphereVersion = version
+	Data.UnisphereAPIVersions = apiVersions
+}
+
+// SetClockSkew sets how far ahead of the local clock (or behind, if negative) the system time of the arrays is
+func SetClockSkew(skew time.Duration) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	Data.ClockSkew = skew
+}
+
+// setRequestHeaders sets the headers Unisphere adds to every response: the ID of the request
+// and any warning about it
+func setRequestHeaders(w http.ResponseWriter) {
+	mockCacheMutex.Lock()
+	requestCount++
+	id := requestCount
+	warning := Data.ResponseWarning
+	deprecation, sunset := Data.ResponseDeprecation, Data.ResponseSunset
+	mockCacheMutex.Unlock()
+	w.Header().Set("X-Request-Id", fmt.Sprintf("mock-request-%d", id))
+	if warning != "" {
+		w.Header().Set("Warning", warning)
+	}
+	if deprecation != "" {
+		w.Header().Set("Deprecation", deprecation)
+	}
+	if sunset != "" {
+		w.Header().Set("Sunset", sunset)
+	}
+}
+
+// RequestCount returns the number of requests served since the last Reset
+func RequestCount() int {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	return requestCount
+}
+
+// delayRequest waits for the configured latency and returns false if the client
+// went away in the meantime, in which case the request is not served
+func delayRequest(r *http.Request) bool {
+	mockCacheMutex.Lock()
+	latency := Data.Latency
+	mockCacheMutex.Unlock()
+	if latency <= 0 {
+		return true
+	}
+	select {
+	case <-r.Context().Done():
+		return false
+	case <-time.After(latency):
+		return true
+	}
+}
+
 // GetHandler returns the http handler
 func GetHandler() http.Handler {
 	handler := http.HandlerFunc(
@@ -392,12 +1030,37 @@ func GetHandler() http.Handler {
 			if Debug {
 				log.Printf("handler called: %s %s", r.Method, r.URL)
 			}
+			if !delayRequest(r) {
+				return
+			}
+			setRequestHeaders(w)
+			applyErrorSchedules()
 			if InducedErrors.InvalidJSON {
 				w.Write([]byte(`this is not json`))
+			} else if InducedErrors.HTMLResponse {
+				writeHTML(w, http.StatusOK)
+			} else if InducedErrors.HTMLErrorResponse {
+				writeHTML(w, http.StatusBadGateway)
+			} else if InducedErrors.TruncatedJSONResponse {
+				recorder := httptest.NewRecorder()
+				if mockRouter != nil {
+					mockRouter.ServeHTTP(recorder, r)
+				} else {
+					getRouter().ServeHTTP(recorder, r)
+				}
+				body := recorder.Body.Bytes()
+				w.WriteHeader(recorder.Code)
+				w.Write(body[:len(body)/2])
 			} else if InducedErrors.NoConnection {
 				writeError(w, "No Connection", http.StatusRequestTimeout)
 			} else if InducedErrors.BadHTTPStatus != 0 {
 				writeError(w, "Internal Error", InducedErrors.BadHTTPStatus)
+			} else if transientError() {
+				writeError(w, "Service Unavailable", http.StatusServiceUnavailable)
+			} else if unauthorizedRequest(r) {
+				writeError(w, "Unauthorized", http.StatusUnauthorized)
+			} else if Data.BlockPrivateRoutes && strings.HasPrefix(r.URL.Path, "/univmax/restapi/private/") {
+				writeError(w, "Forbidden", http.StatusForbidden)
 			} else {
 				if mockRouter != nil {
 					mockRouter.ServeHTTP(w, r)
@@ -409,6 +1072,48 @@ func GetHandler() http.Handler {
 	return handler
 }
 
+// writeHTML writes the kind of error page a load balancer in front of Unisphere returns
+func writeHTML(w http.ResponseWriter, httpStatus int) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(httpStatus)
+	w.Write([]byte("<html><head><title>502 Bad Gateway</title></head><body><h1>502 Bad Gateway</h1></body></html>"))
+}
+
+// selectFields wraps the handler of an object so that a GET with the select query parameter
+// returns only the selected fields of the object, or fails if InducedErrors.FieldSelectionUnsupported is set.
+func selectFields(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		selected := r.URL.Query().Get("select")
+		if r.Method != http.MethodGet || selected == "" {
+			handler(w, r)
+			return
+		}
+		if InducedErrors.FieldSelectionUnsupported {
+			writeError(w, "Invalid query parameter: select", http.StatusBadRequest)
+			return
+		}
+		recorder := httptest.NewRecorder()
+		handler(recorder, r)
+		if recorder.Code != http.StatusOK {
+			w.WriteHeader(recorder.Code)
+			w.Write(recorder.Body.Bytes())
+			return
+		}
+		fields := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(recorder.Body.Bytes(), &fields); err != nil {
+			writeError(w, "problem decoding the object: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result := make(map[string]json.RawMessage)
+		for _, field := range strings.Split(selected, ",") {
+			if value, ok := fields[field]; ok {
+				result[field] = value
+			}
+		}
+		writeJSON(w, result)
+	}
+}
+
 func getRouter() http.Handler {
 	router := mux.NewRouter()
 	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/host/{id}", handleHost)
@@ -417,28 +1122,42 @@ func getRouter() http.Handler {
 	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/initiator", handleInitiator)
 	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/portgroup/{id}", handlePortGroup)
 	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/portgroup", handlePortGroup)
-	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/storagegroup/{id}", handleStorageGroup)
+	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/storagegroup/{id}", selectFields(handleStorageGroup))
 	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/storagegroup", handleStorageGroup)
 	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/maskingview/{mvID}/connections", handleMaskingViewConnections)
 	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/maskingview/{mvID}", handleMaskingView)
 	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/maskingview", handleMaskingView)
+	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/srp/{id}/storage_group_demand_report", handleSGDemandReport)
+	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/srp/{id}/notification_settings", handleSRPNotificationSettings)
 	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/srp/{id}", handleStorageResourcePool)
 	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/srp", handleStorageResourcePool)
 	router.HandleFunc(PREFIXNOVERSION+"/common/Iterator/{iterId}/page", handleIterator)
-	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/volume/{volID}", handleVolume)
+	router.HandleFunc(PREFIXNOVERSION+"/common/Iterator/{iterId}", handleIterator)
+	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/volume/{volID}", selectFields(handleVolume))
 	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/volume", handleVolume)
 	router.HandleFunc(PRIVATEPREFIX+"/sloprovisioning/symmetrix/{symid}/volume", handlePrivVolume)
+	router.HandleFunc(PREFIX+"/system/symmetrix/{symid}/director/{director}/port/{id}/ip_interface/{ifid}", handleIPInterface)
+	router.HandleFunc(PREFIX+"/system/symmetrix/{symid}/director/{director}/port/{id}/ip_interface", handleIPInterface)
 	router.HandleFunc(PREFIX+"/system/symmetrix/{symid}/director/{director}/port/{id}", handlePort)
 	router.HandleFunc(PREFIX+"/system/symmetrix/{symid}/director/{director}/port", handlePort)
+	router.HandleFunc(PREFIX+"/system/symmetrix/{symid}/port", handleSymmetrixPort)
 	router.HandleFunc(PREFIX+"/system/symmetrix/{symid}/director/{id}", handleDirector)
 	router.HandleFunc(PREFIX+"/system/symmetrix/{symid}/director", handleDirector)
+	router.HandleFunc(PREFIX+"/system/symmetrix/{symid}/alert/{id}", handleAlert)
+	router.HandleFunc(PREFIX+"/system/symmetrix/{symid}/alert", handleAlert)
 	router.HandleFunc(PREFIX+"/system/symmetrix/{symid}/job/{jobID}", handleJob)
 	router.HandleFunc(PREFIX+"/system/symmetrix/{symid}/job", handleJob)
+	router.HandleFunc(PREFIX+"/system/symmetrix/{symid}/health", handleArrayHealth)
+	router.HandleFunc(PREFIX+"/system/symmetrix/{symid}/refresh", handleSymmetrixRefresh)
+	router.HandleFunc(PREFIX+"/sloprovisioning/symmetrix/{symid}/compliance/storagegroup", handleSLOCompliance)
 	router.HandleFunc(PREFIX+"/system/symmetrix/{id}", handleSymmetrix)
 	router.HandleFunc(PREFIX+"/system/symmetrix", handleSymmetrix)
 	router.HandleFunc(PREFIX+"/system/version", handleVersion)
 	router.HandleFunc(PREFIX+"/version", handleVersion)
 	router.HandleFunc(PREFIXNOVERSION+"/version", handleVersion)
+	router.HandleFunc(PREFIXNOVERSION+"/session", handleSession)
+	router.HandleFunc(PRIVATEPREFIX+"/system/api_usage", handleAPIUsage)
+	router.HandleFunc(PREFIXNOVERSION+"/performance/Array/register", handlePerfRegistration)
 	router.HandleFunc("/", handleNotFound)
 
 	//Snapshot
@@ -449,12 +1168,19 @@ func getRouter() http.Handler {
 	router.HandleFunc(PRIVATEPREFIX+"/replication/symmetrix/{symid}/volume/{volID}/snapshot/{SnapID}/generation", handleGenerations)
 	router.HandleFunc(PRIVATEPREFIX+"/replication/symmetrix/{symid}/volume/{volID}/snapshot/{SnapID}/generation/{genID}", handleGenerations)
 	router.HandleFunc(PREFIX+"/replication/capabilities/symmetrix", handleCapabilities)
+	router.HandleFunc(PREFIX+"/vvol/symmetrix", handleCapableSymmetrixList(&Data.VVolSymmetrixIDs))
+	router.HandleFunc(PREFIX+"/file/symmetrix", handleCapableSymmetrixList(&Data.FileSymmetrixIDs))
+	router.HandleFunc(PREFIX+"/replication/symmetrix/{symid}/volume", handlePublicSymVolumes)
 
 	// SRDF
 	router.HandleFunc(PREFIX+"/replication/symmetrix/{symid}/rdf_group/{rdf_no}", handleRDFGroup)
+	router.HandleFunc(PREFIX+"/replication/symmetrix/{symid}/rdf_director", handleRDFDirector)
+	router.HandleFunc(PREFIX+"/replication/symmetrix/{symid}/rdf_director/{director_id}/port", handleRDFPort)
 	router.HandleFunc(PREFIX+"/replication/symmetrix/{symid}/storagegroup/{id}", handleRDFStorageGroup)
 	router.HandleFunc(PREFIX+"/replication/symmetrix/{symid}/storagegroup/{id}/rdf_group", handleRDFStorageGroup)
-	router.HandleFunc(PREFIX+"/replication/symmetrix/{symid}/storagegroup/{id}/rdf_group/{rdf_no}", handleSGRDFInfo)
+	router.HandleFunc(PREFIX+"/replication/symmetrix/{symid}/storagegroup/{id}/snapshot/{SnapID}/generation/{genID}", handleStorageGroupSnapshot)
+	router.HandleFunc(PREFIX+"/replication/symmetrix/{symid}/storagegroup/{id}/rdf_group/{rdf_no}", handleSGRDF)
+	router.HandleFunc(PREFIX+"/replication/symmetrix/{symid}/rdf_group/{rdf_no}/volume", handleRDFPairCreationInGroup)
 	router.HandleFunc(PREFIX+"/replication/symmetrix/{symid}/rdf_group/{rdf_no}/volume/{volume_id}", handleRDFDevicePair)
 
 	mockRouter = router
@@ -476,13 +1202,84 @@ func handleTODO(w http.ResponseWriter, r *http.Request) {
 	writeError(w, "Endpoint not implemented yet", http.StatusNotImplemented)
 }
 
-// GET, POST /univmax/restapi/APIVersion/replication/symmetrix/{symID}/rdf_group/{rdf_no}/volume/{volume_id}
+// POST /univmax/restapi/APIVersion/replication/symmetrix/{symID}/rdf_group/{rdf_no}/volume
+func handleRDFPairCreationInGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	routeParams := mux.Vars(r)
+	if routeParams["rdf_no"] != fmt.Sprintf("%d", Data.RDFGroup.RdfgNumber) {
+		writeError(w, "The specified RA group is not valid", http.StatusNotFound)
+		return
+	}
+	payload := new(types.CreateRDFPair)
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		writeError(w, "problem decoding POST RDF pair payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if payload.ExecutionOption != types.ExecutionOptionAsynchronous {
+		writeError(w, "expected ASYNCHRONOUS", http.StatusBadRequest)
+		return
+	}
+	if payload.LocalDeviceListCriteria == nil || len(payload.LocalDeviceListCriteria.LocalDeviceList) == 0 {
+		writeError(w, "no local devices specified", http.StatusBadRequest)
+		return
+	}
+	volIDs := payload.LocalDeviceListCriteria.LocalDeviceList
+	for _, volID := range volIDs {
+		if _, ok := Data.VolumeIDToVolume[volID]; !ok {
+			writeError(w, fmt.Sprintf("Device %s cannot be found", volID), http.StatusNotFound)
+			return
+		}
+		if _, ok := Data.VolumeIDToRDFPair[volID]; ok {
+			writeError(w, fmt.Sprintf("Device %s is already an RDF device", volID), http.StatusBadRequest)
+			return
+		}
+	}
+	jobID := "CreateRDFPair-" + strings.Join(volIDs, "-")
+	resourceLink := fmt.Sprintf("replication/symmetrix/%s/rdf_group/%s/volume", routeParams["symid"], routeParams["rdf_no"])
+	if InducedErrors.CreateRDFPairError {
+		newMockJob(jobID, types.JobStatusRunning, types.JobStatusFailed, resourceLink)
+		returnJobByID(w, jobID)
+		return
+	}
+	state := "Suspended"
+	if payload.Establish {
+		state = "SyncInProg"
+	}
+	for _, volID := range volIDs {
+		Data.VolumeIDToRDFPair[volID] = &types.RDFDevicePair{
+			LocalSymmID:          routeParams["symid"],
+			RemoteSymmID:         Data.RDFGroup.RemoteSymmetrix,
+			LocalRdfGroupNumber:  Data.RDFGroup.RdfgNumber,
+			RemoteRdfGroupNumber: Data.RDFGroup.RemoteRdfgNumber,
+			LocalVolumeName:      volID,
+			RemoteVolumeName:     volID,
+			VolumeConfig:         "RDF1+TDEV",
+			RdfMode:              payload.RdfMode,
+			RdfpairState:         state,
+			LargerRdfSide:        "Equal",
+		}
+	}
+	Data.RDFGroup.NumDevices += len(volIDs)
+	newMockJob(jobID, types.JobStatusRunning, types.JobStatusSucceeded, resourceLink)
+	returnJobByID(w, jobID)
+}
+
+// GET, POST, PUT, DELETE /univmax/restapi/APIVersion/replication/symmetrix/{symID}/rdf_group/{rdf_no}/volume/{volume_id}
 func handleRDFDevicePair(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
 		handleRDFDevicePairInfo(w, r)
 	case http.MethodPost:
 		handleRDFDevicePairCreation(w, r)
+	case http.MethodPut:
+		handleRDFDevicePairModification(w, r)
+	case http.MethodDelete:
+		handleRDFDevicePairDeletion(w, r)
 	default:
 		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -516,6 +1313,12 @@ func handleRDFDevicePairInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	routeParams := mux.Vars(r)
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	if pair, ok := Data.VolumeIDToRDFPair[routeParams["volume_id"]]; ok {
+		writeJSON(w, pair)
+		return
+	}
 	var volumeConfig string
 	if routeParams["symid"] == Data.RDFGroup.RemoteSymmetrix {
 		volumeConfig = "RDF2+TDEV"
@@ -537,6 +1340,54 @@ func handleRDFDevicePairInfo(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, rdfDevicePairInfo)
 }
 
+// PUT /univmax/restapi/APIVersion/replication/symmetrix/{symID}/rdf_group/{rdf_no}/volume/{volume_id}
+func handleRDFDevicePairModification(w http.ResponseWriter, r *http.Request) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	pair, ok := Data.VolumeIDToRDFPair[mux.Vars(r)["volume_id"]]
+	if !ok {
+		writeError(w, "The device is not an RDF device", http.StatusNotFound)
+		return
+	}
+	modifyParam := new(types.ModifySGRDFGroup)
+	if err := json.NewDecoder(r.Body).Decode(modifyParam); err != nil {
+		writeError(w, "problem decoding PUT RDF pair payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch modifyParam.Action {
+	case "Suspend":
+		pair.RdfpairState = "Suspended"
+	case "Establish", "Resume":
+		pair.RdfpairState = "Synchronized"
+	default:
+		writeError(w, "not a supported action on an RDF pair: "+modifyParam.Action, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, pair)
+}
+
+// DELETE /univmax/restapi/APIVersion/replication/symmetrix/{symID}/rdf_group/{rdf_no}/volume/{volume_id}
+func handleRDFDevicePairDeletion(w http.ResponseWriter, r *http.Request) {
+	if InducedErrors.DeleteRDFPairError {
+		writeError(w, "Error deleting the RDF pair: induced error", http.StatusBadRequest)
+		return
+	}
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	volID := mux.Vars(r)["volume_id"]
+	pair, ok := Data.VolumeIDToRDFPair[volID]
+	if !ok {
+		writeError(w, "The device is not an RDF device", http.StatusNotFound)
+		return
+	}
+	if pair.RdfpairState != "Suspended" {
+		writeError(w, "The RDF pair must be suspended before it is deleted", http.StatusBadRequest)
+		return
+	}
+	delete(Data.VolumeIDToRDFPair, volID)
+	Data.RDFGroup.NumDevices--
+}
+
 // GET /univmax/restapi/APIVersion/replication/symmetrix/{symID}/rdf_group/{rdf_no}
 func handleRDFGroup(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -559,6 +1410,61 @@ func handleRDFGroup(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// isRDFDirector returns true for the RDF (RF over Fibre Channel, RE over GigE) directors
+func isRDFDirector(directorID string) bool {
+	return strings.HasPrefix(directorID, "RF-") || strings.HasPrefix(directorID, "RE-")
+}
+
+// GET /univmax/restapi/APIVersion/replication/symmetrix/{symid}/rdf_director
+func handleRDFDirector(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if InducedErrors.GetRDFDirectorError {
+		writeError(w, "Error retrieving RDF directors: induced error", http.StatusRequestTimeout)
+		return
+	}
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	online := r.URL.Query().Get("online") == "true"
+	dirIDs := make([]string, 0)
+	for dirID, dir := range Data.DirectorIDToDirector {
+		if isRDFDirector(dirID) && (!online || dir.Availability == "Online") {
+			dirIDs = append(dirIDs, dirID)
+		}
+	}
+	sort.Strings(dirIDs)
+	writeJSON(w, &types.RDFDirList{RDFDirs: dirIDs})
+}
+
+// GET /univmax/restapi/APIVersion/replication/symmetrix/{symid}/rdf_director/{director_id}/port
+func handleRDFPort(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if InducedErrors.GetRDFPortError {
+		writeError(w, "Error retrieving RDF ports: induced error", http.StatusRequestTimeout)
+		return
+	}
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	dirID := mux.Vars(r)["director_id"]
+	if _, ok := Data.DirectorIDToDirector[dirID]; !ok || !isRDFDirector(dirID) {
+		writeError(w, "The specified RDF director cannot be found", http.StatusNotFound)
+		return
+	}
+	online := r.URL.Query().Get("online") == "true"
+	portIDs := make([]string, 0)
+	for _, port := range getDirectorPorts(dirID) {
+		if !online || port.PortStatus == "ON" {
+			portIDs = append(portIDs, port.SymmetrixPortKey.PortID)
+		}
+	}
+	writeJSON(w, &types.RDFPortList{RDFPorts: portIDs})
+}
+
 // GET /univmax/restapi/APIVersion/replication/symmetrix/{symid}/storagegroup/{id}
 // POST /univmax/restapi/APIVersion/replication/symmetrix/{symid}/storagegroup/{id}/rdf_group
 func handleRDFStorageGroup(w http.ResponseWriter, r *http.Request) {
@@ -618,6 +1524,14 @@ func handleSGRDFCreation(w http.ResponseWriter, r *http.Request) {
 	routeParams := mux.Vars(r)
 	storageGroupName := routeParams["id"]
 	symmetrixID := routeParams["symid"]
+	if InducedErrors.CreateSGReplicaPartialError {
+		if _, err := AddStorageGroup(sgsrdf.RemoteStorageGroupName, DefaultStoragePool, sgsrdf.RemoteSLO); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeError(w, "Failed to establish the SRDF pairs: induced error", http.StatusInternalServerError)
+		return
+	}
 	if _, err := AddRDFStorageGroup(storageGroupName, symmetrixID); err != nil {
 		writeError(w, err.Error(), http.StatusBadRequest)
 		return
@@ -679,22 +1593,96 @@ func handleSGRDFInfo(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func handleSGRDFAction(w http.ResponseWriter, r *http.Request) {
-	// TODO: execute actions by updating the memory cache
-	w.WriteHeader(200)
+// rdfActiveState returns the state of active SRDF pairs in a mode
+func rdfActiveState(mode string) string {
+	switch mode {
+	case "Synchronous":
+		return "Synchronized"
+	case "Active":
+		return "ActiveBias"
+	}
+	return "Consistent"
 }
 
-// GET /univmax/restapi/system/version
-func handleVersion(w http.ResponseWriter, r *http.Request) {
-	auth := defaultUsername + ":" + defaultPassword
-	authExpected := fmt.Sprintf("Basic " + base64.StdEncoding.EncodeToString([]byte(auth)))
-	// Check for valid credentials
-	authSupplied := r.Header.Get("Authorization")
-	if authExpected != authSupplied {
-		writeError(w, "Unauthorized", http.StatusUnauthorized)
+// handleSGRDFAction executes a replication action on the SRDF pairs of a storage group.
+// The mode of the pairs can only be set while they are suspended.
+func handleSGRDFAction(w http.ResponseWriter, r *http.Request) {
+	modifyParam := new(types.ModifySGRDFGroup)
+	if err := json.NewDecoder(r.Body).Decode(modifyParam); err != nil {
+		writeError(w, "problem decoding PUT SG RDF payload: "+err.Error(), http.StatusBadRequest)
 		return
 	}
-	vars := mux.Vars(r)
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	info := Data.SGRDFInfo
+	setStates := func(state string) {
+		for i := range info.States {
+			info.States[i] = state
+		}
+	}
+	switch modifyParam.Action {
+	case "Suspend":
+		setStates("Suspended")
+	case "Establish", "Resume", "Failback":
+		mode := ""
+		if len(info.Modes) > 0 {
+			mode = info.Modes[0]
+		}
+		setStates(rdfActiveState(mode))
+	case "Failover":
+		setStates("Failed Over")
+	case "SetMode":
+		if InducedErrors.SetRDFModeError {
+			writeError(w, "Error setting the SRDF mode: induced error", http.StatusBadRequest)
+			return
+		}
+		if modifyParam.SetMode == nil {
+			writeError(w, "missing setMode parameters", http.StatusBadRequest)
+			return
+		}
+		for _, state := range info.States {
+			if state != "Suspended" {
+				writeError(w, "The SRDF pairs must be suspended to change their mode", http.StatusBadRequest)
+				return
+			}
+		}
+		for i := range info.Modes {
+			info.Modes[i] = modifyParam.SetMode.Mode
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// SetSGRDFState sets the mode and the state of the SRDF pairs of the protected storage groups
+func SetSGRDFState(mode, state string) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	Data.SGRDFInfo.Modes = []string{mode}
+	Data.SGRDFInfo.States = []string{state}
+}
+
+// GET /univmax/restapi/system/version
+func handleVersion(w http.ResponseWriter, r *http.Request) {
+	// Check for valid credentials
+	mockCacheMutex.Lock()
+	authorized := isAuthorized(r)
+	mockCacheMutex.Unlock()
+	if !authorized {
+		writeError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if InducedErrors.GetVersionError {
+		writeError(w, "Error retrieving version: induced error", http.StatusRequestTimeout)
+		return
+	}
+	mockCacheMutex.Lock()
+	unisphereVersion, apiVersions := Data.UnisphereVersion, Data.UnisphereAPIVersions
+	mockCacheMutex.Unlock()
+	if unisphereVersion != "" {
+		writeJSON(w, &types.Version{Version: unisphereVersion, SupportedAPIVersions: apiVersions})
+		return
+	}
+	vars := mux.Vars(r)
 	apiversion := vars["apiversion"]
 	// check the apiversion
 	switch apiversion {
@@ -702,15 +1690,119 @@ func handleVersion(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`{ "version": "V9.0.1.6" }`))
 		break
 	case "": // for version 91, as URL does not have apiversion in V9.1
-		w.Write([]byte(`{ "version": "V9.1.0.2" }`))
+		w.Write([]byte(`{ "version": "V9.1.0.2", "api_version": "91", "supported_api_versions": ["91", "90"], "build_date": "2020-03-18" }`))
 		break
 	default:
 		writeError(w, "Unsupport API version: "+apiversion, http.StatusServiceUnavailable)
 	}
 }
 
+// SessionToken is returned by the mock session login
+type SessionToken struct {
+	Token string `json:"token"`
+}
+
+// POST /univmax/restapi/session logs in with Basic credentials and returns a session token
+// DELETE /univmax/restapi/session logs out the session token used by the request
+func handleSession(w http.ResponseWriter, r *http.Request) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	username, password, _ := r.BasicAuth()
+	switch r.Method {
+	case http.MethodPost:
+		if expectedUsername, expectedPassword := credentials(); username != expectedUsername || password != expectedPassword {
+			writeError(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		sessionTokenCount++
+		token := fmt.Sprintf("session-token-%d", sessionTokenCount)
+		Data.SessionTokens[token] = true
+		writeJSON(w, &SessionToken{Token: token})
+	case http.MethodDelete:
+		if username != "" || !Data.SessionTokens[password] {
+			writeError(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		delete(Data.SessionTokens, password)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /univmax/restapi/private/APIVersion/system/api_usage
+func handleAPIUsage(w http.ResponseWriter, r *http.Request) {
+	if InducedErrors.GetAPIUsageError {
+		writeError(w, "API usage not supported: induced error", http.StatusNotFound)
+		return
+	}
+	usage := &types.UnisphereAPIUsage{
+		ActiveRequests:           2,
+		MaxConcurrentRequests:    20,
+		RequestsPerMinute:        120,
+		CPUUtilizationPercent:    12.5,
+		MemoryUtilizationPercent: 40.0,
+	}
+	writeJSON(w, usage)
+}
+
 // GET /univmax/restapi/APIVersion/system/symmetrix/{id}"
 // GET /univmax/restapi/APIVersion/system/symmetrix"
+// AddAlert - Adds an alert of the given severity to the mock data cache
+func AddAlert(alertID string, severity string, description string, acknowledged bool) *types.Alert {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	now := time.Now()
+	alert := &types.Alert{
+		AlertID:                 alertID,
+		State:                   "NEW",
+		Severity:                severity,
+		Type:                    "ARRAY",
+		Object:                  DefaultSymmetrixID,
+		ObjectType:              "Array",
+		CreatedDate:             now.String(),
+		CreatedDateMilliseconds: now.UnixNano() / int64(time.Millisecond),
+		Description:             description,
+		Acknowledged:            acknowledged,
+	}
+	Data.AlertIDToAlert[alertID] = alert
+	return alert
+}
+
+// GET /univmax/restapi/API_VERSION/system/symmetrix/{symid}/alert/{id}
+// GET /univmax/restapi/API_VERSION/system/symmetrix/{symid}/alert
+func handleAlert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if InducedErrors.GetAlertError {
+		writeError(w, "Error retrieving Alert(s): induced error", http.StatusRequestTimeout)
+		return
+	}
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	alertID := mux.Vars(r)["id"]
+	if alertID != "" {
+		alert, ok := Data.AlertIDToAlert[alertID]
+		if !ok {
+			writeError(w, "Alert "+alertID+" cannot be found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, alert)
+		return
+	}
+	acknowledged := r.URL.Query().Get("acknowledged")
+	alertIDs := make([]string, 0)
+	for id, alert := range Data.AlertIDToAlert {
+		if acknowledged == "" || acknowledged == strconv.FormatBool(alert.Acknowledged) {
+			alertIDs = append(alertIDs, id)
+		}
+	}
+	sort.Strings(alertIDs)
+	writeJSON(w, &types.AlertList{AlertIDs: alertIDs})
+}
+
 func handleSymmetrix(w http.ResponseWriter, r *http.Request) {
 	if InducedErrors.GetSymmetrixError {
 		writeError(w, "Error retrieving Symmetrix: induced error", http.StatusRequestTimeout)
@@ -720,16 +1812,146 @@ func handleSymmetrix(w http.ResponseWriter, r *http.Request) {
 	id := vars["id"]
 	if id == "" {
 		returnJSONFile(Data.JSONDir, "symmetrixList.json", w, nil)
+		return
+	}
+	filename := ""
+	switch id {
+	case "000197900046":
+		filename = "symmetrix46.json"
+	case "000197900047":
+		filename = "symmetrix47.json"
+	case "000197802104":
+		filename = "symmetrix2104.json"
+	default:
+		writeError(w, "Symmetrix not found", http.StatusNotFound)
+		return
+	}
+	mockCacheMutex.Lock()
+	local, ok := Data.SymmetrixIDToLocal[id]
+	skew := Data.ClockSkew
+	mockCacheMutex.Unlock()
+	symmetrix := &types.Symmetrix{}
+	if err := json.Unmarshal(returnJSONFile(Data.JSONDir, filename, nil, nil), symmetrix); err != nil {
+		writeError(w, "Error reading Symmetrix: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if ok {
+		symmetrix.Local = local
+	}
+	symmetrix.SystemTime = time.Now().Add(skew).UnixNano() / int64(time.Millisecond)
+	writeJSON(w, symmetrix)
+}
+
+// SetSymmetrixLocal overrides whether the array symID is reported as local to the mock Unisphere
+// or as managed remotely. By default 000197802104 is remote and the other arrays are local.
+func SetSymmetrixLocal(symID string, local bool) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	Data.SymmetrixIDToLocal[symID] = local
+}
+
+// isMockSymmetrix returns true for the arrays served by the mock
+func isMockSymmetrix(symID string) bool {
+	switch symID {
+	case "000197900046", "000197900047", "000197802104":
+		return true
+	}
+	return false
+}
+
+// healthScoreMetrics are the metrics of the health score of an array
+var healthScoreMetrics = []string{"OVERALL", "CONFIGURATION", "CAPACITY", "SYSTEM_UTILIZATION", "SERVICE_LEVEL_COMPLIANCE"}
+
+// GET /univmax/restapi/APIVersion/system/symmetrix/{symid}/health
+func handleArrayHealth(w http.ResponseWriter, r *http.Request) {
+	if InducedErrors.GetArrayHealthError {
+		writeError(w, "Error retrieving the health of the Symmetrix: induced error", http.StatusRequestTimeout)
+		return
+	}
+	symID := mux.Vars(r)["symid"]
+	if !isMockSymmetrix(symID) {
+		writeError(w, "Symmetrix not found", http.StatusNotFound)
+		return
+	}
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	if health, ok := Data.SymmetrixIDToHealth[symID]; ok {
+		writeJSON(w, health)
+		return
+	}
+	health := &types.ArrayHealth{}
+	for _, metric := range healthScoreMetrics {
+		health.HealthScoreMetrics = append(health.HealthScoreMetrics, types.HealthScoreMetric{
+			Metric:      metric,
+			HealthScore: 100,
+			DataDate:    Data.ResetTime.UnixNano() / int64(time.Millisecond),
+		})
+	}
+	writeJSON(w, health)
+}
+
+// SetArrayHealth overrides the health of an array
+func SetArrayHealth(symID string, health *types.ArrayHealth) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	Data.SymmetrixIDToHealth[symID] = health
+}
+
+// GET /univmax/restapi/APIVersion/system/symmetrix/{symid}/refresh
+func handleSymmetrixRefresh(w http.ResponseWriter, r *http.Request) {
+	if InducedErrors.GetArrayHealthError {
+		writeError(w, "Error retrieving the refresh time of the Symmetrix: induced error", http.StatusRequestTimeout)
+		return
+	}
+	symID := mux.Vars(r)["symid"]
+	if !isMockSymmetrix(symID) {
+		writeError(w, "Symmetrix not found", http.StatusNotFound)
+		return
+	}
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	refreshTime, ok := Data.SymmetrixIDToRefreshTime[symID]
+	if !ok {
+		refreshTime = Data.ResetTime
+	}
+	writeJSON(w, &types.SymmetrixRefresh{LastRefreshTime: refreshTime.UnixNano() / int64(time.Millisecond)})
+}
+
+// SetArrayRefreshTime overrides the time Unisphere last refreshed an array
+func SetArrayRefreshTime(symID string, refreshTime time.Time) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	Data.SymmetrixIDToRefreshTime[symID] = refreshTime
+}
+
+// GET /univmax/restapi/APIVersion/sloprovisioning/symmetrix/{symid}/compliance/storagegroup
+// counts the storage groups by their slo_compliance
+func handleSLOCompliance(w http.ResponseWriter, r *http.Request) {
+	if InducedErrors.GetSLOComplianceError {
+		writeError(w, "Error retrieving the service level compliance: induced error", http.StatusRequestTimeout)
+		return
 	}
-	if id != "000197900046" && id != "000197900047" {
+	if !isMockSymmetrix(mux.Vars(r)["symid"]) {
 		writeError(w, "Symmetrix not found", http.StatusNotFound)
 		return
 	}
-	if id == "000197900046" {
-		returnJSONFile(Data.JSONDir, "symmetrix46.json", w, nil)
-	} else if id == "000197900047" {
-		returnJSONFile(Data.JSONDir, "symmetrix47.json", w, nil)
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	summary := &types.SLOComplianceSummary{}
+	for _, sg := range Data.StorageGroupIDToStorageGroup {
+		summary.StorageGroupCount++
+		switch sg.SLOCompliance {
+		case "STABLE":
+			summary.Stable++
+		case "MARGINAL":
+			summary.Marginal++
+		case "CRITICAL":
+			summary.Critical++
+		default:
+			summary.NoCompliance++
+		}
 	}
+	writeJSON(w, summary)
 }
 
 func handleStorageResourcePool(w http.ResponseWriter, r *http.Request) {
@@ -743,12 +1965,256 @@ func handleStorageResourcePool(w http.ResponseWriter, r *http.Request) {
 		writeError(w, "Error retrieving Storage Pool(s): induced error", http.StatusRequestTimeout)
 		return
 	}
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
 	if srpID == "" {
-		returnJSONFile(Data.JSONDir, "storageResourcePool.json", w, nil)
+		srpIDs := make([]string, 0, len(Data.StoragePoolIDToStoragePool))
+		for id := range Data.StoragePoolIDToStoragePool {
+			srpIDs = append(srpIDs, id)
+		}
+		sort.Strings(srpIDs)
+		writeJSON(w, &types.StoragePoolList{StoragePoolIDs: srpIDs})
+		return
+	}
+	pool, ok := Data.StoragePoolIDToStoragePool[srpID]
+	if !ok {
+		writeError(w, "Storage Resource Pool "+srpID+" cannot be found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, pool)
+}
+
+// StoragePoolCapacity is the capacity and efficiency of a storage resource pool of the mock
+type StoragePoolCapacity struct {
+	UsableTotalTB               float64
+	UsableUsedTB                float64
+	SubscribedTotalTB           float64
+	SubscribedAllocatedTB       float64
+	SnapshotTotalTB             float64
+	SnapshotModifiedTB          float64
+	EfficiencyRatio             float32
+	DataReductionRatio          float32
+	DataReductionEnabledPercent float32
+}
+
+// DefaultStoragePoolCapacity is the capacity of the storage resource pools of the mock after Reset
+var DefaultStoragePoolCapacity = StoragePoolCapacity{
+	UsableTotalTB:               3.42,
+	UsableUsedTB:                1.39,
+	SubscribedTotalTB:           0.4,
+	SubscribedAllocatedTB:       0.18,
+	EfficiencyRatio:             2.2,
+	DataReductionRatio:          1.6,
+	DataReductionEnabledPercent: 75,
+}
+
+// newStoragePool returns a storage resource pool of a single disk group with the given capacity
+func newStoragePool(srpID string, capacity StoragePoolCapacity) *types.StoragePool {
+	pool := &types.StoragePool{
+		StoragePoolID:    srpID,
+		DiskGrouCount:    1,
+		DiskGroupIDs:     []string{"1"},
+		Emulation:        "FBA",
+		CompressionState: "Disabled",
+		ReservedCapPerc:  10,
+		RdfaDse:          true,
+	}
+	setStoragePoolCapacity(pool, capacity)
+	return pool
+}
+
+// setStoragePoolCapacity sets the capacity and efficiency of pool, and its effective used percentage
+func setStoragePoolCapacity(pool *types.StoragePool, capacity StoragePoolCapacity) {
+	pool.SrpCap = &types.SrpCap{
+		UsableTotInTB:   capacity.UsableTotalTB,
+		UsableUsedInTB:  capacity.UsableUsedTB,
+		SubTotInTB:      capacity.SubscribedTotalTB,
+		SubAllocCapInTB: capacity.SubscribedAllocatedTB,
+		SnapTotInTB:     capacity.SnapshotTotalTB,
+		SnapModInTB:     capacity.SnapshotModifiedTB,
+	}
+	pool.SrpEfficiency = &types.SrpEfficiency{
+		EfficiencyRatioToOne:     capacity.EfficiencyRatio,
+		VirtProvSavingRatioToOne: capacity.EfficiencyRatio,
+		DataReductionRatioToOne:  capacity.DataReductionRatio,
+		DataReductionEnabledPerc: capacity.DataReductionEnabledPercent,
+	}
+	pool.EffectiveUsedCapPerc = 0
+	if capacity.UsableTotalTB > 0 {
+		pool.EffectiveUsedCapPerc = int(math.Round(capacity.UsableUsedTB / capacity.UsableTotalTB * 100))
+	}
+}
+
+// AddStoragePool - Adds a storage resource pool with the given usable capacity and efficiency to the mock data cache
+func AddStoragePool(srpID string, usableTotalTB, usableUsedTB float64, efficiencyRatio float32) (*types.StoragePool, error) {
+	return AddStoragePoolWithCapacity(srpID, StoragePoolCapacity{
+		UsableTotalTB:   usableTotalTB,
+		UsableUsedTB:    usableUsedTB,
+		EfficiencyRatio: efficiencyRatio,
+	})
+}
+
+// AddStoragePoolWithCapacity - Adds a storage resource pool with the given capacity to the mock data cache
+func AddStoragePoolWithCapacity(srpID string, capacity StoragePoolCapacity) (*types.StoragePool, error) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	if _, ok := Data.StoragePoolIDToStoragePool[srpID]; ok {
+		return nil, errors.New("Error! Storage Resource Pool already exists")
+	}
+	pool := newStoragePool(srpID, capacity)
+	Data.StoragePoolIDToStoragePool[srpID] = pool
+	Data.SRPIDToNotificationSettings[srpID] = newSRPNotificationSettings(srpID)
+	return pool, nil
+}
+
+// newSRPNotificationSettings returns the default capacity alert thresholds of a storage resource pool
+func newSRPNotificationSettings(srpID string) *types.SRPNotificationSettings {
+	return &types.SRPNotificationSettings{
+		StoragePoolID:            srpID,
+		AlertsEnabled:            true,
+		WarningThresholdPercent:  70,
+		CriticalThresholdPercent: 80,
+	}
+}
+
+// SetStoragePoolCapacity - Sets the capacity of a storage resource pool of the mock data cache
+func SetStoragePoolCapacity(srpID string, capacity StoragePoolCapacity) error {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	pool, ok := Data.StoragePoolIDToStoragePool[srpID]
+	if !ok {
+		return errors.New("Error! Storage Resource Pool not found")
+	}
+	setStoragePoolCapacity(pool, capacity)
+	return nil
+}
+
+// GET /univmax/restapi/API_VERSON/sloprovisioning/symmetrix/{id}/srp/{id}/storage_group_demand_report
+func handleSGDemandReport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	srpID := vars["id"]
+	if InducedErrors.GetSGDemandReportError {
+		writeError(w, "Error retrieving Storage Group demand report: induced error", http.StatusRequestTimeout)
+		return
+	}
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	report := &types.StorageGroupDemandReport{
+		StorageGroupDemands: make([]types.StorageGroupDemand, 0),
+	}
+	for sgID, sg := range Data.StorageGroupIDToStorageGroup {
+		if sg.SRP != srpID {
+			continue
+		}
+		demand := types.StorageGroupDemand{
+			StorageGroupID: sgID,
+			Emulation:      sg.DeviceEmulation,
+			SubscribedGB:   sg.CapacityGB,
+		}
+		for _, volID := range Data.StorageGroupIDToVolumes[sgID] {
+			if vol := Data.VolumeIDToVolume[volID]; vol != nil {
+				demand.AllocatedGB += vol.CapacityGB * float64(vol.AllocatedPercent) / 100
+			}
+		}
+		if demand.SubscribedGB > 0 {
+			demand.AllocatedPercent = demand.AllocatedGB * 100 / demand.SubscribedGB
+		}
+		report.StorageGroupDemands = append(report.StorageGroupDemands, demand)
+	}
+	writeJSON(w, report)
+}
+
+// GET, PUT /univmax/restapi/API_VERSON/sloprovisioning/symmetrix/{id}/srp/{id}/notification_settings
+func handleSRPNotificationSettings(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	srpID := vars["id"]
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	settings := Data.SRPIDToNotificationSettings[srpID]
+	if settings == nil {
+		writeError(w, "Storage Resource Pool "+srpID+" cannot be found", http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		if InducedErrors.GetSRPNotificationError {
+			writeError(w, "Error retrieving notification settings: induced error", http.StatusRequestTimeout)
+			return
+		}
+	case http.MethodPut:
+		if InducedErrors.UpdateSRPNotificationError {
+			writeError(w, "Error updating notification settings: induced error", http.StatusRequestTimeout)
+			return
+		}
+		update := &types.SRPNotificationSettings{}
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(update); err != nil {
+			writeError(w, "InvalidJson", http.StatusBadRequest)
+			return
+		}
+		if update.WarningThresholdPercent <= 0 || update.CriticalThresholdPercent > 100 ||
+			update.WarningThresholdPercent >= update.CriticalThresholdPercent {
+			writeError(w, "Invalid thresholds: the warning threshold must be lower than the critical threshold", http.StatusBadRequest)
+			return
+		}
+		settings.AlertsEnabled = update.AlertsEnabled
+		settings.WarningThresholdPercent = update.WarningThresholdPercent
+		settings.CriticalThresholdPercent = update.CriticalThresholdPercent
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, settings)
+}
+
+// GET /univmax/restapi/performance/Array/register?symmetrixId={id}
+// POST /univmax/restapi/performance/Array/register
+func handlePerfRegistration(w http.ResponseWriter, r *http.Request) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		if InducedErrors.GetPerfRegistrationError {
+			writeError(w, "Error retrieving performance registration: induced error", http.StatusRequestTimeout)
+			return
+		}
+		registrations := &types.PerformanceRegistrationList{
+			RegistrationDetailsInfo: make([]types.PerformanceRegistration, 0),
+		}
+		symID := r.URL.Query().Get("symmetrixId")
+		if registration := Data.SymmetrixIDToPerfRegistration[symID]; registration != nil {
+			registrations.RegistrationDetailsInfo = append(registrations.RegistrationDetailsInfo, *registration)
+		}
+		writeJSON(w, registrations)
+	case http.MethodPost:
+		if InducedErrors.RegisterPerformanceError {
+			writeError(w, "Error registering for performance data: induced error", http.StatusRequestTimeout)
+			return
+		}
+		param := &types.RegisterPerformanceParam{}
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(param); err != nil {
+			writeError(w, "InvalidJson", http.StatusBadRequest)
+			return
+		}
+		if param.SymmetrixID == "" {
+			writeError(w, "symmetrixId is required", http.StatusBadRequest)
+			return
+		}
+		registration := Data.SymmetrixIDToPerfRegistration[param.SymmetrixID]
+		if registration == nil {
+			registration = &types.PerformanceRegistration{
+				SymmetrixID:            param.SymmetrixID,
+				CollectionIntervalMins: 5,
+			}
+			Data.SymmetrixIDToPerfRegistration[param.SymmetrixID] = registration
+		}
+		registration.Diagnostic = registration.Diagnostic || param.Diagnostic
+		registration.RealTime = registration.RealTime || param.RealTime
+		registration.Message = "Success"
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
-	replacements := make(map[string]string)
-	replacements["__SRP_ID__"] = "SRP_1"
-	returnJSONFile(Data.JSONDir, "storage_pool_template.json", w, replacements)
 }
 
 // GET /univmax/restapi/API_VERSON/sloprovisioning/symmetrix/{id}/volume/{id}
@@ -773,9 +2239,16 @@ func handleVolume(w http.ResponseWriter, r *http.Request) {
 				like = true
 				volumeIdentifier = strings.TrimPrefix(volumeIdentifier, "<like>")
 			}
-			// Copy data to Data.VolumeIDIteratorList, while checking for volumeIdentifier match if needed
+			storageGroupID := queryParams.Get("storageGroupId")
+			// Copy data to Data.VolumeIDIteratorList, while checking for volumeIdentifier and storageGroupId match if needed
 			Data.VolumeIDIteratorList = make([]string, 0)
 			for _, vol := range Data.VolumeIDToVolume {
+				if storageGroupID != "" && !volumeInStorageGroup(vol, storageGroupID) {
+					continue
+				}
+				if !volumeMatchesQuery(vol, queryParams) {
+					continue
+				}
 				if volumeIdentifier != "" {
 					if like {
 						if !strings.Contains(vol.VolumeIdentifier, volumeIdentifier) {
@@ -873,10 +2346,62 @@ func handleVolume(w http.ResponseWriter, r *http.Request) {
 			writeError(w, "Error deleting Volume: induced error - device is a member of a storage group", http.StatusForbidden)
 			return
 		}
+		if InducedErrors.DeviceInSGErrorCount > 0 {
+			InducedErrors.DeviceInSGErrorCount--
+			writeError(w, "Error deleting Volume: induced error - device is a member of a storage group", http.StatusForbidden)
+			return
+		}
 		DeleteVolume(volID)
 	}
 }
 
+// volumeMatchesQuery returns true if vol matches the allocated_percent, status, emulation and tdev query params
+func volumeMatchesQuery(vol *types.Volume, queryParams url.Values) bool {
+	if allocated := queryParams.Get("allocated_percent"); allocated != "" {
+		percent, err := strconv.Atoi(strings.TrimLeft(allocated, "<>="))
+		if err != nil {
+			return false
+		}
+		switch allocated[0] {
+		case '<':
+			if vol.AllocatedPercent >= percent {
+				return false
+			}
+		case '>':
+			if vol.AllocatedPercent <= percent {
+				return false
+			}
+		default:
+			if vol.AllocatedPercent != percent {
+				return false
+			}
+		}
+	}
+	if status := queryParams.Get("status"); status != "" && vol.Status != status {
+		return false
+	}
+	if emulation := queryParams.Get("emulation"); emulation != "" && vol.Emulation != emulation {
+		return false
+	}
+	if tdev := queryParams.Get("tdev"); tdev != "" && strconv.FormatBool(strings.Contains(vol.Type, "TDEV")) != tdev {
+		return false
+	}
+	return true
+}
+
+// SetVolumeAllocation sets the allocated percentage and the status, e.g. "Ready" or "Not Ready", of a volume.
+func SetVolumeAllocation(volID string, allocatedPercent int, status string) error {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	vol := Data.VolumeIDToVolume[volID]
+	if vol == nil {
+		return fmt.Errorf("volume %s not found", volID)
+	}
+	vol.AllocatedPercent = allocatedPercent
+	vol.Status = status
+	return nil
+}
+
 // DeleteVolume - Deletes volume from cache
 func DeleteVolume(volID string) error {
 	mockCacheMutex.Lock()
@@ -891,6 +2416,7 @@ func deleteVolume(volID string) error {
 			return errors.New("Volume present in storage group. Can't be deleted")
 		}
 		Data.VolumeIDToVolume[volID] = nil
+		delete(Data.WWNToVolumeID, vol.WWN)
 	} else {
 		return errors.New("Volume not found")
 	}
@@ -899,7 +2425,7 @@ func deleteVolume(volID string) error {
 
 func returnVolume(w http.ResponseWriter, volID string, remote bool) {
 	if volID != "" {
-		if vol, ok := Data.VolumeIDToVolume[volID]; ok {
+		if vol, ok := Data.VolumeIDToVolume[volID]; ok && vol != nil {
 			newVol := new(types.Volume)
 			err := copier.Copy(newVol, vol)
 			if err != nil {
@@ -928,6 +2454,7 @@ func returnVolume(w http.ResponseWriter, volID string, remote bool) {
 					newVol.VolumeIdentifier = ""
 				}
 			}
+			newVol.StorageGroups = volumeStorageGroups(newVol.StorageGroupIDList)
 			writeJSON(w, newVol)
 			return
 		}
@@ -935,6 +2462,19 @@ func returnVolume(w http.ResponseWriter, volID string, remote bool) {
 	}
 }
 
+// volumeStorageGroups returns the storage_groups of a volume in the storage groups sgList
+func volumeStorageGroups(sgList []string) []types.VolumeStorageGroup {
+	var storageGroups []types.VolumeStorageGroup
+	for _, sgID := range sgList {
+		storageGroup := types.VolumeStorageGroup{StorageGroupName: sgID}
+		if sg, ok := Data.StorageGroupIDToStorageGroup[sgID]; ok && len(sg.ParentStorageGroup) > 0 {
+			storageGroup.ParentStorageGroupName = sg.ParentStorageGroup[0]
+		}
+		storageGroups = append(storageGroups, storageGroup)
+	}
+	return storageGroups
+}
+
 // FreeVolume - handler for free volume job
 func FreeVolume(w http.ResponseWriter, param *types.FreeVolumeParam, volID string, executionOption string) {
 	mockCacheMutex.Lock()
@@ -971,7 +2511,19 @@ func renameVolume(w http.ResponseWriter, param *types.ModifyVolumeIdentifierPara
 		writeError(w, "expected SYNCHRONOUS", http.StatusBadRequest)
 		return
 	}
-	Data.VolumeIDToVolume[volID].VolumeIdentifier = param.VolumeIdentifier.IdentifierName
+	switch param.VolumeIdentifier.VolumeIdentifierChoice {
+	case types.VolumeIdentifierChoiceNone:
+		Data.VolumeIDToVolume[volID].VolumeIdentifier = ""
+	case types.VolumeIdentifierChoiceName:
+		if param.VolumeIdentifier.IdentifierName == "" {
+			writeError(w, "identifier_name is required", http.StatusBadRequest)
+			return
+		}
+		Data.VolumeIDToVolume[volID].VolumeIdentifier = param.VolumeIdentifier.IdentifierName
+	default:
+		writeError(w, "Invalid volumeIdentifierChoice: "+param.VolumeIdentifier.VolumeIdentifierChoice, http.StatusBadRequest)
+		return
+	}
 	returnVolume(w, volID, remote)
 }
 
@@ -1020,6 +2572,8 @@ type JobInfo struct {
 	Job          types.Job
 	InitialState string
 	FinalState   string
+	// Created is when the job was scheduled; jobs expire JobExpiry after it
+	Created time.Time
 }
 
 // NewMockJob creates a JobInfo that can be queried
@@ -1036,32 +2590,163 @@ func newMockJob(jobID string, initialState string, finalState string, resourceLi
 	job.FinalState = finalState
 	job.Job.Status = "SCHEDULED"
 	job.Job.ResourceLink = resourceLink
+	setJobCreated(job, time.Now())
 	Data.JobIDToMockJob[jobID] = job
 	return job
 }
 
-func handleJob(w http.ResponseWriter, r *http.Request) {
-	if InducedErrors.GetJobError {
-		writeError(w, "Error getting Job(s): induced error", http.StatusRequestTimeout)
-		return
-	}
-	vars := mux.Vars(r)
+func setJobCreated(job *JobInfo, created time.Time) {
+	job.Created = created
+	job.Job.ScheduledDate = created.String()
+	job.Job.ScheduledMilliseconds = created.UnixNano() / int64(time.Millisecond)
+}
+
+// SetJobCreated backdates (or postdates) when a mock job was scheduled, to test the
+// scheduled_date filters and the expiry of jobs without waiting
+func SetJobCreated(jobID string, created time.Time) error {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	job := Data.JobIDToMockJob[jobID]
+	if job == nil {
+		return fmt.Errorf("Job %s not found", jobID)
+	}
+	setJobCreated(job, created)
+	return nil
+}
+
+// SetJobName names a mock job, as Unisphere names the jobs after their operation
+func SetJobName(jobID string, name string) error {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	job := Data.JobIDToMockJob[jobID]
+	if job == nil {
+		return fmt.Errorf("Job %s not found", jobID)
+	}
+	job.Job.Name = name
+	return nil
+}
+
+// SetJobExpiry makes the completed jobs disappear once they were scheduled longer than
+// expiry ago, as Unisphere prunes its job history. Zero, the default, keeps them forever.
+func SetJobExpiry(expiry time.Duration) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	Data.JobExpiry = expiry
+}
+
+// pruneJobs removes the completed jobs which expired
+func pruneJobs() {
+	if Data.JobExpiry <= 0 {
+		return
+	}
+	oldest := time.Now().Add(-Data.JobExpiry)
+	for jobID, job := range Data.JobIDToMockJob {
+		switch job.Job.Status {
+		case types.JobStatusSucceeded, types.JobStatusFailed:
+			if job.Created.Before(oldest) {
+				delete(Data.JobIDToMockJob, jobID)
+			}
+		}
+	}
+}
+
+// returnJobIDList writes the IDs of the jobs, oldest first, filtered by status and by
+// scheduled_date, whose values are a number of milliseconds since the epoch prefixed by
+// > or < (e.g. scheduled_date=>1600000000000&scheduled_date=<1600000060000) or alone for
+// an exact match. The jobs can also be filtered by name and by resourceLink, whose values
+// match exactly or, prefixed by <like>, anywhere in the field (e.g. resourceLink=<like>/volume/).
+// The list can be paged with page_size and page (from 1).
+func returnJobIDList(w http.ResponseWriter, query url.Values) {
+	pruneJobs()
+	status := query.Get("status")
+	var after, before, exact int64 = math.MinInt64, math.MaxInt64, -1
+	for _, value := range query["scheduled_date"] {
+		operator := ""
+		if strings.HasPrefix(value, ">") || strings.HasPrefix(value, "<") {
+			operator, value = value[:1], value[1:]
+		}
+		ms, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			writeError(w, "Invalid scheduled_date filter: "+value, http.StatusBadRequest)
+			return
+		}
+		switch operator {
+		case ">":
+			after = ms
+		case "<":
+			before = ms
+		default:
+			exact = ms
+		}
+	}
+	jobs := make([]*JobInfo, 0)
+	for _, job := range Data.JobIDToMockJob {
+		ms := job.Job.ScheduledMilliseconds
+		if (status != "" && status != job.Job.Status) || ms <= after || ms >= before || (exact >= 0 && ms != exact) {
+			continue
+		}
+		if !jobFieldMatches(job.Job.Name, query.Get("name")) || !jobFieldMatches(job.Job.ResourceLink, query.Get("resourceLink")) {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].Created.Equal(jobs[j].Created) {
+			return jobs[i].Created.Before(jobs[j].Created)
+		}
+		return jobs[i].Job.JobID < jobs[j].Job.JobID
+	})
+	if query.Get("page_size") != "" {
+		pageSize, err := strconv.Atoi(query.Get("page_size"))
+		if err != nil || pageSize < 1 {
+			writeError(w, "Invalid page_size: "+query.Get("page_size"), http.StatusBadRequest)
+			return
+		}
+		page := 1
+		if query.Get("page") != "" {
+			if page, err = strconv.Atoi(query.Get("page")); err != nil || page < 1 {
+				writeError(w, "Invalid page: "+query.Get("page"), http.StatusBadRequest)
+				return
+			}
+		}
+		from := (page - 1) * pageSize
+		if from > len(jobs) {
+			from = len(jobs)
+		}
+		to := from + pageSize
+		if to > len(jobs) {
+			to = len(jobs)
+		}
+		jobs = jobs[from:to]
+	}
+	jobIDList := &types.JobIDList{JobIDs: make([]string, 0, len(jobs))}
+	for _, job := range jobs {
+		jobIDList.JobIDs = append(jobIDList.JobIDs, job.Job.JobID)
+	}
+	writeJSON(w, jobIDList)
+}
+
+func handleJob(w http.ResponseWriter, r *http.Request) {
+	if InducedErrors.GetJobError {
+		writeError(w, "Error getting Job(s): induced error", http.StatusRequestTimeout)
+		return
+	}
+	vars := mux.Vars(r)
 	jobID := vars["jobID"]
+	if r.Method == http.MethodDelete {
+		if InducedErrors.CancelJobError {
+			writeError(w, "Error cancelling Job: induced error", http.StatusBadRequest)
+			return
+		}
+		mockCacheMutex.Lock()
+		defer mockCacheMutex.Unlock()
+		cancelJob(w, jobID)
+		return
+	}
 	if jobID == "" {
-		queryParams := r.URL.Query()
-		// Return a job id list
-		jobIDList := new(types.JobIDList)
-		jobIDList.JobIDs = make([]string, 0)
 		mockCacheMutex.Lock()
 		defer mockCacheMutex.Unlock()
-		for key := range Data.JobIDToMockJob {
-			job := Data.JobIDToMockJob[key].Job
-			if queryParams.Get("status") == "" || queryParams.Get("status") == job.Status {
-				jobIDList.JobIDs = append(jobIDList.JobIDs, key)
-			}
-		}
-		encoder := json.NewEncoder(w)
-		encoder.Encode(jobIDList)
+		returnJobIDList(w, r.URL.Query())
 		return
 	}
 	// Return a specific job
@@ -1073,6 +2758,43 @@ func handleJob(w http.ResponseWriter, r *http.Request) {
 	ReturnJobByID(w, jobID)
 }
 
+// jobFieldMatches returns true if the filter is empty, equals value or, prefixed by <like>, is in value
+func jobFieldMatches(value, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	if strings.HasPrefix(filter, "<like>") {
+		return strings.Contains(value, strings.TrimPrefix(filter, "<like>"))
+	}
+	return value == filter
+}
+
+// cancelJob deletes a job which is not running from the mock cache
+func cancelJob(w http.ResponseWriter, jobID string) {
+	job := Data.JobIDToMockJob[jobID]
+	if job == nil {
+		writeError(w, "Job not found: "+jobID, http.StatusNotFound)
+		return
+	}
+	if job.Job.Status == types.JobStatusRunning {
+		writeError(w, "Job "+jobID+" is running and cannot be cancelled", http.StatusBadRequest)
+		return
+	}
+	delete(Data.JobIDToMockJob, jobID)
+}
+
+// returnCreationJob - Returns the job of an asynchronous creation, the resource is created by create
+// unless InducedErrors.JobFailedError is set, in which case the job fails
+func returnCreationJob(w http.ResponseWriter, jobID string, resourceLink string, create func()) {
+	if InducedErrors.JobFailedError {
+		NewMockJob(jobID, types.JobStatusRunning, types.JobStatusFailed, resourceLink)
+	} else {
+		create()
+		NewMockJob(jobID, types.JobStatusRunning, types.JobStatusSucceeded, resourceLink)
+	}
+	ReturnJobByID(w, jobID)
+}
+
 // ReturnJobByID - Returns job based on ID from mock cache
 func ReturnJobByID(w http.ResponseWriter, jobID string) {
 	mockCacheMutex.Lock()
@@ -1081,6 +2803,7 @@ func ReturnJobByID(w http.ResponseWriter, jobID string) {
 }
 
 func returnJobByID(w http.ResponseWriter, jobID string) {
+	pruneJobs()
 	job := Data.JobIDToMockJob[jobID]
 	if job == nil {
 		// Not found
@@ -1088,8 +2811,10 @@ func returnJobByID(w http.ResponseWriter, jobID string) {
 		return
 	}
 	if job.Job.Status == job.InitialState {
+		now := time.Now()
 		job.Job.Status = job.FinalState
-		job.Job.CompletedDate = time.Now().String()
+		job.Job.CompletedDate = now.String()
+		job.Job.CompletedMilliseconds = now.UnixNano() / int64(time.Millisecond)
 		job.Job.Result = "Mock job completed"
 	} else {
 		job.Job.Status = job.InitialState
@@ -1124,7 +2849,23 @@ func handleIterator(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			writeError(w, "bad from query parameter", http.StatusBadRequest)
 		}
-		for i := result.From - 1; i < result.To-1; i++ {
+		if vars["iterId"] == "PrivVolume" {
+			writeJSON(w, returnPrivVolumePage(result.From, result.To))
+			return
+		}
+		if vars["iterId"] == "MaskingViewConnection" {
+			writeJSON(w, returnConnectionPage(result.From, result.To))
+			return
+		}
+		if ids, ok := Data.IDIteratorLists[vars["iterId"]]; ok {
+			writeJSON(w, idPage(vars["iterId"], ids, result.From, result.To))
+			return
+		}
+		if vars["iterId"] != "Volume" {
+			writeError(w, "Cannot find iterator "+vars["iterId"], http.StatusNotFound)
+			return
+		}
+		for i := result.From - 1; i <= result.To-1 && i < len(Data.VolumeIDIteratorList); i++ {
 			volIDList := types.VolumeIDList{VolumeIDs: Data.VolumeIDIteratorList[i]}
 			result.VolumeList = append(result.VolumeList, volIDList)
 		}
@@ -1141,6 +2882,96 @@ func handleIterator(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// updateStorageGroup applies the PUT payload of a storage group update
+func updateStorageGroup(w http.ResponseWriter, r *http.Request, sgID, apiversion string) {
+	decoder := json.NewDecoder(r.Body)
+	if apiversion == "90" {
+		updateSGPayload := &types.UpdateStorageGroupPayload{}
+		err := decoder.Decode(updateSGPayload)
+		if err != nil {
+			writeError(w, "problem decoding PUT StorageGroup payload: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Printf("PUT StorageGroup payload: %#v\n", updateSGPayload)
+		editPayload := updateSGPayload.EditStorageGroupActionParam
+		if editPayload.ExpandStorageGroupParam != nil {
+			expandPayload := editPayload.ExpandStorageGroupParam
+			if param := expandPayload.AddExistingStorageGroupParam; param != nil {
+				AddChildStorageGroups(w, sgID, param.StorageGroupIDs)
+			}
+			addVolumeParam := expandPayload.AddVolumeParam
+			if addVolumeParam != nil {
+				name := addVolumeParam.VolumeIdentifier.IdentifierName
+				size := addVolumeParam.VolumeAttribute.VolumeSize
+				AddVolumeToStorageGroupTest(w, name, size, sgID)
+			}
+			addSpecificVolumeParam := expandPayload.AddSpecificVolumeParam
+			if addSpecificVolumeParam != nil {
+				AddSpecificVolumeToStorageGroup(w, addSpecificVolumeParam.VolumeIDs, sgID)
+			}
+		}
+		if editPayload.RemoveVolumeParam != nil {
+			RemoveVolumeFromStorageGroup(w, editPayload.RemoveVolumeParam.VolumeIDs, sgID)
+
+		}
+		if editPayload.RemoveStorageGroupParam != nil {
+			RemoveChildStorageGroups(w, sgID, editPayload.RemoveStorageGroupParam.StorageGroupIDs)
+		}
+		if editPayload.EditSnapshotPoliciesParam != nil {
+			EditSnapshotPolicies(w, sgID, editPayload.EditSnapshotPoliciesParam)
+		}
+	} else {
+		// for apiVersion 91
+		updateSGPayload := &types91.UpdateStorageGroupPayload{}
+		err := decoder.Decode(updateSGPayload)
+		if err != nil {
+			writeError(w, "problem decoding PUT StorageGroup payload: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Printf("PUT StorageGroup payload: %#v\n", updateSGPayload)
+		editPayload := updateSGPayload.EditStorageGroupActionParam
+		if editPayload.ExpandStorageGroupParam != nil {
+			expandPayload := editPayload.ExpandStorageGroupParam
+			if param := expandPayload.AddExistingStorageGroupParam; param != nil {
+				AddChildStorageGroups(w, sgID, param.StorageGroupIDs)
+			}
+			addVolumeParam := expandPayload.AddVolumeParam
+			if addVolumeParam != nil && !addVolumeParam.CreateNewVolumes {
+				AddExistingVolumesToStorageGroup(w, addVolumeParam.Emulation, addVolumeParam.VolumeAttributes[0], sgID)
+			} else if addVolumeParam != nil {
+				name := addVolumeParam.VolumeAttributes[0].VolumeIdentifier.IdentifierName
+				size := addVolumeParam.VolumeAttributes[0].VolumeSize
+				AddVolumeToStorageGroupTest(w, name, size, sgID)
+			}
+			addSpecificVolumeParam := expandPayload.AddSpecificVolumeParam
+			if addSpecificVolumeParam != nil {
+				AddSpecificVolumeToStorageGroup(w, addSpecificVolumeParam.VolumeIDs, sgID)
+			}
+		}
+		if editPayload.RemoveVolumeParam != nil {
+			RemoveVolumeFromStorageGroup(w, editPayload.RemoveVolumeParam.VolumeIDs, sgID)
+		}
+		if editPayload.RemoveStorageGroupParam != nil {
+			RemoveChildStorageGroups(w, sgID, editPayload.RemoveStorageGroupParam.StorageGroupIDs)
+		}
+		if param := editPayload.EditSnapshotPoliciesParam; param != nil {
+			EditSnapshotPolicies(w, sgID, &types.EditSnapshotPoliciesParam{
+				AssociateSnapshotPolicyParam:    (*types.SnapshotPolicyNamesParam)(param.AssociateSnapshotPolicyParam),
+				DisassociateSnapshotPolicyParam: (*types.SnapshotPolicyNamesParam)(param.DisassociateSnapshotPolicyParam),
+			})
+		}
+	}
+}
+
+// remoteStorageGroupErrors returns the induced errors of the remote array of a storage group update
+func remoteStorageGroupErrors(sgID string) []types.RemoteSymmetrixError {
+	return []types.RemoteSymmetrixError{{
+		SymmetrixID:    Data.RDFGroup.RemoteSymmetrix,
+		StorageGroupID: sgID,
+		Message:        "Error updating remote Storage Group: induced error",
+	}}
+}
+
 // /univmax/restapi/90/sloprovisioning/symmetrix/{symid}/storagegroup/{id}
 // /univmax/restapi/90/sloprovisioning/symmetrix/{symid}/storagegroup
 // /univmax/restapi/91/sloprovisioning/symmetrix/{symid}/storagegroup/{id}
@@ -1155,6 +2986,14 @@ func handleStorageGroup(w http.ResponseWriter, r *http.Request) {
 			writeError(w, "Error retrieving Storage Group(s): induced error", http.StatusRequestTimeout)
 			return
 		}
+		if expand := r.URL.Query().Get("expand"); expand != "" && sgID != "" {
+			if InducedErrors.VolumeExpansionUnsupported || expand != "volumes" {
+				writeError(w, "Invalid query parameter: expand", http.StatusBadRequest)
+				return
+			}
+			returnStorageGroupWithVolumes(w, sgID)
+			return
+		}
 		if vars["symid"] == Data.RDFGroup.RemoteSymmetrix && strings.Contains(sgID, "rep") {
 			ReturnStorageGroup(w, sgID, true)
 		} else {
@@ -1170,60 +3009,27 @@ func handleStorageGroup(w http.ResponseWriter, r *http.Request) {
 			writeError(w, "storage group ID must be supplied", http.StatusBadRequest)
 			return
 		}
-		decoder := json.NewDecoder(r.Body)
-		if apiversion == "90" {
-			updateSGPayload := &types.UpdateStorageGroupPayload{}
-			err := decoder.Decode(updateSGPayload)
-			if err != nil {
-				writeError(w, "problem decoding PUT StorageGroup payload: "+err.Error(), http.StatusBadRequest)
-				return
-			}
-			fmt.Printf("PUT StorageGroup payload: %#v\n", updateSGPayload)
-			editPayload := updateSGPayload.EditStorageGroupActionParam
-			if editPayload.ExpandStorageGroupParam != nil {
-				expandPayload := editPayload.ExpandStorageGroupParam
-				addVolumeParam := expandPayload.AddVolumeParam
-				if addVolumeParam != nil {
-					name := addVolumeParam.VolumeIdentifier.IdentifierName
-					size := addVolumeParam.VolumeAttribute.VolumeSize
-					AddVolumeToStorageGroupTest(w, name, size, sgID)
-				}
-				addSpecificVolumeParam := expandPayload.AddSpecificVolumeParam
-				if addSpecificVolumeParam != nil {
-					AddSpecificVolumeToStorageGroup(w, addSpecificVolumeParam.VolumeIDs, sgID)
-				}
-			}
-			if editPayload.RemoveVolumeParam != nil {
-				RemoveVolumeFromStorageGroup(w, editPayload.RemoveVolumeParam.VolumeIDs, sgID)
-
-			}
-		} else {
-			// for apiVersion 91
-			updateSGPayload := &types91.UpdateStorageGroupPayload{}
-			err := decoder.Decode(updateSGPayload)
-			if err != nil {
-				writeError(w, "problem decoding PUT StorageGroup payload: "+err.Error(), http.StatusBadRequest)
+		if InducedErrors.UpdateLocalAndRemoteSGError {
+			w.WriteHeader(http.StatusInternalServerError)
+			writeJSON(w, &types.Error{
+				Message:               "Error updating Storage Group: induced error",
+				RemoteSymmetrixErrors: remoteStorageGroupErrors(sgID),
+			})
+			return
+		}
+		if InducedErrors.UpdateRemoteStorageGroupError {
+			// apply the update locally, then report that it failed on the remote array
+			rec := httptest.NewRecorder()
+			updateStorageGroup(rec, r, sgID, apiversion)
+			if rec.Code < 200 || rec.Code > 299 {
+				w.WriteHeader(rec.Code)
+				w.Write(rec.Body.Bytes())
 				return
 			}
-			fmt.Printf("PUT StorageGroup payload: %#v\n", updateSGPayload)
-			editPayload := updateSGPayload.EditStorageGroupActionParam
-			if editPayload.ExpandStorageGroupParam != nil {
-				expandPayload := editPayload.ExpandStorageGroupParam
-				addVolumeParam := expandPayload.AddVolumeParam
-				if addVolumeParam != nil {
-					name := addVolumeParam.VolumeAttributes[0].VolumeIdentifier.IdentifierName
-					size := addVolumeParam.VolumeAttributes[0].VolumeSize
-					AddVolumeToStorageGroupTest(w, name, size, sgID)
-				}
-				addSpecificVolumeParam := expandPayload.AddSpecificVolumeParam
-				if addSpecificVolumeParam != nil {
-					AddSpecificVolumeToStorageGroup(w, addSpecificVolumeParam.VolumeIDs, sgID)
-				}
-			}
-			if editPayload.RemoveVolumeParam != nil {
-				RemoveVolumeFromStorageGroup(w, editPayload.RemoveVolumeParam.VolumeIDs, sgID)
-			}
+			writeJSON(w, &types.StorageGroupUpdateResult{RemoteSymmetrixErrors: remoteStorageGroupErrors(sgID)})
+			return
 		}
+		updateStorageGroup(w, r, sgID, apiversion)
 	case http.MethodPost:
 		if InducedErrors.CreateStorageGroupError {
 			writeError(w, "Error creating Storage Group: induced error", http.StatusRequestTimeout)
@@ -1267,48 +3073,181 @@ func handleStorageGroup(w http.ResponseWriter, r *http.Request) {
 func handleMaskingViewConnections(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		queryParams := r.URL.Query()
-		volID := queryParams.Get("volume_id")
 		if InducedErrors.GetMaskingViewConnectionsError {
 			writeError(w, "Error retrieving Masking View Connections: induced error", http.StatusRequestTimeout)
 			return
 		}
-		if volID == "" {
-			// Return a response for all volumes
-			index := 1
-			result := &types.MaskingViewConnectionsResult{
-				MaskingViewConnections: make([]*types.MaskingViewConnection, 0),
+		query := r.URL.Query()
+		mockCacheMutex.Lock()
+		defer mockCacheMutex.Unlock()
+		vars := mux.Vars(r)
+		returnMaskingViewConnections(w, vars["symid"], vars["mvID"], query.Get("volume_id"), query.Get("initiator_id"))
+
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// returnMaskingViewConnections returns a connection for every combination of a volume of the
+// masking view's storage group, a port of its port group and an initiator of its host.
+// If volID or initID is not empty, only the connections of that volume or initiator are returned.
+// The connections of NVMe host NQNs expose the volume as the namespace ID following its LUN address.
+// The connections which do not fit in a page of Data.ConnectionPageSize are returned as an iterator.
+func returnMaskingViewConnections(w http.ResponseWriter, symID, mvID, volID, initID string) {
+	connections, ok := maskingViewConnections(symID, mvID, volID, initID)
+	if !ok {
+		writeError(w, "Masking View cannot be found", http.StatusNotFound)
+		return
+	}
+	if Data.ConnectionPageSize == 0 || len(connections) <= Data.ConnectionPageSize {
+		writeJSON(w, &types.MaskingViewConnectionsResult{MaskingViewConnections: connections})
+		return
+	}
+	Data.ConnectionIteratorList = connections
+	iter := &types.MaskingViewConnectionIterator{
+		ID:             "MaskingViewConnection",
+		Count:          len(connections),
+		ExpirationTime: 1576137450163,
+		MaxPageSize:    Data.ConnectionPageSize,
+	}
+	iter.ResultList = returnConnectionPage(1, iter.MaxPageSize)
+	writeJSON(w, iter)
+}
+
+// returnConnectionPage returns the connections of the masking view connection iterator from from to to
+func returnConnectionPage(from, to int) types.MaskingViewConnectionResultList {
+	if to > len(Data.ConnectionIteratorList) {
+		to = len(Data.ConnectionIteratorList)
+	}
+	result := types.MaskingViewConnectionResultList{MaskingViewConnections: make([]*types.MaskingViewConnection, 0), From: from, To: to}
+	for i := from - 1; i < to; i++ {
+		result.MaskingViewConnections = append(result.MaskingViewConnections, Data.ConnectionIteratorList[i])
+	}
+	return result
+}
+
+// maskingViewConnections returns the connections of a masking view as described by
+// returnMaskingViewConnections, or false if the masking view does not exist
+func maskingViewConnections(symID, mvID, volID, initID string) ([]*types.MaskingViewConnection, bool) {
+	mv, ok := Data.MaskingViewIDToMaskingView[mvID]
+	if !ok {
+		return nil, false
+	}
+	dirPorts := make([]string, 0)
+	if pg, ok := Data.PortGroupIDToPortGroup[mv.PortGroupID]; ok {
+		for _, key := range pg.SymmetrixPortKey {
+			// port groups added by AddPortGroup use "<director>:<port>" as the port ID
+			if strings.Contains(key.PortID, ":") {
+				dirPorts = append(dirPorts, key.PortID)
+			} else {
+				dirPorts = append(dirPorts, key.DirectorID+":"+key.PortID)
 			}
-			for id, _ := range Data.VolumeIDToVolume {
-				conn1 := &types.MaskingViewConnection{
-					VolumeID:       id,
-					HostLUNAddress: fmt.Sprintf("%4d", index),
-					CapacityGB:     "0.1",
-					InitiatorID:    "iqn.1993-08.org.debian:01:8f21cc8ad2a7",
-					DirectorPort:   "SE-1E:000",
-					LoggedIn:       false,
-					OnFabric:       true,
+		}
+	}
+	initiators := make([]*types.Initiator, 0)
+	if host, ok := Data.HostIDToHost[mv.HostID]; ok {
+		for _, initID := range host.Initiators {
+			initiator := &types.Initiator{InitiatorID: initID, OnFabric: true}
+			for k, v := range Data.InitiatorIDToInitiator {
+				if v.InitiatorID == initID {
+					advanceInitiatorLogin(k)
+					initiator = v
+					break
+				}
+			}
+			initiators = append(initiators, initiator)
+		}
+	}
+	volumeIDs := make([]string, len(Data.StorageGroupIDToVolumes[mv.StorageGroupID]))
+	copy(volumeIDs, Data.StorageGroupIDToVolumes[mv.StorageGroupID])
+	sort.Strings(volumeIDs)
+
+	connections := make([]*types.MaskingViewConnection, 0)
+	for index, id := range volumeIDs {
+		if volID != "" && id != volID {
+			continue
+		}
+		capacity := ""
+		if vol, ok := Data.VolumeIDToVolume[id]; ok {
+			capacity = strconv.FormatFloat(vol.CapacityGB, 'f', -1, 64)
+		}
+		for _, dirPort := range dirPorts {
+			lunAddress := maskingViewLUNAddress(mvID, id, index)
+			if lun, ok := Data.MaskingViewIDToPathLUNs[mvID][id+"/"+dirPort]; ok {
+				if lun == "" {
+					continue
+				}
+				lunAddress = lun
+			}
+			for _, initiator := range initiators {
+				if initID != "" && initiator.InitiatorID != initID {
+					continue
 				}
-				result.MaskingViewConnections = append(result.MaskingViewConnections, conn1)
-				conn2 := &types.MaskingViewConnection{
+				connection := &types.MaskingViewConnection{
 					VolumeID:       id,
-					HostLUNAddress: fmt.Sprintf("%4d", index),
-					CapacityGB:     "0.1",
-					InitiatorID:    "iqn.1993-08.org.debian:01:8f21cc8ad2a7",
-					DirectorPort:   "SE-2E:000",
-					LoggedIn:       false,
-					OnFabric:       true,
+					HostLUNAddress: lunAddress,
+					CapacityGB:     capacity,
+					InitiatorID:    initiator.InitiatorID,
+					DirectorPort:   dirPort,
+					LoggedIn:       initiator.LoggedIn,
+					OnFabric:       initiator.OnFabric,
 				}
-				result.MaskingViewConnections = append(result.MaskingViewConnections, conn2)
-				index++
+				if strings.HasPrefix(initiator.InitiatorID, "nqn.") {
+					if lun, err := strconv.ParseUint(lunAddress, 16, 32); err == nil {
+						connection.NamespaceID = strconv.FormatUint(lun+1, 10)
+					}
+					connection.SubsystemNQN = "nqn.1988-11.com.dell:PowerMax:00:" + symID
+				}
+				connections = append(connections, connection)
 			}
-			writeJSON(w, result)
-			return
 		}
-		replacements := make(map[string]string)
-		replacements["__VOLUME_ID__"] = volID
-		returnJSONFile(Data.JSONDir, "masking_view_connections_template.json", w, replacements)
 	}
+	return connections, true
+}
+
+// maskingViewLUNAddress returns the host LUN address of the index'th volume (in volume ID order)
+// of a masking view. Unless set by SetMaskingViewLUNAddress, the addresses are assigned
+// consecutively from the starting LUN address of the masking view, which defaults to 1.
+func maskingViewLUNAddress(mvID, volID string, index int) string {
+	if lun, ok := Data.MaskingViewIDToLUNAddresses[mvID][volID]; ok {
+		return lun
+	}
+	start := uint64(1)
+	if startingLUN, ok := Data.MaskingViewIDToStartingLUN[mvID]; ok {
+		if lun, err := strconv.ParseUint(startingLUN, 16, 32); err == nil {
+			start = lun
+		}
+	}
+	return fmt.Sprintf("%04X", start+uint64(index))
+}
+
+// SetMaskingViewPathLUNAddress sets the host LUN address, in hex, of a volume in a masking view through
+// one director port ("<director>:<port>"). An empty lunAddress removes the path of the volume through the port.
+func SetMaskingViewPathLUNAddress(maskingViewID, volumeID, dirPort, lunAddress string) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	if Data.MaskingViewIDToPathLUNs[maskingViewID] == nil {
+		Data.MaskingViewIDToPathLUNs[maskingViewID] = make(map[string]string)
+	}
+	Data.MaskingViewIDToPathLUNs[maskingViewID][volumeID+"/"+dirPort] = lunAddress
+}
+
+// SetMaskingViewLUNAddress sets the host LUN address, in hex, of a volume in a masking view.
+func SetMaskingViewLUNAddress(maskingViewID, volumeID, lunAddress string) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	if Data.MaskingViewIDToLUNAddresses[maskingViewID] == nil {
+		Data.MaskingViewIDToLUNAddresses[maskingViewID] = make(map[string]string)
+	}
+	Data.MaskingViewIDToLUNAddresses[maskingViewID][volumeID] = lunAddress
+}
+
+// SetMaskingViewStartingLUNAddress sets the host LUN address, in hex, of the first volume in
+// a masking view, as if it was requested when the masking view was created.
+func SetMaskingViewStartingLUNAddress(maskingViewID, lunAddress string) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	Data.MaskingViewIDToStartingLUN[maskingViewID] = lunAddress
 }
 
 // /univmax/restapi/90/sloprovisioning/symmetrix/{symid}/maskingview/{id}
@@ -1322,6 +3261,10 @@ func handleMaskingView(w http.ResponseWriter, r *http.Request) {
 			writeError(w, "Error retrieving Masking View(s): induced error", http.StatusRequestTimeout)
 			return
 		}
+		if mvID == "" {
+			returnMaskingViewList(w, r.URL.Query().Get("host_or_host_group_name"))
+			return
+		}
 		returnMaskingView(w, mvID)
 
 	case http.MethodPost:
@@ -1349,6 +3292,13 @@ func handleMaskingView(w http.ResponseWriter, r *http.Request) {
 		mvID := createMVPayload.MaskingViewID
 		//Data.StorageGroupIDToNVolumes[sgID] = 0
 		fmt.Println("MV Name: ", mvID)
+		if createMVPayload.ExecutionOption == types.ExecutionOptionAsynchronous {
+			resourceLink := fmt.Sprintf("sloprovisioning/symmetrix/%s/maskingview/%s", vars["symid"], mvID)
+			returnCreationJob(w, "CreateMaskingView-"+mvID, resourceLink, func() {
+				addMaskingViewFromCreateParams(createMVPayload)
+			})
+			return
+		}
 		addMaskingViewFromCreateParams(createMVPayload)
 		returnMaskingView(w, mvID)
 
@@ -1392,6 +3342,14 @@ func newStorageGroup(storageGroupID string, maskingViewID string, storageResourc
 		Unprotected:       true,
 		ChildStorageGroup: childStorageGroups,
 		MaskingView:       maskingViews,
+		VPSavedPercent:    100,
+	}
+	// Only the storage groups in a storage resource pool are compressed
+	if storageResourcePoolID != "" && storageResourcePoolID != "None" {
+		storageGroup.Compression = true
+		storageGroup.CompressionRatio = "1.8:1"
+		storageGroup.CompressionRatioToOne = 1.8
+		storageGroup.UnreducibleDataGB = 12.5
 	}
 	Data.StorageGroupIDToStorageGroup[storageGroupID] = storageGroup
 	volumes := make([]string, 0)
@@ -1454,9 +3412,8 @@ func removeStorageGroup(w http.ResponseWriter, storageGroupID string) {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
-	if sg.NumOfMaskingViews != 0 {
-		fmt.Println("Can't delete a storage group which is part of masking view")
-		w.WriteHeader(http.StatusInternalServerError)
+	if mvIDs := maskingViewsReferencing(func(mv *types.MaskingView) bool { return mv.StorageGroupID == storageGroupID }); sg.NumOfMaskingViews != 0 || len(mvIDs) > 0 {
+		writeError(w, fmt.Sprintf("Cannot delete storage group %s which is part of masking view(s) %s", storageGroupID, strings.Join(mvIDs, ",")), http.StatusInternalServerError)
 		return
 	}
 	volumes := Data.StorageGroupIDToVolumes[storageGroupID]
@@ -1468,6 +3425,20 @@ func removeStorageGroup(w http.ResponseWriter, storageGroupID string) {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	if len(Data.StorageGroupIDToSnapshotPolicies[storageGroupID]) > 0 {
+		fmt.Println("Can't delete a storage group which has snapshot policies")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if len(sg.ChildStorageGroup) > 0 {
+		writeError(w, fmt.Sprintf("Cannot delete storage group %s which has child storage groups", storageGroupID), http.StatusBadRequest)
+		return
+	}
+	if len(sg.ParentStorageGroup) > 0 {
+		writeError(w, fmt.Sprintf("Cannot delete storage group %s which is a child of storage group %s", storageGroupID, sg.ParentStorageGroup[0]), http.StatusBadRequest)
+		return
+	}
+	delete(Data.StorageGroupIDToSnapshotPolicies, storageGroupID)
 	delete(Data.StorageGroupIDToStorageGroup, storageGroupID)
 	delete(Data.StorageGroupIDToStorageGroup, storageGroupID+"-remote")
 	delete(Data.StorageGroupIDToRDFStorageGroup, storageGroupID)
@@ -1490,6 +3461,19 @@ func addMaskingViewFromCreateParams(createParams *types.MaskingViewCreateParam)
 	} else if hostGroupID != "" {
 		AddMaskingView(mvID, sgID, hostGroupID, portGroupID)
 	}
+	if createParams.StartingLUNAddress != "" {
+		mockCacheMutex.Lock()
+		defer mockCacheMutex.Unlock()
+		Data.MaskingViewIDToStartingLUN[mvID] = createParams.StartingLUNAddress
+	}
+}
+
+// GetMaskingViewStartingLUNAddress returns the starting host LUN address requested
+// when the masking view was created, or "" if none was requested.
+func GetMaskingViewStartingLUNAddress(maskingViewID string) string {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	return Data.MaskingViewIDToStartingLUN[maskingViewID]
 }
 
 // AddMaskingView - Adds a masking view to the mock data cache
@@ -1521,6 +3505,11 @@ func addMaskingView(maskingViewID string, storageGroupID string, hostID string,
 	Data.StorageGroupIDToStorageGroup[storageGroupID].MaskingView = append(
 		currentMaskingViewIDs, maskingViewID)
 	Data.StorageGroupIDToStorageGroup[storageGroupID].NumOfMaskingViews++
+	// Update Port Group
+	if pg, ok := Data.PortGroupIDToPortGroup[portGroupID]; ok {
+		pg.MaskingView = append(pg.MaskingView, maskingViewID)
+		pg.NumberMaskingViews++
+	}
 	// Update the volume cache
 	for _, volumeID := range Data.StorageGroupIDToVolumes[storageGroupID] {
 		Data.VolumeIDToVolume[volumeID].NumberOfFrontEndPaths = 1
@@ -1544,26 +3533,20 @@ func removeMaskingView(w http.ResponseWriter, maskingViewID string) {
 	}
 	// Handle storage groups
 	storageGroupID := mv.StorageGroupID
-	Data.StorageGroupIDToStorageGroup[storageGroupID].NumOfMaskingViews--
-	currentMaskingViewIDs := Data.StorageGroupIDToStorageGroup[storageGroupID].MaskingView
-	newMaskingViewIDs := make([]string, 0)
-	for _, mvID := range currentMaskingViewIDs {
-		if mvID != maskingViewID {
-			newMaskingViewIDs = append(newMaskingViewIDs, mvID)
-		}
+	if sg, ok := Data.StorageGroupIDToStorageGroup[storageGroupID]; ok {
+		sg.MaskingView = removeString(sg.MaskingView, maskingViewID)
+		sg.NumOfMaskingViews = len(sg.MaskingView)
 	}
-	Data.StorageGroupIDToStorageGroup[storageGroupID].MaskingView = newMaskingViewIDs
 	// Handle Hosts
-	hostID := mv.HostID
-	Data.HostIDToHost[hostID].NumberMaskingViews--
-	currentMaskingViewIDs = Data.HostIDToHost[hostID].MaskingviewIDs
-	newMaskingViewIDs = make([]string, 0)
-	for _, mvID := range currentMaskingViewIDs {
-		if mvID != maskingViewID {
-			newMaskingViewIDs = append(newMaskingViewIDs, mvID)
-		}
+	if host, ok := Data.HostIDToHost[mv.HostID]; ok && host != nil {
+		host.MaskingviewIDs = removeString(host.MaskingviewIDs, maskingViewID)
+		host.NumberMaskingViews = int64(len(host.MaskingviewIDs))
+	}
+	// Handle Port Groups
+	if pg, ok := Data.PortGroupIDToPortGroup[mv.PortGroupID]; ok {
+		pg.MaskingView = removeString(pg.MaskingView, maskingViewID)
+		pg.NumberMaskingViews = int64(len(pg.MaskingView))
 	}
-	Data.HostIDToHost[hostID].MaskingviewIDs = newMaskingViewIDs
 	// Check if we need to update the number of front end paths for volumes
 	// Loop through volumes of this particular SG
 	if volumeIDs, ok := Data.StorageGroupIDToVolumes[storageGroupID]; ok {
@@ -1595,7 +3578,32 @@ func removeMaskingView(w http.ResponseWriter, maskingViewID string) {
 			}
 		}
 	}
-	delete(Data.StorageGroupIDToStorageGroup, maskingViewID)
+	delete(Data.MaskingViewIDToMaskingView, maskingViewID)
+	delete(Data.MaskingViewIDToStartingLUN, maskingViewID)
+	delete(Data.MaskingViewIDToLUNAddresses, maskingViewID)
+}
+
+// maskingViewsReferencing - Returns the sorted IDs of the masking views for which match returns true
+func maskingViewsReferencing(match func(mv *types.MaskingView) bool) []string {
+	mvIDs := make([]string, 0)
+	for mvID, mv := range Data.MaskingViewIDToMaskingView {
+		if mv != nil && match(mv) {
+			mvIDs = append(mvIDs, mvID)
+		}
+	}
+	sort.Strings(mvIDs)
+	return mvIDs
+}
+
+// removeString - Returns a copy of slice without any occurrence of item
+func removeString(slice []string, item string) []string {
+	result := make([]string, 0)
+	for _, s := range slice {
+		if s != item {
+			result = append(result, s)
+		}
+	}
+	return result
 }
 
 // compareAndCheck - compares two string slices and returns true if the slices are equal or false if they aren't
@@ -1610,6 +3618,11 @@ func compareAndCheck(slice1 []string, slice2 []string) bool {
 	return true
 }
 
+// stringInSlice returns true if str is an element of list
+func stringInSlice(str string, list []string) bool {
+	return !compareAndCheck([]string{str}, list)
+}
+
 //uniqueElements - Removes duplicates from a string slice and returns a slice containing unique elements only
 func uniqueElements(slice []string) []string {
 	keys := make(map[string]bool)
@@ -1645,6 +3658,7 @@ func newVolume(volumeID, volumeIdentifier string, size int, sgList []string) {
 		StorageGroupIDList:    sgList,
 		Success:               true,
 		Message:               "message",
+		NGUID:                 "600009700001979000465330303" + volumeID,
 	}
 	if _, ok := Data.StorageGroupIDToRDFStorageGroup[sgList[0]]; ok {
 		volume.Type = "RDF1+TDEV"
@@ -1653,6 +3667,7 @@ func newVolume(volumeID, volumeIdentifier string, size int, sgList []string) {
 		}
 	}
 	Data.VolumeIDToVolume[volumeID] = volume
+	Data.WWNToVolumeID[volume.WWN] = volumeID
 }
 
 // AddNewVolume - Add a volume to the mock data cache
@@ -1735,6 +3750,7 @@ func ReturnInitiator(w http.ResponseWriter, initiatorID string) {
 func returnInitiator(w http.ResponseWriter, initiatorID string) {
 	if initiatorID != "" {
 		if init, ok := Data.InitiatorIDToInitiator[initiatorID]; ok {
+			advanceInitiatorLogin(initiatorID)
 			writeJSON(w, init)
 			return
 		}
@@ -1744,6 +3760,7 @@ func returnInitiator(w http.ResponseWriter, initiatorID string) {
 		for k := range Data.InitiatorIDToInitiator {
 			initIDs = append(initIDs, k)
 		}
+		sort.Strings(initIDs)
 		initiatorIDList := &types.InitiatorList{
 			InitiatorIDs: initIDs,
 		}
@@ -1751,16 +3768,113 @@ func returnInitiator(w http.ResponseWriter, initiatorID string) {
 	}
 }
 
-func newHost(hostID string, hostType string, initiatorIDs []string) {
-	maskingViewIDs := []string{}
-	host := &types.Host{
-		HostID:             hostID,
-		NumberMaskingViews: 0,
-		NumberInitiators:   int64(len(initiatorIDs)),
-		NumberHostGroups:   0,
-		PortFlagsOverride:  false,
-		ConsistentLun:      false,
-		EnabledFlags:       "",
+// returnFilteredInitiators returns the IDs of the initiators selected by the nvme_tcp, initiator_hba,
+// in_a_host, logged_in and on_fabric query parameters, an HBA being listed on all the ports it is seen on
+func returnFilteredInitiators(w http.ResponseWriter, query url.Values) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	if query.Get("alias") != "" && InducedErrors.InitiatorAliasFilterUnsupported {
+		writeError(w, "Invalid query parameter: alias", http.StatusBadRequest)
+		return
+	}
+	initIDs := make([]string, 0)
+	for k, v := range Data.InitiatorIDToInitiator {
+		if query.Get("nvme_tcp") == "true" && !strings.HasPrefix(v.InitiatorID, "nqn.") {
+			continue
+		}
+		if hba := query.Get("initiator_hba"); hba != "" && v.InitiatorID != hba {
+			continue
+		}
+		if inHost := query.Get("in_a_host"); inHost != "" && inHost != strconv.FormatBool(v.HostID != "") {
+			continue
+		}
+		if loggedIn := query.Get("logged_in"); loggedIn != "" && loggedIn != strconv.FormatBool(v.LoggedIn) {
+			continue
+		}
+		if onFabric := query.Get("on_fabric"); onFabric != "" && onFabric != strconv.FormatBool(v.OnFabric) {
+			continue
+		}
+		if alias := query.Get("alias"); alias != "" && v.Alias != alias {
+			continue
+		}
+		initIDs = append(initIDs, k)
+	}
+	sort.Strings(initIDs)
+	writeJSON(w, &types.InitiatorList{InitiatorIDs: initIDs})
+}
+
+// SetInitiatorAlias sets the alias of an initiator, e.g. "node1/10000090fa9278dd"
+func SetInitiatorAlias(initiatorID, alias string) error {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	initiator, ok := Data.InitiatorIDToInitiator[initiatorID]
+	if !ok {
+		return errors.New("Error! Initiator doesn't exist")
+	}
+	initiator.Alias = alias
+	return nil
+}
+
+// InitiatorLoginState is whether an initiator is logged in to the array and seen on the fabric
+type InitiatorLoginState struct {
+	LoggedIn bool
+	OnFabric bool
+}
+
+// SetInitiatorLoggedIn sets whether an initiator is logged in and on the fabric,
+// cancelling the events scheduled by SetInitiatorLoginEvents
+func SetInitiatorLoggedIn(initiatorID string, loggedIn, onFabric bool) error {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	initiator, ok := Data.InitiatorIDToInitiator[initiatorID]
+	if !ok {
+		return errors.New("Error! Initiator doesn't exist")
+	}
+	delete(Data.InitiatorIDToLoginEvents, initiatorID)
+	initiator.LoggedIn = loggedIn
+	initiator.OnFabric = onFabric
+	return nil
+}
+
+// SetInitiatorLoginEvents simulates an initiator logging in and out over time: each time
+// the initiator is read, by ID or through the connections of a masking view, it takes the
+// next of states and then keeps the last one
+func SetInitiatorLoginEvents(initiatorID string, states ...InitiatorLoginState) error {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	if _, ok := Data.InitiatorIDToInitiator[initiatorID]; !ok {
+		return errors.New("Error! Initiator doesn't exist")
+	}
+	Data.InitiatorIDToLoginEvents[initiatorID] = states
+	return nil
+}
+
+// advanceInitiatorLogin applies the next login event of an initiator, if any
+func advanceInitiatorLogin(initiatorID string) {
+	states := Data.InitiatorIDToLoginEvents[initiatorID]
+	if len(states) == 0 {
+		return
+	}
+	initiator := Data.InitiatorIDToInitiator[initiatorID]
+	initiator.LoggedIn = states[0].LoggedIn
+	initiator.OnFabric = states[0].OnFabric
+	if len(states) == 1 {
+		delete(Data.InitiatorIDToLoginEvents, initiatorID)
+		return
+	}
+	Data.InitiatorIDToLoginEvents[initiatorID] = states[1:]
+}
+
+func newHost(hostID string, hostType string, initiatorIDs []string) {
+	maskingViewIDs := []string{}
+	host := &types.Host{
+		HostID:             hostID,
+		NumberMaskingViews: 0,
+		NumberInitiators:   int64(len(initiatorIDs)),
+		NumberHostGroups:   0,
+		PortFlagsOverride:  false,
+		ConsistentLun:      false,
+		EnabledFlags:       "",
 		DisabledFlags:      "",
 		HostType:           hostType,
 		Initiators:         initiatorIDs,
@@ -1809,6 +3923,52 @@ func AddHost(hostID string, hostType string, initiatorIDs []string) (*types.Host
 	return Data.HostIDToHost[hostID], nil
 }
 
+// UpdateHostInitiators - Adds and removes initiators of a host in the mock cache
+func UpdateHostInitiators(hostID string, addIDs, removeIDs []string) error {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	return updateHostInitiators(hostID, addIDs, removeIDs)
+}
+
+func updateHostInitiators(hostID string, addIDs, removeIDs []string) error {
+	host, ok := Data.HostIDToHost[hostID]
+	if !ok {
+		return fmt.Errorf("Host %s not found", hostID)
+	}
+	for _, initID := range addIDs {
+		for _, v := range Data.InitiatorIDToInitiator {
+			if v.InitiatorID == initID && v.HostID != "" && v.HostID != hostID {
+				return fmt.Errorf("Initiator %s is already a member of host %s", initID, v.HostID)
+			}
+		}
+	}
+	initiators := make([]string, 0)
+	for _, initID := range host.Initiators {
+		if !stringInSlice(initID, removeIDs) {
+			initiators = append(initiators, initID)
+		}
+	}
+	if len(initiators) == 0 && host.NumberMaskingViews > 0 {
+		return fmt.Errorf("Cannot remove the last initiator of host %s as it is in a masking view", hostID)
+	}
+	for _, initID := range addIDs {
+		if !stringInSlice(initID, initiators) {
+			initiators = append(initiators, initID)
+		}
+	}
+	host.Initiators = initiators
+	host.NumberInitiators = int64(len(initiators))
+	for k, v := range Data.InitiatorIDToInitiator {
+		if stringInSlice(v.InitiatorID, removeIDs) && v.HostID == hostID {
+			Data.InitiatorIDToInitiator[k].HostID = ""
+		}
+		if stringInSlice(v.InitiatorID, addIDs) {
+			Data.InitiatorIDToInitiator[k].HostID = hostID
+		}
+	}
+	return nil
+}
+
 // RemoveHost - Removes host from mock cache
 func RemoveHost(hostID string) error {
 	mockCacheMutex.Lock()
@@ -1822,10 +3982,10 @@ func removeHost(hostID string) error {
 	if !ok {
 		return errors.New("Error! Host doesn't exist")
 	}
-	if host.NumberMaskingViews > 0 {
-		return errors.New("Error! Host is part of a masking view")
+	if mvIDs := maskingViewsReferencing(func(mv *types.MaskingView) bool { return mv.HostID == hostID }); host.NumberMaskingViews > 0 || len(mvIDs) > 0 {
+		return fmt.Errorf("Error! Host %s is part of masking view(s) %s", hostID, strings.Join(mvIDs, ","))
 	}
-	Data.HostIDToHost[hostID] = nil
+	delete(Data.HostIDToHost, hostID)
 	return nil
 }
 
@@ -1925,10 +4085,15 @@ func UpdatePortGroupFromParams(portGroupID string, updateParams *types.EditPortG
 
 // DeletePortGroup - Remove PortGroup by ID 'portGroupID'
 func DeletePortGroup(portGroupID string) (*types.PortGroup, error) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
 	pg, ok := Data.PortGroupIDToPortGroup[portGroupID]
 	if !ok {
 		return nil, fmt.Errorf("Error! PortGroup %s does not exist.", portGroupID)
 	}
+	if mvIDs := maskingViewsReferencing(func(mv *types.MaskingView) bool { return mv.PortGroupID == portGroupID }); pg.NumberMaskingViews > 0 || len(mvIDs) > 0 {
+		return nil, fmt.Errorf("Error! PortGroup %s is part of masking view(s) %s", portGroupID, strings.Join(mvIDs, ","))
+	}
 
 	delete(Data.PortGroupIDToPortGroup, portGroupID)
 	return pg, nil
@@ -1938,7 +4103,16 @@ func DeletePortGroup(portGroupID string) (*types.PortGroup, error) {
 func AddPortGroupFromCreateParams(createParams *types.CreatePortGroupParams) {
 	portGroupID := createParams.PortGroupID
 	portKeys := createParams.SymmetrixPortKey
-	addPortGroup(portGroupID, "Fibre", portKeys)
+	portGroupType := "Fibre"
+	switch createParams.PortGroupProtocol {
+	case types.PortGroupProtocolISCSI:
+		portGroupType = "ISCSI"
+	case types.PortGroupProtocolNVMeTCP:
+		portGroupType = types.PortGroupProtocolNVMeTCP
+	}
+	if pg, err := addPortGroup(portGroupID, portGroupType, portKeys); err == nil {
+		pg.PortGroupProtocol = createParams.PortGroupProtocol
+	}
 }
 
 // AddPortGroup - Adds a port group to the mock data cache
@@ -1975,7 +4149,27 @@ func AddStorageGroupFromCreateParams(createParams *types.CreateStorageGroupParam
 	} else {
 		srpID = ""
 	}
-	AddStorageGroup(sgID, srpID, serviceLevel)
+	sg, err := AddStorageGroup(sgID, srpID, serviceLevel)
+	if err != nil {
+		return
+	}
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	if createParams.Emulation != "" {
+		sg.DeviceEmulation = createParams.Emulation
+	}
+	if srpID != "" {
+		sloBasedParams := createParams.SLOBasedStorageGroupParam[0]
+		if sloBasedParams.WorkloadSelection != "" {
+			sg.Workload = sloBasedParams.WorkloadSelection
+		}
+		if sloBasedParams.NoCompression {
+			sg.Compression = false
+			sg.CompressionRatio = ""
+			sg.CompressionRatioToOne = 0
+			sg.UnreducibleDataGB = 0
+		}
+	}
 }
 
 // keys - Return keys of the given map
@@ -2020,11 +4214,25 @@ func returnStorageGroup(w http.ResponseWriter, sgID string, remote bool) {
 		writeError(w, "StorageGroup not found", http.StatusNotFound)
 	} else {
 		storageGroupIDs := keys(Data.StorageGroupIDToStorageGroup)
-		storageGroupIDList := &types.StorageGroupIDList{
-			StorageGroupIDs: storageGroupIDs,
-		}
-		writeJSON(w, storageGroupIDList)
+		sort.Strings(storageGroupIDs)
+		writeIDListing(w, "storageGroupId", storageGroupIDs)
+	}
+}
+
+// returnStorageGroupWithVolumes returns a storage group with the IDs of its volumes expanded
+func returnStorageGroupWithVolumes(w http.ResponseWriter, sgID string) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	sg, ok := Data.StorageGroupIDToStorageGroup[sgID]
+	if !ok {
+		writeError(w, "StorageGroup not found", http.StatusNotFound)
+		return
 	}
+	expanded := *sg
+	expanded.VolumeIDs = make([]string, len(Data.StorageGroupIDToVolumes[sgID]))
+	copy(expanded.VolumeIDs, Data.StorageGroupIDToVolumes[sgID])
+	sort.Strings(expanded.VolumeIDs)
+	writeJSON(w, &expanded)
 }
 
 func returnMaskingView(w http.ResponseWriter, mvID string) {
@@ -2035,15 +4243,51 @@ func returnMaskingView(w http.ResponseWriter, mvID string) {
 		}
 		w.WriteHeader(http.StatusNotFound)
 	} else {
-		maskingViewIDs := make([]string, 0)
-		for k := range Data.MaskingViewIDToMaskingView {
+		returnMaskingViewList(w, "")
+	}
+}
+
+// returnMaskingViewList writes the sorted IDs of the masking views of hostID, or of all masking views
+func returnMaskingViewList(w http.ResponseWriter, hostID string) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	maskingViewIDs := make([]string, 0)
+	for k, mv := range Data.MaskingViewIDToMaskingView {
+		if hostID == "" || mv.HostID == hostID || mv.HostGroupID == hostID {
 			maskingViewIDs = append(maskingViewIDs, k)
 		}
-		maskingViewIDList := &types.MaskingViewList{
-			MaskingViewIDs: maskingViewIDs,
-		}
-		writeJSON(w, maskingViewIDList)
 	}
+	sort.Strings(maskingViewIDs)
+	writeIDListing(w, "maskingViewId", maskingViewIDs)
+}
+
+// writeIDListing writes the listing of ids under key, or the first page of an iterator of them if they
+// do not fit in a page of Data.IDListPageSize IDs. The iterator is named after key.
+func writeIDListing(w http.ResponseWriter, key string, ids []string) {
+	if Data.IDListPageSize == 0 || len(ids) <= Data.IDListPageSize {
+		writeJSON(w, map[string][]string{key: ids})
+		return
+	}
+	Data.IDIteratorLists[key] = ids
+	writeJSON(w, map[string]interface{}{
+		"id":             key,
+		"count":          len(ids),
+		"maxPageSize":    Data.IDListPageSize,
+		"expirationTime": time.Now().Add(time.Minute).UnixNano() / int64(time.Millisecond),
+		"resultList":     idPage(key, ids, 1, Data.IDListPageSize),
+	})
+}
+
+// idPage returns the page from from to to of the iterator of the ID listing under key
+func idPage(key string, ids []string, from, to int) map[string]interface{} {
+	if to > len(ids) {
+		to = len(ids)
+	}
+	result := make([]map[string]string, 0)
+	for i := from - 1; i < to; i++ {
+		result = append(result, map[string]string{key: ids[i]})
+	}
+	return map[string]interface{}{"result": result, "from": from, "to": to}
 }
 
 func writeJSON(w http.ResponseWriter, val interface{}) {
@@ -2132,12 +4376,37 @@ func AddVolumeToStorageGroupTest(w http.ResponseWriter, name, size, sgID string)
 func addVolumeToStorageGroupTest(w http.ResponseWriter, name, size, sgID string) {
 	if name == "" || size == "" {
 		writeError(w, "null name or size", http.StatusBadRequest)
+		return
+	}
+	if isParentStorageGroup(sgID) {
+		writeError(w, fmt.Sprintf("Cannot add volumes to parent storage group %s", sgID), http.StatusBadRequest)
+		return
 	}
-	id := strconv.Itoa(time.Now().Nanosecond())
 	sizeInt, err := strconv.Atoi(size)
 	if err != nil {
 		writeError(w, "unable to convert size string to integer", http.StatusBadRequest)
+		return
+	}
+	if !InducedErrors.AllowInvalidVolumes {
+		if err := validateNewVolume(name, sizeInt); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if !InducedErrors.AllowDuplicateVolumeIdentifiers {
+		for _, vol := range Data.VolumeIDToVolume {
+			if vol.VolumeIdentifier == name {
+				writeError(w, fmt.Sprintf("A volume with identifier %s already exists: %s", name, vol.VolumeID), http.StatusConflict)
+				return
+			}
+		}
+	}
+	id, err := allocateDeviceID()
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
+	Data.VolumeNameToAllocatedID[name] = id
 	if InducedErrors.VolumeNotCreatedError == false {
 		addOneVolumeToStorageGroup(id, name, sgID, sizeInt)
 	}
@@ -2151,6 +4420,99 @@ func addVolumeToStorageGroupTest(w http.ResponseWriter, name, size, sgID string)
 	returnJobByID(w, id)
 }
 
+// validateNewVolume returns the error of Unisphere for a volume of an invalid size or identifier
+func validateNewVolume(name string, sizeInCylinders int) error {
+	if sizeInCylinders <= 0 || sizeInCylinders > MaxVolumeSizeCYL {
+		return fmt.Errorf("Invalid volume size %d CYL, the size must be between 1 and %d CYL", sizeInCylinders, MaxVolumeSizeCYL)
+	}
+	if len(name) > maxVolumeIdentifierLength {
+		return fmt.Errorf("Invalid volume identifier %s, it exceeds %d characters", name, maxVolumeIdentifierLength)
+	}
+	if !volumeIdentifierRegexp.MatchString(name) {
+		return fmt.Errorf("Invalid volume identifier %s, only letters, digits and the characters _-.: are allowed", name)
+	}
+	return nil
+}
+
+// EditSnapshotPolicies - Associates and disassociates snapshot policies of a storage group in the mock cache
+func EditSnapshotPolicies(w http.ResponseWriter, sgID string, param *types.EditSnapshotPoliciesParam) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	editSnapshotPolicies(w, sgID, param)
+}
+
+func editSnapshotPolicies(w http.ResponseWriter, sgID string, param *types.EditSnapshotPoliciesParam) {
+	if InducedErrors.EditSnapshotPolicyError {
+		writeError(w, "Error editing snapshot policies: induced error", http.StatusRequestTimeout)
+		return
+	}
+	if _, ok := Data.StorageGroupIDToStorageGroup[sgID]; !ok {
+		writeError(w, "The requested storage group resource does not exist", http.StatusNotFound)
+		return
+	}
+	policies := Data.StorageGroupIDToSnapshotPolicies[sgID]
+	if param.AssociateSnapshotPolicyParam != nil {
+		for _, name := range param.AssociateSnapshotPolicyParam.SnapshotPolicyNames {
+			if stringInSlice(name, policies) {
+				writeError(w, fmt.Sprintf("Snapshot policy %s is already associated with storage group %s", name, sgID), http.StatusBadRequest)
+				return
+			}
+			policies = append(policies, name)
+		}
+	}
+	if param.DisassociateSnapshotPolicyParam != nil {
+		names := param.DisassociateSnapshotPolicyParam.SnapshotPolicyNames
+		for _, name := range names {
+			if !stringInSlice(name, policies) {
+				writeError(w, fmt.Sprintf("Snapshot policy %s is not associated with storage group %s", name, sgID), http.StatusBadRequest)
+				return
+			}
+		}
+		remaining := make([]string, 0)
+		for _, name := range policies {
+			if !stringInSlice(name, names) {
+				remaining = append(remaining, name)
+			}
+		}
+		policies = remaining
+	}
+	Data.StorageGroupIDToSnapshotPolicies[sgID] = policies
+}
+
+// SetFirstDeviceID sets the next device ID, a hex string, handed out for volumes created through the mock
+func SetFirstDeviceID(deviceID string) error {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	next, err := strconv.ParseInt(deviceID, 16, 32)
+	if err != nil || next < 0 || next > MaxDeviceID {
+		return fmt.Errorf("invalid device ID %s", deviceID)
+	}
+	Data.NextDeviceID = int(next)
+	return nil
+}
+
+// GetAllocatedVolumeID returns the device ID allocated to the volume created through the mock with the given name
+func GetAllocatedVolumeID(name string) (string, bool) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	id, ok := Data.VolumeNameToAllocatedID[name]
+	return id, ok
+}
+
+// allocateDeviceID returns the next sequential 5 hex digit device ID not in use by a volume or job
+func allocateDeviceID() (string, error) {
+	for ; Data.NextDeviceID <= MaxDeviceID; Data.NextDeviceID++ {
+		id := fmt.Sprintf("%05X", Data.NextDeviceID)
+		_, volumeExists := Data.VolumeIDToVolume[id]
+		_, jobExists := Data.JobIDToMockJob[id]
+		if !volumeExists && !jobExists {
+			Data.NextDeviceID++
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("no device IDs left to allocate")
+}
+
 // AddSpecificVolumeToStorageGroup - Add volume based on volumeids to storage group mock cache
 func AddSpecificVolumeToStorageGroup(w http.ResponseWriter, volumeIDs []string, sgID string) {
 	mockCacheMutex.Lock()
@@ -2167,6 +4529,10 @@ func addSpecificVolumeToStorageGroup(w http.ResponseWriter, volumeIDs []string,
 		writeError(w, "Error adding volume to the SG", http.StatusRequestTimeout)
 		return
 	}
+	if isParentStorageGroup(sgID) {
+		writeError(w, fmt.Sprintf("Cannot add volumes to parent storage group %s", sgID), http.StatusBadRequest)
+		return
+	}
 	for _, volumeID := range volumeIDs {
 		addOneVolumeToStorageGroup(volumeID, "TestVol", sgID, 0)
 	}
@@ -2180,6 +4546,38 @@ func addSpecificVolumeToStorageGroup(w http.ResponseWriter, volumeIDs []string,
 	returnJobByID(w, jobID)
 }
 
+// AddExistingVolumesToStorageGroup adds to a storage group the first volumes, in volume ID order,
+// which are not in any storage group and have the emulation and size of the attributes.
+func AddExistingVolumesToStorageGroup(w http.ResponseWriter, emulation string, attributes types91.VolumeAttributeType, sgID string) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	volumeIDs := make([]string, 0)
+	for volumeID, vol := range Data.VolumeIDToVolume {
+		if len(vol.StorageGroupIDList) == 0 && vol.Emulation == emulation && volumeHasSize(vol, attributes.VolumeSize, attributes.CapacityUnit) {
+			volumeIDs = append(volumeIDs, volumeID)
+		}
+	}
+	if len(volumeIDs) < attributes.NumberOfVolumes {
+		writeError(w, fmt.Sprintf("Only %d of the %d volumes requested are available", len(volumeIDs), attributes.NumberOfVolumes), http.StatusBadRequest)
+		return
+	}
+	sort.Strings(volumeIDs)
+	addSpecificVolumeToStorageGroup(w, volumeIDs[:attributes.NumberOfVolumes], sgID)
+}
+
+// volumeHasSize returns true if the size of vol in capacityUnit is size
+func volumeHasSize(vol *types.Volume, size, capacityUnit string) bool {
+	switch capacityUnit {
+	case "CYL":
+		return strconv.Itoa(vol.CapacityCYL) == size
+	case "MB":
+		return strconv.FormatFloat(vol.FloatCapacityMB, 'f', -1, 64) == size
+	case "GB":
+		return strconv.FormatFloat(vol.CapacityGB, 'f', -1, 64) == size
+	}
+	return false
+}
+
 func removeOneVolumeFromStorageGroup(volumeID, storageGroupID string) error {
 	if _, ok := Data.StorageGroupIDToStorageGroup[storageGroupID]; !ok {
 		return errors.New("The requested storage group doesn't exist")
@@ -2249,6 +4647,10 @@ func RemoveVolumeFromStorageGroup(w http.ResponseWriter, volumeIDs []string, sgI
 }
 
 func removeVolumeFromStorageGroup(w http.ResponseWriter, volumeIDs []string, sgID string) {
+	if mvID := maskingViewLeftEmpty(sgID, volumeIDs); Data.ProtectMaskingViewVolumes && mvID != "" {
+		writeError(w, fmt.Sprintf("Cannot remove the last volumes of storage group %s which is in masking view %s", sgID, mvID), http.StatusBadRequest)
+		return
+	}
 	for _, volID := range volumeIDs {
 		fmt.Println("Volume ID: " + volID)
 		removeOneVolumeFromStorageGroup(volID, sgID)
@@ -2256,6 +4658,144 @@ func removeVolumeFromStorageGroup(w http.ResponseWriter, volumeIDs []string, sgI
 	returnStorageGroup(w, sgID, false)
 }
 
+// maskingViewLeftEmpty returns the masking view which would be left without volumes if volumeIDs were
+// removed from the storage group sgID, either directly or through the parent of sgID, or ""
+func maskingViewLeftEmpty(sgID string, volumeIDs []string) string {
+	sg, ok := Data.StorageGroupIDToStorageGroup[sgID]
+	if !ok {
+		return ""
+	}
+	exposed := sg
+	members := []string{sgID}
+	if len(sg.ParentStorageGroup) > 0 {
+		if parent, ok := Data.StorageGroupIDToStorageGroup[sg.ParentStorageGroup[0]]; ok && len(parent.MaskingView) > 0 {
+			exposed = parent
+			members = parent.ChildStorageGroup
+		}
+	}
+	if len(exposed.MaskingView) == 0 {
+		return ""
+	}
+	for _, memberID := range members {
+		for _, volID := range Data.StorageGroupIDToVolumes[memberID] {
+			if memberID != sgID || !stringInSlice(volID, volumeIDs) {
+				return ""
+			}
+		}
+	}
+	return exposed.MaskingView[0]
+}
+
+// isParentStorageGroup returns true if the storage group has child storage groups
+func isParentStorageGroup(sgID string) bool {
+	sg, ok := Data.StorageGroupIDToStorageGroup[sgID]
+	return ok && len(sg.ChildStorageGroup) > 0
+}
+
+// volumeInStorageGroup returns true if the volume is in the storage group or in one of its child storage groups
+func volumeInStorageGroup(vol *types.Volume, sgID string) bool {
+	if stringInSlice(sgID, vol.StorageGroupIDList) {
+		return true
+	}
+	if sg, ok := Data.StorageGroupIDToStorageGroup[sgID]; ok {
+		for _, childID := range sg.ChildStorageGroup {
+			if stringInSlice(childID, vol.StorageGroupIDList) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AddChildStorageGroups makes storage groups the children of a parent storage group.
+// As on the array, a parent cannot have volumes of its own, a child has only one parent,
+// and a storage group cannot be both a parent and a child.
+func AddChildStorageGroups(w http.ResponseWriter, parentID string, childIDs []string) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	addChildStorageGroups(w, parentID, childIDs)
+}
+
+func addChildStorageGroups(w http.ResponseWriter, parentID string, childIDs []string) {
+	parent, ok := Data.StorageGroupIDToStorageGroup[parentID]
+	if !ok {
+		writeError(w, "StorageGroup not found", http.StatusNotFound)
+		return
+	}
+	if len(childIDs) == 0 {
+		writeError(w, "no child storage groups specified", http.StatusBadRequest)
+		return
+	}
+	if len(Data.StorageGroupIDToVolumes[parentID]) > 0 {
+		writeError(w, fmt.Sprintf("Storage group %s has volumes and cannot be a parent storage group", parentID), http.StatusBadRequest)
+		return
+	}
+	if len(parent.ParentStorageGroup) > 0 {
+		writeError(w, fmt.Sprintf("Storage group %s is a child storage group and cannot have child storage groups", parentID), http.StatusBadRequest)
+		return
+	}
+	for _, childID := range childIDs {
+		child, ok := Data.StorageGroupIDToStorageGroup[childID]
+		if !ok {
+			writeError(w, fmt.Sprintf("Storage group %s cannot be found", childID), http.StatusNotFound)
+			return
+		}
+		if childID == parentID || len(child.ChildStorageGroup) > 0 {
+			writeError(w, fmt.Sprintf("Storage group %s is a parent storage group and cannot be a child storage group", childID), http.StatusBadRequest)
+			return
+		}
+		if len(child.ParentStorageGroup) > 0 && child.ParentStorageGroup[0] != parentID {
+			writeError(w, fmt.Sprintf("Storage group %s is already a child of storage group %s", childID, child.ParentStorageGroup[0]), http.StatusBadRequest)
+			return
+		}
+	}
+	for _, childID := range childIDs {
+		child := Data.StorageGroupIDToStorageGroup[childID]
+		if !stringInSlice(childID, parent.ChildStorageGroup) {
+			parent.ChildStorageGroup = append(parent.ChildStorageGroup, childID)
+		}
+		child.ParentStorageGroup = []string{parentID}
+		child.NumOfParentSGs = 1
+	}
+	parent.NumOfChildSGs = len(parent.ChildStorageGroup)
+	returnStorageGroup(w, parentID, false)
+}
+
+// RemoveChildStorageGroups removes child storage groups from their parent storage group
+func RemoveChildStorageGroups(w http.ResponseWriter, parentID string, childIDs []string) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	removeChildStorageGroups(w, parentID, childIDs)
+}
+
+func removeChildStorageGroups(w http.ResponseWriter, parentID string, childIDs []string) {
+	parent, ok := Data.StorageGroupIDToStorageGroup[parentID]
+	if !ok {
+		writeError(w, "StorageGroup not found", http.StatusNotFound)
+		return
+	}
+	for _, childID := range childIDs {
+		if !stringInSlice(childID, parent.ChildStorageGroup) {
+			writeError(w, fmt.Sprintf("Storage group %s is not a child of storage group %s", childID, parentID), http.StatusBadRequest)
+			return
+		}
+	}
+	for _, childID := range childIDs {
+		children := make([]string, 0, len(parent.ChildStorageGroup))
+		for _, id := range parent.ChildStorageGroup {
+			if id != childID {
+				children = append(children, id)
+			}
+		}
+		parent.ChildStorageGroup = children
+		child := Data.StorageGroupIDToStorageGroup[childID]
+		child.ParentStorageGroup = []string{}
+		child.NumOfParentSGs = 0
+	}
+	parent.NumOfChildSGs = len(parent.ChildStorageGroup)
+	returnStorageGroup(w, parentID, false)
+}
+
 // /univmax/restapi/90/sloprovisioning/symmetrix/{symid}/portgroup/{id}
 // /univmax/restapi/90/sloprovisioning/symmetrix/{symid}/portgroup
 func handlePortGroup(w http.ResponseWriter, r *http.Request) {
@@ -2268,6 +4808,10 @@ func handlePortGroup(w http.ResponseWriter, r *http.Request) {
 			writeError(w, "Error retrieving Port Group(s): induced error", http.StatusRequestTimeout)
 			return
 		}
+		if pgID == "" {
+			ReturnPortGroupList(w, r.URL.Query())
+			return
+		}
 		ReturnPortGroup(w, pgID)
 
 	case http.MethodPost:
@@ -2282,6 +4826,12 @@ func handlePortGroup(w http.ResponseWriter, r *http.Request) {
 			writeError(w, "InvalidJson", http.StatusBadRequest)
 			return
 		}
+		switch createPortGroupParams.PortGroupProtocol {
+		case "", types.PortGroupProtocolFibre, types.PortGroupProtocolISCSI, types.PortGroupProtocolNVMeTCP:
+		default:
+			writeError(w, "Invalid port_group_protocol: "+createPortGroupParams.PortGroupProtocol, http.StatusBadRequest)
+			return
+		}
 		AddPortGroupFromCreateParams(createPortGroupParams)
 		ReturnPortGroup(w, createPortGroupParams.PortGroupID)
 	case http.MethodPut:
@@ -2303,99 +4853,305 @@ func handlePortGroup(w http.ResponseWriter, r *http.Request) {
 			writeError(w, "Error deleting Port Group: induced error", http.StatusRequestTimeout)
 			return
 		}
-		DeletePortGroup(pgID)
+		if _, err := DeletePortGroup(pgID); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		writeError(w, "Invalid Method", http.StatusBadRequest)
+	}
+}
+
+// /univmax/restapi/90/system/symmetrix/{symid}/director/{director}/port/{id}
+// /univmax/restapi/90/system/symmetrix/{symid}/director/{director}/port
+func handlePort(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	dID := vars["director"]
+	pID := vars["id"]
+	queryString := r.URL.Query()
+	switch r.Method {
+
+	case http.MethodGet:
+		if InducedErrors.GetPortError {
+			writeError(w, "Error retrieving Port(s): induced error", http.StatusRequestTimeout)
+			return
+		}
+		if InducedErrors.GetPortGigEError {
+			queryType, ok := queryString["type"]
+			if ok {
+				if queryType[0] == "Gige" { // The first ?type=<value>
+					writeError(w, "Error retrieving GigE ports: induced error", http.StatusRequestTimeout)
+					return
+				}
+			}
+		}
+		if InducedErrors.GetPortISCSITargetError {
+			queryType, ok := queryString["iscsi_target"]
+			if ok {
+				if queryType[0] == "true" { // The first ?iscsi_target=<value>
+					writeError(w, "Error retrieving ISCSI targets: induced error", http.StatusRequestTimeout)
+					return
+				}
+			}
+		}
+		mockCacheMutex.Lock()
+		defer mockCacheMutex.Unlock()
+		if Data.DirectorIDToDirector[dID] == nil {
+			writeError(w, "director not found", http.StatusNotFound)
+			return
+		}
+		// if we asked for a specific Port, return those details
+		if pID != "" {
+			if InducedErrors.GetSpecificPortError {
+				writeError(w, "Error retrieving Specific Port: induced error", http.StatusRequestTimeout)
+				return
+			}
+			returnPort(w, dID, pID)
+			return
+		}
+		// return a list of Ports
+		returnPortIDList(w, getDirectorPorts(dID), queryString.Get("type"), queryString.Get("iscsi_target"))
+
+	case http.MethodPut:
+		if InducedErrors.CreateIPInterfaceError {
+			writeError(w, "Error creating IP interface: induced error", http.StatusRequestTimeout)
+			return
+		}
+		editParam := &types.EditPortParam{}
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(editParam); err != nil {
+			writeError(w, "InvalidJson", http.StatusBadRequest)
+			return
+		}
+		createParam := editParam.EditPortActionParam.CreateIPInterfaceParam
+		if createParam == nil {
+			writeError(w, "Unsupported port action", http.StatusBadRequest)
+			return
+		}
+		mockCacheMutex.Lock()
+		defer mockCacheMutex.Unlock()
+		if _, err := addIPInterface(dID+":"+pID, types.IPInterface{
+			IPAddress:      createParam.IPAddress,
+			IPPrefixLength: createParam.IPPrefixLength,
+			NetworkID:      createParam.NetworkID,
+			VLANID:         createParam.VLANID,
+			MTU:            createParam.MTU,
+		}); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 	default:
 		writeError(w, "Invalid Method", http.StatusBadRequest)
 	}
 }
 
-// /univmax/restapi/90/system/symmetrix/{symid}/director/{director}/port/{id}
-// /univmax/restapi/90/system/symmetrix/{symid}/director/{director}/port
-func handlePort(w http.ResponseWriter, r *http.Request) {
+// /univmax/restapi/90/system/symmetrix/{symid}/port
+func handleSymmetrixPort(w http.ResponseWriter, r *http.Request) {
+	queryString := r.URL.Query()
+	switch r.Method {
+
+	case http.MethodGet:
+		if InducedErrors.GetPortError {
+			writeError(w, "Error retrieving Port(s): induced error", http.StatusRequestTimeout)
+			return
+		}
+		if InducedErrors.GetPortGigEError && queryString.Get("type") == "Gige" {
+			writeError(w, "Error retrieving GigE ports: induced error", http.StatusRequestTimeout)
+			return
+		}
+		if InducedErrors.GetPortISCSITargetError && queryString.Get("iscsi_target") == "true" {
+			writeError(w, "Error retrieving ISCSI targets: induced error", http.StatusRequestTimeout)
+			return
+		}
+		mockCacheMutex.Lock()
+		defer mockCacheMutex.Unlock()
+		directorIDs := make([]string, 0)
+		for dID := range Data.DirectorIDToDirector {
+			directorIDs = append(directorIDs, dID)
+		}
+		sort.Strings(directorIDs)
+		ports := make([]*types.SymmetrixPortType, 0)
+		for _, dID := range directorIDs {
+			ports = append(ports, getDirectorPorts(dID)...)
+		}
+		returnPortIDList(w, ports, queryString.Get("type"), queryString.Get("iscsi_target"))
+
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// /univmax/restapi/90/system/symmetrix/{symid}/director/{director}/port/{id}/ip_interface/{ifid}
+// /univmax/restapi/90/system/symmetrix/{symid}/director/{director}/port/{id}/ip_interface
+func handleIPInterface(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	dID := vars["director"]
-	pID := vars["id"]
-	queryString := r.URL.Query()
+	portID := vars["director"] + ":" + vars["id"]
+	ifID := vars["ifid"]
 	switch r.Method {
 
 	case http.MethodGet:
-		if InducedErrors.GetPortError {
-			writeError(w, "Error retrieving Port(s): induced error", http.StatusRequestTimeout)
+		if InducedErrors.GetIPInterfaceError {
+			writeError(w, "Error retrieving IP interface(s): induced error", http.StatusRequestTimeout)
 			return
 		}
-		if InducedErrors.GetPortGigEError {
-			queryType, ok := queryString["type"]
-			if ok {
-				if queryType[0] == "Gige" { // The first ?type=<value>
-					writeError(w, "Error retrieving GigE ports: induced error", http.StatusRequestTimeout)
-					return
-				}
-			}
+		mockCacheMutex.Lock()
+		defer mockCacheMutex.Unlock()
+		port := Data.PortIDToSymmetrixPortType[portID]
+		if port == nil || port.Type == "" {
+			writeError(w, "port not found", http.StatusNotFound)
+			return
 		}
-		if InducedErrors.GetPortISCSITargetError {
-			queryType, ok := queryString["iscsi_target"]
-			if ok {
-				if queryType[0] == "true" { // The first ?iscsi_target=<value>
-					writeError(w, "Error retrieving ISCSI targets: induced error", http.StatusRequestTimeout)
+		if ifID != "" {
+			for _, ipInterface := range Data.PortIDToIPInterfaces[portID] {
+				if ipInterface.IPInterfaceID == ifID {
+					writeJSON(w, ipInterface)
 					return
 				}
 			}
+			writeError(w, "IP interface not found", http.StatusNotFound)
+			return
 		}
-		mockCacheMutex.Lock()
-		defer mockCacheMutex.Unlock()
-		// if we asked for a specific Port, return those details
-		if pID != "" {
-			if InducedErrors.GetSpecificPortError {
-				writeError(w, "Error retrieving Specific Port: induced error", http.StatusRequestTimeout)
-				return
-			}
-			// Specific ports can be modeleted
-			portName := dID + ":" + pID
-			if Data.PortIDToSymmetrixPortType[portName] != nil {
-				port := Data.PortIDToSymmetrixPortType[portName]
-				if port == nil || port.Type == "" {
-					writeError(w, "port not found", http.StatusNotFound)
-				} else {
-					symPort := &types.Port{
-						SymmetrixPort: *port,
-					}
-					encoder := json.NewEncoder(w)
-					encoder.Encode(symPort)
-				}
-				return
-			}
-			returnPort(w, dID, pID)
+		list := &types.IPInterfaceList{IPInterfaceIDs: make([]string, 0)}
+		for _, ipInterface := range Data.PortIDToIPInterfaces[portID] {
+			list.IPInterfaceIDs = append(list.IPInterfaceIDs, ipInterface.IPInterfaceID)
 		}
-		// return a list of Ports
-		returnPortIDList(w, dID)
+		writeJSON(w, list)
 
 	default:
-		writeError(w, "Invalid Method", http.StatusBadRequest)
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
-// AddPort adds a port entry. Port type can either be "FibreChannel" or "GigE", or "" for a non existent port.
-func AddPort(id, identifier, portType string) {
+// AddIPInterface adds an IP interface to the port "<director>:<port>" and its IP address to the portal IPs of the port.
+// The ID of the interface is "<IP address>-<network ID>".
+func AddIPInterface(id string, ipInterface types.IPInterface) (*types.IPInterface, error) {
 	mockCacheMutex.Lock()
 	defer mockCacheMutex.Unlock()
+	return addIPInterface(id, ipInterface)
+}
+
+func addIPInterface(id string, ipInterface types.IPInterface) (*types.IPInterface, error) {
+	port := Data.PortIDToSymmetrixPortType[id]
+	if port == nil || port.Type == "" {
+		return nil, fmt.Errorf("port %s not found", id)
+	}
+	ipInterface.IPInterfaceID = fmt.Sprintf("%s-%d", ipInterface.IPAddress, ipInterface.NetworkID)
+	for _, existing := range Data.PortIDToIPInterfaces[id] {
+		if existing.IPInterfaceID == ipInterface.IPInterfaceID {
+			return nil, fmt.Errorf("IP interface %s already exists on port %s", ipInterface.IPInterfaceID, id)
+		}
+	}
+	Data.PortIDToIPInterfaces[id] = append(Data.PortIDToIPInterfaces[id], &ipInterface)
+	hasIP := false
+	for _, ip := range port.IPAddresses {
+		hasIP = hasIP || ip == ipInterface.IPAddress
+	}
+	if !hasIP {
+		port.IPAddresses = append(port.IPAddresses, ipInterface.IPAddress)
+	}
+	return &ipInterface, nil
+}
+
+// AddPort adds a port entry. Port type can either be "FibreChannel" or "GigE", or "" for a non existent port.
+// The id is of the form "<director>:<port>". Use AddSymmetrixPort to supply the other port attributes.
+func AddPort(id, identifier, portType string) {
 	port := &types.SymmetrixPortType{
 		Type:       portType,
 		Identifier: identifier,
 	}
+	if dirPort := strings.Split(id, ":"); len(dirPort) == 2 {
+		port.SymmetrixPortKey = &types.PortKey{DirectorID: dirPort[0], PortID: dirPort[1]}
+		AddSymmetrixPort(port)
+		return
+	}
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
 	Data.PortIDToSymmetrixPortType[id] = port
 }
 
+// AddSymmetrixPort adds a port with all of its attributes, keyed by its SymmetrixPortKey.
+// The director is added as well if it does not exist yet.
+func AddSymmetrixPort(port *types.SymmetrixPortType) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	addSymmetrixPort(port)
+}
+
+func addSymmetrixPort(port *types.SymmetrixPortType) {
+	key := port.SymmetrixPortKey
+	if Data.DirectorIDToDirector[key.DirectorID] == nil {
+		addDirector(key.DirectorID, "Online")
+	}
+	Data.PortIDToSymmetrixPortType[key.DirectorID+":"+key.PortID] = port
+}
+
+// SetPortStatus sets the status, e.g. "ON" or "OFF", of the port "<director>:<port>".
+func SetPortStatus(id, status string) error {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	port := Data.PortIDToSymmetrixPortType[id]
+	if port == nil {
+		return fmt.Errorf("port %s not found", id)
+	}
+	port.PortStatus = status
+	return nil
+}
+
+// AddDirector adds a director with no ports.
+func AddDirector(directorID, availability string) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	addDirector(directorID, availability)
+}
+
+func addDirector(directorID, availability string) {
+	Data.DirectorIDToDirector[directorID] = &types.Director{
+		DirectorID:   directorID,
+		Availability: availability,
+	}
+}
+
+// getDirectorPorts returns the ports of a director sorted by port ID
+func getDirectorPorts(dID string) []*types.SymmetrixPortType {
+	ports := make([]*types.SymmetrixPortType, 0)
+	for _, port := range Data.PortIDToSymmetrixPortType {
+		if port.SymmetrixPortKey != nil && port.SymmetrixPortKey.DirectorID == dID && port.Type != "" {
+			ports = append(ports, port)
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		return ports[i].SymmetrixPortKey.PortID < ports[j].SymmetrixPortKey.PortID
+	})
+	return ports
+}
+
 func returnPort(w http.ResponseWriter, dID, pID string) {
-	replacements := make(map[string]string)
-	replacements["__PORT_ID__"] = pID
-	replacements["__DIRECTOR_ID__"] = dID
-	returnJSONFile(Data.JSONDir, "port_template.json", w, replacements)
+	port := Data.PortIDToSymmetrixPortType[dID+":"+pID]
+	if port == nil || port.Type == "" {
+		writeError(w, "port not found", http.StatusNotFound)
+		return
+	}
+	symPort := &types.Port{
+		SymmetrixPort: *port,
+	}
+	writeJSON(w, symPort)
 }
 
-func returnPortIDList(w http.ResponseWriter, dID string) {
-	replacements := make(map[string]string)
-	replacements["__DIRECTOR_ID__"] = dID
-	returnJSONFile(Data.JSONDir, "portIDList.json", w, replacements)
+func returnPortIDList(w http.ResponseWriter, ports []*types.SymmetrixPortType, portType, iscsiTarget string) {
+	portList := &types.PortList{
+		SymmetrixPortKey: make([]types.PortKey, 0),
+	}
+	for _, port := range ports {
+		if portType != "" && !strings.EqualFold(port.Type, portType) {
+			continue
+		}
+		if iscsiTarget != "" && strconv.FormatBool(port.ISCSITarget) != iscsiTarget {
+			continue
+		}
+		portList.SymmetrixPortKey = append(portList.SymmetrixPortKey, *port.SymmetrixPortKey)
+	}
+	writeJSON(w, portList)
 }
 
 // /univmax/restapi/90/system/symmetrix/{symid}/director/{{id}
@@ -2410,9 +5166,12 @@ func handleDirector(w http.ResponseWriter, r *http.Request) {
 			writeError(w, "Error retrieving Director(s): induced error", http.StatusRequestTimeout)
 			return
 		}
+		mockCacheMutex.Lock()
+		defer mockCacheMutex.Unlock()
 		// if we asked for a specific Director, return those details
 		if dID != "" {
 			returnDirector(w, dID)
+			return
 		}
 		// return a list of Directors
 		returnDirectorIDList(w)
@@ -2423,14 +5182,25 @@ func handleDirector(w http.ResponseWriter, r *http.Request) {
 }
 
 func returnDirector(w http.ResponseWriter, dID string) {
-	replacements := make(map[string]string)
-	replacements["__DIRECTOR_ID__"] = dID
-	returnJSONFile(Data.JSONDir, "director_template.json", w, replacements)
+	director := Data.DirectorIDToDirector[dID]
+	if director == nil {
+		writeError(w, "director not found", http.StatusNotFound)
+		return
+	}
+	result := *director
+	result.NumberOfPorts = int64(len(getDirectorPorts(dID)))
+	writeJSON(w, result)
 }
 
 func returnDirectorIDList(w http.ResponseWriter) {
-	replacements := make(map[string]string)
-	returnJSONFile(Data.JSONDir, "directorIDList.json", w, replacements)
+	directorList := &types.DirectorIDList{
+		DirectorIDs: make([]string, 0),
+	}
+	for id := range Data.DirectorIDToDirector {
+		directorList.DirectorIDs = append(directorList.DirectorIDs, id)
+	}
+	sort.Strings(directorList.DirectorIDs)
+	writeJSON(w, directorList)
 }
 
 // /univmax/restapi/90/sloprovisioning/symmetrix/{symid}/initiator/{id}
@@ -2451,6 +5221,10 @@ func handleInitiator(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 		}
+		if initID == "" && len(r.URL.Query()) > 0 {
+			returnFilteredInitiators(w, r.URL.Query())
+			return
+		}
 		ReturnInitiator(w, initID)
 
 	default:
@@ -2485,21 +5259,46 @@ func handleHost(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		// Scan the initiators to see if there are any non iqn ones; then assume
-		// host type Fibre.
+		// host type Fibre, unless they are all NVMe host NQNs.
 		isFibre := false
+		isNVMe := len(createHostParam.InitiatorIDs) != 0
 		for _, initiator := range createHostParam.InitiatorIDs {
 			if !strings.HasPrefix(initiator, "iqn.") {
 				isFibre = true
 			}
+			if !strings.HasPrefix(initiator, "nqn.") {
+				isNVMe = false
+			}
 		}
-		if isFibre {
+		hostType := types.HostTypeISCSI
+		if isNVMe {
+			hostType = types.HostTypeNVMe
+		} else if isFibre {
 			// Might need to add the Port information here
-			AddHost(createHostParam.HostID, "Fibre", createHostParam.InitiatorIDs)
-		} else {
-			//initNode := make([]string, 0)
-			//initNode = append(initNode, "iqn.1993-08.org.centos:01:5ae577b352a7")
-			AddHost(createHostParam.HostID, "iSCSI", createHostParam.InitiatorIDs)
+			hostType = types.HostTypeFibre
+		}
+		if InducedErrors.CreateHostConflict {
+			// Another node creates the host, without initiators yet, just before this request
+			InducedErrors.CreateHostConflict = false
+			mockCacheMutex.Lock()
+			newHost(createHostParam.HostID, hostType, []string{})
+			mockCacheMutex.Unlock()
 		}
+		mockCacheMutex.Lock()
+		_, exists := Data.HostIDToHost[createHostParam.HostID]
+		mockCacheMutex.Unlock()
+		if exists {
+			writeError(w, "Host "+createHostParam.HostID+" already exists", http.StatusConflict)
+			return
+		}
+		if createHostParam.ExecutionOption == types.ExecutionOptionAsynchronous {
+			resourceLink := fmt.Sprintf("sloprovisioning/symmetrix/%s/host/%s", vars["symid"], createHostParam.HostID)
+			returnCreationJob(w, "CreateHost-"+createHostParam.HostID, resourceLink, func() {
+				AddHost(createHostParam.HostID, hostType, createHostParam.InitiatorIDs)
+			})
+			return
+		}
+		AddHost(createHostParam.HostID, hostType, createHostParam.InitiatorIDs)
 		ReturnHost(w, createHostParam.HostID)
 
 	case http.MethodPut:
@@ -2508,13 +5307,37 @@ func handleHost(w http.ResponseWriter, r *http.Request) {
 			writeError(w, "Error updating Host: induced error", http.StatusRequestTimeout)
 			return
 		}
-		decoder := json.NewDecoder(r.Body)
-		updateHostParam := &types.UpdateHostParam{}
-		err := decoder.Decode(updateHostParam)
+		if hasError(&InducedErrors.UpdateHostConflict) {
+			writeError(w, "Host "+hostID+" is being modified by another request", http.StatusConflict)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
 		if err != nil {
 			writeError(w, "InvalidJson", http.StatusBadRequest)
 			return
 		}
+		updateHostParam := &types.UpdateHostParam{}
+		addInitiatorsParam := &types.UpdateHostAddInitiatorsParam{}
+		removeInitiatorsParam := &types.UpdateHostRemoveInititorsParam{}
+		if json.Unmarshal(body, updateHostParam) != nil ||
+			json.Unmarshal(body, addInitiatorsParam) != nil ||
+			json.Unmarshal(body, removeInitiatorsParam) != nil {
+			writeError(w, "InvalidJson", http.StatusBadRequest)
+			return
+		}
+		var addIDs, removeIDs []string
+		if action := addInitiatorsParam.EditHostAction; action != nil && action.AddInitiator != nil {
+			addIDs = action.AddInitiator.Initiators
+		}
+		if action := removeInitiatorsParam.EditHostAction; action != nil && action.RemoveInitiator != nil {
+			removeIDs = action.RemoveInitiator.Initiators
+		}
+		if len(addIDs) > 0 || len(removeIDs) > 0 {
+			if err = UpdateHostInitiators(hostID, addIDs, removeIDs); err != nil {
+				writeError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
 		ReturnHost(w, hostID)
 
 	case http.MethodDelete:
@@ -2522,7 +5345,10 @@ func handleHost(w http.ResponseWriter, r *http.Request) {
 			writeError(w, "Error deleting Host: induced error", http.StatusRequestTimeout)
 			return
 		}
-		RemoveHost(hostID)
+		if err := RemoveHost(hostID); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
 	default:
 		writeError(w, "Invalid Method", http.StatusBadRequest)
@@ -2548,10 +5374,8 @@ func returnHost(w http.ResponseWriter, hostID string) {
 		for k := range Data.HostIDToHost {
 			hostIDs = append(hostIDs, k)
 		}
-		hostIDList := &types.HostList{
-			HostIDs: hostIDs,
-		}
-		writeJSON(w, hostIDList)
+		sort.Strings(hostIDs)
+		writeIDListing(w, "hostId", hostIDs)
 	}
 }
 
@@ -2582,6 +5406,29 @@ func returnPortGroup(w http.ResponseWriter, portGroupID string) {
 	}
 }
 
+// ReturnPortGroupList writes the IDs of the port groups, filtered by the fibre, iscsi
+// and nvme_tcp query parameters
+func ReturnPortGroupList(w http.ResponseWriter, query url.Values) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	portGroupType := ""
+	switch {
+	case query.Get("fibre") == "true":
+		portGroupType = "Fibre"
+	case query.Get("iscsi") == "true":
+		portGroupType = "ISCSI"
+	case query.Get("nvme_tcp") == "true":
+		portGroupType = types.PortGroupProtocolNVMeTCP
+	}
+	portGroupIDs := make([]string, 0)
+	for k, pg := range Data.PortGroupIDToPortGroup {
+		if portGroupType == "" || strings.EqualFold(pg.PortGroupType, portGroupType) {
+			portGroupIDs = append(portGroupIDs, k)
+		}
+	}
+	writeJSON(w, &types.PortGroupList{PortGroupIDs: portGroupIDs})
+}
+
 func handleNotFound(w http.ResponseWriter, r *http.Request) {
 	writeError(w, "URL not found: "+r.URL.String(), http.StatusNotFound)
 }
@@ -2606,7 +5453,12 @@ func writeError(w http.ResponseWriter, message string, httpStatus int) {
 //  wrriter ResponseWriter where data is output
 // An optional replacement map. If supplied every instance of a key in the JSON file will be replaced with the corresponding value.
 func returnJSONFile(directory, filename string, w http.ResponseWriter, replacements map[string]string) (jsonBytes []byte) {
-	jsonBytes, err := ioutil.ReadFile(filepath.Join(directory, filename))
+	var err error
+	if directory == "" {
+		jsonBytes, err = jsonFiles.ReadFile(filename)
+	} else {
+		jsonBytes, err = ioutil.ReadFile(filepath.Join(directory, filename))
+	}
 	if err != nil {
 		log.Printf("Couldn't read %s/%s\n", directory, filename)
 		if w != nil {
@@ -2651,6 +5503,46 @@ func AddTempSnapshots() {
 
 }
 
+// /univmax/restapi/APIVersion/replication/symmetrix/{symid}/storagegroup/{id}/snapshot/{SnapID}/generation/{genID}
+func handleStorageGroupSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	vars := mux.Vars(r)
+	param := new(types.ModifyStorageGroupSnapshot)
+	if err := json.NewDecoder(r.Body).Decode(param); err != nil {
+		writeError(w, "problem decoding PUT storage group snapshot payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if param.Action != "Link" || param.Link == nil {
+		writeError(w, "unsupported storage group snapshot action: "+param.Action, http.StatusBadRequest)
+		return
+	}
+	if InducedErrors.LinkSnapshotError {
+		writeError(w, "error linking the snapshot: induced error", http.StatusBadRequest)
+		return
+	}
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	// the volumes of the source and target storage groups are paired in volume ID order
+	sources := append([]string{}, Data.StorageGroupIDToVolumes[vars["id"]]...)
+	targets := append([]string{}, Data.StorageGroupIDToVolumes[param.Link.LinkStorageGroupName]...)
+	if len(sources) == 0 || len(sources) != len(targets) {
+		writeError(w, "the source and target storage groups must have the same number of volumes", http.StatusBadRequest)
+		return
+	}
+	sort.Strings(sources)
+	sort.Strings(targets)
+	sourceList := make([]types.VolumeList, len(sources))
+	targetList := make([]types.VolumeList, len(targets))
+	for i := range sources {
+		sourceList[i] = types.VolumeList{Name: sources[i]}
+		targetList[i] = types.VolumeList{Name: targets[i]}
+	}
+	linkSnapshot(w, r, sourceList, targetList, param.ExecutionOption, vars["SnapID"], param.Link.Copy)
+}
+
 // univmax/restapi/private/APIVersion/replication/symmetrix/{symid}/snapshot/{SnapID}
 func handleSnapshot(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -2707,7 +5599,7 @@ func handleSnapshot(w http.ResponseWriter, r *http.Request) {
 				writeError(w, "error linking the snapshot: induced error", http.StatusBadRequest)
 				return
 			}
-			LinkSnapshot(w, r, updateSnapParam.VolumeNameListSource, updateSnapParam.VolumeNameListTarget, executionOption, SnapID)
+			LinkSnapshot(w, r, updateSnapParam.VolumeNameListSource, updateSnapParam.VolumeNameListTarget, executionOption, SnapID, updateSnapParam.Copy)
 			return
 		}
 		if updateSnapParam.Action == "Unlink" {
@@ -2718,6 +5610,14 @@ func handleSnapshot(w http.ResponseWriter, r *http.Request) {
 			UnlinkSnapshot(w, r, updateSnapParam.VolumeNameListSource, updateSnapParam.VolumeNameListTarget, executionOption, SnapID)
 			return
 		}
+		if updateSnapParam.Action == "Relink" || updateSnapParam.Action == "SetMode" {
+			if InducedErrors.LinkSnapshotError {
+				writeError(w, "error modifying the snapshot links: induced error", http.StatusBadRequest)
+				return
+			}
+			ModifySnapshotLinks(w, r, updateSnapParam.VolumeNameListSource, updateSnapParam.VolumeNameListTarget, updateSnapParam.Action, updateSnapParam.Copy, SnapID)
+			return
+		}
 		if updateSnapParam.Action == "Restore" {
 			// restoreSnapshot(w, r, updateSnapParam.VolumeNameListSource, updateSnapParam.VolumeNameListTarget, executionOption, SnapID)
 			// return
@@ -2755,6 +5655,23 @@ func createSnapshot(w http.ResponseWriter, r *http.Request, SnapID, executionOpt
 		writeError(w, "few devices not available", http.StatusBadRequest)
 		return
 	}
+	arraySnapshots := 0
+	for _, snapshots := range Data.VolIDToSnapshots {
+		arraySnapshots += len(snapshots)
+	}
+	for _, source := range sourceVolumeList {
+		if duplicateSnapshotCreationRequest(source.Name, SnapID) {
+			continue
+		}
+		if len(Data.VolIDToSnapshots[source.Name]) >= Data.MaxSnapshotsPerVolume {
+			writeError(w, fmt.Sprintf("The maximum number of snapshots (%d) of device %s has been reached", Data.MaxSnapshotsPerVolume, source.Name), http.StatusBadRequest)
+			return
+		}
+		if arraySnapshots++; Data.MaxSnapshotsPerArray > 0 && arraySnapshots > Data.MaxSnapshotsPerArray {
+			writeError(w, fmt.Sprintf("The maximum number of snapshots (%d) of the array has been reached", Data.MaxSnapshotsPerArray), http.StatusBadRequest)
+			return
+		}
+	}
 	// Make a job to return
 	resourceLink := fmt.Sprintf("/replication/symmetrix/%s/snapshot/%s", DefaultSymmetrixID, SnapID)
 	jobID := fmt.Sprintf("SnapID-%d", time.Now().Nanosecond())
@@ -2774,6 +5691,20 @@ func createSnapshot(w http.ResponseWriter, r *http.Request, SnapID, executionOpt
 	returnJobByID(w, jobID)
 }
 
+// SetMaxSnapshotsPerVolume sets the number of snapshots a volume can have
+func SetMaxSnapshotsPerVolume(max int) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	Data.MaxSnapshotsPerVolume = max
+}
+
+// SetMaxSnapshotsPerArray sets the number of snapshots the array can have, unlimited if 0
+func SetMaxSnapshotsPerArray(max int) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	Data.MaxSnapshotsPerArray = max
+}
+
 // AddNewSnapshot adds a snapshot to the mock cache
 func AddNewSnapshot(source, SnapID string) {
 	mockCacheMutex.Lock()
@@ -2782,12 +5713,13 @@ func AddNewSnapshot(source, SnapID string) {
 }
 
 func addNewSnapshot(source, SnapID string) {
-	time := time.Now().Nanosecond()
+	now := time.Now()
 	snapshot := &types.Snapshot{
-		Name:       SnapID,
-		Generation: 0,
-		State:      "Established",
-		Timestamp:  strconv.Itoa(time),
+		Name:         SnapID,
+		Generation:   0,
+		State:        "Established",
+		Timestamp:    strconv.Itoa(now.Nanosecond()),
+		TimestampUTC: now.UnixNano()/int64(time.Millisecond) - InducedErrors.SnapshotAge.Milliseconds(),
 	}
 	snapIDtoSnap := Data.VolIDToSnapshots[source]
 	if snapIDtoSnap == nil {
@@ -2890,13 +5822,13 @@ func renameSnapshot(w http.ResponseWriter, r *http.Request, sourceVolumeList []t
 }
 
 // LinkSnapshot - Links a snapshot and updates a mock cache
-func LinkSnapshot(w http.ResponseWriter, r *http.Request, sourceVolumeList []types.VolumeList, targetVolumeList []types.VolumeList, executionOption, SnapID string) {
+func LinkSnapshot(w http.ResponseWriter, r *http.Request, sourceVolumeList []types.VolumeList, targetVolumeList []types.VolumeList, executionOption, SnapID string, copy bool) {
 	mockCacheMutex.Lock()
 	defer mockCacheMutex.Unlock()
-	linkSnapshot(w, r, sourceVolumeList, targetVolumeList, executionOption, SnapID)
+	linkSnapshot(w, r, sourceVolumeList, targetVolumeList, executionOption, SnapID, copy)
 }
 
-func linkSnapshot(w http.ResponseWriter, r *http.Request, sourceVolumeList []types.VolumeList, targetVolumeList []types.VolumeList, executionOption, SnapID string) {
+func linkSnapshot(w http.ResponseWriter, r *http.Request, sourceVolumeList []types.VolumeList, targetVolumeList []types.VolumeList, executionOption, SnapID string, copy bool) {
 	if sourceVolumeList[0].Name == "" {
 		writeError(w, "no source volume names given to link the snapshot", http.StatusBadRequest)
 		return
@@ -2950,12 +5882,11 @@ func linkSnapshot(w http.ResponseWriter, r *http.Request, sourceVolumeList []typ
 			linkedVolume := &types.LinkedVolumes{
 				TargetDevice: targetVolID,
 				Timestamp:    strconv.Itoa(time),
-				State:        "Linked",
-				Copy:         false,
 				Restored:     false,
 				Linked:       true,
 				Defined:      true,
 			}
+			setLinkCopy(linkedVolume, copy)
 			if InducedErrors.TargetNotDefinedError {
 				linkedVolume.Defined = false
 			}
@@ -3030,6 +5961,45 @@ func unlinkSnapshot(w http.ResponseWriter, r *http.Request, sourceVolumeList []t
 	returnJobByID(w, jobID)
 }
 
+// ModifySnapshotLinks - Relinks or sets the copy mode of linked snapshots and updates mock cache
+func ModifySnapshotLinks(w http.ResponseWriter, r *http.Request, sourceVolumeList []types.VolumeList, targetVolumeList []types.VolumeList, action string, copy bool, SnapID string) {
+	mockCacheMutex.Lock()
+	defer mockCacheMutex.Unlock()
+	modifySnapshotLinks(w, r, sourceVolumeList, targetVolumeList, action, copy, SnapID)
+}
+
+func modifySnapshotLinks(w http.ResponseWriter, r *http.Request, sourceVolumeList []types.VolumeList, targetVolumeList []types.VolumeList, action string, copy bool, SnapID string) {
+	if len(sourceVolumeList) != len(targetVolumeList) {
+		writeError(w, "cannot modify snapshot links, the number of source and devices should be same", http.StatusBadRequest)
+		return
+	}
+	if fewVolumeUnavalaible(sourceVolumeList) {
+		writeError(w, "few source devices not available", http.StatusBadRequest)
+		return
+	}
+	if fewVolumeUnavalaible(targetVolumeList) {
+		writeError(w, "few target devices not available", http.StatusBadRequest)
+		return
+	}
+	for key, volID := range sourceVolumeList {
+		if Data.VolIDToSnapshots[volID.Name][SnapID] == nil {
+			writeError(w, "no snapshot information, snopshot cannot be found on this device", http.StatusBadRequest)
+			return
+		}
+		snapIDtoLinkedVolKey := SnapID + ":" + volID.Name
+		linkedVolume := Data.SnapIDToLinkedVol[snapIDtoLinkedVolKey][targetVolumeList[key].Name]
+		if linkedVolume == nil {
+			writeError(w, "devices not in the linked state", http.StatusBadRequest)
+			return
+		}
+		setLinkCopy(linkedVolume, copy)
+		if action == "Relink" {
+			linkedVolume.Timestamp = strconv.Itoa(time.Now().Nanosecond())
+		}
+	}
+	writeJSON(w, struct{}{})
+}
+
 //check if all the devices exist in the Mock VolumeIDToVolume or check if any unvailable devices
 func fewVolumeUnavalaible(sourceVolumeList []types.VolumeList) bool {
 	for _, volID := range sourceVolumeList {
@@ -3046,12 +6016,25 @@ func duplicateSnapshotCreationRequest(source, SnapID string) bool {
 	return ok
 }
 
+// GET /univmax/restapi/APIVersion/replication/symmetrix/{symID}/volume
+func handlePublicSymVolumes(w http.ResponseWriter, r *http.Request) {
+	if Data.NoPublicSnapVolumeList {
+		http.NotFound(w, r)
+		return
+	}
+	handleSymVolumes(w, r)
+}
+
 // GET univmax/restapi/private/APIVersion/replication/symmetrix/{symid}/volume
 func handleSymVolumes(w http.ResponseWriter, r *http.Request) {
 	if InducedErrors.GetSymVolumeError {
 		writeError(w, "error fetching the list: induced error", http.StatusBadRequest)
 		return
 	}
+	if !isMockSymmetrix(mux.Vars(r)["symid"]) {
+		writeError(w, "Symmetrix not found", http.StatusNotFound)
+		return
+	}
 	mockCacheMutex.Lock()
 	defer mockCacheMutex.Unlock()
 	queryParams := r.URL.Query()
@@ -3146,6 +6129,7 @@ func returnSnapshotObjectList(volID string) ([]types.VolumeSnapshotSource, []int
 			SnapshotName:  snap.Name,
 			Generation:    snap.Generation,
 			TimeStamp:     snap.Timestamp,
+			TimeStampUTC:  snap.TimestampUTC,
 			State:         snap.State,
 			LinkedVolumes: returnLinkedVolumes(snap.Name + ":" + volID),
 		}
@@ -3168,6 +6152,42 @@ func returnLinkedVolumes(snapIDtoLinkedVolKey string) []types.LinkedVolumes {
 	return linkedVolumes
 }
 
+// defineLinkedVolumes marks all the linked snapshot targets as defined
+func defineLinkedVolumes() {
+	for _, volIDToLinkedVols := range Data.SnapIDToLinkedVol {
+		for _, linkedVolume := range volIDToLinkedVols {
+			linkedVolume.Defined = true
+		}
+	}
+}
+
+// setLinkCopy sets the copy mode of a link. The data of a link in copy mode is copied at once,
+// unless InducedErrors.CopyInProgressError keeps it copying.
+func setLinkCopy(linkedVolume *types.LinkedVolumes, copy bool) {
+	linkedVolume.Copy = copy
+	linkedVolume.State = types.SnapshotLinkStateLinked
+	linkedVolume.PercentageCopied = 0
+	if copy && InducedErrors.CopyInProgressError {
+		linkedVolume.State = types.SnapshotLinkStateCopyInProg
+		linkedVolume.PercentageCopied = 50
+	} else if copy {
+		linkedVolume.State = types.SnapshotLinkStateCopied
+		linkedVolume.PercentageCopied = 100
+	}
+}
+
+// copyLinkedVolumes completes the copy of all the snapshot links in copy mode
+func copyLinkedVolumes() {
+	for _, volIDToLinkedVols := range Data.SnapIDToLinkedVol {
+		for _, linkedVolume := range volIDToLinkedVols {
+			if linkedVolume.Copy {
+				linkedVolume.State = types.SnapshotLinkStateCopied
+				linkedVolume.PercentageCopied = 100
+			}
+		}
+	}
+}
+
 //returns the List of volumeSnapshotLink to a Snapshot
 func returnVolumeSnapshotLink(targetVolID string) []types.VolumeSnapshotLink {
 	var snapshotLnk []types.VolumeSnapshotLink
@@ -3206,6 +6226,19 @@ func handleGenerations(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if InducedErrors.TargetDefinedAfterPolls > 0 {
+		InducedErrors.TargetDefinedAfterPolls--
+		if InducedErrors.TargetDefinedAfterPolls == 0 {
+			defineLinkedVolumes()
+		}
+	}
+	if InducedErrors.CopiedAfterPolls > 0 {
+		InducedErrors.CopiedAfterPolls--
+		if InducedErrors.CopiedAfterPolls == 0 {
+			copyLinkedVolumes()
+		}
+	}
+
 	volumeSnapshotSource, generations := returnSnapshotObjectList(volID)
 	volumeSnapshotLink := returnVolumeSnapshotLink(volID)
 
@@ -3241,6 +6274,9 @@ func handleGenerations(w http.ResponseWriter, r *http.Request) {
 }
 
 func handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	mockCacheMutex.Lock()
+	Data.CapabilityRequests++
+	mockCacheMutex.Unlock()
 	var jsonBytes []byte
 	if InducedErrors.SnapshotNotLicensed {
 		jsonBytes = []byte("{\"symmetrixCapability\":[{\"symmetrixId\":\"000197900046\",\"snapVxCapable\":false,\"rdfCapable\":true,\"virtualWitnessCapable\":false}]}")
@@ -3250,7 +6286,16 @@ func handleCapabilities(w http.ResponseWriter, r *http.Request) {
 	} else if InducedErrors.UnisphereMismatchError {
 		jsonBytes = []byte("{\"symmetrixCapability\":[{\"symmetrixId\":\"000000000000\",\"snapVxCapable\":true,\"rdfCapable\":true,\"virtualWitnessCapable\":false}]}")
 	} else {
-		jsonBytes = []byte("{\"symmetrixCapability\":[{\"symmetrixId\":\"000197900046\",\"snapVxCapable\":true,\"rdfCapable\":true,\"virtualWitnessCapable\":false}]}")
+		mockCacheMutex.Lock()
+		capability := types.SymmetrixCapability{
+			SymmetrixID:                 DefaultSymmetrixID,
+			SnapVxCapable:               true,
+			RdfCapable:                  true,
+			SnapVxMaxSnapshotsPerVolume: Data.MaxSnapshotsPerVolume,
+			SnapVxMaxSnapshotsPerArray:  Data.MaxSnapshotsPerArray,
+		}
+		mockCacheMutex.Unlock()
+		jsonBytes, _ = json.Marshal(&types.SymReplicationCapabilities{SymmetrixCapability: []types.SymmetrixCapability{capability}})
 	}
 	_, err := w.Write(jsonBytes)
 	if err != nil {
@@ -3260,6 +6305,25 @@ func handleCapabilities(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// handleCapableSymmetrixList returns the handler of a list of the arrays having a capability
+// GET /univmax/restapi/API_VERSION/vvol/symmetrix
+// GET /univmax/restapi/API_VERSION/file/symmetrix
+func handleCapableSymmetrixList(symIDs *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mockCacheMutex.Lock()
+		defer mockCacheMutex.Unlock()
+		if r.Method != http.MethodGet {
+			writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if *symIDs == nil {
+			writeError(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, &types.SymmetrixIDList{SymmetrixIDs: *symIDs})
+	}
+}
+
 func handlePrivVolume(w http.ResponseWriter, r *http.Request) {
 	mockCacheMutex.Lock()
 	defer mockCacheMutex.Unlock()
@@ -3267,32 +6331,65 @@ func handlePrivVolume(w http.ResponseWriter, r *http.Request) {
 		writeError(w, "error fetching the Volume structure: induced error", http.StatusBadRequest)
 		return
 	}
-	queryParams := r.URL.Query()
-	privateVolumeIterator := new(types.PrivVolumeIterator)
-	if wwn := queryParams.Get("wwn"); wwn != "" {
-		volID := wwn[27:]
-		volume := Data.VolumeIDToVolume[volID]
-		volumeHeader := parseVolumetoVolumeHeader(volume)
-		timeFinderInfo := returnTimeFinderInfo(volID)
-		var result []types.VolumeResultPrivate
-		result = append(result, types.VolumeResultPrivate{
-			VolumeHeader:   *volumeHeader,
-			TimeFinderInfo: *timeFinderInfo,
-		})
-		privVolumeResultList := types.PrivVolumeResultList{
-			PrivVolumeList: result,
-			From:           1,
-			To:             1,
+	if InducedErrors.GetPrivateVolumeIterator {
+		writeError(w, "error getting the private volume iterator: induced error", http.StatusBadRequest)
+		return
+	}
+	// the wwn query matches a WWN exactly, or the WWNs containing it with the <like> prefix
+	wwn := r.URL.Query().Get("wwn")
+	Data.PrivVolumeIteratorList = make([]string, 0)
+	if strings.HasPrefix(wwn, "<like>") {
+		wwn = strings.TrimPrefix(wwn, "<like>")
+		for volWWN, volID := range Data.WWNToVolumeID {
+			if strings.Contains(volWWN, wwn) {
+				Data.PrivVolumeIteratorList = append(Data.PrivVolumeIteratorList, volID)
+			}
+		}
+	} else if wwn != "" {
+		if volID, ok := Data.WWNToVolumeID[wwn]; ok {
+			Data.PrivVolumeIteratorList = append(Data.PrivVolumeIteratorList, volID)
+		}
+	} else {
+		for volID, vol := range Data.VolumeIDToVolume {
+			if vol != nil {
+				Data.PrivVolumeIteratorList = append(Data.PrivVolumeIteratorList, volID)
+			}
 		}
-		privateVolumeIterator.ResultList = privVolumeResultList
-		privateVolumeIterator.ID = "70e15d35-baaf-43d3-865a-bf3300684895_0"
-		privateVolumeIterator.ExpirationTime = 1576137450163
-		privateVolumeIterator.MaxPageSize = 1000
-		privateVolumeIterator.Count = 1
 	}
+	sort.Strings(Data.PrivVolumeIteratorList)
+	privateVolumeIterator := &types.PrivVolumeIterator{
+		ID:             "PrivVolume",
+		Count:          len(Data.PrivVolumeIteratorList),
+		ExpirationTime: 1576137450163,
+		MaxPageSize:    10,
+	}
+	privateVolumeIterator.ResultList = returnPrivVolumePage(1, privateVolumeIterator.MaxPageSize)
 	writeJSON(w, privateVolumeIterator)
 }
 
+// returnPrivVolumePage returns the private volumes of the private volume iterator from from to to,
+// or only the first half of them with InducedErrors.PrivVolumePartialPageError
+func returnPrivVolumePage(from, to int) types.PrivVolumeResultList {
+	if to > len(Data.PrivVolumeIteratorList) {
+		to = len(Data.PrivVolumeIteratorList)
+	}
+	if InducedErrors.PrivVolumePartialPageError && from > 1 {
+		to = from + (to-from)/2
+	}
+	result := types.PrivVolumeResultList{PrivVolumeList: make([]types.VolumeResultPrivate, 0), From: from, To: to}
+	for i := from - 1; i < to; i++ {
+		volID := Data.PrivVolumeIteratorList[i]
+		if Data.VolumeIDToVolume[volID] == nil {
+			continue
+		}
+		result.PrivVolumeList = append(result.PrivVolumeList, types.VolumeResultPrivate{
+			VolumeHeader:   *parseVolumetoVolumeHeader(Data.VolumeIDToVolume[volID]),
+			TimeFinderInfo: *returnTimeFinderInfo(volID),
+		})
+	}
+	return result
+}
+
 func parseVolumetoVolumeHeader(volume *types.Volume) *types.VolumeHeader {
 	volumeHeader := &types.VolumeHeader{
 		VolumeID:     volume.VolumeID,
@@ -3339,6 +6436,7 @@ func returnSnapVXSession(volID string, isSource, isTarget bool) types.SnapVXSess
 					TargetDevice: volID,
 					SourceDevice: sourceVolID,
 					SnapshotName: SnapID,
+					Defined:      volIDToLinkedVolumes[volID].State,
 				}
 			}
 		}
@@ -3351,6 +6449,14 @@ func returnSrcSnapshotGenInfo(volID string) []types.SourceSnapshotGenInfo {
 
 	for _, snapIDtoSnap := range Data.VolIDToSnapshots[volID] {
 		timestamp, _ := strconv.ParseInt(snapIDtoSnap.Timestamp, 10, 64)
+		var linkSnapGenInfo []types.LinkSnapshotGenInfo
+		for targetVolID, linkedVol := range Data.SnapIDToLinkedVol[snapIDtoSnap.Name+":"+volID] {
+			linkSnapGenInfo = append(linkSnapGenInfo, types.LinkSnapshotGenInfo{
+				TargetDevice: targetVolID,
+				State:        linkedVol.State,
+				Defined:      linkedVol.Defined,
+			})
+		}
 		srcSnapGenInfo = append(srcSnapGenInfo, types.SourceSnapshotGenInfo{
 			SnapshotHeader: types.SnapshotHeader{
 				Device:       volID,
@@ -3358,6 +6464,7 @@ func returnSrcSnapshotGenInfo(volID string) []types.SourceSnapshotGenInfo {
 				Generation:   snapIDtoSnap.Generation,
 				Timestamp:    timestamp,
 			},
+			LinkSnapshotGenInfo: linkSnapGenInfo,
 		})
 	}
 
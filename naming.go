@@ -0,0 +1,119 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxResourceNameLength is the maximum length of the name of a storage group, host, port group
+// or masking view, and of the identifier of a volume.
+const MaxResourceNameLength = 64
+
+// A CSI resource name is the prefix, cluster ID, namespace and name joined by csiNameSeparator,
+// e.g. "csi_cluster1_default_pvc-2d8f0c1e-5c1a-4c57-9d9c-1f0b9e0c7a11". The fields cannot
+// contain the separator, so the name can always be split back into them.
+const (
+	csiNameSeparator = "_"
+	csiNameFields    = 4
+	// nameHashLength is the number of hex digits of the hash appended to a truncated name
+	nameHashLength = 8
+)
+
+var csiNameFieldRegex = regexp.MustCompile(`^[A-Za-z0-9-]*$`)
+
+// CSIResourceName is the ownership metadata a CSI driver encodes in the names of the resources it creates.
+type CSIResourceName struct {
+	// Prefix identifies the driver, e.g. "csi"
+	Prefix string
+
+	// ClusterID identifies the cluster which owns the resource
+	ClusterID string
+
+	// Namespace is the namespace of the owning object; it is empty for cluster scoped objects
+	Namespace string
+
+	// Name is the name or UID of the owning object, e.g. the PVC UID. When the resource name
+	// is truncated, it ends with a hash of the untruncated resource name.
+	Name string
+}
+
+// BuildCSIResourceName returns the resource name encoding r. If it is longer than maxLength, or
+// MaxResourceNameLength if maxLength is 0, the Name field is truncated and a hash of the
+// complete resource name is appended, so that different long names remain distinct.
+func BuildCSIResourceName(r CSIResourceName, maxLength int) (string, error) {
+	if maxLength <= 0 {
+		maxLength = MaxResourceNameLength
+	}
+	if r.Prefix == "" || r.ClusterID == "" || r.Name == "" {
+		return "", fmt.Errorf("the prefix, cluster ID and name of a CSI resource name must be supplied")
+	}
+	for _, field := range []string{r.Prefix, r.ClusterID, r.Namespace, r.Name} {
+		if !csiNameFieldRegex.MatchString(field) {
+			return "", fmt.Errorf("Invalid CSI resource name field %s, it may only contain letters, digits and '-'", field)
+		}
+	}
+	name := strings.Join([]string{r.Prefix, r.ClusterID, r.Namespace, r.Name}, csiNameSeparator)
+	if len(name) <= maxLength {
+		return name, nil
+	}
+	// the truncated Name keeps at least one character besides the hash
+	fixedLength := len(name) - len(r.Name)
+	if maxLength-fixedLength < nameHashLength+2 {
+		return "", fmt.Errorf("the CSI resource name %s cannot be truncated to %d characters", name, maxLength)
+	}
+	return TruncateResourceName(name, maxLength), nil
+}
+
+// ParseCSIResourceName returns the ownership metadata encoded in a name built by BuildCSIResourceName.
+func ParseCSIResourceName(name string) (*CSIResourceName, error) {
+	fields := strings.Split(name, csiNameSeparator)
+	if len(fields) != csiNameFields || fields[0] == "" || fields[1] == "" || fields[3] == "" {
+		return nil, fmt.Errorf("%s is not a CSI resource name", name)
+	}
+	for _, field := range fields {
+		if !csiNameFieldRegex.MatchString(field) {
+			return nil, fmt.Errorf("%s is not a CSI resource name", name)
+		}
+	}
+	return &CSIResourceName{
+		Prefix:    fields[0],
+		ClusterID: fields[1],
+		Namespace: fields[2],
+		Name:      fields[3],
+	}, nil
+}
+
+// TruncateResourceName returns name if it is at most maxLength characters long. Otherwise it
+// returns the start of name followed by '-' and a hash of the complete name, maxLength
+// characters in total.
+func TruncateResourceName(name string, maxLength int) string {
+	if len(name) <= maxLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])[:nameHashLength]
+	keep := maxLength - nameHashLength - 1
+	if keep <= 0 && maxLength < nameHashLength {
+		return hash[:maxLength]
+	} else if keep <= 0 {
+		return hash
+	}
+	return name[:keep] + "-" + hash
+}
@@ -0,0 +1,96 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_BuildAndParseCSIResourceName(t *testing.T) {
+	pvcUID := "2d8f0c1e-5c1a-4c57-9d9c-1f0b9e0c7a11"
+	var tests = []struct {
+		name      string
+		resource  CSIResourceName
+		maxLength int
+		expected  string
+		wantErr   bool
+	}{
+		{"pvc", CSIResourceName{"csi", "cluster1", "default", pvcUID}, 0,
+			"csi_cluster1_default_" + pvcUID, false},
+		{"cluster scoped", CSIResourceName{"csi", "cluster1", "", "pv-1"}, 0, "csi_cluster1__pv-1", false},
+		{"truncated", CSIResourceName{"csi", "cluster1", "namespace-with-a-long-name", pvcUID}, 0,
+			"csi_cluster1_namespace-with-a-long-name_2d8f0c1e-5c1a-4-614e36e5", false},
+		{"short limit", CSIResourceName{"csi", "cluster1", "default", pvcUID}, 32,
+			"csi_cluster1_default_2d-2a0e0ad7", false},
+		{"no room for the name", CSIResourceName{"csi", "cluster1", "default", pvcUID}, 28, "", true},
+		{"separator in a field", CSIResourceName{"csi", "cluster_1", "default", pvcUID}, 0, "", true},
+		{"invalid character", CSIResourceName{"csi", "cluster1", "default", "pvc.1"}, 0, "", true},
+		{"missing cluster ID", CSIResourceName{"csi", "", "default", pvcUID}, 0, "", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			name, err := BuildCSIResourceName(tt.resource, tt.maxLength)
+			if (err != nil) != tt.wantErr || name != tt.expected {
+				t.Errorf("BuildCSIResourceName(%+v) = %s, %v; expected %s", tt.resource, name, err, tt.expected)
+			}
+			if tt.wantErr {
+				return
+			}
+			parsed, err := ParseCSIResourceName(name)
+			if err != nil {
+				t.Fatalf("ParseCSIResourceName(%s) failed: %v", name, err)
+			}
+			expected := tt.resource
+			if len(tt.expected) < len(tt.resource.Prefix+tt.resource.ClusterID+tt.resource.Namespace+tt.resource.Name)+3 {
+				expected.Name = parsed.Name
+				if !strings.HasPrefix(tt.resource.Name, parsed.Name[:len(parsed.Name)-nameHashLength-1]) {
+					t.Errorf("truncated name %s is not a prefix of %s", parsed.Name, tt.resource.Name)
+				}
+			}
+			if *parsed != expected {
+				t.Errorf("ParseCSIResourceName(%s) = %+v; expected %+v", name, *parsed, expected)
+			}
+		})
+	}
+}
+
+func Test_ParseCSIResourceNameErrors(t *testing.T) {
+	for _, name := range []string{"", "csi-cluster1-default-pvc", "csi_cluster1_pvc", "csi_cluster1_default_pvc_1", "_cluster1_default_pvc", "csi_cluster1_default_pvc.1"} {
+		if _, err := ParseCSIResourceName(name); err == nil {
+			t.Errorf("ParseCSIResourceName(%s) expected an error", name)
+		}
+	}
+}
+
+func Test_TruncateResourceName(t *testing.T) {
+	long := strings.Repeat("a", 70)
+	truncated := TruncateResourceName(long, MaxResourceNameLength)
+	if len(truncated) != MaxResourceNameLength || !strings.HasPrefix(truncated, strings.Repeat("a", 55)+"-") {
+		t.Errorf("TruncateResourceName(%s) = %s", long, truncated)
+	}
+	if other := TruncateResourceName(long+"b", MaxResourceNameLength); other == truncated {
+		t.Errorf("different names truncated to the same name %s", other)
+	}
+	if short := TruncateResourceName("abc", MaxResourceNameLength); short != "abc" {
+		t.Errorf("TruncateResourceName(abc) = %s", short)
+	}
+	for _, maxLength := range []int{4, 9, 10} {
+		if got := TruncateResourceName(long, maxLength); len(got) > maxLength || got == "" {
+			t.Errorf("TruncateResourceName(%s, %d) = %s", long, maxLength, got)
+		}
+	}
+}
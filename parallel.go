@@ -0,0 +1,74 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultMaxConcurrentRequests is the number of requests a client has outstanding at once when it
+// reads or waits for several objects concurrently, if ClientOptions.MaxConcurrentRequests is not set.
+const DefaultMaxConcurrentRequests = 8
+
+// forEachConcurrently calls fn with every index from 0 to n-1, up to ClientOptions.MaxConcurrentRequests
+// calls at once, see forEachConcurrently.
+func (c *Client) forEachConcurrently(ctx context.Context, operation string, n int, fn func(ctx context.Context, i int) error) error {
+	return forEachConcurrently(ctx, c.maxConcurrentRequests, operation, n, fn)
+}
+
+// forEachConcurrently calls fn with every index from 0 to n-1, up to limit calls at once, or
+// DefaultMaxConcurrentRequests if limit is 0, and waits for the calls it started. No call is started once
+// ctx is done or a call returned an error, and the context passed to fn is then cancelled. It returns the
+// first error of fn, else the error of ctx naming the operation if ctx was done. fn which should not stop
+// the others records its errors itself and returns nil.
+func forEachConcurrently(ctx context.Context, limit int, operation string, n int, fn func(ctx context.Context, i int) error) error {
+	if limit <= 0 {
+		limit = DefaultMaxConcurrentRequests
+	}
+	fnCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var firstErr error
+	var once sync.Once
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-fnCtx.Done():
+		}
+		if fnCtx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := fn(fnCtx, i); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return abortedError(ctx, operation)
+}
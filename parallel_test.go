@@ -0,0 +1,89 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_forEachConcurrentlyLimit(t *testing.T) {
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	done := make([]bool, 20)
+	err := forEachConcurrently(context.Background(), 3, "test", len(done), func(ctx context.Context, i int) error {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+		time.Sleep(time.Millisecond)
+		mutex.Lock()
+		running--
+		done[i] = true
+		mutex.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("forEachConcurrently failed: %v", err)
+	}
+	if maxRunning > 3 {
+		t.Errorf("Expected at most 3 calls at once but got %d", maxRunning)
+	}
+	for i, ok := range done {
+		if !ok {
+			t.Errorf("Expected a call with %d", i)
+		}
+	}
+}
+
+func Test_forEachConcurrentlyStops(t *testing.T) {
+	failure := errors.New("failure")
+	var calls int32
+	err := forEachConcurrently(context.Background(), 2, "test", 100, func(ctx context.Context, i int) error {
+		atomic.AddInt32(&calls, 1)
+		if i == 1 {
+			return failure
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected the error of the failed call but got: %v", err)
+	}
+	if calls > 3 {
+		t.Errorf("Expected the calls to stop after the failure but got %d", calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = 0
+	err = forEachConcurrently(ctx, 2, "test", 100, func(ctx context.Context, i int) error {
+		if atomic.AddInt32(&calls, 1) == 2 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || err.Error() != "test aborted: context canceled" {
+		t.Errorf("Expected the cancellation of test but got: %v", err)
+	}
+	if calls > 4 {
+		t.Errorf("Expected the calls to stop after the cancellation but got %d", calls)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	types "github.com/dell/gopowermax/types/v90"
+	types91 "github.com/dell/gopowermax/types/v91"
+)
+
+// ForceOption is the force flag of the remoteSymmSGInfoParam of a storage group update built by
+// GetCreateVolInSGPayloadWithForceOption, GetAddVolumeToSGPayloadWithForceOption or
+// GetRemoveVolumeFromSGPayloadWithForceOption. GetCreateVolInSGPayload always forces the update.
+// Unisphere rejects adding volumes to or removing volumes from an SRDF protected storage group when
+// the state of its RDF pairs does not allow the change to be made on the remote array as well.
+// A forced update goes ahead regardless, which can move devices which are still replicating and
+// leave the local and remote storage groups out of step, so only force after checking the RDF state.
+// The flag is only sent to Unisphere 9.1 and later.
+type ForceOption bool
+
+const (
+	// DoNotForce lets Unisphere reject the update if the RDF state of the storage group does not allow it
+	DoNotForce ForceOption = false
+	// ForceUpdate makes Unisphere update the storage group whatever the RDF state of its volumes
+	ForceUpdate ForceOption = true
+)
+
+// StorageGroupPayload is the payload of a storage group update built by GetCreateVolInSGPayload,
+// GetAddVolumeToSGPayload and GetRemoveVolumeFromSGPayload. Only the field of the Unisphere
+// version of the client is set: V90 for version 90, V91 for the later versions.
+type StorageGroupPayload struct {
+	V90 *types.UpdateStorageGroupPayload
+	V91 *types91.UpdateStorageGroupPayload
+}
+
+// MarshalJSON marshals the payload of the version which is set
+func (p *StorageGroupPayload) MarshalJSON() ([]byte, error) {
+	if p.V90 != nil {
+		return json.Marshal(p.V90)
+	}
+	return json.Marshal(p.V91)
+}
+
+// MetaData returns the metadata headers of the payload, which are sent with the request
+func (p *StorageGroupPayload) MetaData() http.Header {
+	if p.V90 != nil {
+		return p.V90.MetaData()
+	}
+	if p.V91 != nil {
+		return p.V91.MetaData()
+	}
+	return make(http.Header)
+}
+
+// Forced returns true if the payload forces the update of the storage group, see ForceOption
+func (p *StorageGroupPayload) Forced() bool {
+	if p.V91 == nil {
+		return false
+	}
+	param := p.V91.EditStorageGroupActionParam
+	if expand := param.ExpandStorageGroupParam; expand != nil {
+		if expand.AddVolumeParam != nil && expand.AddVolumeParam.RemoteSymmSGInfoParam.Force {
+			return true
+		}
+		if expand.AddSpecificVolumeParam != nil && expand.AddSpecificVolumeParam.RemoteSymmSGInfoParam.Force {
+			return true
+		}
+	}
+	return param.RemoveVolumeParam != nil && param.RemoveVolumeParam.RemoteSymmSGInfoParam.Force
+}
+
+// SetMetaData sets the metadata headers of the payload
+func (p *StorageGroupPayload) SetMetaData(metadata http.Header) {
+	if p.V90 != nil {
+		p.V90.SetMetaData(metadata)
+	}
+	if p.V91 != nil {
+		p.V91.SetMetaData(metadata)
+	}
+}
+
+// MarshalStable returns v as indented JSON with the keys of every object sorted and a final newline,
+// so that the output does not depend on the order of the struct fields and can be compared to a golden file.
+func MarshalStable(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// Decoding into interface{} turns the objects into maps, which encoding/json marshals in key order
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(generic); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,122 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func Test_MarshalStable(t *testing.T) {
+	var tests = []struct {
+		name     string
+		value    interface{}
+		expected string
+	}{
+		{"sorted keys", map[string]interface{}{"b": 1, "a": map[string]string{"d": "x", "c": "<y>"}},
+			"{\n  \"a\": {\n    \"c\": \"<y>\",\n    \"d\": \"x\"\n  },\n  \"b\": 1\n}\n"},
+		{"struct fields sorted", struct {
+			Z int64  `json:"z"`
+			A string `json:"a"`
+		}{Z: 9007199254740993, A: "v"},
+			"{\n  \"a\": \"v\",\n  \"z\": 9007199254740993\n}\n"},
+		{"null", nil, "null\n"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalStable(tt.value)
+			if err != nil || string(got) != tt.expected {
+				t.Errorf("MarshalStable() = %q, %v; expected %q", got, err, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_StorageGroupPayloads(t *testing.T) {
+	client90 := &Client{version: APIVersion90}
+	client91 := &Client{version: APIVersion91}
+	var tests = []struct {
+		name     string
+		payload  *StorageGroupPayload
+		expected string
+	}{
+		{"create volume 90", client90.GetCreateVolInSGPayload(10, "vol1", true, "", ""),
+			`{"editStorageGroupActionParam":{"expandStorageGroupParam":{"addVolumeParam":{"num_of_vols":1,"volumeAttribute":{"capacityUnit":"CYL","volume_size":"10"},"emulation":"FBA","volumeIdentifier":{"volumeIdentifierChoice":"identifier_name","identifier_name":"vol1"}}}},"executionOption":"SYNCHRONOUS"}`},
+		{"create volume 91", client91.GetCreateVolInSGPayload(10, "vol1", false, "000000000002", "sg2"),
+			`{"editStorageGroupActionParam":{"expandStorageGroupParam":{"addVolumeParam":{"emulation":"FBA","create_new_volumes":true,"volumeAttributes":[{"num_of_vols":1,"volumeIdentifier":{"volumeIdentifierChoice":"identifier_name","identifier_name":"vol1"},"capacityUnit":"CYL","volume_size":"10"}],"remoteSymmSGInfoParam":{"remote_symmetrix_1_id":"000000000002","remote_symmetrix_1_sgs":["sg2"],"force":true}}}},"executionOption":"ASYNCHRONOUS"}`},
+		{"create volume 91 without force", client91.GetCreateVolInSGPayloadWithForceOption(10, "vol1", true, DoNotForce, "000000000002", "sg2"),
+			`{"editStorageGroupActionParam":{"expandStorageGroupParam":{"addVolumeParam":{"emulation":"FBA","create_new_volumes":true,"volumeAttributes":[{"num_of_vols":1,"volumeIdentifier":{"volumeIdentifierChoice":"identifier_name","identifier_name":"vol1"},"capacityUnit":"CYL","volume_size":"10"}],"remoteSymmSGInfoParam":{"remote_symmetrix_1_id":"000000000002","remote_symmetrix_1_sgs":["sg2"]}}}},"executionOption":"SYNCHRONOUS"}`},
+		{"add volumes 90", client90.GetAddVolumeToSGPayload(false, true, "", "", "00001", "00002"),
+			`{"editStorageGroupActionParam":{"expandStorageGroupParam":{"addSpecificVolumeParam":{"volumeId":["00001","00002"]}}},"executionOption":"ASYNCHRONOUS"}`},
+		{"add volumes 91", client91.GetAddVolumeToSGPayload(true, false, "", "", "00001"),
+			`{"editStorageGroupActionParam":{"expandStorageGroupParam":{"addSpecificVolumeParam":{"volumeId":["00001"],"remoteSymmSGInfoParam":{}}}},"executionOption":"SYNCHRONOUS"}`},
+		{"remove volumes 90", client90.GetRemoveVolumeFromSGPayload(false, "", "", "00001"),
+			`{"editStorageGroupActionParam":{"removeVolumeParam":{"volumeId":["00001"]}},"executionOption":"SYNCHRONOUS"}`},
+		{"remove volumes 91", client91.GetRemoveVolumeFromSGPayload(true, "000000000002", "sg2", "00001"),
+			`{"editStorageGroupActionParam":{"removeVolumeParam":{"volumeId":["00001"],"remoteSymmSGInfoParam":{"remote_symmetrix_1_id":"000000000002","remote_symmetrix_1_sgs":["sg2"],"force":true}}},"executionOption":"SYNCHRONOUS"}`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.payload)
+			if err != nil || string(got) != tt.expected {
+				t.Errorf("json.Marshal() = %s, %v; expected %s", got, err, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_StorageGroupPayloadForced(t *testing.T) {
+	client90 := &Client{version: APIVersion90}
+	client91 := &Client{version: APIVersion91}
+	var tests = []struct {
+		name     string
+		payload  *StorageGroupPayload
+		expected bool
+	}{
+		{"create volume 90 ignores force", client90.GetCreateVolInSGPayload(10, "vol1", true, "", ""), false},
+		{"create volume 91", client91.GetCreateVolInSGPayloadWithForceOption(10, "vol1", true, DoNotForce, "000000000002", "sg2"), false},
+		{"create volume 91 forced by default", client91.GetCreateVolInSGPayload(10, "vol1", true, "000000000002", "sg2"), true},
+		{"add volumes 91", client91.GetAddVolumeToSGPayloadWithForceOption(true, DoNotForce, "", "", "00001"), false},
+		{"add volumes 91 forced", client91.GetAddVolumeToSGPayloadWithForceOption(true, ForceUpdate, "", "", "00001"), true},
+		{"remove volumes 90 ignores force", client90.GetRemoveVolumeFromSGPayloadWithForceOption(ForceUpdate, "", "", "00001"), false},
+		{"remove volumes 91 forced", client91.GetRemoveVolumeFromSGPayloadWithForceOption(ForceUpdate, "000000000002", "sg2", "00001"), true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.payload.Forced(); got != tt.expected {
+				t.Errorf("Forced() = %v; expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_StorageGroupPayloadMetaData(t *testing.T) {
+	metadata := http.Header{}
+	metadata.Set("x-csi-pv-name", "pv1")
+	for _, version := range []string{APIVersion90, APIVersion91} {
+		client := &Client{version: version}
+		payload := client.GetCreateVolInSGPayload(10, "vol1", true, "", "", metadata)
+		if got := payload.MetaData().Get("x-csi-pv-name"); got != "pv1" {
+			t.Errorf("version %s: MetaData() = %q; expected pv1", version, got)
+		}
+		if len(client.GetAddVolumeToSGPayload(true, false, "", "", "00001").MetaData()) != 0 {
+			t.Errorf("version %s: expected no metadata without options", version)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	types "github.com/dell/gopowermax/types/v90"
+	log "github.com/sirupsen/logrus"
+)
+
+// The following constants are for internal use of the pmax library.
+const (
+	PerformanceX   = "performance/"
+	XArrayRegister = "Array/register"
+)
+
+// The performance API is not versioned
+func (c *Client) getPerformanceRegistrationURL() string {
+	return RESTPrefix + PerformanceX + XArrayRegister
+}
+
+// GetArrayPerfRegistration returns how the array is registered for the collection of performance data
+func (c *Client) GetArrayPerfRegistration(ctx context.Context, symID string) (*types.PerformanceRegistration, error) {
+	defer c.TimeSpent("GetArrayPerfRegistration", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	URL := c.getPerformanceRegistrationURL() + "?symmetrixId=" + url.QueryEscape(symID)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
+	defer cancel()
+	registrations := &types.PerformanceRegistrationList{}
+	if err := c.api.Get(ctx, URL, c.getDefaultHeaders(), registrations); err != nil {
+		log.Error("GetArrayPerfRegistration failed: " + err.Error())
+		return nil, err
+	}
+	for _, registration := range registrations.RegistrationDetailsInfo {
+		if registration.SymmetrixID == symID {
+			return &registration, nil
+		}
+	}
+	// An array which was never registered is not listed
+	return &types.PerformanceRegistration{SymmetrixID: symID}, nil
+}
+
+// IsArrayPerfRegistered returns true if the array is registered for the collection of diagnostic
+// or real time performance data. The metrics of an array which is not registered are empty.
+func (c *Client) IsArrayPerfRegistered(ctx context.Context, symID string) (bool, error) {
+	registration, err := c.GetArrayPerfRegistration(ctx, symID)
+	if err != nil {
+		return false, err
+	}
+	return registration.Diagnostic || registration.RealTime, nil
+}
+
+// RegisterArrayForPerformance registers the array for the collection of diagnostic performance data,
+// and of real time data if realTime is set. The first metrics are available once Unisphere has
+// collected them, a few collection intervals after the registration.
+func (c *Client) RegisterArrayForPerformance(ctx context.Context, symID string, realTime bool) error {
+	defer c.TimeSpent("RegisterArrayForPerformance", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return err
+	}
+	payload := &types.RegisterPerformanceParam{
+		SymmetrixID: symID,
+		Diagnostic:  true,
+		RealTime:    realTime,
+	}
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
+	defer cancel()
+	if err := c.api.Post(ctx, c.getPerformanceRegistrationURL(), c.getDefaultHeaders(), payload, nil); err != nil {
+		log.Error("RegisterArrayForPerformance failed: " + err.Error())
+		return err
+	}
+	log.Info(fmt.Sprintf("Registered Symmetrix %s for performance data", symID))
+	return nil
+}
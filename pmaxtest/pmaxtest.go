@@ -0,0 +1,304 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package pmaxtest runs a client against the mock Unisphere server, so that drivers
+// built on gopowermax can write integration tests without copying the setup of the
+// unit tests:
+//
+//	h := pmaxtest.New(t, pmaxtest.WithMaskingView("mv1", 2, []string{"SE-1E:000"}, []string{"iqn.1993-08.org.centos:01:5ae577b352a0"}))
+//	vol, err := h.Client.CreateVolumeInStorageGroup(h.Ctx, h.SymID, "mv1-sg", "vol1", 10)
+//	h.AssertNoError(err)
+//	h.AssertVolumeInStorageGroup(vol.VolumeID, "mv1-sg")
+package pmaxtest
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	pmax "github.com/dell/gopowermax"
+	"github.com/dell/gopowermax/mock"
+)
+
+// Credentials accepted by the mock server
+const (
+	Username = "username"
+	Password = "password"
+)
+
+// firstVolumeID is the device ID after which the volumes of the options are numbered,
+// clear of the volumes created by mock.Reset and of those created through the client
+const firstVolumeID = 0x01000
+
+// The harnesses are serialized, as the state of the mock is global. A test may create
+// several harnesses: harnessOwner is the test holding the mock and harnessCount the
+// number of its harnesses not cleaned up yet.
+var (
+	harnessMutex sync.Mutex
+	harnessFree  = sync.NewCond(&harnessMutex)
+	harnessOwner testing.TB
+	harnessCount int
+)
+
+// Harness is a mock Unisphere server and a client authenticated against it
+type Harness struct {
+	T      testing.TB
+	Server *httptest.Server
+	Client pmax.Pmax
+	// Ctx is the context the assertions use
+	Ctx context.Context
+	// SymID is the array the fixtures are created on
+	SymID string
+	// VolumeIDs are the volumes created by the options, in order
+	VolumeIDs []string
+
+	clientOptions pmax.ClientOptions
+	fixtures      []func(h *Harness) error
+	nextVolume    int
+}
+
+// Option configures a Harness
+type Option func(h *Harness)
+
+// WithClientOptions sets the options of the client. Insecure and AllowHTTP are always set.
+func WithClientOptions(options pmax.ClientOptions) Option {
+	return func(h *Harness) {
+		h.clientOptions = options
+	}
+}
+
+// WithNVolumes creates n volumes in mock.DefaultStorageGroup
+func WithNVolumes(n int) Option {
+	return func(h *Harness) {
+		h.fixtures = append(h.fixtures, func(h *Harness) error {
+			for i := 0; i < n; i++ {
+				if _, err := h.addVolume(mock.DefaultStorageGroup); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
+
+// WithMaskingView creates the masking view mvID, with the storage group mvID-sg holding nvols
+// volumes, the port group mvID-pg of ports (director:port, e.g. SE-1E:000) and the iSCSI host
+// mvID-host of initiators (IQNs)
+func WithMaskingView(mvID string, nvols int, ports, initiators []string) Option {
+	return func(h *Harness) {
+		h.fixtures = append(h.fixtures, func(h *Harness) error {
+			sgID := mvID + "-sg"
+			pgID := mvID + "-pg"
+			hostID := mvID + "-host"
+			if len(ports) == 0 {
+				return fmt.Errorf("masking view %s needs at least one port", mvID)
+			}
+			for _, iqn := range initiators {
+				if _, err := mock.AddInitiator(ports[0]+":"+iqn, iqn, "GigE", ports, ""); err != nil {
+					return fmt.Errorf("adding initiator %s: %s", iqn, err.Error())
+				}
+			}
+			if _, err := mock.AddHost(hostID, "iSCSI", initiators); err != nil {
+				return fmt.Errorf("adding host %s: %s", hostID, err.Error())
+			}
+			if _, err := mock.AddPortGroup(pgID, "ISCSI", ports); err != nil {
+				return fmt.Errorf("adding port group %s: %s", pgID, err.Error())
+			}
+			if _, err := mock.AddStorageGroup(sgID, mock.DefaultStoragePool, "Diamond"); err != nil {
+				return fmt.Errorf("adding storage group %s: %s", sgID, err.Error())
+			}
+			for i := 0; i < nvols; i++ {
+				if _, err := h.addVolume(sgID); err != nil {
+					return err
+				}
+			}
+			if _, err := mock.AddMaskingView(mvID, sgID, hostID, pgID); err != nil {
+				return fmt.Errorf("adding masking view %s: %s", mvID, err.Error())
+			}
+			return nil
+		})
+	}
+}
+
+// New resets the mock, starts a server for it, applies the options and authenticates a
+// client against it. The server is closed when the test ends. Harnesses of different tests
+// do not run concurrently: New blocks until the harnesses of the previous test are cleaned
+// up. Calling New again in the same test resets the mock under the previous harnesses.
+func New(t testing.TB, options ...Option) *Harness {
+	t.Helper()
+	acquireMock(t)
+	mock.Reset()
+	mock.Data.JSONDir = ""
+	h := &Harness{
+		T:      t,
+		Server: httptest.NewServer(mock.GetHandler()),
+		Ctx:    context.Background(),
+		SymID:  mock.DefaultSymmetrixID,
+	}
+	t.Cleanup(func() {
+		h.Server.Close()
+		releaseMock()
+	})
+	for _, option := range options {
+		option(h)
+	}
+	for _, fixture := range h.fixtures {
+		if err := fixture(h); err != nil {
+			t.Fatalf("pmaxtest: %s", err.Error())
+		}
+	}
+
+	clientOptions := h.clientOptions
+	clientOptions.Insecure = true
+	clientOptions.AllowHTTP = true
+	client, err := pmax.NewClientWithOptions(h.Server.URL, "", "", clientOptions)
+	if err != nil {
+		t.Fatalf("pmaxtest: creating the client: %s", err.Error())
+	}
+	err = client.Authenticate(h.Ctx, &pmax.ConfigConnect{
+		Username: Username,
+		Password: Password,
+	})
+	if err != nil {
+		t.Fatalf("pmaxtest: authenticating: %s", err.Error())
+	}
+	if err = client.SetAllowedArrays([]string{}); err != nil {
+		t.Fatalf("pmaxtest: allowing the arrays: %s", err.Error())
+	}
+	h.Client = client
+	return h
+}
+
+// acquireMock waits until the mock is free or already held by t
+func acquireMock(t testing.TB) {
+	harnessMutex.Lock()
+	defer harnessMutex.Unlock()
+	for harnessOwner != nil && harnessOwner != t {
+		harnessFree.Wait()
+	}
+	harnessOwner = t
+	harnessCount++
+}
+
+// releaseMock frees the mock once the last harness of its owner is cleaned up
+func releaseMock() {
+	harnessMutex.Lock()
+	defer harnessMutex.Unlock()
+	harnessCount--
+	if harnessCount == 0 {
+		harnessOwner = nil
+		harnessFree.Broadcast()
+	}
+}
+
+// addVolume creates a volume with the next free device ID in sgID
+func (h *Harness) addVolume(sgID string) (string, error) {
+	h.nextVolume++
+	id := fmt.Sprintf("%05X", firstVolumeID+h.nextVolume)
+	if err := mock.AddNewVolume(id, "Vol"+id, 7, sgID); err != nil {
+		return "", fmt.Errorf("adding volume %s: %s", id, err.Error())
+	}
+	h.VolumeIDs = append(h.VolumeIDs, id)
+	return id, nil
+}
+
+// AssertNoError fails the test if err is not nil
+func (h *Harness) AssertNoError(err error) {
+	h.T.Helper()
+	if err != nil {
+		h.T.Fatalf("Unexpected error: %s", err.Error())
+	}
+}
+
+// AssertErrorContains fails the test unless err contains message
+func (h *Harness) AssertErrorContains(err error, message string) {
+	h.T.Helper()
+	if err == nil {
+		h.T.Fatalf("Expected an error containing %q but got none", message)
+	}
+	if !strings.Contains(err.Error(), message) {
+		h.T.Fatalf("Expected an error containing %q but got: %s", message, err.Error())
+	}
+}
+
+// AssertStorageGroupExists fails the test unless the storage group sgID exists
+func (h *Harness) AssertStorageGroupExists(sgID string) {
+	h.T.Helper()
+	if _, err := h.Client.GetStorageGroup(h.Ctx, h.SymID, sgID); err != nil {
+		h.T.Errorf("Expected storage group %s to exist: %s", sgID, err.Error())
+	}
+}
+
+// AssertMaskingViewExists fails the test unless the masking view mvID exists
+func (h *Harness) AssertMaskingViewExists(mvID string) {
+	h.T.Helper()
+	if _, err := h.Client.GetMaskingViewByID(h.Ctx, h.SymID, mvID); err != nil {
+		h.T.Errorf("Expected masking view %s to exist: %s", mvID, err.Error())
+	}
+}
+
+// AssertMaskingViewNotFound fails the test if the masking view mvID exists
+func (h *Harness) AssertMaskingViewNotFound(mvID string) {
+	h.T.Helper()
+	_, err := h.Client.GetMaskingViewByID(h.Ctx, h.SymID, mvID)
+	if err == nil {
+		h.T.Errorf("Expected masking view %s not to exist", mvID)
+	}
+}
+
+// AssertVolumeInStorageGroup fails the test unless the volume volID is in the storage group sgID
+func (h *Harness) AssertVolumeInStorageGroup(volID, sgID string) {
+	h.T.Helper()
+	if !h.volumeInStorageGroup(volID, sgID) {
+		h.T.Errorf("Expected volume %s to be in storage group %s", volID, sgID)
+	}
+}
+
+// AssertVolumeNotInStorageGroup fails the test if the volume volID is in the storage group sgID
+func (h *Harness) AssertVolumeNotInStorageGroup(volID, sgID string) {
+	h.T.Helper()
+	if h.volumeInStorageGroup(volID, sgID) {
+		h.T.Errorf("Expected volume %s not to be in storage group %s", volID, sgID)
+	}
+}
+
+// AssertVolumeCountInStorageGroup fails the test unless the storage group sgID holds count volumes
+func (h *Harness) AssertVolumeCountInStorageGroup(sgID string, count int) {
+	h.T.Helper()
+	volIDs, err := h.Client.GetVolumeIDListInStorageGroup(h.Ctx, h.SymID, sgID)
+	if err != nil {
+		h.T.Errorf("Unable to list the volumes of storage group %s: %s", sgID, err.Error())
+		return
+	}
+	if len(volIDs) != count {
+		h.T.Errorf("Expected %d volumes in storage group %s but found %d: %v", count, sgID, len(volIDs), volIDs)
+	}
+}
+
+func (h *Harness) volumeInStorageGroup(volID, sgID string) bool {
+	h.T.Helper()
+	vol, err := h.Client.GetVolumeByID(h.Ctx, h.SymID, volID)
+	if err != nil {
+		h.T.Fatalf("Unable to read volume %s: %s", volID, err.Error())
+	}
+	for _, name := range vol.StorageGroupNames() {
+		if name == sgID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmaxtest
+
+import (
+	"testing"
+
+	"github.com/dell/gopowermax/mock"
+)
+
+const iqn = "iqn.1993-08.org.centos:01:5ae577b352a0"
+
+func Test_WithNVolumes(t *testing.T) {
+	h := New(t, WithNVolumes(3))
+	if len(h.VolumeIDs) != 3 {
+		t.Fatalf("Expected 3 volumes but got %v", h.VolumeIDs)
+	}
+	for _, volID := range h.VolumeIDs {
+		h.AssertVolumeInStorageGroup(volID, mock.DefaultStorageGroup)
+	}
+	// mock.Reset puts two volumes in the default storage group
+	h.AssertVolumeCountInStorageGroup(mock.DefaultStorageGroup, 5)
+}
+
+func Test_ProvisionAndUnmap(t *testing.T) {
+	h := New(t, WithMaskingView("mv1", 2, []string{"SE-1E:000"}, []string{iqn}))
+	h.AssertMaskingViewExists("mv1")
+	h.AssertStorageGroupExists("mv1-sg")
+	h.AssertVolumeCountInStorageGroup("mv1-sg", 2)
+
+	vol, err := h.Client.CreateVolumeInStorageGroup(h.Ctx, h.SymID, "mv1-sg", "vol1", 10)
+	h.AssertNoError(err)
+	h.AssertVolumeInStorageGroup(vol.VolumeID, "mv1-sg")
+
+	_, err = h.Client.RemoveVolumesFromStorageGroup(h.Ctx, h.SymID, "mv1-sg", true, vol.VolumeID)
+	h.AssertNoError(err)
+	h.AssertVolumeNotInStorageGroup(vol.VolumeID, "mv1-sg")
+
+	h.AssertNoError(h.Client.DeleteMaskingView(h.Ctx, h.SymID, "mv1"))
+	h.AssertMaskingViewNotFound("mv1")
+}
+
+func Test_StateIsResetBetweenHarnesses(t *testing.T) {
+	h := New(t)
+	h.AssertMaskingViewNotFound("mv1")
+	_, err := h.Client.GetStorageGroup(h.Ctx, h.SymID, "mv1-sg")
+	h.AssertErrorContains(err, "not found")
+	// the JSON fixtures are found from another working directory
+	_, err = h.Client.GetSymmetrixByID(h.Ctx, h.SymID)
+	h.AssertNoError(err)
+}
+
+func Test_NewTwiceInOneTest(t *testing.T) {
+	first := New(t, WithNVolumes(1))
+	first.AssertVolumeCountInStorageGroup(mock.DefaultStorageGroup, 3)
+	second := New(t)
+	second.AssertVolumeCountInStorageGroup(mock.DefaultStorageGroup, 2)
+}
@@ -16,15 +16,23 @@ package pmax
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
 	"net/http"
+	"net/url"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/dell/gopowermax/api"
 	types "github.com/dell/gopowermax/types/v90"
 	types91 "github.com/dell/gopowermax/types/v91"
 	log "github.com/sirupsen/logrus"
@@ -42,6 +50,8 @@ const (
 	XInitiator             = "/initiator"
 	XHost                  = "/host"
 	XMaskingView           = "/maskingview"
+	XSGDemandReport        = "/storage_group_demand_report"
+	XNotificationSettings  = "/notification_settings"
 	Emulation              = "FBA"
 	MaxVolIdentifierLength = 64
 )
@@ -90,6 +100,59 @@ func (c *Client) GetVolumesInStorageGroupIterator(ctx context.Context, symID str
 	return c.getVolumeIDsIteratorBase(ctx, symID, query)
 }
 
+// VolumeFilter selects the volumes returned by GetVolumeIDsIteratorWithFilter. Unisphere applies
+// the filter, so only the IDs of the matching volumes are downloaded. Empty fields match every volume.
+type VolumeFilter struct {
+	// StorageGroupID matches the volumes of a storage group
+	StorageGroupID string
+
+	// AllocatedPercent is a percentage, optionally preceded by "<", ">" or "=", which is compared
+	// with the allocated capacity of the volumes, e.g. "0" matches the volumes without allocations.
+	AllocatedPercent string
+
+	// Status matches the status of the volumes, e.g. "Ready"
+	Status string
+
+	// Emulation matches the emulation of the volumes, e.g. "FBA"
+	Emulation string
+
+	// TDev, if set, matches thin devices when true and other devices when false
+	TDev *bool
+}
+
+var allocatedPercentRegex = regexp.MustCompile(`^[<>=]?[0-9]{1,3}$`)
+
+// GetVolumeIDsIteratorWithFilter returns an iterator of the volumes matching all the fields of filter.
+func (c *Client) GetVolumeIDsIteratorWithFilter(ctx context.Context, symID string, filter VolumeFilter) (*types.VolumeIterator, error) {
+	defer c.TimeSpent("GetVolumeIDsIterator", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	if filter.StorageGroupID != "" {
+		query.Set("storageGroupId", filter.StorageGroupID)
+	}
+	if filter.AllocatedPercent != "" {
+		if !allocatedPercentRegex.MatchString(filter.AllocatedPercent) {
+			return nil, fmt.Errorf("Invalid allocated percent filter %s, e.g. 0, <10 or >90 are valid", filter.AllocatedPercent)
+		}
+		query.Set("allocated_percent", filter.AllocatedPercent)
+	}
+	if filter.Status != "" {
+		query.Set("status", filter.Status)
+	}
+	if filter.Emulation != "" {
+		query.Set("emulation", filter.Emulation)
+	}
+	if filter.TDev != nil {
+		query.Set("tdev", strconv.FormatBool(*filter.TDev))
+	}
+	if len(query) == 0 {
+		return c.getVolumeIDsIteratorBase(ctx, symID, "")
+	}
+	return c.getVolumeIDsIteratorBase(ctx, symID, "?"+query.Encode())
+}
+
 // GetVolumeIDsIterator returns a VolumeIDs Iterator. It generally fetches the first page in the result as part of the operation.
 func (c *Client) getVolumeIDsIteratorBase(ctx context.Context, symID string, query string) (*types.VolumeIterator, error) {
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XVolume
@@ -97,7 +160,7 @@ func (c *Client) getVolumeIDsIteratorBase(ctx context.Context, symID string, que
 		URL = URL + query
 	}
 
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	resp, err := c.api.DoAndGetResponseBody(
 		ctx, http.MethodGet, URL, c.getDefaultHeaders(), nil)
@@ -111,8 +174,7 @@ func (c *Client) getVolumeIDsIteratorBase(ctx context.Context, symID string, que
 	}
 
 	iter := &types.VolumeIterator{}
-	decoder := json.NewDecoder(resp.Body)
-	if err = decoder.Decode(iter); err != nil {
+	if err = api.DecodeJSON(resp, iter); err != nil {
 		return nil, err
 	}
 	return iter, nil
@@ -121,52 +183,18 @@ func (c *Client) getVolumeIDsIteratorBase(ctx context.Context, symID string, que
 // GetVolumeIDsIteratorPage fetches the next page of the iterator's result. From is the starting point. To can be left as 0, or can be set to the last element desired.
 func (c *Client) GetVolumeIDsIteratorPage(ctx context.Context, iter *types.VolumeIterator, from, to int) ([]string, error) {
 	defer c.TimeSpent("GetVolumeIDsIteratorPage", time.Now())
-	if to == 0 || to-from+1 > iter.MaxPageSize {
-		to = from + iter.MaxPageSize - 1
-	}
-	if to > iter.Count {
-		to = iter.Count
-	}
-	queryParams := fmt.Sprintf("?from=%d&to=%d", from, to)
-	URL := RESTPrefix + IteratorX + iter.ID + XPage + queryParams
-
-	ctx, cancel := c.GetTimeoutContext(ctx)
-	defer cancel()
-	resp, err := c.api.DoAndGetResponseBody(
-		ctx, http.MethodGet, URL, c.getDefaultHeaders(), nil)
+	page, err := c.volumeIterator(iter).Page(ctx, from, to)
 	if err != nil {
 		log.Error("GetVolumeIDsIteratorPage failed: " + err.Error())
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if err = c.checkResponse(resp); err != nil {
-		return nil, err
-	}
-
-	result := &types.VolumeResultList{}
-	decoder := json.NewDecoder(resp.Body)
-	if err = decoder.Decode(result); err != nil {
-		return nil, err
-	}
-
-	volumeIDList := make([]string, to-from+1)
-	for i := range result.VolumeList {
-		volumeIDList[i] = result.VolumeList[i].VolumeIDs
-	}
-	return volumeIDList, nil
+	return toVolumeIDs(page), nil
 }
 
 // DeleteVolumeIDsIterator deletes a volume iterator.
 func (c *Client) DeleteVolumeIDsIterator(ctx context.Context, iter *types.VolumeIterator) error {
 	defer c.TimeSpent("DeleteVolumeIDsIterator", time.Now())
-	URL := RESTPrefix + IteratorX + iter.ID
-	ctx, cancel := c.GetTimeoutContext(ctx)
-	defer cancel()
-	err := c.api.Delete(ctx, URL, c.getDefaultHeaders(), nil)
-	if err != nil {
-		return err
-	}
-	return nil
+	return c.volumeIterator(iter).Delete(ctx)
 }
 
 // GetVolumeIDList gets a list of matching volume ids. If volumeIdentifierMatch is the empty string,
@@ -185,6 +213,15 @@ func (c *Client) GetVolumeIDList(ctx context.Context, symID string, volumeIdenti
 	return c.volumeIteratorToVolIDList(ctx, iter)
 }
 
+// GetVolumeIDListWithFilter returns the IDs of the volumes matching all the fields of filter.
+func (c *Client) GetVolumeIDListWithFilter(ctx context.Context, symID string, filter VolumeFilter) ([]string, error) {
+	iter, err := c.GetVolumeIDsIteratorWithFilter(ctx, symID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return c.volumeIteratorToVolIDList(ctx, iter)
+}
+
 // GetVolumeIDListInStorageGroup - Gets a list of volume in a SG
 func (c *Client) GetVolumeIDListInStorageGroup(ctx context.Context, symID string, storageGroupID string) ([]string, error) {
 	iter, err := c.GetVolumesInStorageGroupIterator(ctx, symID, storageGroupID)
@@ -195,125 +232,356 @@ func (c *Client) GetVolumeIDListInStorageGroup(ctx context.Context, symID string
 }
 
 func (c *Client) volumeIteratorToVolIDList(ctx context.Context, iter *types.VolumeIterator) ([]string, error) {
-	if iter.MaxPageSize < iter.Count {
-		// The iterator only needs to be deleted if there are more entries than MaxPageSize?
-		defer c.DeleteVolumeIDsIterator(ctx, iter)
+	volumes, err := c.volumeIterator(iter).All(ctx)
+	if err != nil {
+		return nil, err
 	}
+	return toVolumeIDs(volumes), nil
+}
 
-	// Get the initial results
-	result := iter.ResultList
-	volumeIDList := make([]string, len(result.VolumeList))
-	for i := range result.VolumeList {
-		volumeIDList[i] = result.VolumeList[i].VolumeIDs
-	}
+// VolumePage is a page of the volume IDs returned by ListVolumesPaged
+type VolumePage struct {
+	// VolumeIDs are the 5 digit IDs of the volumes of the page
+	VolumeIDs []string
+
+	// Count is the number of volumes of the whole listing
+	Count int
+
+	// ResumeToken is passed to ListVolumesPaged to get the next page; it is empty after the last page
+	ResumeToken string
+}
+
+// volumeResumeToken is the state of a paged volume listing encoded in a resume token
+type volumeResumeToken struct {
+	SymID       string `json:"symId"`
+	IteratorID  string `json:"iteratorId"`
+	From        int    `json:"from"`
+	Count       int    `json:"count"`
+	MaxPageSize int    `json:"maxPageSize"`
+}
 
-	// Iterate through addiional pages
-	for from := result.To + 1; from <= iter.Count; {
-		idlist, err := c.GetVolumeIDsIteratorPage(ctx, iter, from, 0)
+// ListVolumesPaged returns a page of at most pageSize volume IDs of the array. An empty resumeToken
+// starts a new listing; the ResumeToken of the returned page continues it, also from another client,
+// as long as Unisphere keeps the iterator of the listing. A pageSize of 0 selects the page size of Unisphere.
+// Unisphere keeps no iterator for a listing which fits in one of its pages, so such a listing is returned
+// whole in a single page, whatever pageSize.
+func (c *Client) ListVolumesPaged(ctx context.Context, symID string, pageSize int, resumeToken string) (*VolumePage, error) {
+	defer c.TimeSpent("ListVolumesPaged", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	var token *volumeResumeToken
+	var volumeIDs []string
+	if resumeToken == "" {
+		iter, err := c.GetVolumeIDsIterator(ctx, symID, "", false)
 		if err != nil {
 			return nil, err
 		}
-		volumeIDList = append(volumeIDList, idlist...)
-		from = from + len(idlist)
+		token = &volumeResumeToken{SymID: symID, IteratorID: iter.ID, From: 1, Count: iter.Count, MaxPageSize: iter.MaxPageSize}
+		volumeIDs = toVolumeIDs(iter.ResultList.VolumeList)
+		if pageSize > 0 && len(volumeIDs) > pageSize && iter.MaxPageSize < iter.Count {
+			volumeIDs = volumeIDs[:pageSize]
+		}
+	} else {
+		var err error
+		if token, err = decodeVolumeResumeToken(resumeToken); err != nil {
+			return nil, err
+		}
+		if token.SymID != symID {
+			return nil, fmt.Errorf("the resume token is for array %s, not %s", token.SymID, symID)
+		}
+		iter := &types.VolumeIterator{ID: token.IteratorID, Count: token.Count, MaxPageSize: token.MaxPageSize}
+		to := 0
+		if pageSize > 0 {
+			to = token.From + pageSize - 1
+		}
+		if volumeIDs, err = c.GetVolumeIDsIteratorPage(ctx, iter, token.From, to); err != nil {
+			if httpStatus(err) == http.StatusNotFound {
+				return nil, fmt.Errorf("the iterator of the resume token has expired: %s", err.Error())
+			}
+			return nil, err
+		}
+	}
+	page := &VolumePage{VolumeIDs: volumeIDs, Count: token.Count}
+	token.From += len(volumeIDs)
+	if token.MaxPageSize >= token.Count {
+		// the listing fit in one page and Unisphere kept no iterator to resume it
+		return page, nil
+	}
+	if token.From <= token.Count && len(volumeIDs) != 0 {
+		page.ResumeToken = encodeVolumeResumeToken(token)
+	} else {
+		c.DeleteVolumeIDsIterator(ctx, &types.VolumeIterator{ID: token.IteratorID})
+	}
+	return page, nil
+}
+
+func encodeVolumeResumeToken(token *volumeResumeToken) string {
+	tokenBytes, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(tokenBytes)
+}
+
+func decodeVolumeResumeToken(resumeToken string) (*volumeResumeToken, error) {
+	tokenBytes, err := base64.RawURLEncoding.DecodeString(resumeToken)
+	token := &volumeResumeToken{}
+	if err == nil {
+		err = json.Unmarshal(tokenBytes, token)
+	}
+	if err != nil || token.IteratorID == "" || token.From < 1 {
+		return nil, fmt.Errorf("invalid resume token")
+	}
+	return token, nil
+}
+
+// VolumeMatch is a volume found by FindVolumeAcrossArrays and the array it was found on
+type VolumeMatch struct {
+	SymID  string
+	Volume *types.Volume
+}
+
+// FindVolumeAcrossArrays queries the arrays in parallel for the volumes whose identifier is
+// exactly identifier. The matches are returned in the order of symIDs. If some of the arrays
+// can not be queried, the matches found on the others are returned together with an error.
+func (c *Client) FindVolumeAcrossArrays(ctx context.Context, symIDs []string, identifier string) ([]VolumeMatch, error) {
+	defer c.TimeSpent("FindVolumeAcrossArrays", time.Now())
+	if identifier == "" {
+		return nil, fmt.Errorf("A volume identifier must be supplied")
+	}
+	matches := make([][]VolumeMatch, len(symIDs))
+	errs := make([]error, len(symIDs))
+	var wg sync.WaitGroup
+	for i, symID := range symIDs {
+		wg.Add(1)
+		go func(i int, symID string) {
+			defer wg.Done()
+			volumeIDs, err := c.GetVolumeIDList(ctx, symID, identifier, false)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %s", symID, err.Error())
+				return
+			}
+			for _, volumeID := range volumeIDs {
+				vol, err := c.GetVolumeByID(ctx, symID, volumeID)
+				if err != nil {
+					errs[i] = fmt.Errorf("%s: %s", symID, err.Error())
+					return
+				}
+				matches[i] = append(matches[i], VolumeMatch{SymID: symID, Volume: vol})
+			}
+		}(i, symID)
+	}
+	wg.Wait()
+
+	result := make([]VolumeMatch, 0)
+	for _, m := range matches {
+		result = append(result, m...)
+	}
+	msgs := make([]string, 0)
+	for _, err := range errs {
+		if err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	if len(msgs) != 0 {
+		err := fmt.Errorf("FindVolumeAcrossArrays failed on %d of %d arrays: %s", len(msgs), len(symIDs), strings.Join(msgs, "; "))
+		log.Error(err.Error())
+		return result, err
+	}
+	return result, nil
+}
+
+// VolumeProgressFunc is called by GetVolumesByStorageGroupWithDetails after each volume is read, with the
+// number of volumes read so far and the number of volumes of the storage group. The calls are serialized.
+type VolumeProgressFunc func(done, total int)
+
+// GetVolumesByStorageGroupWithDetails returns the volumes of a storage group, in the order of their IDs.
+// The volume IDs are read with GetStorageGroupWithVolumes and the volumes are read concurrently, up to
+// ClientOptions.MaxConcurrentRequests at a time; progress, if not nil, is called after each volume is read.
+// The first volume which can not be read aborts the reading of the others.
+func (c *Client) GetVolumesByStorageGroupWithDetails(ctx context.Context, symID string, storageGroupID string, progress VolumeProgressFunc) ([]*types.Volume, error) {
+	defer c.TimeSpent("GetVolumesByStorageGroupWithDetails", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	storageGroup, err := c.GetStorageGroupWithVolumes(ctx, symID, storageGroupID)
+	if err != nil {
+		return nil, err
 	}
-	if len(volumeIDList) != iter.Count {
-		return nil, fmt.Errorf("Expected %d ids but got %d ids", iter.Count, len(volumeIDList))
+	volumeIDs := storageGroup.VolumeIDs
+	sort.Strings(volumeIDs)
+
+	volumes := make([]*types.Volume, len(volumeIDs))
+	var mutex sync.Mutex
+	done := 0
+	err = c.forEachConcurrently(ctx, "GetVolumesByStorageGroupWithDetails", len(volumeIDs), func(ctx context.Context, i int) error {
+		vol, err := c.GetVolumeByID(ctx, symID, volumeIDs[i])
+		if err != nil {
+			return fmt.Errorf("GetVolumesByStorageGroupWithDetails failed to get volume %s of %s: %w", volumeIDs[i], storageGroupID, err)
+		}
+		volumes[i] = vol
+		if progress != nil {
+			mutex.Lock()
+			done++
+			progress(done, len(volumeIDs))
+			mutex.Unlock()
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error(err.Error())
+		return nil, err
 	}
-	return volumeIDList, nil
+	return volumes, nil
 }
 
 // GetVolumeByID returns a Volume structure given the symmetrix and volume ID (volume ID is 5-digit hex field)
 func (c *Client) GetVolumeByID(ctx context.Context, symID string, volumeID string) (*types.Volume, error) {
+	return c.GetVolumeByIDWithFields(ctx, symID, volumeID, nil)
+}
+
+// GetVolumeByIDWithFields is GetVolumeByID asking Unisphere to return only the given fields of the
+// volume, named as in the JSON of types.Volume, e.g. "cap_cyl". If Unisphere does not support field
+// selection, the complete volume is returned.
+func (c *Client) GetVolumeByIDWithFields(ctx context.Context, symID string, volumeID string, fields []string) (*types.Volume, error) {
 	defer c.TimeSpent("GetVolumeByID", time.Now())
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XVolume + "/" + volumeID
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	volume := &types.Volume{}
+	if err := c.getWithFields(ctx, URL, fields, volume); err != nil {
+		log.Error("GetVolumeByID failed: " + err.Error())
+		return nil, err
+	}
+	return volume, nil
+}
+
+// getWithFields decodes the response of a GET of URL into result, asking Unisphere to return only
+// the given fields. If Unisphere rejects the field selection, the request is repeated without it,
+// and the client does not select fields any more.
+func (c *Client) getWithFields(ctx context.Context, URL string, fields []string, result interface{}) error {
+	if len(fields) != 0 && atomic.LoadInt32(&c.noFieldSelection) == 0 {
+		err := c.getJSON(ctx, URL+"?select="+url.QueryEscape(strings.Join(fields, ",")), result)
+		if !isQueryParameterUnsupported(err, "select") {
+			return err
+		}
+		log.Info("Unisphere does not support field selection, requesting all fields")
+		atomic.StoreInt32(&c.noFieldSelection, 1)
+	}
+	return c.getJSON(ctx, URL, result)
+}
+
+// getJSON decodes the response of a GET of URL into result
+func (c *Client) getJSON(ctx context.Context, URL string, result interface{}) error {
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	resp, err := c.api.DoAndGetResponseBody(
 		ctx, http.MethodGet, URL, c.getDefaultHeaders(), nil)
 	if err != nil {
-		log.Error("GetVolumeByID failed: " + err.Error())
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
 	if err = c.checkResponse(resp); err != nil {
-		return nil, err
+		return err
 	}
+	return api.DecodeJSON(resp, result)
+}
 
-	volume := &types.Volume{}
-	decoder := json.NewDecoder(resp.Body)
-	if err = decoder.Decode(volume); err != nil {
-		return nil, err
-	}
-	return volume, nil
+// isQueryParameterUnsupported returns true if err is the rejection of the query parameter
+func isQueryParameterUnsupported(err error, parameter string) bool {
+	return httpStatus(err) == http.StatusBadRequest && strings.Contains(err.Error(), parameter)
 }
 
 // GetStorageGroupIDList returns a list of StorageGroupIds in a StorageGroupIDList type.
+// It pages through the listing with the Unisphere iterator when the listing does not fit in a page.
 func (c *Client) GetStorageGroupIDList(ctx context.Context, symID string) (*types.StorageGroupIDList, error) {
 	defer c.TimeSpent("GetStorageGroupIDList", time.Now())
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XStorageGroup
-
-	ctx, cancel := c.GetTimeoutContext(ctx)
-	defer cancel()
-	resp, err := c.api.DoAndGetResponseBody(
-		ctx, http.MethodGet, URL, c.getDefaultHeaders(), nil)
+	ids, err := c.getIDList(ctx, URL, "Storage group", "storageGroupId")
 	if err != nil {
 		log.Error("GetStorageGroupIDList failed: " + err.Error())
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if err = c.checkResponse(resp); err != nil {
-		return nil, err
-	}
+	return &types.StorageGroupIDList{StorageGroupIDs: ids}, nil
+}
 
-	sgIDList := &types.StorageGroupIDList{}
-	decoder := json.NewDecoder(resp.Body)
-	if err = decoder.Decode(sgIDList); err != nil {
-		return nil, err
-	}
-	return sgIDList, nil
+// StorageGroupOptions are the settings of CreateStorageGroupWithOptions.
+type StorageGroupOptions struct {
+	// SRPID is the storage resource pool of the storage group. If it is "None" the
+	// storage group has no service level and the other settings are ignored.
+	SRPID string
+
+	// ServiceLevel is the service level of the storage group, e.g. "Diamond"
+	ServiceLevel string
+
+	// Workload is the workload type of the service level. If empty, "None" is used.
+	Workload string
+
+	// ThickVolumes allocates the full capacity of each volume of the storage group;
+	// it implies NoCompression, as compression is not allowed with thick volumes.
+	ThickVolumes bool
+
+	// Emulation is the emulation of the volumes of the storage group. If empty, "FBA" is used.
+	Emulation string
+
+	// NoCompression disables compression for the storage group
+	NoCompression bool
 }
 
 //GetCreateStorageGroupPayload returns U4P payload for creating storage group
 func (c *Client) GetCreateStorageGroupPayload(storageGroupID, srpID, serviceLevel string, thickVolumes bool) (payload interface{}) {
-	workload := "None"
+	return c.GetCreateStorageGroupPayloadWithOptions(storageGroupID, StorageGroupOptions{
+		SRPID:        srpID,
+		ServiceLevel: serviceLevel,
+		ThickVolumes: thickVolumes,
+	})
+}
+
+// GetCreateStorageGroupPayloadWithOptions returns U4P payload for creating storage group with the given StorageGroupOptions
+func (c *Client) GetCreateStorageGroupPayloadWithOptions(storageGroupID string, options StorageGroupOptions) (payload interface{}) {
+	workload := options.Workload
+	if workload == "" {
+		workload = "None"
+	}
+	emulation := options.Emulation
+	if emulation == "" {
+		emulation = Emulation
+	}
+	// compression not allowed with thick volumes
+	noCompression := options.NoCompression || options.ThickVolumes
 	if c.version == "90" {
 		sloParams := []types.SLOBasedStorageGroupParam{}
-		if srpID != "None" {
+		if options.SRPID != "None" {
 			sloParams = []types.SLOBasedStorageGroupParam{
 				{
-					SLOID:             serviceLevel,
+					SLOID:             options.ServiceLevel,
 					WorkloadSelection: workload,
 					NumberOfVolumes:   0,
 					VolumeAttribute: types.VolumeAttributeType{
 						VolumeSize:   "0",
 						CapacityUnit: "CYL",
 					},
-					AllocateCapacityForEachVol: thickVolumes,
-					// compression not allowed with thick volumes
-					NoCompression: thickVolumes,
+					AllocateCapacityForEachVol: options.ThickVolumes,
+					NoCompression:              noCompression,
 				},
 			}
 		}
 		createStorageGroupParam := &types.CreateStorageGroupParam{
 			StorageGroupID:            storageGroupID,
-			SRPID:                     srpID,
-			Emulation:                 Emulation,
+			SRPID:                     options.SRPID,
+			Emulation:                 emulation,
 			ExecutionOption:           types.ExecutionOptionSynchronous,
 			SLOBasedStorageGroupParam: sloParams,
 		}
 		return createStorageGroupParam
 	}
 	sloParams := []types91.SLOBasedStorageGroupParam{}
-	if srpID != "None" {
+	if options.SRPID != "None" {
 		sloParams = []types91.SLOBasedStorageGroupParam{
 			{
-				SLOID:             serviceLevel,
+				SLOID:             options.ServiceLevel,
 				WorkloadSelection: workload,
 				VolumeAttributes: []types91.VolumeAttributeType{
 					{
@@ -322,16 +590,15 @@ func (c *Client) GetCreateStorageGroupPayload(storageGroupID, srpID, serviceLeve
 						NumberOfVolumes: 0,
 					},
 				},
-				AllocateCapacityForEachVol: thickVolumes,
-				// compression not allowed with thick volumes
-				NoCompression: thickVolumes,
+				AllocateCapacityForEachVol: options.ThickVolumes,
+				NoCompression:              noCompression,
 			},
 		}
 	}
 	createStorageGroupParam := &types91.CreateStorageGroupParam{
 		StorageGroupID:            storageGroupID,
-		SRPID:                     srpID,
-		Emulation:                 Emulation,
+		SRPID:                     options.SRPID,
+		Emulation:                 emulation,
 		ExecutionOption:           types91.ExecutionOptionSynchronous,
 		SLOBasedStorageGroupParam: sloParams,
 	}
@@ -341,23 +608,36 @@ func (c *Client) GetCreateStorageGroupPayload(storageGroupID, srpID, serviceLeve
 // CreateStorageGroup creates a Storage Group given the storageGroupID (name), srpID (storage resource pool), service level, and boolean for thick volumes.
 // If srpID is "None" then serviceLevel and thickVolumes settings are ignored
 func (c *Client) CreateStorageGroup(ctx context.Context, symID, storageGroupID, srpID, serviceLevel string, thickVolumes bool) (*types.StorageGroup, error) {
+	return c.CreateStorageGroupWithOptions(ctx, symID, storageGroupID, StorageGroupOptions{
+		SRPID:        srpID,
+		ServiceLevel: serviceLevel,
+		ThickVolumes: thickVolumes,
+	})
+}
+
+// CreateStorageGroupWithOptions creates a Storage Group given the storageGroupID (name) and the settings given as StorageGroupOptions.
+// If options.SRPID is "None" then the service level, workload and compression settings are ignored
+func (c *Client) CreateStorageGroupWithOptions(ctx context.Context, symID, storageGroupID string, options StorageGroupOptions) (*types.StorageGroup, error) {
 	defer c.TimeSpent("CreateStorageGroup", time.Now())
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XStorageGroup
-	payload := c.GetCreateStorageGroupPayload(storageGroupID, srpID, serviceLevel, thickVolumes)
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	payload := c.GetCreateStorageGroupPayloadWithOptions(storageGroupID, options)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
 	resp, err := c.api.DoAndGetResponseBody(
 		ctx, http.MethodPost, URL, c.getDefaultHeaders(), payload)
+	if err != nil {
+		log.Error("CreateStorageGroup failed: " + err.Error())
+		return nil, err
+	}
 	if err = c.checkResponse(resp); err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 	storageGroup := &types.StorageGroup{}
-	decoder := json.NewDecoder(resp.Body)
-	if err = decoder.Decode(storageGroup); err != nil {
+	if err = api.DecodeJSON(resp, storageGroup); err != nil {
 		return nil, err
 	}
 	log.Info(fmt.Sprintf("Successfully created SG: %s", storageGroupID))
@@ -371,7 +651,7 @@ func (c *Client) DeleteStorageGroup(ctx context.Context, symID string, storageGr
 		return err
 	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XStorageGroup + "/" + storageGroupID
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
 	err := c.api.Delete(ctx, URL, c.getDefaultHeaders(), nil)
 	if err != nil {
@@ -389,7 +669,7 @@ func (c *Client) DeleteMaskingView(ctx context.Context, symID string, maskingVie
 		return err
 	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XMaskingView + "/" + maskingViewID
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
 	err := c.api.Delete(ctx, URL, c.getDefaultHeaders(), nil)
 	if err != nil {
@@ -402,29 +682,74 @@ func (c *Client) DeleteMaskingView(ctx context.Context, symID string, maskingVie
 
 // GetStorageGroup returns a StorageGroup given the Symmetrix ID and Storage Group ID (which is really a name).
 func (c *Client) GetStorageGroup(ctx context.Context, symID string, storageGroupID string) (*types.StorageGroup, error) {
+	return c.GetStorageGroupWithFields(ctx, symID, storageGroupID, nil)
+}
+
+// GetStorageGroupWithFields is GetStorageGroup asking Unisphere to return only the given fields of the
+// storage group, named as in the JSON of types.StorageGroup, e.g. "num_of_vols". If Unisphere does not
+// support field selection, the complete storage group is returned.
+func (c *Client) GetStorageGroupWithFields(ctx context.Context, symID string, storageGroupID string, fields []string) (*types.StorageGroup, error) {
 	defer c.TimeSpent("GetStorageGroup", time.Now())
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XStorageGroup + "/" + storageGroupID
-	ctx, cancel := c.GetTimeoutContext(ctx)
-	defer cancel()
-	resp, err := c.api.DoAndGetResponseBody(
-		ctx, http.MethodGet, URL, c.getDefaultHeaders(), nil)
-	if err != nil {
+	storageGroup := &types.StorageGroup{}
+	if err := c.getWithFields(ctx, URL, fields, storageGroup); err != nil {
 		log.Error("GetStorageGroup failed: " + err.Error())
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if err = c.checkResponse(resp); err != nil {
-		return nil, err
-	}
+	return storageGroup, nil
+}
 
-	storageGroup := &types.StorageGroup{}
-	decoder := json.NewDecoder(resp.Body)
-	if err = decoder.Decode(storageGroup); err != nil {
+// GetStorageGroupWithVolumes is GetStorageGroup also returning the IDs of the volumes of the storage group
+// in VolumeIDs. Unisphere is asked to expand the volumes in the storage group it returns; if it does not,
+// the storage group and its volumes are read concurrently, and the client does not ask for the expansion any more.
+func (c *Client) GetStorageGroupWithVolumes(ctx context.Context, symID string, storageGroupID string) (*types.StorageGroup, error) {
+	defer c.TimeSpent("GetStorageGroupWithVolumes", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
+	if atomic.LoadInt32(&c.noVolumeExpansion) == 0 {
+		URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XStorageGroup + "/" + storageGroupID + "?expand=volumes"
+		storageGroup := &types.StorageGroup{}
+		err := c.getJSON(ctx, URL, storageGroup)
+		if err == nil && (storageGroup.VolumeIDs != nil || storageGroup.NumOfVolumes == 0) {
+			if storageGroup.VolumeIDs == nil {
+				storageGroup.VolumeIDs = make([]string, 0)
+			}
+			return storageGroup, nil
+		}
+		if err != nil && !isQueryParameterUnsupported(err, "expand") {
+			log.Error("GetStorageGroupWithVolumes failed: " + err.Error())
+			return nil, err
+		}
+		log.Info("Unisphere does not expand the volumes of storage groups, reading them separately")
+		atomic.StoreInt32(&c.noVolumeExpansion, 1)
+	}
+
+	var storageGroup *types.StorageGroup
+	var volumeIDs []string
+	var sgErr, volErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		storageGroup, sgErr = c.GetStorageGroup(ctx, symID, storageGroupID)
+	}()
+	go func() {
+		defer wg.Done()
+		volumeIDs, volErr = c.GetVolumeIDListInStorageGroup(ctx, symID, storageGroupID)
+	}()
+	wg.Wait()
+	if sgErr != nil {
+		return nil, sgErr
+	}
+	if volErr != nil {
+		log.Error("GetStorageGroupWithVolumes failed: " + volErr.Error())
+		return nil, volErr
+	}
+	storageGroup.VolumeIDs = volumeIDs
 	return storageGroup, nil
 }
 
@@ -436,7 +761,7 @@ func (c *Client) GetStoragePool(ctx context.Context, symID string, storagePoolID
 	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + "/" + StorageResourcePool + "/" + storagePoolID
 	storagePool := &types.StoragePool{}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), storagePool)
 	if err != nil {
@@ -446,109 +771,582 @@ func (c *Client) GetStoragePool(ctx context.Context, symID string, storagePoolID
 	return storagePool, nil
 }
 
-// UpdateStorageGroup is a general method to update a StorageGroup (PUT operation) using a UpdateStorageGroupPayload.
-func (c *Client) UpdateStorageGroup(ctx context.Context, symID string, storageGroupID string, payload interface{}) (*types.Job, error) {
-	defer c.TimeSpent("UpdateStorageGroup", time.Now())
-	if _, err := c.IsAllowedArray(symID); err != nil {
+// DataReduction is the data reduction status and savings of a Storage Pool or of a storage group
+type DataReduction struct {
+	// ID of the Storage Pool or of the storage group
+	ID string
+	// Enabled is true if data reduction is enabled on some of the capacity
+	Enabled bool
+	// EnabledPercent is the percentage of the capacity on which data reduction is enabled,
+	// a storage group is either fully reduced or not at all
+	EnabledPercent float64
+	// RatioToOne is the data reduction ratio, e.g. 2 for 2:1, or 0 when it is not reported
+	RatioToOne float64
+	// OverallEfficiencyRatioToOne also counts the savings of thin provisioning and snapshots, for Storage Pools only
+	OverallEfficiencyRatioToOne float64
+}
+
+// GetSRPDataReduction returns the data reduction status and savings of the given Storage Pool
+func (c *Client) GetSRPDataReduction(ctx context.Context, symID string, storagePoolID string) (*DataReduction, error) {
+	pool, err := c.GetStoragePool(ctx, symID, storagePoolID)
+	if err != nil {
 		return nil, err
 	}
-	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XStorageGroup + "/" + storageGroupID
-	job := &types.Job{}
-	fields := map[string]interface{}{
-		http.MethodPut: URL,
+	reduction := &DataReduction{ID: storagePoolID}
+	if pool.SrpEfficiency != nil {
+		reduction.EnabledPercent = float64(pool.SrpEfficiency.DataReductionEnabledPerc)
+		reduction.Enabled = reduction.EnabledPercent > 0
+		reduction.RatioToOne = float64(pool.SrpEfficiency.DataReductionRatioToOne)
+		reduction.OverallEfficiencyRatioToOne = float64(pool.SrpEfficiency.EfficiencyRatioToOne)
 	}
+	return reduction, nil
+}
 
-	ctx, cancel := c.GetTimeoutContext(ctx)
-	defer cancel()
-	err := c.api.Put(
-		ctx, URL, c.getDefaultHeaders(), payload, job)
+// GetStorageGroupDataReduction returns the data reduction status and savings of the given storage group
+func (c *Client) GetStorageGroupDataReduction(ctx context.Context, symID string, storageGroupID string) (*DataReduction, error) {
+	sg, err := c.GetStorageGroupWithFields(ctx, symID, storageGroupID, []string{"compression", "compression_ratio_to_one"})
 	if err != nil {
-		log.WithFields(fields).Error("Error in UpdateStorageGroup: " + err.Error())
 		return nil, err
 	}
-	return job, nil
+	reduction := &DataReduction{ID: storageGroupID, Enabled: sg.Compression}
+	if sg.Compression {
+		reduction.EnabledPercent = 100
+		reduction.RatioToOne = sg.CompressionRatioToOne
+	}
+	return reduction, nil
 }
 
-// UpdateStorageGroupS is a general method to update a StorageGroup (PUT operation) using a UpdateStorageGroupPayload.
-func (c *Client) UpdateStorageGroupS(ctx context.Context, symID string, storageGroupID string, payload interface{}) error {
-	defer c.TimeSpent("UpdateStorageGroupS", time.Now())
+// GetStorageGroupDemandReport returns the allocated and subscribed capacity of every storage group in the given Storage Pool
+func (c *Client) GetStorageGroupDemandReport(ctx context.Context, symID string, storagePoolID string) (*types.StorageGroupDemandReport, error) {
+	defer c.TimeSpent("GetStorageGroupDemandReport", time.Now())
 	if _, err := c.IsAllowedArray(symID); err != nil {
-		return err
-	}
-	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XStorageGroup + "/" + storageGroupID
-	fields := map[string]interface{}{
-		http.MethodPut: URL,
+		return nil, err
 	}
-
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + "/" + StorageResourcePool + "/" + storagePoolID + XSGDemandReport
+	report := &types.StorageGroupDemandReport{}
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
-	err := c.api.Put(
-		ctx, URL, c.getDefaultHeaders(), payload, nil)
+	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), report)
 	if err != nil {
-		log.WithFields(fields).Error("Error in UpdateStorageGroup: " + err.Error())
-		return err
+		log.Error("GetStorageGroupDemandReport failed: " + err.Error())
+		return nil, err
 	}
-	return nil
+	return report, nil
 }
 
-func ifDebugLogPayload(payload interface{}) {
-	if Debug == false {
-		return
-	}
-	payloadBytes, err := json.Marshal(payload)
+// GetStorageGroupDemand returns the allocated and subscribed capacity of a single storage group in the given Storage Pool.
+// The error matches ErrNotFound if the storage group is not in the demand report of the Storage Pool.
+func (c *Client) GetStorageGroupDemand(ctx context.Context, symID string, storagePoolID string, storageGroupID string) (*types.StorageGroupDemand, error) {
+	report, err := c.GetStorageGroupDemandReport(ctx, symID, storagePoolID)
 	if err != nil {
-		log.Error("could not Marshal json payload: " + err.Error())
-	} else {
-		log.Info("payload: " + string(payloadBytes))
+		return nil, err
+	}
+	for i := range report.StorageGroupDemands {
+		if report.StorageGroupDemands[i].StorageGroupID == storageGroupID {
+			return &report.StorageGroupDemands[i], nil
+		}
 	}
+	return nil, fmt.Errorf("storage group %s not found in demand report of storage pool %s: %w", storageGroupID, storagePoolID, ErrNotFound)
 }
 
-// CreateVolumeInStorageGroup creates a volume in the specified Storage Group with a given volumeName
-// and the size of the volume in cylinders.
-func (c *Client) CreateVolumeInStorageGroup(
-	ctx context.Context, symID string, storageGroupID string, volumeName string, sizeInCylinders int) (*types.Volume, error) {
-	defer c.TimeSpent("CreateVolumeInStorageGroup", time.Now())
-	if _, err := c.IsAllowedArray(symID); err != nil {
-		return nil, err
+// ServiceLevelDemand is the capacity demand (in GB) of the storage groups of a service level on a Storage Pool
+type ServiceLevelDemand struct {
+	ServiceLevel  string
+	StorageGroups int
+	SubscribedGB  float64
+	AllocatedGB   float64
+}
+
+// SRPDemandByServiceLevel is the usable capacity (in GB) of a Storage Pool and the demand placed on it
+// by each service level. The free capacity of the pool is shared by all the service levels.
+type SRPDemandByServiceLevel struct {
+	StoragePoolID string
+	UsableTotalGB float64
+	UsableUsedGB  float64
+	ServiceLevels []ServiceLevelDemand
+}
+
+// FreeGB returns the usable capacity of the Storage Pool which is not used
+func (d *SRPDemandByServiceLevel) FreeGB() float64 {
+	if d.UsableTotalGB < d.UsableUsedGB {
+		return 0
 	}
+	return d.UsableTotalGB - d.UsableUsedGB
+}
 
-	if len(volumeName) > MaxVolIdentifierLength {
-		return nil, fmt.Errorf("Length of volumeName exceeds max limit")
+// ServiceLevel returns the demand of serviceLevel, which is zero if no storage group has it
+func (d *SRPDemandByServiceLevel) ServiceLevel(serviceLevel string) ServiceLevelDemand {
+	for _, demand := range d.ServiceLevels {
+		if strings.EqualFold(demand.ServiceLevel, serviceLevel) {
+			return demand
+		}
 	}
+	return ServiceLevelDemand{ServiceLevel: serviceLevel}
+}
 
-	job := &types.Job{}
-	var err error
-	payload := c.GetCreateVolInSGPayload(sizeInCylinders, volumeName, false, "", "")
-	job, err = c.UpdateStorageGroup(ctx, symID, storageGroupID, payload)
-	if err != nil || job == nil {
-		return nil, fmt.Errorf("A job was not returned from UpdateStorageGroup")
+// GetSRPDemandByServiceLevel returns the usable capacity of a Storage Pool and the subscribed and allocated
+// capacity of its storage groups summed by service level, sorted by service level. The service levels of the
+// storage groups are read concurrently, up to ClientOptions.MaxConcurrentRequests at a time; the storage groups
+// without a service level are summed under "None".
+func (c *Client) GetSRPDemandByServiceLevel(ctx context.Context, symID string, storagePoolID string) (*SRPDemandByServiceLevel, error) {
+	defer c.TimeSpent("GetSRPDemandByServiceLevel", time.Now())
+	pool, err := c.GetStoragePool(ctx, symID, storagePoolID)
+	if err != nil {
+		return nil, err
 	}
-	job, err = c.WaitOnJobCompletion(ctx, symID, job.JobID)
+	report, err := c.GetStorageGroupDemandReport(ctx, symID, storagePoolID)
 	if err != nil {
 		return nil, err
 	}
 
-	switch job.Status {
-	case types.JobStatusFailed:
-		return nil, fmt.Errorf("The UpdateStorageGroup job failed: " + c.JobToString(job))
+	serviceLevels := make([]string, len(report.StorageGroupDemands))
+	err = c.forEachConcurrently(ctx, "GetSRPDemandByServiceLevel", len(report.StorageGroupDemands), func(ctx context.Context, i int) error {
+		sgID := report.StorageGroupDemands[i].StorageGroupID
+		sg, err := c.GetStorageGroupWithFields(ctx, symID, sgID, []string{"slo"})
+		if err != nil {
+			return fmt.Errorf("GetSRPDemandByServiceLevel failed to get the service level of storage group %s: %s", sgID, err.Error())
+		}
+		serviceLevels[i] = sg.SLO
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	volume, err := c.GetVolumeByIdentifier(ctx, symID, storageGroupID, volumeName, sizeInCylinders)
-	return volume, err
-}
 
-// GetVolumeByIdentifier on the given symmetrix in specific storage group with a volume name and having size in cylinders
-func (c *Client) GetVolumeByIdentifier(ctx context.Context, symID, storageGroupID string, volumeName string, sizeInCylinders int) (*types.Volume, error) {
-	volIDList, err := c.GetVolumeIDList(ctx, symID, volumeName, false)
-	if err != nil {
-		return nil, fmt.Errorf("Couldn't get Volume ID List: " + err.Error())
+	result := &SRPDemandByServiceLevel{StoragePoolID: storagePoolID, ServiceLevels: make([]ServiceLevelDemand, 0)}
+	if pool.SrpCap != nil {
+		result.UsableTotalGB = pool.SrpCap.UsableTotInTB * 1024
+		result.UsableUsedGB = pool.SrpCap.UsableUsedInTB * 1024
 	}
-	if len(volIDList) > 1 {
-		log.Warning("Found multiple volumes matching the identifier " + volumeName)
+	byServiceLevel := make(map[string]*ServiceLevelDemand)
+	for i, demand := range report.StorageGroupDemands {
+		serviceLevel := serviceLevels[i]
+		if serviceLevel == "" {
+			serviceLevel = "None"
+		}
+		sum := byServiceLevel[serviceLevel]
+		if sum == nil {
+			sum = &ServiceLevelDemand{ServiceLevel: serviceLevel}
+			byServiceLevel[serviceLevel] = sum
+		}
+		sum.StorageGroups++
+		sum.SubscribedGB += demand.SubscribedGB
+		sum.AllocatedGB += demand.AllocatedGB
+	}
+	for _, sum := range byServiceLevel {
+		result.ServiceLevels = append(result.ServiceLevels, *sum)
+	}
+	sort.Slice(result.ServiceLevels, func(i, j int) bool {
+		return result.ServiceLevels[i].ServiceLevel < result.ServiceLevels[j].ServiceLevel
+	})
+	return result, nil
+}
+
+// GetSRPNotificationSettings returns the capacity alert thresholds of the given Storage Pool
+func (c *Client) GetSRPNotificationSettings(ctx context.Context, symID string, storagePoolID string) (*types.SRPNotificationSettings, error) {
+	defer c.TimeSpent("GetSRPNotificationSettings", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + "/" + StorageResourcePool + "/" + storagePoolID + XNotificationSettings
+	settings := &types.SRPNotificationSettings{}
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
+	defer cancel()
+	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), settings)
+	if err != nil {
+		log.Error("GetSRPNotificationSettings failed: " + err.Error())
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetSRPCapacityAlertThresholds enables the capacity alerts of the given Storage Pool and sets their thresholds,
+// as a percentage of its usable capacity. The warning threshold must be lower than the critical one.
+func (c *Client) SetSRPCapacityAlertThresholds(ctx context.Context, symID string, storagePoolID string, warningPercent, criticalPercent int) (*types.SRPNotificationSettings, error) {
+	defer c.TimeSpent("SetSRPCapacityAlertThresholds", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	if err := validateCapacityAlertThresholds(warningPercent, criticalPercent); err != nil {
+		return nil, err
+	}
+	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + "/" + StorageResourcePool + "/" + storagePoolID + XNotificationSettings
+	payload := &types.SRPNotificationSettings{
+		AlertsEnabled:            true,
+		WarningThresholdPercent:  warningPercent,
+		CriticalThresholdPercent: criticalPercent,
+	}
+	c.ifDebugLogPayload(payload)
+	fields := map[string]interface{}{
+		http.MethodPut:  URL,
+		"StoragePoolID": storagePoolID,
+		"Warning":       warningPercent,
+		"Critical":      criticalPercent,
+	}
+	log.WithFields(fields).Info("Setting capacity alert thresholds")
+	settings := &types.SRPNotificationSettings{}
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
+	defer cancel()
+	err := c.api.Put(ctx, URL, c.getDefaultHeaders(), payload, settings)
+	if err != nil {
+		log.WithFields(fields).Error("Error in SetSRPCapacityAlertThresholds: " + err.Error())
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetCapacityAlertThresholds sets the capacity alert thresholds of every Storage Pool of the array,
+// so that they all alert alike. The settings of the pools updated before a failure are returned with the error.
+func (c *Client) SetCapacityAlertThresholds(ctx context.Context, symID string, warningPercent, criticalPercent int) ([]types.SRPNotificationSettings, error) {
+	defer c.TimeSpent("SetCapacityAlertThresholds", time.Now())
+	if err := validateCapacityAlertThresholds(warningPercent, criticalPercent); err != nil {
+		return nil, err
+	}
+	pools, err := c.GetStoragePoolList(ctx, symID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]types.SRPNotificationSettings, 0, len(pools.StoragePoolIDs))
+	for _, storagePoolID := range pools.StoragePoolIDs {
+		settings, err := c.SetSRPCapacityAlertThresholds(ctx, symID, storagePoolID, warningPercent, criticalPercent)
+		if err != nil {
+			return result, fmt.Errorf("SetCapacityAlertThresholds failed on Storage Pool %s: %s", storagePoolID, err.Error())
+		}
+		result = append(result, *settings)
+	}
+	return result, nil
+}
+
+func validateCapacityAlertThresholds(warningPercent, criticalPercent int) error {
+	if warningPercent <= 0 || criticalPercent > 100 || warningPercent >= criticalPercent {
+		return fmt.Errorf("Invalid capacity alert thresholds %d%%/%d%%, the warning threshold must be above 0 and below the critical one, which must be at most 100", warningPercent, criticalPercent)
+	}
+	return nil
+}
+
+// SRPRanking is the criterion by which ChooseSRP ranks the Storage Pools of an array
+type SRPRanking string
+
+const (
+	// RankByFreeCapacity prefers the Storage Pool with the most free usable capacity
+	RankByFreeCapacity SRPRanking = "FreeCapacity"
+	// RankByEfficiency prefers the Storage Pool with the highest overall efficiency ratio
+	RankByEfficiency SRPRanking = "Efficiency"
+	// RankByPreference prefers the Storage Pools in the order of SRPPlacementPolicy.Preferred
+	RankByPreference SRPRanking = "Preference"
+)
+
+// SRPPlacementPolicy tells ChooseSRP how to select a Storage Pool. The Storage Pools with the same rank
+// are ordered by free usable capacity.
+type SRPPlacementPolicy struct {
+	// Ranking defaults to RankByFreeCapacity
+	Ranking SRPRanking
+	// Preferred lists the Storage Pools which can be chosen, in order of preference. It is required by
+	// RankByPreference; with the other rankings all the Storage Pools can be chosen if it is empty.
+	Preferred []string
+	// MinFreeGB excludes the Storage Pools with less free usable capacity
+	MinFreeGB float64
+}
+
+// SRPCandidate is the data on which ChooseSRP ranked a Storage Pool
+type SRPCandidate struct {
+	StoragePoolID   string
+	UsableTotalGB   float64
+	FreeGB          float64
+	EfficiencyRatio float64
+	// Excluded is the reason why the Storage Pool cannot be chosen, empty if it can
+	Excluded string
+}
+
+// SRPChoice is the Storage Pool selected by ChooseSRP and the candidates it was chosen from,
+// in ranking order with the excluded ones last
+type SRPChoice struct {
+	StoragePoolID string
+	Ranking       SRPRanking
+	Candidates    []SRPCandidate
+}
+
+// ChooseSRP selects the Storage Pool of an array in which to provision according to policy.
+// An error is returned if no Storage Pool satisfies the policy.
+func (c *Client) ChooseSRP(ctx context.Context, symID string, policy SRPPlacementPolicy) (*SRPChoice, error) {
+	defer c.TimeSpent("ChooseSRP", time.Now())
+	ranking := policy.Ranking
+	if ranking == "" {
+		ranking = RankByFreeCapacity
+	}
+	switch ranking {
+	case RankByFreeCapacity, RankByEfficiency:
+	case RankByPreference:
+		if len(policy.Preferred) == 0 {
+			return nil, fmt.Errorf("The %s ranking requires a list of preferred Storage Pools", ranking)
+		}
+	default:
+		return nil, fmt.Errorf("Unknown Storage Pool ranking %s", ranking)
+	}
+	pools, err := c.GetStoragePoolList(ctx, symID)
+	if err != nil {
+		return nil, err
+	}
+
+	preference := make(map[string]int, len(policy.Preferred))
+	for i, storagePoolID := range policy.Preferred {
+		if _, ok := preference[storagePoolID]; !ok {
+			preference[storagePoolID] = i
+		}
+	}
+	choice := &SRPChoice{Ranking: ranking, Candidates: make([]SRPCandidate, 0, len(pools.StoragePoolIDs))}
+	for _, storagePoolID := range pools.StoragePoolIDs {
+		pool, err := c.GetStoragePool(ctx, symID, storagePoolID)
+		if err != nil {
+			return nil, fmt.Errorf("ChooseSRP failed to get Storage Pool %s: %s", storagePoolID, err.Error())
+		}
+		candidate := SRPCandidate{StoragePoolID: storagePoolID}
+		if pool.SrpCap != nil {
+			candidate.UsableTotalGB = pool.SrpCap.UsableTotInTB * 1024
+			if pool.SrpCap.UsableTotInTB > pool.SrpCap.UsableUsedInTB {
+				candidate.FreeGB = (pool.SrpCap.UsableTotInTB - pool.SrpCap.UsableUsedInTB) * 1024
+			}
+		}
+		if pool.SrpEfficiency != nil {
+			candidate.EfficiencyRatio = float64(pool.SrpEfficiency.EfficiencyRatioToOne)
+		}
+		if _, ok := preference[storagePoolID]; !ok && len(preference) > 0 {
+			candidate.Excluded = "not a preferred Storage Pool"
+		} else if candidate.FreeGB < policy.MinFreeGB {
+			candidate.Excluded = fmt.Sprintf("%.2f GB free, below the minimum of %.2f GB", candidate.FreeGB, policy.MinFreeGB)
+		}
+		choice.Candidates = append(choice.Candidates, candidate)
+	}
+
+	sort.SliceStable(choice.Candidates, func(i, j int) bool {
+		a, b := choice.Candidates[i], choice.Candidates[j]
+		if (a.Excluded == "") != (b.Excluded == "") {
+			return a.Excluded == ""
+		}
+		switch {
+		case ranking == RankByEfficiency && a.EfficiencyRatio != b.EfficiencyRatio:
+			return a.EfficiencyRatio > b.EfficiencyRatio
+		case ranking == RankByPreference && preference[a.StoragePoolID] != preference[b.StoragePoolID]:
+			return preference[a.StoragePoolID] < preference[b.StoragePoolID]
+		case a.FreeGB != b.FreeGB:
+			return a.FreeGB > b.FreeGB
+		}
+		return a.StoragePoolID < b.StoragePoolID
+	})
+	if len(choice.Candidates) == 0 || choice.Candidates[0].Excluded != "" {
+		reasons := make([]string, 0, len(choice.Candidates))
+		for _, candidate := range choice.Candidates {
+			reasons = append(reasons, candidate.StoragePoolID+" is "+candidate.Excluded)
+		}
+		return nil, fmt.Errorf("No Storage Pool of array %s satisfies the placement policy: %s", symID, strings.Join(reasons, ", "))
+	}
+	choice.StoragePoolID = choice.Candidates[0].StoragePoolID
+	log.Infof("ChooseSRP selected Storage Pool %s of array %s by %s", choice.StoragePoolID, symID, ranking)
+	return choice, nil
+}
+
+// UpdateStorageGroup is a general method to update a StorageGroup (PUT operation) using a UpdateStorageGroupPayload.
+func (c *Client) UpdateStorageGroup(ctx context.Context, symID string, storageGroupID string, payload interface{}) (*types.Job, error) {
+	defer c.TimeSpent("UpdateStorageGroup", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XStorageGroup + "/" + storageGroupID
+	job := &types.Job{}
+	fields := map[string]interface{}{
+		http.MethodPut: URL,
+	}
+
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
+	defer cancel()
+	err := c.api.Put(
+		ctx, URL, c.getDefaultHeaders(), payload, job)
+	if err != nil {
+		log.WithFields(fields).Error("Error in UpdateStorageGroup: " + err.Error())
+		return nil, err
+	}
+	return job, nil
+}
+
+// StorageGroupUpdateError is returned by UpdateStorageGroupS when Unisphere reports errors for
+// the remote (R2) arrays of a storage group update. LocalError is set if the update failed on the
+// local (R1) array as well.
+type StorageGroupUpdateError struct {
+	SymmetrixID    string
+	StorageGroupID string
+	LocalError     error
+	RemoteErrors   []types.RemoteSymmetrixError
+}
+
+func (e *StorageGroupUpdateError) Error() string {
+	msgs := make([]string, 0)
+	if e.LocalError != nil {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", e.SymmetrixID, e.LocalError.Error()))
+	}
+	for _, remote := range e.RemoteErrors {
+		msgs = append(msgs, fmt.Sprintf("remote %s: %s", remote.SymmetrixID, remote.Message))
+	}
+	return fmt.Sprintf("update of storage group %s failed on %s", e.StorageGroupID, strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the error of the local array, if any
+func (e *StorageGroupUpdateError) Unwrap() error {
+	return e.LocalError
+}
+
+// LocalFailed returns true if the update failed on the local (R1) array
+func (e *StorageGroupUpdateError) LocalFailed() bool {
+	return e.LocalError != nil
+}
+
+// RemoteFailed returns true if the update failed on a remote (R2) array
+func (e *StorageGroupUpdateError) RemoteFailed() bool {
+	return len(e.RemoteErrors) != 0
+}
+
+// UpdateStorageGroupS is a general method to update a StorageGroup (PUT operation) using a UpdateStorageGroupPayload.
+// If Unisphere reports errors for the remote arrays of the storage group, a *StorageGroupUpdateError is returned.
+func (c *Client) UpdateStorageGroupS(ctx context.Context, symID string, storageGroupID string, payload interface{}) error {
+	defer c.TimeSpent("UpdateStorageGroupS", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return err
+	}
+	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XStorageGroup + "/" + storageGroupID
+	fields := map[string]interface{}{
+		http.MethodPut: URL,
+	}
+
+	result := &types.StorageGroupUpdateResult{}
+	ctx, cancel := c.getTimeoutContext(ctx, longJobOperation)
+	defer cancel()
+	err := c.api.Put(
+		ctx, URL, c.getDefaultHeaders(), payload, result)
+	if err != nil {
+		log.WithFields(fields).Error("Error in UpdateStorageGroup: " + err.Error())
+		if apiErr, ok := err.(*types.Error); ok && len(apiErr.RemoteSymmetrixErrors) != 0 {
+			return &StorageGroupUpdateError{
+				SymmetrixID:    symID,
+				StorageGroupID: storageGroupID,
+				LocalError:     err,
+				RemoteErrors:   apiErr.RemoteSymmetrixErrors,
+			}
+		}
+		return err
+	}
+	if len(result.RemoteSymmetrixErrors) != 0 {
+		err := &StorageGroupUpdateError{
+			SymmetrixID:    symID,
+			StorageGroupID: storageGroupID,
+			RemoteErrors:   result.RemoteSymmetrixErrors,
+		}
+		log.WithFields(fields).Error("Error in UpdateStorageGroup: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+// AssociateSnapshotPolicies associates the given snapshot policies with a storage group.
+func (c *Client) AssociateSnapshotPolicies(ctx context.Context, symID, storageGroupID string, policyNames []string) error {
+	defer c.TimeSpent("AssociateSnapshotPolicies", time.Now())
+	return c.editSnapshotPolicies(ctx, symID, storageGroupID, &types.EditSnapshotPoliciesParam{
+		AssociateSnapshotPolicyParam: &types.SnapshotPolicyNamesParam{SnapshotPolicyNames: policyNames},
+	})
+}
+
+// DisassociateSnapshotPolicies disassociates the given snapshot policies from a storage group.
+func (c *Client) DisassociateSnapshotPolicies(ctx context.Context, symID, storageGroupID string, policyNames []string) error {
+	defer c.TimeSpent("DisassociateSnapshotPolicies", time.Now())
+	return c.editSnapshotPolicies(ctx, symID, storageGroupID, &types.EditSnapshotPoliciesParam{
+		DisassociateSnapshotPolicyParam: &types.SnapshotPolicyNamesParam{SnapshotPolicyNames: policyNames},
+	})
+}
+
+func (c *Client) editSnapshotPolicies(ctx context.Context, symID, storageGroupID string, param *types.EditSnapshotPoliciesParam) error {
+	payload := &types.UpdateStorageGroupPayload{
+		EditStorageGroupActionParam: types.EditStorageGroupActionParam{
+			EditSnapshotPoliciesParam: param,
+		},
+		ExecutionOption: types.ExecutionOptionSynchronous,
+	}
+	c.ifDebugLogPayload(payload)
+	return c.UpdateStorageGroupS(ctx, symID, storageGroupID, payload)
+}
+
+// ifDebugLogPayload logs the payload if payload logging is enabled for the client or by Debug
+func (c *Client) ifDebugLogPayload(payload interface{}) {
+	if !Debug && !c.logPayloads {
+		return
+	}
+	payloadStr, err := formatPayloadForLog(payload, c.maxPayloadLog)
+	if err != nil {
+		log.Error("could not Marshal json payload: " + err.Error())
+	} else {
+		log.Info("payload: " + payloadStr)
+	}
+}
+
+// formatPayloadForLog returns the payload as JSON with its secrets redacted, truncated to maxSize bytes
+func formatPayloadForLog(payload interface{}, maxSize int) (string, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	payloadStr := api.RedactSecrets(string(payloadBytes))
+	if maxSize > 0 && len(payloadStr) > maxSize {
+		payloadStr = fmt.Sprintf("%s... (truncated, %d bytes)", payloadStr[:maxSize], len(payloadStr))
+	}
+	return payloadStr, nil
+}
+
+// CreateVolumeInStorageGroup creates a volume in the specified Storage Group with a given volumeName
+// and the size of the volume in cylinders.
+func (c *Client) CreateVolumeInStorageGroup(
+	ctx context.Context, symID string, storageGroupID string, volumeName string, sizeInCylinders int) (*types.Volume, error) {
+	defer c.TimeSpent("CreateVolumeInStorageGroup", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+
+	if len(volumeName) > MaxVolIdentifierLength {
+		return nil, fmt.Errorf("Length of volumeName exceeds max limit")
+	}
+
+	ctx, unlock, err := c.lockResource(ctx, symID, LockStorageGroup, storageGroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	payload := c.GetCreateVolInSGPayload(sizeInCylinders, volumeName, false, "", "")
+	job, err := c.UpdateStorageGroup(ctx, symID, storageGroupID, payload)
+	if err != nil || job == nil {
+		return nil, fmt.Errorf("A job was not returned from UpdateStorageGroup")
+	}
+	job, err = c.WaitOnJobCompletion(ctx, symID, job.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch job.Status {
+	case types.JobStatusFailed:
+		return nil, fmt.Errorf("The UpdateStorageGroup job failed: " + c.JobToString(job))
+	}
+	volume, err := c.GetVolumeByIdentifier(ctx, symID, storageGroupID, volumeName, sizeInCylinders)
+	return volume, err
+}
+
+// GetVolumeByIdentifier on the given symmetrix in specific storage group with a volume name and having size in cylinders
+func (c *Client) GetVolumeByIdentifier(ctx context.Context, symID, storageGroupID string, volumeName string, sizeInCylinders int) (*types.Volume, error) {
+	volIDList, err := c.GetVolumeIDList(ctx, symID, volumeName, false)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't get Volume ID List: " + err.Error())
+	}
+	if len(volIDList) > 1 {
+		log.Warning("Found multiple volumes matching the identifier " + volumeName)
 	}
 	for _, volumeID := range volIDList {
 		vol, err := c.GetVolumeByID(ctx, symID, volumeID)
 		if err == nil {
-			for _, sgID := range vol.StorageGroupIDList {
+			for _, sgID := range vol.StorageGroupNames() {
 				if sgID == storageGroupID && vol.CapacityCYL == sizeInCylinders {
 					// Return the first match
 					return vol, nil
@@ -586,8 +1384,14 @@ func (c *Client) CreateVolumeInStorageGroupS(ctx context.Context, symID, storage
 
 // CreateVolumeInProtectedStorageGroupS takes simplified input arguments to create a volume of a give name and size in a protected storage group.
 // This will add volume in both Local and Remote Storage group
-// This method is run synchronously
+// This method is run synchronously. The update is forced, see CreateVolumeInProtectedStorageGroupSWithForceOption.
 func (c *Client) CreateVolumeInProtectedStorageGroupS(ctx context.Context, symID, remoteSymID, storageGroupID string, remoteStorageGroupID string, volumeName string, sizeInCylinders int, opts ...http.Header) (*types.Volume, error) {
+	return c.CreateVolumeInProtectedStorageGroupSWithForceOption(ctx, symID, remoteSymID, storageGroupID, remoteStorageGroupID, volumeName, sizeInCylinders, ForceUpdate, opts...)
+}
+
+// CreateVolumeInProtectedStorageGroupSWithForceOption is CreateVolumeInProtectedStorageGroupS with the force flag of
+// the update, see ForceOption for what it implies
+func (c *Client) CreateVolumeInProtectedStorageGroupSWithForceOption(ctx context.Context, symID, remoteSymID, storageGroupID string, remoteStorageGroupID string, volumeName string, sizeInCylinders int, force ForceOption, opts ...http.Header) (*types.Volume, error) {
 	defer c.TimeSpent("CreateVolumeInStorageGroup", time.Now())
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
@@ -597,16 +1401,64 @@ func (c *Client) CreateVolumeInProtectedStorageGroupS(ctx context.Context, symID
 		return nil, fmt.Errorf("Length of volumeName exceeds max limit")
 	}
 
-	payload := c.GetCreateVolInSGPayload(sizeInCylinders, volumeName, true, remoteSymID, remoteStorageGroupID, opts...)
+	payload := c.GetCreateVolInSGPayloadWithForceOption(sizeInCylinders, volumeName, true, force, remoteSymID, remoteStorageGroupID, opts...)
 	err := c.UpdateStorageGroupS(ctx, symID, storageGroupID, payload)
 	if err != nil {
-		return nil, fmt.Errorf("couldn't create volume. error - %s", err.Error())
+		return nil, fmt.Errorf("couldn't create volume. error - %w", err)
 	}
 
 	volume, err := c.GetVolumeByIdentifier(ctx, symID, storageGroupID, volumeName, sizeInCylinders)
 	return volume, err
 }
 
+// CreateVolumeIfNotExists returns the volume with the given volumeName (exact match) if it already
+// exists in storageGroupID with the requested size, otherwise it creates the volume synchronously.
+// The returned flag is true if the volume was created by this call and false if it was found.
+// An error is returned if a volume with the same name exists but with a different size or storage group.
+func (c *Client) CreateVolumeIfNotExists(ctx context.Context, symID, storageGroupID string, volumeName string, sizeInCylinders int) (*types.Volume, bool, error) {
+	defer c.TimeSpent("CreateVolumeIfNotExists", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, false, err
+	}
+
+	if len(volumeName) > MaxVolIdentifierLength {
+		return nil, false, fmt.Errorf("Length of volumeName exceeds max limit")
+	}
+
+	volIDList, err := c.GetVolumeIDList(ctx, symID, volumeName, false)
+	if err != nil {
+		return nil, false, fmt.Errorf("Couldn't get Volume ID List: " + err.Error())
+	}
+	for _, volumeID := range volIDList {
+		vol, err := c.GetVolumeByID(ctx, symID, volumeID)
+		if err != nil {
+			return nil, false, err
+		}
+		if vol.VolumeIdentifier != volumeName {
+			continue
+		}
+		inSG := false
+		for _, sgID := range vol.StorageGroupNames() {
+			if sgID == storageGroupID {
+				inSG = true
+				break
+			}
+		}
+		if !inSG || vol.CapacityCYL != sizeInCylinders {
+			return nil, false, fmt.Errorf("volume %s (%s) already exists with size %d CYL in storage groups %v, requested size %d CYL in storage group %s",
+				volumeName, volumeID, vol.CapacityCYL, vol.StorageGroupNames(), sizeInCylinders, storageGroupID)
+		}
+		log.Info(fmt.Sprintf("Found existing volume %s (%s) in SG: %s", volumeName, volumeID, storageGroupID))
+		return vol, false, nil
+	}
+
+	vol, err := c.CreateVolumeInStorageGroupS(ctx, symID, storageGroupID, volumeName, sizeInCylinders)
+	if err != nil {
+		return nil, false, err
+	}
+	return vol, true, nil
+}
+
 // ExpandVolume expands an existing volume to a new (larger) size in CYL
 func (c *Client) ExpandVolume(ctx context.Context, symID string, volumeID string, newSizeCYL int) (*types.Volume, error) {
 	payload := &types.EditVolumeParam{
@@ -621,8 +1473,8 @@ func (c *Client) ExpandVolume(ctx context.Context, symID string, volumeID string
 	}
 
 	payload.ExecutionOption = types.ExecutionOptionSynchronous
-	ifDebugLogPayload(payload)
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	c.ifDebugLogPayload(payload)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XVolume + "/" + volumeID
 	err := c.api.Put(ctx, URL, c.getDefaultHeaders(), payload, nil)
@@ -645,6 +1497,11 @@ func (c *Client) AddVolumesToStorageGroup(ctx context.Context, symID, storageGro
 	if len(volumeIDs) == 0 {
 		return fmt.Errorf("At least one volume id has to be specified")
 	}
+	ctx, unlock, err := c.lockResource(ctx, symID, LockStorageGroup, storageGroupID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	payload := c.GetAddVolumeToSGPayload(false, force, "", "", volumeIDs...)
 	job, err := c.UpdateStorageGroup(ctx, symID, storageGroupID, payload)
 	if err != nil || job == nil {
@@ -654,30 +1511,140 @@ func (c *Client) AddVolumesToStorageGroup(ctx context.Context, symID, storageGro
 	if err != nil {
 		return err
 	}
-
-	switch job.Status {
-	case types.JobStatusFailed:
-		return fmt.Errorf("The UpdateStorageGroup job failed: " + c.JobToString(job))
+
+	switch job.Status {
+	case types.JobStatusFailed:
+		return fmt.Errorf("The UpdateStorageGroup job failed: " + c.JobToString(job))
+	}
+	return nil
+}
+
+// UpdateStorageGroupAndGetNewVolumeIDs runs an asynchronous UpdateStorageGroup with the given payload
+// (e.g. from GetCreateVolInSGPayload or GetAddVolumeToSGPayload), waits for the job to complete and
+// returns the IDs of the volumes which were added to the storage group by it.
+// The volume IDs are taken from the job's resource link when it points to a volume, otherwise they are
+// computed from the difference between the storage group's volumes before and after the job.
+func (c *Client) UpdateStorageGroupAndGetNewVolumeIDs(ctx context.Context, symID, storageGroupID string, payload interface{}) ([]string, error) {
+	defer c.TimeSpent("UpdateStorageGroupAndGetNewVolumeIDs", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	ctx, unlock, err := c.lockResource(ctx, symID, LockStorageGroup, storageGroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	before, err := c.GetVolumeIDListInStorageGroup(ctx, symID, storageGroupID)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't get Volume ID List for SG %s: %s", storageGroupID, err.Error())
+	}
+	job, err := c.UpdateStorageGroup(ctx, symID, storageGroupID, payload)
+	if err != nil || job == nil {
+		return nil, fmt.Errorf("A job was not returned from UpdateStorageGroup")
+	}
+	job, err = c.WaitOnJobCompletion(ctx, symID, job.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch job.Status {
+	case types.JobStatusFailed:
+		return nil, fmt.Errorf("The UpdateStorageGroup job failed: " + c.JobToString(job))
+	}
+	if _, resourceType, resourceID := job.GetJobResource(); resourceType == "volume" && resourceID != "" {
+		return []string{resourceID}, nil
+	}
+
+	after, err := c.GetVolumeIDListInStorageGroup(ctx, symID, storageGroupID)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't get Volume ID List for SG %s: %s", storageGroupID, err.Error())
+	}
+	newVolumeIDs := make([]string, 0)
+	for _, volumeID := range after {
+		if !stringInSlice(volumeID, before) {
+			newVolumeIDs = append(newVolumeIDs, volumeID)
+		}
+	}
+	return newVolumeIDs, nil
+}
+
+// AddVolumesToStorageGroupS adds one or more volumes (given by their volumeIDs) to a StorageGroup.
+func (c *Client) AddVolumesToStorageGroupS(ctx context.Context, symID, storageGroupID string, force bool, volumeIDs ...string) error {
+	defer c.TimeSpent("AddVolumesToStorageGroupS", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return err
+	}
+	// Check if the volume id list is not empty
+	if len(volumeIDs) == 0 {
+		return fmt.Errorf("at least one volume id has to be specified")
+	}
+	payload := c.GetAddVolumeToSGPayload(true, force, "", "", volumeIDs...)
+	err := c.UpdateStorageGroupS(ctx, symID, storageGroupID, payload)
+	if err != nil {
+		return fmt.Errorf("An error(%w) was returned from UpdateStorageGroup", err)
+	}
+	return nil
+}
+
+// VolumeCriteria selects pre-existing volumes which are not in any storage group by their attributes,
+// see AddVolumesToStorageGroupByCriteria.
+type VolumeCriteria struct {
+	// NumberOfVolumes is the number of volumes to add
+	NumberOfVolumes int
+
+	// VolumeSize is the size of the volumes, in CapacityUnit
+	VolumeSize string
+
+	// CapacityUnit is one of CYL, MB, GB or TB; it defaults to CYL
+	CapacityUnit string
+
+	// Emulation is the emulation of the volumes; it defaults to FBA
+	Emulation string
+}
+
+// AddVolumesToStorageGroupByCriteria adds pre-existing volumes, which are not in any storage group,
+// to a StorageGroup by their attributes instead of their IDs, e.g. to re-import orphaned volumes.
+// The IDs of the volumes added are returned. It requires Unisphere 9.1 or later.
+func (c *Client) AddVolumesToStorageGroupByCriteria(ctx context.Context, symID, storageGroupID string, force bool, criteria VolumeCriteria) ([]string, error) {
+	defer c.TimeSpent("AddVolumesToStorageGroupByCriteria", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	payload, err := c.GetAddExistingVolumesToSGPayload(false, ForceOption(force), criteria)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return c.UpdateStorageGroupAndGetNewVolumeIDs(ctx, symID, storageGroupID, payload)
 }
 
-// AddVolumesToStorageGroupS adds one or more volumes (given by their volumeIDs) to a StorageGroup.
-func (c *Client) AddVolumesToStorageGroupS(ctx context.Context, symID, storageGroupID string, force bool, volumeIDs ...string) error {
-	defer c.TimeSpent("AddVolumesToStorageGroupS", time.Now())
-	if _, err := c.IsAllowedArray(symID); err != nil {
+// AddVolumeRangeToStorageGroup adds the volumes from firstVolumeID to lastVolumeID, both included,
+// to a StorageGroup. The volume IDs are the 5 digit hexadecimal volume IDs.
+func (c *Client) AddVolumeRangeToStorageGroup(ctx context.Context, symID, storageGroupID string, force bool, firstVolumeID, lastVolumeID string) error {
+	volumeIDs, err := volumeIDRange(firstVolumeID, lastVolumeID)
+	if err != nil {
 		return err
 	}
-	// Check if the volume id list is not empty
-	if len(volumeIDs) == 0 {
-		return fmt.Errorf("at least one volume id has to be specified")
+	return c.AddVolumesToStorageGroup(ctx, symID, storageGroupID, force, volumeIDs...)
+}
+
+// volumeIDRange returns the volume IDs from first to last, both included
+func volumeIDRange(first, last string) ([]string, error) {
+	from, err := strconv.ParseUint(first, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid volume ID %s", first)
 	}
-	payload := c.GetAddVolumeToSGPayload(true, force, "", "", volumeIDs...)
-	err := c.UpdateStorageGroupS(ctx, symID, storageGroupID, payload)
+	to, err := strconv.ParseUint(last, 16, 32)
 	if err != nil {
-		return fmt.Errorf("An error(%s) was returned from UpdateStorageGroup", err.Error())
+		return nil, fmt.Errorf("Invalid volume ID %s", last)
 	}
-	return nil
+	if to < from {
+		return nil, fmt.Errorf("Invalid volume range %s-%s", first, last)
+	}
+	volumeIDs := make([]string, 0, to-from+1)
+	for id := from; id <= to; id++ {
+		volumeIDs = append(volumeIDs, fmt.Sprintf("%05X", id))
+	}
+	return volumeIDs, nil
 }
 
 // AddVolumesToProtectedStorageGroup adds one or more volumes (given by their volumeIDs) to a Protected StorageGroup.
@@ -715,7 +1682,7 @@ func (c *Client) RemoveVolumesFromStorageGroup(ctx context.Context, symID string
 	}
 
 	updatedStorageGroup := &types.StorageGroup{}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, longJobOperation)
 	defer cancel()
 	err := c.api.Put(
 		ctx, URL, c.getDefaultHeaders(), payload, updatedStorageGroup)
@@ -744,7 +1711,7 @@ func (c *Client) RemoveVolumesFromProtectedStorageGroup(ctx context.Context, sym
 	}
 
 	updatedStorageGroup := &types.StorageGroup{}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, longJobOperation)
 	defer cancel()
 	err := c.api.Put(
 		ctx, URL, c.getDefaultHeaders(), payload, updatedStorageGroup)
@@ -758,14 +1725,22 @@ func (c *Client) RemoveVolumesFromProtectedStorageGroup(ctx context.Context, sym
 
 // GetCreateVolInSGPayload returns payload for adding volume/s to SG.
 // if remoteSymID is passed then the payload includes RemoteSymmSGInfoParam.
-func (c *Client) GetCreateVolInSGPayload(sizeInCylinders int, volumeName string, isSync bool, remoteSymID, remoteStorageGroupID string, opts ...http.Header) (payload interface{}) {
-	var executionOption string
+// From Unisphere 9.1 the update is forced, see GetCreateVolInSGPayloadWithForceOption.
+func (c *Client) GetCreateVolInSGPayload(sizeInCylinders int, volumeName string, isSync bool, remoteSymID, remoteStorageGroupID string, opts ...http.Header) *StorageGroupPayload {
+	return c.GetCreateVolInSGPayloadWithForceOption(sizeInCylinders, volumeName, isSync, ForceUpdate, remoteSymID, remoteStorageGroupID, opts...)
+}
+
+// GetCreateVolInSGPayloadWithForceOption returns the payload creating a volume of sizeInCylinders named volumeName
+// in a storage group, and in remoteStorageGroupID of remoteSymID if it is passed. force says whether Unisphere 9.1
+// and later create the remote volume whatever the RDF state of the storage groups, see ForceOption.
+func (c *Client) GetCreateVolInSGPayloadWithForceOption(sizeInCylinders int, volumeName string, isSync bool, force ForceOption, remoteSymID, remoteStorageGroupID string, opts ...http.Header) *StorageGroupPayload {
+	c.checkForceOption("GetCreateVolInSGPayload", force)
+	payload := &StorageGroupPayload{}
 	size := strconv.Itoa(sizeInCylinders)
 	if c.version == "90" {
+		executionOption := types.ExecutionOptionAsynchronous
 		if isSync {
 			executionOption = types.ExecutionOptionSynchronous
-		} else {
-			executionOption = types.ExecutionOptionAsynchronous
 		}
 		addVolumeParam := &types.AddVolumeParam{
 			NumberOfVols: 1,
@@ -776,12 +1751,11 @@ func (c *Client) GetCreateVolInSGPayload(sizeInCylinders int, volumeName string,
 			// CreateNewVolumes: true,
 			Emulation: "FBA",
 			VolumeIdentifier: types.VolumeIdentifierType{
-				VolumeIdentifierChoice: "identifier_name",
+				VolumeIdentifierChoice: types.VolumeIdentifierChoiceName,
 				IdentifierName:         volumeName,
 			},
 		}
-
-		payload = &types.UpdateStorageGroupPayload{
+		payload.V90 = &types.UpdateStorageGroupPayload{
 			EditStorageGroupActionParam: types.EditStorageGroupActionParam{
 				ExpandStorageGroupParam: &types.ExpandStorageGroupParam{
 					AddVolumeParam: addVolumeParam,
@@ -789,23 +1763,10 @@ func (c *Client) GetCreateVolInSGPayload(sizeInCylinders int, volumeName string,
 			},
 			ExecutionOption: executionOption,
 		}
-
-		if opts != nil && len(opts) != 0 {
-			// If the payload has a SetMetaData method, set the metadata headers.
-			if t, ok := interface{}(payload).(interface {
-				SetMetaData(metadata http.Header)
-			}); ok {
-				t.SetMetaData(opts[0])
-			} else {
-				log.Println("warning: gopowermax.UpdateStorageGroupPayload: no SetMetaData method exists, consider updating gopowermax library.")
-			}
-		}
-
 	} else {
+		executionOption := types91.ExecutionOptionAsynchronous
 		if isSync {
 			executionOption = types91.ExecutionOptionSynchronous
-		} else {
-			executionOption = types91.ExecutionOptionAsynchronous
 		}
 		addVolumeParam := &types91.AddVolumeParam{
 			CreateNewVolumes: true,
@@ -814,7 +1775,7 @@ func (c *Client) GetCreateVolInSGPayload(sizeInCylinders int, volumeName string,
 				{
 					NumberOfVolumes: 1,
 					VolumeIdentifier: &types91.VolumeIdentifierType{
-						VolumeIdentifierChoice: "identifier_name",
+						VolumeIdentifierChoice: types.VolumeIdentifierChoiceName,
 						IdentifierName:         volumeName,
 					},
 					CapacityUnit: "CYL",
@@ -822,14 +1783,14 @@ func (c *Client) GetCreateVolInSGPayload(sizeInCylinders int, volumeName string,
 				},
 			},
 			RemoteSymmSGInfoParam: types91.RemoteSymmSGInfoParam{
-				Force: true,
+				Force: bool(force),
 			},
 		}
 		if remoteSymID != "" {
 			addVolumeParam.RemoteSymmSGInfoParam.RemoteSymmetrix1ID = remoteSymID
 			addVolumeParam.RemoteSymmSGInfoParam.RemoteSymmetrix1SGs = []string{remoteStorageGroupID}
 		}
-		payload = &types91.UpdateStorageGroupPayload{
+		payload.V91 = &types91.UpdateStorageGroupPayload{
 			EditStorageGroupActionParam: types91.EditStorageGroupActionParam{
 				ExpandStorageGroupParam: &types91.ExpandStorageGroupParam{
 					AddVolumeParam: addVolumeParam,
@@ -837,36 +1798,34 @@ func (c *Client) GetCreateVolInSGPayload(sizeInCylinders int, volumeName string,
 			},
 			ExecutionOption: executionOption,
 		}
-		if opts != nil && len(opts) != 0 {
-			// If the payload has a SetMetaData method, set the metadata headers.
-			if t, ok := interface{}(payload).(interface {
-				SetMetaData(metadata http.Header)
-			}); ok {
-				t.SetMetaData(opts[0])
-			} else {
-				log.Println("warning: gopowermax.UpdateStorageGroupPayload: no SetMetaData method exists, consider updating gopowermax library.")
-			}
-		}
 	}
-	if payload != nil {
-		ifDebugLogPayload(payload)
+	if len(opts) != 0 {
+		payload.SetMetaData(opts[0])
 	}
+	c.ifDebugLogPayload(payload)
 	return payload
 }
 
 // GetAddVolumeToSGPayload returns payload for adding specific volume/s to SG.
-func (c *Client) GetAddVolumeToSGPayload(isSync, force bool, remoteSymID, remoteStorageGroupID string, volumeIDs ...string) (payload interface{}) {
-	executionOption := ""
+func (c *Client) GetAddVolumeToSGPayload(isSync, force bool, remoteSymID, remoteStorageGroupID string, volumeIDs ...string) *StorageGroupPayload {
+	return c.GetAddVolumeToSGPayloadWithForceOption(isSync, ForceOption(force), remoteSymID, remoteStorageGroupID, volumeIDs...)
+}
+
+// GetAddVolumeToSGPayloadWithForceOption returns the payload adding the existing volumes volumeIDs to a storage
+// group, and their remote pairs to remoteStorageGroupID of remoteSymID if it is passed. A forced update adds the
+// volumes even if their RDF pairs are not in a state allowing it; Unisphere 9.0 ignores force.
+func (c *Client) GetAddVolumeToSGPayloadWithForceOption(isSync bool, force ForceOption, remoteSymID, remoteStorageGroupID string, volumeIDs ...string) *StorageGroupPayload {
+	c.checkForceOption("GetAddVolumeToSGPayload", force)
+	payload := &StorageGroupPayload{}
 	if c.version == "90" {
+		executionOption := types.ExecutionOptionAsynchronous
 		if isSync {
 			executionOption = types.ExecutionOptionSynchronous
-		} else {
-			executionOption = types.ExecutionOptionAsynchronous
 		}
 		addSpecificVolumeParam := &types.AddSpecificVolumeParam{
 			VolumeIDs: volumeIDs,
 		}
-		payload = &types.UpdateStorageGroupPayload{
+		payload.V90 = &types.UpdateStorageGroupPayload{
 			EditStorageGroupActionParam: types.EditStorageGroupActionParam{
 				ExpandStorageGroupParam: &types.ExpandStorageGroupParam{
 					AddSpecificVolumeParam: addSpecificVolumeParam,
@@ -875,22 +1834,21 @@ func (c *Client) GetAddVolumeToSGPayload(isSync, force bool, remoteSymID, remote
 			ExecutionOption: executionOption,
 		}
 	} else {
+		executionOption := types91.ExecutionOptionAsynchronous
 		if isSync {
 			executionOption = types91.ExecutionOptionSynchronous
-		} else {
-			executionOption = types91.ExecutionOptionAsynchronous
 		}
 		addSpecificVolumeParam := &types91.AddSpecificVolumeParam{
 			VolumeIDs: volumeIDs,
 			RemoteSymmSGInfoParam: types91.RemoteSymmSGInfoParam{
-				Force: force,
+				Force: bool(force),
 			},
 		}
 		if remoteSymID != "" {
 			addSpecificVolumeParam.RemoteSymmSGInfoParam.RemoteSymmetrix1ID = remoteSymID
 			addSpecificVolumeParam.RemoteSymmSGInfoParam.RemoteSymmetrix1SGs = []string{remoteStorageGroupID}
 		}
-		payload = &types91.UpdateStorageGroupPayload{
+		payload.V91 = &types91.UpdateStorageGroupPayload{
 			EditStorageGroupActionParam: types91.EditStorageGroupActionParam{
 				ExpandStorageGroupParam: &types91.ExpandStorageGroupParam{
 					AddSpecificVolumeParam: addSpecificVolumeParam,
@@ -899,19 +1857,80 @@ func (c *Client) GetAddVolumeToSGPayload(isSync, force bool, remoteSymID, remote
 			ExecutionOption: executionOption,
 		}
 	}
-	if payload != nil {
-		ifDebugLogPayload(payload)
-	}
+	c.ifDebugLogPayload(payload)
 	return payload
 }
 
+// GetAddExistingVolumesToSGPayload returns payload for adding pre-existing volumes, which are not in any
+// storage group, to SG by their attributes. Unisphere 9.0 does not support it.
+// force is only honoured by Unisphere 9.1 and later, see ForceOption.
+func (c *Client) GetAddExistingVolumesToSGPayload(isSync bool, force ForceOption, criteria VolumeCriteria) (*StorageGroupPayload, error) {
+	c.checkForceOption("GetAddExistingVolumesToSGPayload", force)
+	if c.version == APIVersion90 {
+		return nil, fmt.Errorf("Adding volumes to a storage group by criteria requires Unisphere 9.1 or later")
+	}
+	if criteria.NumberOfVolumes <= 0 {
+		return nil, fmt.Errorf("The number of volumes must be positive")
+	}
+	if criteria.VolumeSize == "" {
+		return nil, fmt.Errorf("A volume size has to be specified")
+	}
+	capacityUnit := strings.ToUpper(criteria.CapacityUnit)
+	if capacityUnit == "" {
+		capacityUnit = "CYL"
+	}
+	emulation := criteria.Emulation
+	if emulation == "" {
+		emulation = "FBA"
+	}
+	executionOption := types91.ExecutionOptionAsynchronous
+	if isSync {
+		executionOption = types91.ExecutionOptionSynchronous
+	}
+	payload := &StorageGroupPayload{
+		V91: &types91.UpdateStorageGroupPayload{
+			EditStorageGroupActionParam: types91.EditStorageGroupActionParam{
+				ExpandStorageGroupParam: &types91.ExpandStorageGroupParam{
+					AddVolumeParam: &types91.AddVolumeParam{
+						// Unisphere reuses the existing volumes unless create_new_volumes is set
+						CreateNewVolumes: false,
+						Emulation:        emulation,
+						VolumeAttributes: []types91.VolumeAttributeType{
+							{
+								NumberOfVolumes: criteria.NumberOfVolumes,
+								CapacityUnit:    capacityUnit,
+								VolumeSize:      criteria.VolumeSize,
+							},
+						},
+						RemoteSymmSGInfoParam: types91.RemoteSymmSGInfoParam{
+							Force: bool(force),
+						},
+					},
+				},
+			},
+			ExecutionOption: executionOption,
+		},
+	}
+	c.ifDebugLogPayload(payload)
+	return payload, nil
+}
+
 // GetRemoveVolumeFromSGPayload returns payload for removing volume/s from SG.
-func (c *Client) GetRemoveVolumeFromSGPayload(force bool, remoteSymID, remoteStorageGroupID string, volumeIDs ...string) (payload interface{}) {
+func (c *Client) GetRemoveVolumeFromSGPayload(force bool, remoteSymID, remoteStorageGroupID string, volumeIDs ...string) *StorageGroupPayload {
+	return c.GetRemoveVolumeFromSGPayloadWithForceOption(ForceOption(force), remoteSymID, remoteStorageGroupID, volumeIDs...)
+}
+
+// GetRemoveVolumeFromSGPayloadWithForceOption returns the synchronous payload removing volumeIDs from a storage
+// group, and their remote pairs from remoteStorageGroupID of remoteSymID if it is passed. Forcing the removal from
+// an SRDF protected storage group can leave the remote storage group out of step, see ForceOption.
+func (c *Client) GetRemoveVolumeFromSGPayloadWithForceOption(force ForceOption, remoteSymID, remoteStorageGroupID string, volumeIDs ...string) *StorageGroupPayload {
+	c.checkForceOption("GetRemoveVolumeFromSGPayload", force)
+	payload := &StorageGroupPayload{}
 	if c.version == "90" {
 		removeVolumeParam := &types.RemoveVolumeParam{
 			VolumeIDs: volumeIDs,
 		}
-		payload = &types.UpdateStorageGroupPayload{
+		payload.V90 = &types.UpdateStorageGroupPayload{
 			EditStorageGroupActionParam: types.EditStorageGroupActionParam{
 				RemoveVolumeParam: removeVolumeParam,
 			},
@@ -921,26 +1940,36 @@ func (c *Client) GetRemoveVolumeFromSGPayload(force bool, remoteSymID, remoteSto
 		removeVolumeParam := &types91.RemoveVolumeParam{
 			VolumeIDs: volumeIDs,
 			RemoteSymmSGInfoParam: types91.RemoteSymmSGInfoParam{
-				Force: force,
+				Force: bool(force),
 			},
 		}
 		if remoteSymID != "" {
 			removeVolumeParam.RemoteSymmSGInfoParam.RemoteSymmetrix1ID = remoteSymID
 			removeVolumeParam.RemoteSymmSGInfoParam.RemoteSymmetrix1SGs = []string{remoteStorageGroupID}
 		}
-		payload = &types91.UpdateStorageGroupPayload{
+		payload.V91 = &types91.UpdateStorageGroupPayload{
 			EditStorageGroupActionParam: types91.EditStorageGroupActionParam{
 				RemoveVolumeParam: removeVolumeParam,
 			},
 			ExecutionOption: types91.ExecutionOptionSynchronous,
 		}
 	}
-	if payload != nil {
-		ifDebugLogPayload(payload)
-	}
+	c.ifDebugLogPayload(payload)
 	return payload
 }
 
+// checkForceOption logs that a forced storage group update is built, or that force is ignored by Unisphere 9.0
+func (c *Client) checkForceOption(builder string, force ForceOption) {
+	if force != ForceUpdate {
+		return
+	}
+	if c.version == APIVersion90 {
+		log.Debug(builder + ": force is not supported by Unisphere 9.0 and is ignored")
+		return
+	}
+	log.Debug(builder + ": building a forced storage group update, Unisphere will not check the RDF state of the volumes")
+}
+
 // GetStoragePoolList returns a StoragePoolList object, which contains a list of all the Storage Pool names.
 func (c *Client) GetStoragePoolList(ctx context.Context, symid string) (*types.StoragePoolList, error) {
 	defer c.TimeSpent("GetStoragePoolList", time.Now())
@@ -949,7 +1978,7 @@ func (c *Client) GetStoragePoolList(ctx context.Context, symid string) (*types.S
 	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symid + "/" + StorageResourcePool
 	spList := &types.StoragePoolList{}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), spList)
 	if err != nil {
@@ -959,17 +1988,35 @@ func (c *Client) GetStoragePoolList(ctx context.Context, symid string) (*types.S
 	return spList, nil
 }
 
-// RenameVolume renames a volume.
+// RenameVolume renames a volume. An empty newName clears the identifier, see ClearVolumeIdentifier.
 func (c *Client) RenameVolume(ctx context.Context, symID string, volumeID string, newName string) (*types.Volume, error) {
 	defer c.TimeSpent("RenameVolume", time.Now())
+	if newName == "" {
+		return c.ClearVolumeIdentifier(ctx, symID, volumeID)
+	}
+	identifier := types.VolumeIdentifierType{
+		VolumeIdentifierChoice: types.VolumeIdentifierChoiceName,
+		IdentifierName:         newName,
+	}
+	return c.modifyVolumeIdentifier(ctx, symID, volumeID, identifier)
+}
+
+// ClearVolumeIdentifier removes the identifier (name) of a volume, e.g. before it is released
+// back to a pool of free devices, and returns the updated volume.
+func (c *Client) ClearVolumeIdentifier(ctx context.Context, symID string, volumeID string) (*types.Volume, error) {
+	defer c.TimeSpent("ClearVolumeIdentifier", time.Now())
+	identifier := types.VolumeIdentifierType{
+		VolumeIdentifierChoice: types.VolumeIdentifierChoiceNone,
+	}
+	return c.modifyVolumeIdentifier(ctx, symID, volumeID, identifier)
+}
+
+func (c *Client) modifyVolumeIdentifier(ctx context.Context, symID string, volumeID string, identifier types.VolumeIdentifierType) (*types.Volume, error) {
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
 	modifyVolumeIdentifierParam := &types.ModifyVolumeIdentifierParam{
-		VolumeIdentifier: types.VolumeIdentifierType{
-			VolumeIdentifierChoice: "identifier_name",
-			IdentifierName:         newName,
-		},
+		VolumeIdentifier: identifier,
 	}
 
 	payload := &types.EditVolumeParam{
@@ -978,17 +2025,17 @@ func (c *Client) RenameVolume(ctx context.Context, symID string, volumeID string
 		},
 		ExecutionOption: types.ExecutionOptionSynchronous,
 	}
-	ifDebugLogPayload(payload)
+	c.ifDebugLogPayload(payload)
 	volume := &types.Volume{}
 
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XVolume + "/" + volumeID
 	fields := map[string]interface{}{
 		http.MethodPut: URL,
 		"VolumeID":     volumeID,
-		"NewName":      newName,
+		"NewName":      identifier.IdentifierName,
 	}
 	log.WithFields(fields).Info("Renaming volume")
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
 	err := c.api.Put(
 		ctx, URL, c.getDefaultHeaders(), payload, volume)
@@ -1014,7 +2061,7 @@ func (c *Client) DeleteVolume(ctx context.Context, symID string, volumeID string
 		"VolumeID":     volumeID,
 	}
 	log.WithFields(fields).Info("Deleting volume")
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
 	err := c.api.Delete(ctx, URL, c.getDefaultHeaders(), nil)
 	if err != nil {
@@ -1025,6 +2072,94 @@ func (c *Client) DeleteVolume(ctx context.Context, symID string, volumeID string
 	return err
 }
 
+var (
+	// DeleteVolumeRetryInterval is the initial wait between the attempts of DeleteVolumeWithRetry.
+	// It doubles after every attempt up to MaxDeleteVolumeRetryInterval.
+	DeleteVolumeRetryInterval = 1 * time.Second
+	// MaxDeleteVolumeRetryInterval is the longest wait between the attempts of DeleteVolumeWithRetry.
+	MaxDeleteVolumeRetryInterval = 15 * time.Second
+)
+
+// DefaultDeleteVolumeRetryWindow is how long DeleteVolumeWithRetry retries when it is given no window
+const DefaultDeleteVolumeRetryWindow = 1 * time.Minute
+
+// ErrVolumeInStorageGroup is returned, wrapped with the volume and its storage groups, by DeleteVolumeWithRetry
+// when the volume is still a member of a storage group at the end of the retry window
+var ErrVolumeInStorageGroup = errors.New("volume is a member of a storage group")
+
+// isVolumeInStorageGroupError returns whether err is Unisphere refusing to delete a volume in a storage group
+func isVolumeInStorageGroupError(err error) bool {
+	status := httpStatus(err)
+	return status == http.StatusForbidden || status == http.StatusConflict
+}
+
+// DeleteVolumeWithRetry deletes a volume like DeleteVolume, for a volume just removed from its storage groups,
+// which Unisphere may still report as a member of a storage group for a while. The storage groups of the volume
+// are read before each attempt, and the deletion is retried with a growing interval for up to window, zero using
+// DefaultDeleteVolumeRetryWindow. A volume which no longer exists is not an error. If the volume remains in
+// a storage group, including when the window ends during a request, the error matches ErrVolumeInStorageGroup
+// and names the storage groups.
+func (c *Client) DeleteVolumeWithRetry(ctx context.Context, symID string, volumeID string, window time.Duration) error {
+	defer c.TimeSpent("DeleteVolumeWithRetry", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return err
+	}
+	if window <= 0 {
+		window = DefaultDeleteVolumeRetryWindow
+	}
+	retryCtx, cancel := context.WithTimeout(ctx, window)
+	defer cancel()
+
+	var storageGroupIDs []string
+	inStorageGroupError := func(attempt int) error {
+		return fmt.Errorf("volume %s is still a member of storage groups %v after %d attempts: %w",
+			volumeID, storageGroupIDs, attempt, ErrVolumeInStorageGroup)
+	}
+	// expired tells whether err is the end of the window while a request was in flight
+	expired := func(err error) bool {
+		return err != nil && retryCtx.Err() != nil && ctx.Err() == nil
+	}
+	interval := DeleteVolumeRetryInterval
+	for attempt := 1; ; attempt++ {
+		vol, err := c.GetVolumeByID(retryCtx, symID, volumeID)
+		if errors.Is(err, ErrNotFound) {
+			log.Info(fmt.Sprintf("Volume %s is already deleted", volumeID))
+			return nil
+		} else if expired(err) && attempt > 1 {
+			return inStorageGroupError(attempt - 1)
+		} else if err != nil {
+			return err
+		}
+		storageGroupIDs = vol.StorageGroupIDList
+		if len(storageGroupIDs) == 0 {
+			err = c.DeleteVolume(retryCtx, symID, volumeID)
+			if err == nil || errors.Is(err, ErrNotFound) {
+				return nil
+			}
+			if expired(err) {
+				return inStorageGroupError(attempt)
+			}
+			if !isVolumeInStorageGroupError(err) {
+				return err
+			}
+		}
+		log.Debug(fmt.Sprintf("Volume %s is still a member of storage groups %v (attempt %d), retrying in %v",
+			volumeID, storageGroupIDs, attempt, interval))
+		select {
+		case <-retryCtx.Done():
+			if err := abortedError(ctx, "DeleteVolumeWithRetry"); err != nil {
+				return err
+			}
+			return inStorageGroupError(attempt)
+		case <-time.After(interval):
+		}
+		interval = interval * 2
+		if interval > MaxDeleteVolumeRetryInterval {
+			interval = MaxDeleteVolumeRetryInterval
+		}
+	}
+}
+
 // InitiateDeallocationOfTracksFromVolume is an asynchrnous operation (that returns a job) to remove tracks from a volume.
 func (c *Client) InitiateDeallocationOfTracksFromVolume(ctx context.Context, symID string, volumeID string) (*types.Job, error) {
 	defer c.TimeSpent("InitiateDeallocationOfTracksFromVolume", time.Now())
@@ -1040,7 +2175,7 @@ func (c *Client) InitiateDeallocationOfTracksFromVolume(ctx context.Context, sym
 		},
 		ExecutionOption: types.ExecutionOptionAsynchronous,
 	}
-	ifDebugLogPayload(payload)
+	c.ifDebugLogPayload(payload)
 	job := &types.Job{}
 
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XVolume + "/" + volumeID
@@ -1049,7 +2184,7 @@ func (c *Client) InitiateDeallocationOfTracksFromVolume(ctx context.Context, sym
 		"VolumeID":     volumeID,
 	}
 	log.WithFields(fields).Info("Initiating track deletion...")
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
 	err := c.api.Put(ctx, URL, c.getDefaultHeaders(), payload, job)
 	if err != nil {
@@ -1071,6 +2206,10 @@ func (c *Client) GetPortGroupList(ctx context.Context, symID string, portGroupTy
 		filter += "fibre=true"
 	} else if strings.EqualFold(portGroupType, "iscsi") {
 		filter += "iscsi=true"
+	} else if strings.EqualFold(portGroupType, "nvme") || strings.EqualFold(portGroupType, "nvme_tcp") {
+		filter += "nvme_tcp=true"
+	} else if portGroupType != "" {
+		return nil, fmt.Errorf("Invalid port group type %s, it must be fibre, iscsi or nvme", portGroupType)
 	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XPortGroup
 	if len(filter) > 1 {
@@ -1078,7 +2217,7 @@ func (c *Client) GetPortGroupList(ctx context.Context, symID string, portGroupTy
 	}
 	pgList := &types.PortGroupList{}
 
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), pgList)
 	if err != nil {
@@ -1096,7 +2235,7 @@ func (c *Client) GetPortGroupByID(ctx context.Context, symID string, portGroupID
 	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XPortGroup + "/" + portGroupID
 	portGroup := &types.PortGroup{}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), portGroup)
 	if err != nil {
@@ -1109,45 +2248,153 @@ func (c *Client) GetPortGroupByID(ctx context.Context, symID string, portGroupID
 // GetInitiatorList returns an InitiatorList object, which contains a list of all the Initiators.
 // initiatorHBA, isISCSI, inHost are optional arguments which act as filters for the initiator list
 func (c *Client) GetInitiatorList(ctx context.Context, symID string, initiatorHBA string, isISCSI bool, inHost bool) (*types.InitiatorList, error) {
+	filter := InitiatorListFilter{HBA: initiatorHBA, InHost: inHost}
+	if isISCSI {
+		filter.Protocol = "iscsi"
+	}
+	return c.GetInitiatorListWithFilter(ctx, symID, filter)
+}
+
+// InitiatorListFilter selects the initiators returned by GetInitiatorListWithFilter. Empty fields do not filter.
+type InitiatorListFilter struct {
+	// HBA is the IQN, FC WWN or NVMe host NQN of the initiators
+	HBA string
+	// Protocol is "iscsi" or "nvme"
+	Protocol string
+	// InHost only returns the initiators which are a member of a host
+	InHost bool
+	// NotInHost only returns the initiators which are not a member of a host
+	NotInHost bool
+	// LoggedIn only returns the initiators which are logged in to the array
+	LoggedIn bool
+	// OnFabric only returns the initiators which are seen on the fabric
+	OnFabric bool
+	// Alias is the alias of the initiators, e.g. "node1/10000090fa66060a". If Unisphere does not
+	// filter initiators by alias, the initiators selected by the other fields are read and filtered.
+	Alias string
+}
+
+// GetInitiatorListWithFilter returns the IDs of the initiators selected by filter
+func (c *Client) GetInitiatorListWithFilter(ctx context.Context, symID string, filter InitiatorListFilter) (*types.InitiatorList, error) {
 	defer c.TimeSpent("GetInitiatorList", time.Now())
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
-	filter := "?"
-	if inHost {
-		if len(filter) > 1 {
-			filter += "&"
-		}
-		filter += "in_a_host=true"
+	query := url.Values{}
+	if filter.InHost && filter.NotInHost {
+		return nil, fmt.Errorf("The InHost and NotInHost filters are exclusive")
 	}
-	if initiatorHBA != "" {
-		if len(filter) > 1 {
-			filter += "&"
+	if filter.InHost {
+		query.Set("in_a_host", "true")
+	} else if filter.NotInHost {
+		query.Set("in_a_host", "false")
+	}
+	if filter.HBA != "" {
+		query.Set("initiator_hba", filter.HBA)
+	}
+	if filter.LoggedIn {
+		query.Set("logged_in", "true")
+	}
+	if filter.OnFabric {
+		query.Set("on_fabric", "true")
+	}
+	if strings.EqualFold(filter.Protocol, "iscsi") {
+		query.Set("iscsi", "true")
+	} else if strings.EqualFold(filter.Protocol, "nvme") || strings.EqualFold(filter.Protocol, "nvme_tcp") {
+		query.Set("nvme_tcp", "true")
+	} else if filter.Protocol != "" {
+		return nil, fmt.Errorf("Invalid initiator protocol %s, it must be iscsi or nvme", filter.Protocol)
+	}
+	if filter.Alias != "" && atomic.LoadInt32(&c.noInitiatorAliasFilter) == 0 {
+		query.Set("alias", filter.Alias)
+		initList, err := c.getInitiatorList(ctx, symID, query)
+		if !isQueryParameterUnsupported(err, "alias") {
+			if err != nil {
+				log.Error("GetInitiatorList failed: " + err.Error())
+			}
+			return initList, err
 		}
-		filter = filter + "initiator_hba=" + initiatorHBA
+		log.Info("Unisphere does not filter initiators by alias, reading them")
+		atomic.StoreInt32(&c.noInitiatorAliasFilter, 1)
+		query.Del("alias")
 	}
-	if isISCSI {
-		if len(filter) > 1 {
-			filter += "&"
+	initList, err := c.getInitiatorList(ctx, symID, query)
+	if err != nil {
+		log.Error("GetInitiatorList failed: " + err.Error())
+		return nil, err
+	}
+	if filter.Alias != "" {
+		initiators, err := c.getInitiators(ctx, symID, initList.InitiatorIDs)
+		if err != nil {
+			log.Error("GetInitiatorList failed: " + err.Error())
+			return nil, err
 		}
-		filter += "iscsi=true"
+		initIDs := make([]string, 0)
+		for i, initiator := range initiators {
+			if initiator.Alias == filter.Alias {
+				initIDs = append(initIDs, initList.InitiatorIDs[i])
+			}
+		}
+		initList.InitiatorIDs = initIDs
 	}
+	return initList, nil
+}
+
+// getInitiatorList returns the IDs of the initiators selected by the query parameters
+func (c *Client) getInitiatorList(ctx context.Context, symID string, query url.Values) (*types.InitiatorList, error) {
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XInitiator
-	if len(filter) > 1 {
-		URL += filter
+	if len(query) > 0 {
+		URL += "?" + query.Encode()
 	}
 	initList := &types.InitiatorList{}
-
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
-	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), initList)
-	if err != nil {
-		log.Error("GetInitiatorList failed: " + err.Error())
+	if err := c.api.Get(ctx, URL, c.getDefaultHeaders(), initList); err != nil {
 		return nil, err
 	}
 	return initList, nil
 }
 
+// getInitiators reads the initiators initIDs, up to ClientOptions.MaxConcurrentRequests at a time,
+// and returns them in the order of initIDs
+func (c *Client) getInitiators(ctx context.Context, symID string, initIDs []string) ([]*types.Initiator, error) {
+	initiators := make([]*types.Initiator, len(initIDs))
+	err := c.forEachConcurrently(ctx, "getInitiators", len(initIDs), func(ctx context.Context, i int) error {
+		initiator, err := c.GetInitiatorByID(ctx, symID, initIDs[i])
+		if err != nil {
+			return fmt.Errorf("failed to get initiator %s: %s", initIDs[i], err.Error())
+		}
+		initiators[i] = initiator
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return initiators, nil
+}
+
+// GetInitiatorsByAlias returns the initiators with the given alias, i.e. the HBA of a host port on
+// every director port it is seen on. The error matches ErrNotFound if no initiator has the alias.
+func (c *Client) GetInitiatorsByAlias(ctx context.Context, symID, alias string) ([]*types.Initiator, error) {
+	defer c.TimeSpent("GetInitiatorsByAlias", time.Now())
+	if alias == "" {
+		return nil, fmt.Errorf("An initiator alias has to be specified")
+	}
+	initList, err := c.GetInitiatorListWithFilter(ctx, symID, InitiatorListFilter{Alias: alias})
+	if err != nil {
+		return nil, err
+	}
+	if len(initList.InitiatorIDs) == 0 {
+		return nil, fmt.Errorf("No initiator has alias %s on %s: %w", alias, symID, ErrNotFound)
+	}
+	initiators, err := c.getInitiators(ctx, symID, initList.InitiatorIDs)
+	if err != nil {
+		log.Error("GetInitiatorsByAlias failed: " + err.Error())
+		return nil, err
+	}
+	return initiators, nil
+}
+
 // GetInitiatorByID returns an Initiator given the Symmetrix ID and Initiator ID.
 func (c *Client) GetInitiatorByID(ctx context.Context, symID string, initID string) (*types.Initiator, error) {
 	defer c.TimeSpent("GetInitiatorByID", time.Now())
@@ -1156,7 +2403,7 @@ func (c *Client) GetInitiatorByID(ctx context.Context, symID string, initID stri
 	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XInitiator + "/" + initID
 	initiator := &types.Initiator{}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), initiator)
 	if err != nil {
@@ -1166,22 +2413,124 @@ func (c *Client) GetInitiatorByID(ctx context.Context, symID string, initID stri
 	return initiator, nil
 }
 
+// InitiatorPathState is the state of an initiator on one director port
+type InitiatorPathState struct {
+	// InitiatorID is the ID of the initiator on the port, i.e. "<director>:<port>:<HBA>"
+	InitiatorID  string
+	DirectorPort string
+	LoggedIn     bool
+	OnFabric     bool
+}
+
+// InitiatorSessionStats are the login statistics of an HBA (IQN, FC WWN or NVMe host NQN) across
+// the director ports it is seen on. A path which is on the fabric but not logged in is dead.
+type InitiatorSessionStats struct {
+	HBA    string
+	HostID string
+	// Paths are in director port order
+	Paths         []InitiatorPathState
+	LoggedInPaths int
+	OnFabricPaths int
+}
+
+// DeadPaths returns the director ports through which the HBA is on the fabric but not logged in
+func (s *InitiatorSessionStats) DeadPaths() []string {
+	dead := make([]string, 0)
+	for _, path := range s.Paths {
+		if path.OnFabric && !path.LoggedIn {
+			dead = append(dead, path.DirectorPort)
+		}
+	}
+	return dead
+}
+
+// LoginChanges returns the number of paths which logged in or out since previous, an earlier sample
+// of the same HBA, counting the paths which appeared or disappeared as well. Calling it on each sample
+// of a health check loop tells a flapping HBA from one which is steadily down.
+func (s *InitiatorSessionStats) LoginChanges(previous *InitiatorSessionStats) int {
+	if previous == nil {
+		return 0
+	}
+	before := make(map[string]bool)
+	for _, path := range previous.Paths {
+		before[path.DirectorPort] = path.LoggedIn
+	}
+	changes := 0
+	for _, path := range s.Paths {
+		loggedIn, ok := before[path.DirectorPort]
+		if !ok || loggedIn != path.LoggedIn {
+			changes++
+		}
+		delete(before, path.DirectorPort)
+	}
+	return changes + len(before)
+}
+
+// GetInitiatorSessionStats returns the login statistics of an HBA on every director port it is seen on.
+// The initiators are read concurrently, up to ClientOptions.MaxConcurrentRequests at a time.
+func (c *Client) GetInitiatorSessionStats(ctx context.Context, symID, initiatorHBA string) (*InitiatorSessionStats, error) {
+	defer c.TimeSpent("GetInitiatorSessionStats", time.Now())
+	if initiatorHBA == "" {
+		return nil, fmt.Errorf("An initiator HBA has to be specified")
+	}
+	initList, err := c.GetInitiatorListWithFilter(ctx, symID, InitiatorListFilter{HBA: initiatorHBA})
+	if err != nil {
+		return nil, err
+	}
+	initiatorIDs := make([]string, 0, len(initList.InitiatorIDs))
+	for _, initID := range initList.InitiatorIDs {
+		if strings.HasSuffix(initID, ":"+initiatorHBA) {
+			initiatorIDs = append(initiatorIDs, initID)
+		}
+	}
+	if len(initiatorIDs) == 0 {
+		return nil, fmt.Errorf("Initiator %s is not seen on any port of %s", initiatorHBA, symID)
+	}
+	sort.Strings(initiatorIDs)
+
+	initiators, err := c.getInitiators(ctx, symID, initiatorIDs)
+	if err != nil {
+		return nil, fmt.Errorf("GetInitiatorSessionStats %s", err.Error())
+	}
+
+	stats := &InitiatorSessionStats{
+		HBA:   initiatorHBA,
+		Paths: make([]InitiatorPathState, 0, len(initiatorIDs)),
+	}
+	for i, initiator := range initiators {
+		stats.Paths = append(stats.Paths, InitiatorPathState{
+			InitiatorID:  initiatorIDs[i],
+			DirectorPort: strings.TrimSuffix(initiatorIDs[i], ":"+initiatorHBA),
+			LoggedIn:     initiator.LoggedIn,
+			OnFabric:     initiator.OnFabric,
+		})
+		if initiator.LoggedIn {
+			stats.LoggedInPaths++
+		}
+		if initiator.OnFabric {
+			stats.OnFabricPaths++
+		}
+		if initiator.HostID != "" {
+			stats.HostID = initiator.HostID
+		}
+	}
+	return stats, nil
+}
+
 // GetHostList returns an HostList object, which contains a list of all the Hosts.
+// It pages through the listing with the Unisphere iterator when the listing does not fit in a page.
 func (c *Client) GetHostList(ctx context.Context, symID string) (*types.HostList, error) {
 	defer c.TimeSpent("GetHostList", time.Now())
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XHost
-	hostList := &types.HostList{}
-	ctx, cancel := c.GetTimeoutContext(ctx)
-	defer cancel()
-	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), hostList)
+	ids, err := c.getIDList(ctx, URL, "Host", "hostId")
 	if err != nil {
 		log.Error("GetHostList failed: " + err.Error())
 		return nil, err
 	}
-	return hostList, nil
+	return &types.HostList{HostIDs: ids}, nil
 }
 
 // GetHostByID returns a Host given the Symmetrix ID and Host ID.
@@ -1192,43 +2541,232 @@ func (c *Client) GetHostByID(ctx context.Context, symID string, hostID string) (
 	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XHost + "/" + hostID
 	host := &types.Host{}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), host)
 	if err != nil {
 		log.Error("GetHostByID failed: " + err.Error())
 		return nil, err
 	}
-	return host, nil
-}
+	return host, nil
+}
+
+// GetHostForInitiator returns the host owning the initiator with the FC WWN, IQN or NVMe host NQN hba, or nil if
+// the initiator is not in a host. The host is found from the initiator details rather than by reading every host.
+// An FC WWN may be given in any form accepted by NormalizeFCWWN.
+func (c *Client) GetHostForInitiator(ctx context.Context, symID string, hba string) (*types.Host, error) {
+	defer c.TimeSpent("GetHostForInitiator", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	if wwn, err := NormalizeFCWWN(hba); err == nil {
+		hba = wwn
+	}
+	initList, err := c.GetInitiatorListWithFilter(ctx, symID, InitiatorListFilter{HBA: hba, InHost: true})
+	if err != nil {
+		return nil, err
+	}
+	// the initiator is listed once per port, and an initiator can only be a member of one host
+	for _, initID := range initList.InitiatorIDs {
+		initiator, err := c.GetInitiatorByID(ctx, symID, initID)
+		if err != nil {
+			return nil, err
+		}
+		if initiator.HostID != "" {
+			return c.GetHostByID(ctx, symID, initiator.HostID)
+		}
+	}
+	log.Debug(fmt.Sprintf("Initiator %s is not in a host", hba))
+	return nil, nil
+}
+
+// CreateHost creates a host from a list of InitiatorIDs (and optional HostFlags) return returns a types.Host.
+// Initiator IDs do not contain the storage port designations, just the IQN string, FC WWN or NVMe host NQN.
+// Initiator IDs cannot be a member of more than one host, and an NVMe host can only have NQNs.
+func (c *Client) CreateHost(ctx context.Context, symID string, hostID string, initiatorIDs []string, hostFlags *types.HostFlags) (*types.Host, error) {
+	defer c.TimeSpent("CreateHost", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	hostParam, err := getCreateHostParam(hostID, initiatorIDs, hostFlags)
+	if err != nil {
+		return nil, err
+	}
+	host := &types.Host{}
+	c.ifDebugLogPayload(hostParam)
+	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XHost
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
+	defer cancel()
+	err = c.api.Post(ctx, URL, c.getDefaultHeaders(), hostParam, host)
+	if err != nil {
+		log.Error("CreateHost failed: " + err.Error())
+		return nil, err
+	}
+	log.Info(fmt.Sprintf("Successfully created Host: %s", hostID))
+	return host, nil
+}
+
+// CreateHostAsync is CreateHost executed asynchronously by Unisphere, for hosts with many initiators whose
+// creation outlasts the timeout of proxies. It returns the job creating the host, see WaitOnJobCompletion.
+func (c *Client) CreateHostAsync(ctx context.Context, symID string, hostID string, initiatorIDs []string, hostFlags *types.HostFlags) (*types.Job, error) {
+	defer c.TimeSpent("CreateHostAsync", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	hostParam, err := getCreateHostParam(hostID, initiatorIDs, hostFlags)
+	if err != nil {
+		return nil, err
+	}
+	hostParam.ExecutionOption = types.ExecutionOptionAsynchronous
+	job := &types.Job{}
+	c.ifDebugLogPayload(hostParam)
+	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XHost
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
+	defer cancel()
+	err = c.api.Post(ctx, URL, c.getDefaultHeaders(), hostParam, job)
+	if err != nil {
+		log.Error("CreateHostAsync failed: " + err.Error())
+		return nil, err
+	}
+	log.Info(fmt.Sprintf("Job %s is creating Host: %s", job.JobID, hostID))
+	return job, nil
+}
+
+// getCreateHostParam returns the payload creating a host of the given initiators, executed synchronously
+func getCreateHostParam(hostID string, initiatorIDs []string, hostFlags *types.HostFlags) (*types.CreateHostParam, error) {
+	if err := checkHostInitiators(initiatorIDs); err != nil {
+		return nil, err
+	}
+	return &types.CreateHostParam{
+		HostID:          hostID,
+		InitiatorIDs:    initiatorIDs,
+		HostFlags:       hostFlags,
+		ExecutionOption: types.ExecutionOptionSynchronous,
+	}, nil
+}
+
+// checkHostInitiators returns an error if NVMe host NQNs are mixed with iSCSI or FC initiators,
+// which Unisphere does not allow in a host
+func checkHostInitiators(initiatorIDs []string) error {
+	nqns := 0
+	for _, initiatorID := range initiatorIDs {
+		if IsValidNQN(initiatorID) {
+			nqns++
+		}
+	}
+	if nqns != 0 && nqns != len(initiatorIDs) {
+		return fmt.Errorf("A host can't have both NVMe and SCSI initiators: %s", strings.Join(initiatorIDs, ", "))
+	}
+	return nil
+}
+
+// HostGrouping returns the name of the host an initiator belongs to, or "" to leave the initiator out
+type HostGrouping func(initiator *types.Initiator) string
+
+// HostImportReport is the outcome of ImportHostsFromInitiators
+type HostImportReport struct {
+	// Created maps each host created to its initiators
+	Created map[string][]string
+	// Failed maps each host which could not be created to the error
+	Failed map[string]error
+	// Skipped are the discovered initiators for which the grouping returned no host
+	Skipped []string
+}
+
+// ImportHostsFromInitiators discovers the initiators which are logged in to the array but are not in a host,
+// groups them into hosts with grouping and creates the hosts. The initiators are selected by Unisphere and only
+// those are read, concurrently, up to ClientOptions.MaxConcurrentRequests at a time. An initiator logged in through
+// several ports is read once, and is left out if it is in a host on any of them. The hosts are created one by one;
+// if some fail, the report of all of them is returned along with an error.
+func (c *Client) ImportHostsFromInitiators(ctx context.Context, symID string, grouping HostGrouping) (*HostImportReport, error) {
+	defer c.TimeSpent("ImportHostsFromInitiators", time.Now())
+	if grouping == nil {
+		return nil, fmt.Errorf("Host grouping can't be nil")
+	}
+	assigned, err := c.GetInitiatorListWithFilter(ctx, symID, InitiatorListFilter{InHost: true})
+	if err != nil {
+		return nil, err
+	}
+	unassigned, err := c.GetInitiatorListWithFilter(ctx, symID, InitiatorListFilter{NotInHost: true, LoggedIn: true})
+	if err != nil {
+		return nil, err
+	}
+
+	// an initiator has an entry per port it is seen on, whose ID ends with the HBA
+	inHost := make(map[string]bool)
+	for _, initID := range assigned.InitiatorIDs {
+		inHost[initiatorIDHBA(initID)] = true
+	}
+	initIDs := make([]string, 0)
+	listed := make(map[string]bool)
+	for _, initID := range unassigned.InitiatorIDs {
+		hba := initiatorIDHBA(initID)
+		if !inHost[hba] && !listed[hba] {
+			listed[hba] = true
+			initIDs = append(initIDs, initID)
+		}
+	}
+	initiators, err := c.getInitiators(ctx, symID, initIDs)
+	if err != nil {
+		return nil, fmt.Errorf("ImportHostsFromInitiators %s", err.Error())
+	}
+	loggedIn := make(map[string]*types.Initiator)
+	for _, initiator := range initiators {
+		if initiator.HostID == "" && initiator.LoggedIn {
+			loggedIn[initiator.InitiatorID] = initiator
+		}
+	}
+	report := &HostImportReport{
+		Created: make(map[string][]string),
+		Failed:  make(map[string]error),
+		Skipped: make([]string, 0),
+	}
+	hosts := make(map[string][]string)
+	for name, initiator := range loggedIn {
+		hostID := grouping(initiator)
+		if hostID == "" {
+			report.Skipped = append(report.Skipped, name)
+			continue
+		}
+		hosts[hostID] = append(hosts[hostID], name)
+	}
+	sort.Strings(report.Skipped)
+	hostIDs := make([]string, 0, len(hosts))
+	for hostID := range hosts {
+		hostIDs = append(hostIDs, hostID)
+	}
+	sort.Strings(hostIDs)
 
-// CreateHost creates a host from a list of InitiatorIDs (and optional HostFlags) return returns a types.Host.
-// Initiator IDs do not contain the storage port designations, just the IQN string or FC WWN.
-// Initiator IDs cannot be a member of more than one host.
-func (c *Client) CreateHost(ctx context.Context, symID string, hostID string, initiatorIDs []string, hostFlags *types.HostFlags) (*types.Host, error) {
-	defer c.TimeSpent("CreateHost", time.Now())
-	if _, err := c.IsAllowedArray(symID); err != nil {
-		return nil, err
+	for _, hostID := range hostIDs {
+		initiatorIDs := hosts[hostID]
+		sort.Strings(initiatorIDs)
+		if _, err := c.CreateHost(ctx, symID, hostID, initiatorIDs, nil); err != nil {
+			report.Failed[hostID] = err
+			continue
+		}
+		report.Created[hostID] = initiatorIDs
 	}
-	hostParam := &types.CreateHostParam{
-		HostID:          hostID,
-		InitiatorIDs:    initiatorIDs,
-		HostFlags:       hostFlags,
-		ExecutionOption: types.ExecutionOptionSynchronous,
+	if len(report.Failed) > 0 {
+		failed := make([]string, 0, len(report.Failed))
+		for _, hostID := range hostIDs {
+			if err, ok := report.Failed[hostID]; ok {
+				failed = append(failed, hostID+": "+err.Error())
+			}
+		}
+		return report, fmt.Errorf("ImportHostsFromInitiators failed to create %d of %d hosts: %s",
+			len(report.Failed), len(hostIDs), strings.Join(failed, "; "))
 	}
-	host := &types.Host{}
-	Debug = true
-	ifDebugLogPayload(hostParam)
-	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XHost
-	ctx, cancel := c.GetTimeoutContext(ctx)
-	defer cancel()
-	err := c.api.Post(ctx, URL, c.getDefaultHeaders(), hostParam, host)
-	if err != nil {
-		log.Error("CreateHost failed: " + err.Error())
-		return nil, err
+	return report, nil
+}
+
+// initiatorIDHBA returns the HBA of the ID of an initiator on a port, e.g. iqn.1993-08.org.debian:01:aa
+// of SE-1E:000:iqn.1993-08.org.debian:01:aa
+func initiatorIDHBA(initID string) string {
+	parts := strings.SplitN(initID, ":", 3)
+	if len(parts) < 3 {
+		return initID
 	}
-	log.Info(fmt.Sprintf("Successfully created Host: %s", hostID))
-	return host, nil
+	return parts[2]
 }
 
 // UpdateHostInitiators updates a host from a list of InitiatorIDs and returns a types.Host.
@@ -1240,10 +2778,11 @@ func (c *Client) UpdateHostInitiators(ctx context.Context, symID string, host *t
 	if host == nil {
 		return nil, fmt.Errorf("Host can't be nil")
 	}
+	if err := checkHostInitiators(initiatorIDs); err != nil {
+		return nil, err
+	}
 	initRemove := []string{}
 	initAdd := []string{}
-	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XHost + "/" + host.HostID
-	updatedHost := &types.Host{}
 
 	// figure out which initiators are being added
 	for _, init := range initiatorIDs {
@@ -1259,8 +2798,98 @@ func (c *Client) UpdateHostInitiators(ctx context.Context, symID string, host *t
 		}
 	}
 
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
+	updatedHost, err := c.editHostInitiators(ctx, symID, host.HostID, initAdd, initRemove)
+	if err != nil {
+		log.Error("UpdateHostInitiators failed: " + err.Error())
+		return nil, err
+	}
+	return updatedHost, nil
+}
+
+// maxHostConflictRetries is the number of times CreateOrUpdateHost reads the host again after
+// a concurrent change of the host made its request conflict
+const maxHostConflictRetries = 5
+
+// hostConflictRetryInterval is the initial wait before CreateOrUpdateHost reads the host again after a conflict.
+// It doubles after every conflict, and is jittered so that the nodes racing for the host do not retry in lockstep.
+var hostConflictRetryInterval = 100 * time.Millisecond
+
+// CreateOrUpdateHost makes sure the host exists and holds the initiators, whichever of the nodes sharing
+// the host gets to create it. The host is created if it does not exist, with the optional HostFlags;
+// otherwise the initiators it lacks are added to it. Its other initiators are kept, as they may have been
+// added by another node. If another node creates or updates the host at the same time, Unisphere answers
+// 409 Conflict and the host is read again after a growing, randomized wait, up to 5 times.
+func (c *Client) CreateOrUpdateHost(ctx context.Context, symID string, hostID string, initiatorIDs []string, hostFlags *types.HostFlags) (*types.Host, error) {
+	defer c.TimeSpent("CreateOrUpdateHost", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	var err error
+	interval := hostConflictRetryInterval
+	for i := 0; i <= maxHostConflictRetries; i++ {
+		if i > 0 {
+			wait := jittered(interval)
+			log.Debug(fmt.Sprintf("Retrying CreateOrUpdateHost of host %s in %v after a conflict: %s", hostID, wait, err.Error()))
+			select {
+			case <-ctx.Done():
+			case <-time.After(wait):
+			}
+			interval = interval * 2
+		}
+		if abortErr := abortedError(ctx, "CreateOrUpdateHost"); abortErr != nil {
+			return nil, abortErr
+		}
+		var host *types.Host
+		host, err = c.GetHostByID(ctx, symID, hostID)
+		if errors.Is(err, ErrNotFound) {
+			host, err = c.CreateHost(ctx, symID, hostID, initiatorIDs, hostFlags)
+			if httpStatus(err) == http.StatusConflict {
+				continue
+			}
+			return host, err
+		}
+		if err != nil {
+			return nil, err
+		}
+		initAdd := make([]string, 0)
+		for _, init := range initiatorIDs {
+			if !stringInSlice(init, host.Initiators) {
+				initAdd = append(initAdd, init)
+			}
+		}
+		if len(initAdd) == 0 {
+			return host, nil
+		}
+		if err = checkHostInitiators(append(initAdd, host.Initiators...)); err != nil {
+			return nil, err
+		}
+		updateCtx, cancel := c.getTimeoutContext(ctx, writeOperation)
+		host, err = c.editHostInitiators(updateCtx, symID, hostID, initAdd, nil)
+		cancel()
+		if httpStatus(err) == http.StatusConflict {
+			continue
+		}
+		if err != nil {
+			log.Error("CreateOrUpdateHost failed: " + err.Error())
+			return nil, err
+		}
+		return host, nil
+	}
+	log.Error("CreateOrUpdateHost failed: " + err.Error())
+	return nil, fmt.Errorf("CreateOrUpdateHost of host %s still conflicting after %d retries: %w", hostID, maxHostConflictRetries, err)
+}
+
+// jittered returns a random wait between half and one and a half times interval
+func jittered(interval time.Duration) time.Duration {
+	return interval/2 + time.Duration(rand.Int63n(int64(interval)+1))
+}
+
+// editHostInitiators adds and then removes the given initiators of a host and returns the updated host.
+func (c *Client) editHostInitiators(ctx context.Context, symID, hostID string, initAdd, initRemove []string) (*types.Host, error) {
+	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XHost + "/" + hostID
+	updatedHost := &types.Host{}
 	// add initiators if needed
 	if len(initAdd) > 0 {
 		hostParam := &types.UpdateHostAddInitiatorsParam{}
@@ -1269,10 +2898,9 @@ func (c *Client) UpdateHostInitiators(ctx context.Context, symID string, host *t
 		hostParam.EditHostAction.AddInitiator.Initiators = initAdd
 		hostParam.ExecutionOption = types.ExecutionOptionSynchronous
 
-		ifDebugLogPayload(hostParam)
+		c.ifDebugLogPayload(hostParam)
 		err := c.api.Put(ctx, URL, c.getDefaultHeaders(), hostParam, updatedHost)
 		if err != nil {
-			log.Error("UpdateHostInitiators failed: " + err.Error())
 			return nil, err
 		}
 	}
@@ -1284,14 +2912,62 @@ func (c *Client) UpdateHostInitiators(ctx context.Context, symID string, host *t
 		hostParam.EditHostAction.RemoveInitiator.Initiators = initRemove
 		hostParam.ExecutionOption = types.ExecutionOptionSynchronous
 
-		ifDebugLogPayload(hostParam)
+		c.ifDebugLogPayload(hostParam)
 		err := c.api.Put(ctx, URL, c.getDefaultHeaders(), hostParam, updatedHost)
 		if err != nil {
-			log.Error("UpdateHostInitiators failed: " + err.Error())
 			return nil, err
 		}
 	}
+	return updatedHost, nil
+}
+
+// MoveInitiatorToHost moves an initiator from one host to another and returns the updated target host.
+// An initiator cannot be a member of two hosts, so it is removed from fromHostID before it is added
+// to toHostID; if the add fails the initiator is put back in fromHostID.
+// The move is refused up front if the initiator is the last one of a host in a masking view,
+// as Unisphere rejects removing it. Moving an initiator that is already in toHostID is a no-op.
+func (c *Client) MoveInitiatorToHost(ctx context.Context, symID, initiatorID, fromHostID, toHostID string) (*types.Host, error) {
+	defer c.TimeSpent("MoveInitiatorToHost", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	if fromHostID == toHostID {
+		return nil, fmt.Errorf("source and target host are both %s", fromHostID)
+	}
+	toHost, err := c.GetHostByID(ctx, symID, toHostID)
+	if err != nil {
+		return nil, err
+	}
+	if stringInSlice(initiatorID, toHost.Initiators) {
+		return toHost, nil
+	}
+	fromHost, err := c.GetHostByID(ctx, symID, fromHostID)
+	if err != nil {
+		return nil, err
+	}
+	if !stringInSlice(initiatorID, fromHost.Initiators) {
+		return nil, fmt.Errorf("initiator %s is not a member of host %s", initiatorID, fromHostID)
+	}
+	if len(fromHost.Initiators) == 1 && fromHost.NumberMaskingViews > 0 {
+		return nil, fmt.Errorf("initiator %s is the last initiator of host %s which is in masking view(s) %v",
+			initiatorID, fromHostID, fromHost.MaskingviewIDs)
+	}
 
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
+	defer cancel()
+	if _, err = c.editHostInitiators(ctx, symID, fromHostID, nil, []string{initiatorID}); err != nil {
+		log.Error("MoveInitiatorToHost failed: " + err.Error())
+		return nil, err
+	}
+	updatedHost, err := c.editHostInitiators(ctx, symID, toHostID, []string{initiatorID}, nil)
+	if err != nil {
+		log.Error("MoveInitiatorToHost failed: " + err.Error())
+		if _, rollbackErr := c.editHostInitiators(ctx, symID, fromHostID, []string{initiatorID}, nil); rollbackErr != nil {
+			log.Error(fmt.Sprintf("Failed to add initiator %s back to host %s: %s", initiatorID, fromHostID, rollbackErr.Error()))
+		}
+		return nil, err
+	}
+	log.Info(fmt.Sprintf("Successfully moved initiator %s from host %s to host %s", initiatorID, fromHostID, toHostID))
 	return updatedHost, nil
 }
 
@@ -1305,7 +2981,7 @@ func (c *Client) UpdateHostName(ctx context.Context, symID, oldHostID, newHostID
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XHost + "/" + oldHostID
 	updatedHost := &types.Host{}
 
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
 	// add initiators if needed
 	if newHostID != "" {
@@ -1314,7 +2990,7 @@ func (c *Client) UpdateHostName(ctx context.Context, symID, oldHostID, newHostID
 		hostParam.EditHostAction.RenameHostParam = &types.RenameHostParam{}
 		hostParam.EditHostAction.RenameHostParam.NewHostName = newHostID
 		hostParam.ExecutionOption = types.ExecutionOptionSynchronous
-		ifDebugLogPayload(hostParam)
+		c.ifDebugLogPayload(hostParam)
 		err := c.api.Put(ctx, URL, c.getDefaultHeaders(), hostParam, updatedHost)
 		if err != nil {
 			log.Error("UpdateHostName failed: " + err.Error())
@@ -1341,7 +3017,7 @@ func (c *Client) DeleteHost(ctx context.Context, symID string, hostID string) er
 		return err
 	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XHost + "/" + hostID
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
 	err := c.api.Delete(ctx, URL, c.getDefaultHeaders(), nil)
 	if err != nil {
@@ -1352,22 +3028,87 @@ func (c *Client) DeleteHost(ctx context.Context, symID string, hostID string) er
 	return nil
 }
 
+// MaskingViewFilter selects the masking views returned by GetMaskingViewListWithFilter.
+// Empty fields match every masking view.
+type MaskingViewFilter struct {
+	// HostID matches the masking views of a host or host group. Unisphere filters on it.
+	HostID string
+
+	// PortGroupID and StorageGroupID match the masking views of a port group or storage group.
+	// Unisphere cannot filter on them, so the list is filtered using the masking views
+	// reported by the port group or storage group.
+	PortGroupID    string
+	StorageGroupID string
+}
+
 // GetMaskingViewList  returns a list of the MaskingView names.
 func (c *Client) GetMaskingViewList(ctx context.Context, symID string) (*types.MaskingViewList, error) {
+	return c.GetMaskingViewListWithFilter(ctx, symID, MaskingViewFilter{})
+}
+
+// GetMaskingViewListWithFilter returns the names of the masking views matching all the fields of filter.
+func (c *Client) GetMaskingViewListWithFilter(ctx context.Context, symID string, filter MaskingViewFilter) (*types.MaskingViewList, error) {
+	mvList := &types.MaskingViewList{MaskingViewIDs: make([]string, 0)}
+	err := c.ForEachMaskingView(ctx, symID, filter, func(mvID string) error {
+		mvList.MaskingViewIDs = append(mvList.MaskingViewIDs, mvID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mvList, nil
+}
+
+// ForEachMaskingView calls fn with the name of every masking view matching all the fields of filter.
+// It pages through the listing with the Unisphere iterator when the listing does not fit in a page,
+// so that the names of all the masking views of a large array are never held at once. It stops at the
+// first error of fn, which it returns.
+func (c *Client) ForEachMaskingView(ctx context.Context, symID string, filter MaskingViewFilter, fn func(mvID string) error) error {
 	defer c.TimeSpent("GetMaskingViewList", time.Now())
 	if _, err := c.IsAllowedArray(symID); err != nil {
-		return nil, err
+		return err
+	}
+	var matches []map[string]bool
+	if filter.PortGroupID != "" {
+		pg, err := c.GetPortGroupByID(ctx, symID, filter.PortGroupID)
+		if err != nil {
+			return err
+		}
+		matches = append(matches, toSet(pg.MaskingView))
+	}
+	if filter.StorageGroupID != "" {
+		sg, err := c.GetStorageGroup(ctx, symID, filter.StorageGroupID)
+		if err != nil {
+			return err
+		}
+		matches = append(matches, toSet(sg.MaskingView))
 	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XMaskingView
-	mvList := &types.MaskingViewList{}
-	ctx, cancel := c.GetTimeoutContext(ctx)
-	defer cancel()
-	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), mvList)
+	if filter.HostID != "" {
+		URL = URL + "?host_or_host_group_name=" + url.QueryEscape(filter.HostID)
+	}
+	iter, err := c.idListing(ctx, URL, "Masking view", "maskingViewId")
 	if err != nil {
 		log.Error("GetMaskingViewList failed: " + err.Error())
-		return nil, err
+		return err
 	}
-	return mvList, nil
+	return iter.ForEach(ctx, func(id listedID) error {
+		for _, match := range matches {
+			if !match[string(id)] {
+				return nil
+			}
+		}
+		return fn(string(id))
+	})
+}
+
+// toSet returns the set of the elements of list
+func toSet(list []string) map[string]bool {
+	set := make(map[string]bool, len(list))
+	for _, s := range list {
+		set[s] = true
+	}
+	return set
 }
 
 // GetMaskingViewByID returns a masking view given it's identifier (which is the name)
@@ -1378,7 +3119,7 @@ func (c *Client) GetMaskingViewByID(ctx context.Context, symID string, maskingVi
 	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XMaskingView + "/" + maskingViewID
 	mv := &types.MaskingView{}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), mv)
 	if err != nil {
@@ -1388,45 +3129,271 @@ func (c *Client) GetMaskingViewByID(ctx context.Context, symID string, maskingVi
 	return mv, nil
 }
 
+// MaskingViewConnectionFilter selects the connections returned by GetMaskingViewConnectionsWithFilter.
+// Empty fields match every connection. Unisphere filters on all of them.
+type MaskingViewConnectionFilter struct {
+	// VolumeID matches the connections of a volume, given by its 5 digit volume ID
+	VolumeID string
+
+	// InitiatorID matches the connections of an initiator, e.g. the WWN of an HBA or an iSCSI IQN
+	InitiatorID string
+}
+
+// query returns the query parameters of the filter
+func (filter MaskingViewConnectionFilter) query() url.Values {
+	query := url.Values{}
+	if filter.VolumeID != "" {
+		query.Set("volume_id", filter.VolumeID)
+	}
+	if filter.InitiatorID != "" {
+		query.Set("initiator_id", filter.InitiatorID)
+	}
+	return query
+}
+
+// maskingViewConnectionsURL returns the URL of the connections of a masking view selected by query
+func (c *Client) maskingViewConnectionsURL(symID, maskingViewID string, query url.Values) string {
+	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XMaskingView + "/" + maskingViewID + "/connections"
+	if len(query) > 0 {
+		URL = URL + "?" + query.Encode()
+	}
+	return URL
+}
+
 // GetMaskingViewConnections returns the connections of a masking view (optionally for a specific volume id.)
 // Here volume id is the 5 digit volume ID.
 func (c *Client) GetMaskingViewConnections(ctx context.Context, symID string, maskingViewID string, volumeID string) ([]*types.MaskingViewConnection, error) {
+	return c.GetMaskingViewConnectionsWithFilter(ctx, symID, maskingViewID, MaskingViewConnectionFilter{VolumeID: volumeID})
+}
+
+// GetMaskingViewConnectionsWithFilter returns the connections of a masking view matching all the fields of filter.
+func (c *Client) GetMaskingViewConnectionsWithFilter(ctx context.Context, symID string, maskingViewID string, filter MaskingViewConnectionFilter) ([]*types.MaskingViewConnection, error) {
 	defer c.TimeSpent("GetMaskingViewConnections", time.Now())
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
-	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XMaskingView + "/" + maskingViewID + "/connections"
-	if volumeID != "" {
-		URL = URL + "?volume_id=" + volumeID
+	iter, err := c.maskingViewConnectionListing(ctx, c.maskingViewConnectionsURL(symID, maskingViewID, filter.query()))
+	if err != nil {
+		log.Error("GetMaskingViewConnections failed: " + err.Error())
+		return nil, err
 	}
-	cn := &types.MaskingViewConnectionsResult{}
-	ctx, cancel := c.GetTimeoutContext(ctx)
-	defer cancel()
-	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), cn)
+	connections, err := c.connectionIterator(iter).All(ctx)
 	if err != nil {
 		log.Error("GetMaskingViewConnections failed: " + err.Error())
 		return nil, err
 	}
-	return cn.MaskingViewConnections, nil
+	return connections, nil
+}
+
+// maskingViewConnectionListing reads the connections at URL, which Unisphere returns either whole, or as an
+// iterator holding the first page of them when they do not fit in a page, and returns them as an iterator
+func (c *Client) maskingViewConnectionListing(ctx context.Context, URL string) (*types.MaskingViewConnectionIterator, error) {
+	var body json.RawMessage
+	if err := c.getJSON(ctx, URL, &body); err != nil {
+		return nil, err
+	}
+	iter := new(types.MaskingViewConnectionIterator)
+	if err := json.Unmarshal(body, iter); err != nil {
+		return nil, err
+	}
+	if iter.ID != "" || iter.ResultList.MaskingViewConnections != nil {
+		return iter, nil
+	}
+	cn := &types.MaskingViewConnectionsResult{}
+	if err := json.Unmarshal(body, cn); err != nil {
+		return nil, err
+	}
+	count := len(cn.MaskingViewConnections)
+	iter = &types.MaskingViewConnectionIterator{Count: count, MaxPageSize: count}
+	iter.ResultList = types.MaskingViewConnectionResultList{MaskingViewConnections: cn.MaskingViewConnections, From: 1, To: count}
+	return iter, nil
+}
+
+// GetMaskingViewConnectionsIterator returns an iterator of the connections of a masking view matching all
+// the fields of filter, holding the first page of them, so that the connections of a masking view exposing
+// thousands of volumes can be read a page at a time. If Unisphere returns the connections whole, all of
+// them are in the first page. The iterator is deleted by DeleteMaskingViewConnectionsIterator.
+func (c *Client) GetMaskingViewConnectionsIterator(ctx context.Context, symID, maskingViewID string, filter MaskingViewConnectionFilter) (*types.MaskingViewConnectionIterator, error) {
+	defer c.TimeSpent("GetMaskingViewConnectionsIterator", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	iter, err := c.maskingViewConnectionListing(ctx, c.maskingViewConnectionsURL(symID, maskingViewID, filter.query()))
+	if err != nil {
+		log.Error("GetMaskingViewConnectionsIterator failed: " + err.Error())
+		return nil, err
+	}
+	return iter, nil
+}
+
+// GetMaskingViewConnectionsIteratorPage returns the connections from..to, counted from 1, of a masking view
+// connection iterator. To can be left as 0 to read a whole page.
+func (c *Client) GetMaskingViewConnectionsIteratorPage(ctx context.Context, iter *types.MaskingViewConnectionIterator, from, to int) ([]*types.MaskingViewConnection, error) {
+	defer c.TimeSpent("GetMaskingViewConnectionsIteratorPage", time.Now())
+	if iter.ID == "" {
+		// Unisphere returned all the connections at once
+		if to == 0 || to > iter.Count {
+			to = iter.Count
+		}
+		if from < 1 || from > to {
+			return nil, fmt.Errorf("Invalid page from %d to %d of %d masking view connections", from, to, iter.Count)
+		}
+		return iter.ResultList.MaskingViewConnections[from-1 : to], nil
+	}
+	page, err := c.connectionIterator(iter).Page(ctx, from, to)
+	if err != nil {
+		log.Error("GetMaskingViewConnectionsIteratorPage failed: " + err.Error())
+		return nil, err
+	}
+	return page, nil
+}
+
+// DeleteMaskingViewConnectionsIterator deletes a masking view connection iterator.
+func (c *Client) DeleteMaskingViewConnectionsIterator(ctx context.Context, iter *types.MaskingViewConnectionIterator) error {
+	defer c.TimeSpent("DeleteMaskingViewConnectionsIterator", time.Now())
+	if iter.ID == "" {
+		// Unisphere kept no iterator
+		return nil
+	}
+	return c.connectionIterator(iter).Delete(ctx)
+}
+
+// ForEachMaskingViewConnection calls fn with every connection of a masking view matching all the fields of
+// filter, reading the connections a page at a time with GetMaskingViewConnectionsIterator. It stops at the
+// first error of fn, which it returns, and deletes the iterator afterwards.
+func (c *Client) ForEachMaskingViewConnection(ctx context.Context, symID, maskingViewID string, filter MaskingViewConnectionFilter, fn func(*types.MaskingViewConnection) error) error {
+	defer c.TimeSpent("ForEachMaskingViewConnection", time.Now())
+	iter, err := c.GetMaskingViewConnectionsIterator(ctx, symID, maskingViewID, filter)
+	if err != nil {
+		return err
+	}
+	return c.connectionIterator(iter).ForEach(ctx, fn)
+}
+
+// GetConnectionsForInitiator returns the connections of a masking view through one initiator, e.g. to
+// confirm after the login of a host that an HBA sees the expected LUNs. Unless volumeID is empty,
+// only the connections of that volume are returned.
+func (c *Client) GetConnectionsForInitiator(ctx context.Context, symID, maskingViewID, initiatorID, volumeID string) ([]*types.MaskingViewConnection, error) {
+	defer c.TimeSpent("GetConnectionsForInitiator", time.Now())
+	if initiatorID == "" {
+		return nil, fmt.Errorf("An initiator ID has to be specified")
+	}
+	return c.GetMaskingViewConnectionsWithFilter(ctx, symID, maskingViewID, MaskingViewConnectionFilter{VolumeID: volumeID, InitiatorID: initiatorID})
+}
+
+// GetVolumeNamespaceID returns the NVMe namespace ID of a volume exposed to an NVMe host by a masking view.
+// Here volume id is the 5 digit volume ID.
+func (c *Client) GetVolumeNamespaceID(ctx context.Context, symID, maskingViewID, volumeID string) (string, error) {
+	defer c.TimeSpent("GetVolumeNamespaceID", time.Now())
+	if volumeID == "" {
+		return "", fmt.Errorf("A volume ID has to be specified")
+	}
+	connections, err := c.GetMaskingViewConnections(ctx, symID, maskingViewID, volumeID)
+	if err != nil {
+		return "", err
+	}
+	for _, connection := range connections {
+		if connection.VolumeID == volumeID && connection.IsNVMe() {
+			return connection.NamespaceID, nil
+		}
+	}
+	return "", fmt.Errorf("Volume %s is not exposed over NVMe by masking view %s", volumeID, maskingViewID)
+}
+
+// MaskingViewPathingDiscrepancy describes a volume of a masking view which is not exposed through the
+// expected number of director ports, or not with the same host LUN address through all of them.
+type MaskingViewPathingDiscrepancy struct {
+	VolumeID         string
+	DirectorPorts    []string
+	HostLUNAddresses []string
+	Reason           string
+}
+
+// ValidateMaskingViewPathing checks that every volume of the storage group of a masking view is exposed
+// through expectedPathsPerVolume director ports, with the same host LUN address through all of them.
+// The paths are counted whether or not the initiators are logged in. The volumes which are not exposed
+// as expected are returned, in volume ID order; an empty list means the pathing is as expected.
+func (c *Client) ValidateMaskingViewPathing(ctx context.Context, symID, mvID string, expectedPathsPerVolume int) ([]MaskingViewPathingDiscrepancy, error) {
+	defer c.TimeSpent("ValidateMaskingViewPathing", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	if expectedPathsPerVolume <= 0 {
+		return nil, fmt.Errorf("the expected number of paths per volume must be positive")
+	}
+	maskingView, err := c.GetMaskingViewByID(ctx, symID, mvID)
+	if err != nil {
+		return nil, err
+	}
+	volumeIDs, err := c.GetVolumeIDListInStorageGroup(ctx, symID, maskingView.StorageGroupID)
+	if err != nil {
+		return nil, err
+	}
+	dirPorts := make(map[string][]string)
+	lunAddresses := make(map[string][]string)
+	err = c.ForEachMaskingViewConnection(ctx, symID, mvID, MaskingViewConnectionFilter{}, func(conn *types.MaskingViewConnection) error {
+		if !stringInSlice(conn.DirectorPort, dirPorts[conn.VolumeID]) {
+			dirPorts[conn.VolumeID] = append(dirPorts[conn.VolumeID], conn.DirectorPort)
+		}
+		if !stringInSlice(conn.HostLUNAddress, lunAddresses[conn.VolumeID]) {
+			lunAddresses[conn.VolumeID] = append(lunAddresses[conn.VolumeID], conn.HostLUNAddress)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(volumeIDs)
+	discrepancies := make([]MaskingViewPathingDiscrepancy, 0)
+	for _, volumeID := range volumeIDs {
+		reasons := make([]string, 0)
+		if paths := len(dirPorts[volumeID]); paths != expectedPathsPerVolume {
+			reasons = append(reasons, fmt.Sprintf("exposed through %d director ports instead of %d", paths, expectedPathsPerVolume))
+		}
+		if len(lunAddresses[volumeID]) > 1 {
+			reasons = append(reasons, "exposed with host LUN addresses "+strings.Join(lunAddresses[volumeID], ","))
+		}
+		if len(reasons) > 0 {
+			discrepancies = append(discrepancies, MaskingViewPathingDiscrepancy{
+				VolumeID:         volumeID,
+				DirectorPorts:    dirPorts[volumeID],
+				HostLUNAddresses: lunAddresses[volumeID],
+				Reason:           strings.Join(reasons, "; "),
+			})
+		}
+	}
+	return discrepancies, nil
 }
 
 // CreatePortGroup - Creates a Port Group
 func (c *Client) CreatePortGroup(ctx context.Context, symID string, portGroupID string, dirPorts []types.PortKey) (*types.PortGroup, error) {
+	return c.CreatePortGroupWithProtocol(ctx, symID, portGroupID, dirPorts, "")
+}
+
+// CreatePortGroupWithProtocol creates a port group of the given protocol: SCSI_FC, iSCSI or
+// NVMe_TCP (matched case-insensitively). If protocol is empty, Unisphere picks it from the ports.
+func (c *Client) CreatePortGroupWithProtocol(ctx context.Context, symID string, portGroupID string, dirPorts []types.PortKey, protocol string) (*types.PortGroup, error) {
 	defer c.TimeSpent("CreatePortGroup", time.Now())
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
+	portGroupProtocol, err := validatePortGroupProtocol(protocol)
+	if err != nil {
+		return nil, err
+	}
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XPortGroup
 	createPortGroupParams := &types.CreatePortGroupParams{
-		PortGroupID:      portGroupID,
-		SymmetrixPortKey: dirPorts,
-		ExecutionOption:  types.ExecutionOptionSynchronous,
+		PortGroupID:       portGroupID,
+		SymmetrixPortKey:  dirPorts,
+		ExecutionOption:   types.ExecutionOptionSynchronous,
+		PortGroupProtocol: portGroupProtocol,
 	}
-	ifDebugLogPayload(createPortGroupParams)
+	c.ifDebugLogPayload(createPortGroupParams)
 	portGroup := &types.PortGroup{}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
-	err := c.api.Post(ctx, URL, c.getDefaultHeaders(), createPortGroupParams, portGroup)
+	err = c.api.Post(ctx, URL, c.getDefaultHeaders(), createPortGroupParams, portGroup)
 	if err != nil {
 		log.Error("CreatePortGroup failed: " + err.Error())
 		return nil, err
@@ -1435,13 +3402,90 @@ func (c *Client) CreatePortGroup(ctx context.Context, symID string, portGroupID
 	return portGroup, nil
 }
 
+// validatePortGroupProtocol returns the protocol as spelled by Unisphere
+func validatePortGroupProtocol(protocol string) (string, error) {
+	if protocol == "" {
+		return "", nil
+	}
+	for _, p := range []string{types.PortGroupProtocolFibre, types.PortGroupProtocolISCSI, types.PortGroupProtocolNVMeTCP} {
+		if strings.EqualFold(protocol, p) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("Invalid port group protocol %s, it must be %s, %s or %s", protocol,
+		types.PortGroupProtocolFibre, types.PortGroupProtocolISCSI, types.PortGroupProtocolNVMeTCP)
+}
+
+// MaskingViewOptions are the optional settings of CreateMaskingViewWithOptions.
+type MaskingViewOptions struct {
+	// StartingLUNAddress is the host LUN address, in hex, of the first volume of the storage
+	// group, e.g. "0" for hosts which boot from HLU 0. If empty, Unisphere picks the addresses.
+	StartingLUNAddress string
+}
+
 // CreateMaskingView creates a masking view and returns the masking view object
 func (c *Client) CreateMaskingView(ctx context.Context, symID string, maskingViewID string, storageGroupID string, hostOrhostGroupID string, isHost bool, portGroupID string) (*types.MaskingView, error) {
+	return c.CreateMaskingViewWithOptions(ctx, symID, maskingViewID, storageGroupID, hostOrhostGroupID, isHost, portGroupID, MaskingViewOptions{})
+}
+
+// CreateMaskingViewWithOptions creates a masking view with the optional settings given as
+// MaskingViewOptions and returns the masking view object
+func (c *Client) CreateMaskingViewWithOptions(ctx context.Context, symID string, maskingViewID string, storageGroupID string, hostOrhostGroupID string, isHost bool, portGroupID string, options MaskingViewOptions) (*types.MaskingView, error) {
 	defer c.TimeSpent("CreateMaskingView", time.Now())
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
+	createMaskingViewParam, err := getCreateMaskingViewParam(maskingViewID, storageGroupID, hostOrhostGroupID, isHost, portGroupID, options)
+	if err != nil {
+		return nil, err
+	}
+	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XMaskingView
+	c.ifDebugLogPayload(createMaskingViewParam)
+	maskingView := &types.MaskingView{}
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
+	defer cancel()
+	err = c.api.Post(ctx, URL, c.getDefaultHeaders(), createMaskingViewParam, maskingView)
+	if err != nil {
+		log.Error("CreateMaskingView failed: " + err.Error())
+		return nil, err
+	}
+	log.Info(fmt.Sprintf("Successfully created Masking View: %s", maskingViewID))
+	return maskingView, nil
+}
+
+// CreateMaskingViewAsync is CreateMaskingViewWithOptions executed asynchronously by Unisphere, for masking views
+// whose creation outlasts the timeout of proxies. It returns the job creating the masking view, see WaitOnJobCompletion.
+func (c *Client) CreateMaskingViewAsync(ctx context.Context, symID string, maskingViewID string, storageGroupID string, hostOrhostGroupID string, isHost bool, portGroupID string, options MaskingViewOptions) (*types.Job, error) {
+	defer c.TimeSpent("CreateMaskingViewAsync", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	createMaskingViewParam, err := getCreateMaskingViewParam(maskingViewID, storageGroupID, hostOrhostGroupID, isHost, portGroupID, options)
+	if err != nil {
+		return nil, err
+	}
+	createMaskingViewParam.ExecutionOption = types.ExecutionOptionAsynchronous
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XMaskingView
+	c.ifDebugLogPayload(createMaskingViewParam)
+	job := &types.Job{}
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
+	defer cancel()
+	err = c.api.Post(ctx, URL, c.getDefaultHeaders(), createMaskingViewParam, job)
+	if err != nil {
+		log.Error("CreateMaskingViewAsync failed: " + err.Error())
+		return nil, err
+	}
+	log.Info(fmt.Sprintf("Job %s is creating Masking View: %s", job.JobID, maskingViewID))
+	return job, nil
+}
+
+// getCreateMaskingViewParam returns the payload creating a masking view of the given storage group, host or host group and port group
+func getCreateMaskingViewParam(maskingViewID string, storageGroupID string, hostOrhostGroupID string, isHost bool, portGroupID string, options MaskingViewOptions) (*types.MaskingViewCreateParam, error) {
+	if options.StartingLUNAddress != "" {
+		if _, err := strconv.ParseUint(options.StartingLUNAddress, 16, 32); err != nil {
+			return nil, fmt.Errorf("Invalid starting LUN address %s, it must be a hex number", options.StartingLUNAddress)
+		}
+	}
 	useExistingStorageGroupParam := &types.UseExistingStorageGroupParam{
 		StorageGroupID: storageGroupID,
 	}
@@ -1467,24 +3511,15 @@ func (c *Client) CreateMaskingView(ctx context.Context, symID string, maskingVie
 		StorageGroupSelection: &types.StorageGroupSelection{
 			UseExistingStorageGroupParam: useExistingStorageGroupParam,
 		},
+		StartingLUNAddress: options.StartingLUNAddress,
 	}
-	ifDebugLogPayload(createMaskingViewParam)
-	maskingView := &types.MaskingView{}
-	ctx, cancel := c.GetTimeoutContext(ctx)
-	defer cancel()
-	err := c.api.Post(ctx, URL, c.getDefaultHeaders(), createMaskingViewParam, maskingView)
-	if err != nil {
-		log.Error("CreateMaskingView failed: " + err.Error())
-		return nil, err
-	}
-	log.Info(fmt.Sprintf("Successfully created Masking View: %s", maskingViewID))
-	return maskingView, nil
+	return createMaskingViewParam, nil
 }
 
 // DeletePortGroup - Deletes a PG
 func (c *Client) DeletePortGroup(ctx context.Context, symID string, portGroupID string) error {
 	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + XPortGroup + "/" + portGroupID
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
 	err := c.api.Delete(ctx, URL, c.getDefaultHeaders(), nil)
 	if err != nil {
@@ -1560,7 +3595,7 @@ func (c *Client) UpdatePortGroup(ctx context.Context, symID string, portGroupID
 		}
 	}
 
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
 	defer cancel()
 
 	if len(added) > 0 {
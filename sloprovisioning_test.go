@@ -0,0 +1,49 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"strings"
+	"testing"
+
+	types "github.com/dell/gopowermax/types/v90"
+)
+
+func Test_formatPayloadForLog(t *testing.T) {
+	var tests = []struct {
+		name     string
+		payload  interface{}
+		maxSize  int
+		expected string
+	}{
+		{"small payload", &types.CreateHostParam{HostID: "host1"}, 1024,
+			`{"hostId":"host1","initiatorId":null,"executionOption":""}`},
+		{"truncated payload", &types.CreateHostParam{HostID: "host1"}, 10,
+			`{"hostId":... (truncated, 58 bytes)`},
+		{"no limit", map[string]string{"id": strings.Repeat("a", 20)}, 0,
+			`{"id":"aaaaaaaaaaaaaaaaaaaa"}`},
+		{"redacted secret", map[string]string{"chap_secret": "s3cr3t"}, 1024,
+			`{"chap_secret":"******"}`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatPayloadForLog(tt.payload, tt.maxSize)
+			if err != nil || got != tt.expected {
+				t.Errorf("formatPayloadForLog() = %s, %v; expected %s", got, err, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dell/gopowermax/api"
+	types "github.com/dell/gopowermax/types/v90"
+	log "github.com/sirupsen/logrus"
+)
+
+// Defaults of the SupportBundleOptions
+const (
+	DefaultSupportBundleMaxObjects = 500
+	DefaultSupportBundleMaxLogSize = 4 * 1024 * 1024
+	DefaultSupportBundleMaxSize    = 32 * 1024 * 1024
+	DefaultSupportBundleJobsWindow = 24 * time.Hour
+)
+
+// SupportBundleOptions are the settings of CollectSupportBundle. Zero values fall back to the defaults.
+type SupportBundleOptions struct {
+	// JobsSince selects the jobs scheduled after it, by default those of the last DefaultSupportBundleJobsWindow
+	JobsSince time.Time
+	// MaxObjects is the maximum number of storage groups, masking views, hosts, jobs and alerts
+	// whose details are collected, of each kind
+	MaxObjects int
+	// LogFiles are client-side log files to include in the bundle, under logs/
+	LogFiles []string
+	// MaxLogSize is the number of bytes kept from the end of each log file
+	MaxLogSize int
+	// MaxSize is the maximum uncompressed size of the bundle; the files which would exceed it are left out
+	MaxSize int
+}
+
+// SupportBundleFile is a file of a support bundle
+type SupportBundleFile struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+	// Truncated is true if some of the objects or of the log were left out of the file
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// SupportBundleManifest describes the content of a support bundle, it is the manifest.json file of the bundle
+type SupportBundleManifest struct {
+	SymmetrixID string              `json:"symmetrixId"`
+	Collected   time.Time           `json:"collected"`
+	APIVersion  string              `json:"apiVersion"`
+	Files       []SupportBundleFile `json:"files"`
+	// Errors maps the files, or the objects within them, which could not be collected to the error
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// SupportBundle is a zip archive of JSON files describing an array, for Dell support
+type SupportBundle struct {
+	Zip      []byte
+	Manifest SupportBundleManifest
+}
+
+// supportBundleWriter adds files to a support bundle within its size limit
+type supportBundleWriter struct {
+	zip      *zip.Writer
+	manifest *SupportBundleManifest
+	size     int
+	maxSize  int
+}
+
+// add writes a file to the bundle with its secrets redacted, unless it would exceed the size of the bundle
+func (b *supportBundleWriter) add(name string, content []byte, truncated bool) error {
+	content = []byte(api.RedactSecrets(string(content)))
+	if b.size+len(content) > b.maxSize {
+		b.manifest.Errors[name] = fmt.Sprintf("left out, the bundle would exceed %d bytes", b.maxSize)
+		return nil
+	}
+	w, err := b.zip.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(content); err != nil {
+		return err
+	}
+	b.size += len(content)
+	b.manifest.Files = append(b.manifest.Files, SupportBundleFile{Name: name, Size: len(content), Truncated: truncated})
+	return nil
+}
+
+// addJSON writes value as an indented JSON file
+func (b *supportBundleWriter) addJSON(name string, value interface{}, truncated bool) error {
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return b.add(name, content, truncated)
+}
+
+// CollectSupportBundle gathers the description of an array, the inventory of its storage groups, masking views
+// and hosts, its recent jobs, its unacknowledged alerts and the given client-side log files into a zip archive of
+// JSON files, with the secrets they contain redacted. The objects are read concurrently, up to
+// ClientOptions.MaxConcurrentRequests at a time. Only failing to read the array, or ctx being done, is an
+// error: the other failures are listed in the errors of the manifest, and the bundle is collected without the
+// objects concerned.
+func (c *Client) CollectSupportBundle(ctx context.Context, symID string, opts SupportBundleOptions) (*SupportBundle, error) {
+	defer c.TimeSpent("CollectSupportBundle", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	if opts.MaxObjects <= 0 {
+		opts.MaxObjects = DefaultSupportBundleMaxObjects
+	}
+	if opts.MaxLogSize <= 0 {
+		opts.MaxLogSize = DefaultSupportBundleMaxLogSize
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultSupportBundleMaxSize
+	}
+	if opts.JobsSince.IsZero() {
+		opts.JobsSince = time.Now().Add(-DefaultSupportBundleJobsWindow)
+	}
+	symmetrix, err := c.GetSymmetrixByID(ctx, symID)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := &bytes.Buffer{}
+	manifest := SupportBundleManifest{
+		SymmetrixID: symID,
+		Collected:   time.Now().UTC(),
+		APIVersion:  c.version,
+		Files:       make([]SupportBundleFile, 0),
+		Errors:      make(map[string]string),
+	}
+	bundle := &supportBundleWriter{zip: zip.NewWriter(buffer), manifest: &manifest, maxSize: opts.MaxSize}
+	if err = bundle.addJSON("array.json", symmetrix, false); err != nil {
+		return nil, err
+	}
+	if info, err := c.GetUnisphereInfo(ctx); err != nil {
+		manifest.Errors["unisphere.json"] = err.Error()
+	} else if err = bundle.addJSON("unisphere.json", info, false); err != nil {
+		return nil, err
+	}
+
+	inventories := []struct {
+		name string
+		list func() ([]string, error)
+		get  func(ctx context.Context, id string) (interface{}, error)
+	}{
+		{
+			name: "storage_groups.json",
+			list: func() ([]string, error) {
+				list, err := c.GetStorageGroupIDList(ctx, symID)
+				if err != nil {
+					return nil, err
+				}
+				return list.StorageGroupIDs, nil
+			},
+			get: func(ctx context.Context, id string) (interface{}, error) { return c.GetStorageGroup(ctx, symID, id) },
+		},
+		{
+			name: "masking_views.json",
+			list: func() ([]string, error) {
+				list, err := c.GetMaskingViewList(ctx, symID)
+				if err != nil {
+					return nil, err
+				}
+				return list.MaskingViewIDs, nil
+			},
+			get: func(ctx context.Context, id string) (interface{}, error) { return c.GetMaskingViewByID(ctx, symID, id) },
+		},
+		{
+			name: "hosts.json",
+			list: func() ([]string, error) {
+				list, err := c.GetHostList(ctx, symID)
+				if err != nil {
+					return nil, err
+				}
+				return list.HostIDs, nil
+			},
+			get: func(ctx context.Context, id string) (interface{}, error) { return c.GetHostByID(ctx, symID, id) },
+		},
+		{
+			name: "jobs.json",
+			list: func() ([]string, error) {
+				return c.GetJobIDListWithFilter(ctx, symID, JobListFilter{ScheduledAfter: opts.JobsSince})
+			},
+			get: func(ctx context.Context, id string) (interface{}, error) { return c.GetJobByID(ctx, symID, id) },
+		},
+		{
+			name: "alerts.json",
+			list: func() ([]string, error) {
+				list := &types.AlertList{}
+				URL := c.getSymmetrixIDListURL() + "/" + symID + "/alert?acknowledged=false"
+				if err := c.getJSON(ctx, URL, list); err != nil {
+					return nil, err
+				}
+				return list.AlertIDs, nil
+			},
+			get: func(ctx context.Context, id string) (interface{}, error) {
+				alert := &types.Alert{}
+				URL := c.getSymmetrixIDListURL() + "/" + symID + "/alert/" + id
+				if err := c.getJSON(ctx, URL, alert); err != nil {
+					return nil, err
+				}
+				return alert, nil
+			},
+		},
+	}
+	for _, inventory := range inventories {
+		ids, err := inventory.list()
+		if err != nil {
+			manifest.Errors[inventory.name] = err.Error()
+			continue
+		}
+		truncated := len(ids) > opts.MaxObjects
+		if truncated {
+			ids = ids[:opts.MaxObjects]
+		}
+		objects, err := c.collectSupportBundleObjects(ctx, ids, inventory.get, inventory.name, manifest.Errors)
+		if err != nil {
+			log.Error("CollectSupportBundle failed: " + err.Error())
+			return nil, err
+		}
+		if err = bundle.addJSON(inventory.name, objects, truncated); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range opts.LogFiles {
+		name := "logs/" + filepath.Base(path)
+		content, truncated, err := readLogTail(path, opts.MaxLogSize)
+		if err != nil {
+			manifest.Errors[name] = err.Error()
+			continue
+		}
+		if err = bundle.add(name, content, truncated); err != nil {
+			return nil, err
+		}
+	}
+
+	// The manifest is not counted in the size of the bundle, so that it is always there
+	content, err := json.MarshalIndent(&manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	w, err := bundle.zip.Create("manifest.json")
+	if err == nil {
+		_, err = w.Write(content)
+	}
+	if err == nil {
+		err = bundle.zip.Close()
+	}
+	if err != nil {
+		log.Error("CollectSupportBundle failed: " + err.Error())
+		return nil, err
+	}
+	log.Info(fmt.Sprintf("Collected a support bundle of %d files for array %s, %d could not be collected", len(manifest.Files), symID, len(manifest.Errors)))
+	return &SupportBundle{Zip: buffer.Bytes(), Manifest: manifest}, nil
+}
+
+// collectSupportBundleObjects reads the objects of ids with get, in the order of ids, leaving out those
+// which cannot be read; their errors are added to errs under file/id
+func (c *Client) collectSupportBundleObjects(ctx context.Context, ids []string, get func(ctx context.Context, id string) (interface{}, error),
+	file string, errs map[string]string) ([]interface{}, error) {
+	objects := make([]interface{}, len(ids))
+	objectErrs := make([]error, len(ids))
+	err := c.forEachConcurrently(ctx, "CollectSupportBundle", len(ids), func(ctx context.Context, i int) error {
+		objects[i], objectErrs[i] = get(ctx, ids[i])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, 0, len(ids))
+	for i, id := range ids {
+		if objectErrs[i] != nil {
+			errs[file+"/"+id] = objectErrs[i].Error()
+			continue
+		}
+		result = append(result, objects[i])
+	}
+	return result, nil
+}
+
+// readLogTail returns the last maxSize bytes of a log file, and whether the beginning of the file was left out
+func readLogTail(path string, maxSize int) ([]byte, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	truncated := info.Size() > int64(maxSize)
+	if truncated {
+		if _, err = file.Seek(-int64(maxSize), io.SeekEnd); err != nil {
+			return nil, false, err
+		}
+	}
+	content, err := io.ReadAll(io.LimitReader(file, int64(maxSize)))
+	if err != nil {
+		return nil, false, err
+	}
+	return content, truncated, nil
+}
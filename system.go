@@ -16,12 +16,14 @@ package pmax
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
+	"github.com/dell/gopowermax/api"
 	types "github.com/dell/gopowermax/types/v90"
 	log "github.com/sirupsen/logrus"
 )
@@ -46,6 +48,38 @@ func (c *Client) urlPrefix() string {
 func (c *Client) getSymmetrixIDListURL() string {
 	return c.urlPrefix() + "system/symmetrix"
 }
+func (c *Client) getVersionURL() string {
+	for _, v := range supportedAPIVersions {
+		if v.Version == c.version {
+			return RESTPrefix + v.VersionEndpoint
+		}
+	}
+	// Path for version has been changed from u4p 91 onwards
+	return RESTPrefix + "version"
+}
+
+// abortedError returns an error wrapping ctx.Err() and naming the operation if ctx is canceled or expired,
+// so that callers can test for context.Canceled or context.DeadlineExceeded with errors.Is.
+func abortedError(ctx context.Context, operation string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s aborted: %w", operation, err)
+	}
+	return nil
+}
+
+// ErrNotFound matches, with errors.Is, the error of a request for an object which does not
+// exist on the array, e.g. of GetStorageGroup for a storage group which has to be created.
+// Any other error, e.g. a timeout, does not tell whether the object exists.
+var ErrNotFound = types.ErrNotFound
+
+// httpStatus returns the HTTP status of the Unisphere answer err is or wraps, or 0 if err is not one
+func httpStatus(err error) int {
+	var apiErr *types.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode
+	}
+	return 0
+}
 
 // Check respone to see if is nil or has bad HTTP status code.
 func (c *Client) checkResponse(resp *http.Response) error {
@@ -62,7 +96,7 @@ func (c *Client) checkResponse(resp *http.Response) error {
 // GetSymmetrixIDList returns a list of all the symmetrix systems known to the connected Unisphere instance.
 func (c *Client) GetSymmetrixIDList(ctx context.Context) (*types.SymmetrixIDList, error) {
 
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	resp, err := c.api.DoAndGetResponseBody(
 		ctx, http.MethodGet, c.getSymmetrixIDListURL(), c.getDefaultHeaders(), nil)
@@ -76,8 +110,7 @@ func (c *Client) GetSymmetrixIDList(ctx context.Context) (*types.SymmetrixIDList
 	}
 
 	symIDList := &types.SymmetrixIDList{}
-	decoder := json.NewDecoder(resp.Body)
-	if err = decoder.Decode(symIDList); err != nil {
+	if err = api.DecodeJSON(resp, symIDList); err != nil {
 		return nil, err
 	}
 	// we have the list of all arrays, filter out those not in the allowed arrays
@@ -99,7 +132,7 @@ func (c *Client) GetSymmetrixByID(ctx context.Context, id string) (*types.Symmet
 		return nil, err
 	}
 	url := c.getSymmetrixIDListURL() + "/" + id
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	resp, err := c.api.DoAndGetResponseBody(
 		ctx, http.MethodGet, url, c.getDefaultHeaders(), nil)
@@ -113,27 +146,260 @@ func (c *Client) GetSymmetrixByID(ctx context.Context, id string) (*types.Symmet
 	}
 
 	symmetrix := &types.Symmetrix{}
-	decoder := json.NewDecoder(resp.Body)
-	if err = decoder.Decode(symmetrix); err != nil {
+	if err = api.DecodeJSON(resp, symmetrix); err != nil {
 		return nil, err
 	}
 	return symmetrix, nil
 }
 
+// GetLocalSymmetrixIDList returns the allowed arrays which are local to the connected Unisphere instance,
+// leaving out those it manages remotely through SRDF, which cannot be provisioned from it.
+func (c *Client) GetLocalSymmetrixIDList(ctx context.Context) (*types.SymmetrixIDList, error) {
+	defer c.TimeSpent("GetLocalSymmetrixIDList", time.Now())
+	symIDList, err := c.GetSymmetrixIDList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	local := make([]string, 0)
+	for _, symID := range symIDList.SymmetrixIDs {
+		symmetrix, err := c.GetSymmetrixByID(ctx, symID)
+		if err != nil {
+			return nil, fmt.Errorf("GetLocalSymmetrixIDList failed to get Symmetrix %s: %s", symID, err.Error())
+		}
+		if symmetrix.Local {
+			local = append(local, symID)
+		}
+	}
+	symIDList.SymmetrixIDs = local
+	return symIDList, nil
+}
+
+// ValidateLocalArray returns an error unless the array is allowed and local to the connected Unisphere
+// instance, so that provisioning against an array managed remotely fails early with a clear message.
+func (c *Client) ValidateLocalArray(ctx context.Context, symID string) error {
+	symmetrix, err := c.GetSymmetrixByID(ctx, symID)
+	if err != nil {
+		return err
+	}
+	if !symmetrix.Local {
+		return fmt.Errorf("the requested array (%s) is managed remotely by this Unisphere, use the Unisphere local to it", symID)
+	}
+	return nil
+}
+
+// ArrayTime is the clock of an array and how far it is from the local clock
+type ArrayTime struct {
+	SymmetrixID string
+	// Time is the time of the array when it answered, in UTC
+	Time time.Time
+	// Skew is the array clock minus the local clock; it is positive when the array is ahead
+	Skew time.Duration
+	// Uncertainty bounds the error of Skew: half the round trip of the request plus the millisecond
+	// resolution of the array time
+	Uncertainty time.Duration
+}
+
+// ToLocal converts a timestamp taken by the array, such as the time of a snapshot, to the local clock
+func (t *ArrayTime) ToLocal(arrayTime time.Time) time.Time {
+	return arrayTime.Add(-t.Skew)
+}
+
+// ToArray converts a local time, such as the start of a job scheduling window, to the array clock
+func (t *ArrayTime) ToArray(localTime time.Time) time.Time {
+	return localTime.Add(t.Skew)
+}
+
+// GetArrayTime returns the current time of an array, from the system time of its symmetrix resource,
+// and its skew from the local clock, measured at the midpoint of the request.
+func (c *Client) GetArrayTime(ctx context.Context, symID string) (*ArrayTime, error) {
+	defer c.TimeSpent("GetArrayTime", time.Now())
+	sent := time.Now()
+	symmetrix, err := c.GetSymmetrixByID(ctx, symID)
+	received := time.Now()
+	if err != nil {
+		log.Error("GetArrayTime failed: " + err.Error())
+		return nil, err
+	}
+	if symmetrix.SystemTime == 0 {
+		return nil, fmt.Errorf("Symmetrix %s time not available", symID)
+	}
+	arrayTime := time.Unix(0, symmetrix.SystemTime*int64(time.Millisecond))
+	roundTrip := received.Sub(sent)
+	return &ArrayTime{
+		SymmetrixID: symID,
+		Time:        arrayTime.UTC(),
+		Skew:        arrayTime.Sub(sent.Add(roundTrip / 2)),
+		Uncertainty: roundTrip/2 + time.Millisecond,
+	}, nil
+}
+
+// HealthScoreOverall is the metric of the overall health score of an array
+const HealthScoreOverall = "OVERALL"
+
+// ArrayHealth is the health of an array, as scored by Unisphere
+type ArrayHealth struct {
+	SymmetrixID string
+	// Scores are the health scores, from 0 to 100, by metric such as HealthScoreOverall or CAPACITY.
+	// Expired scores are left out.
+	Scores map[string]float64
+	// ComputedAt is when the most recent of the scores was computed
+	ComputedAt     time.Time
+	NumFailedDisks int
+}
+
+// millisToTime converts a timestamp of Unisphere, in milliseconds since the epoch, to a time in UTC
+func millisToTime(millis int64) time.Time {
+	return time.Unix(millis/1000, (millis%1000)*int64(time.Millisecond)).UTC()
+}
+
+// GetArrayHealth returns the health scores of an array
+func (c *Client) GetArrayHealth(ctx context.Context, symID string) (*ArrayHealth, error) {
+	defer c.TimeSpent("GetArrayHealth", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	health := &types.ArrayHealth{}
+	if err := c.getJSON(ctx, c.getSymmetrixIDListURL()+"/"+symID+"/health", health); err != nil {
+		log.Error("GetArrayHealth failed: " + err.Error())
+		return nil, err
+	}
+	arrayHealth := &ArrayHealth{
+		SymmetrixID:    symID,
+		Scores:         make(map[string]float64),
+		NumFailedDisks: health.NumFailedDisks,
+	}
+	for _, metric := range health.HealthScoreMetrics {
+		if metric.Expired {
+			continue
+		}
+		arrayHealth.Scores[metric.Metric] = metric.HealthScore
+		if computedAt := millisToTime(metric.DataDate); computedAt.After(arrayHealth.ComputedAt) {
+			arrayHealth.ComputedAt = computedAt
+		}
+	}
+	return arrayHealth, nil
+}
+
+// GetArrayLastRefresh returns when Unisphere last refreshed its data about an array, in UTC
+func (c *Client) GetArrayLastRefresh(ctx context.Context, symID string) (time.Time, error) {
+	defer c.TimeSpent("GetArrayLastRefresh", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return time.Time{}, err
+	}
+	refresh := &types.SymmetrixRefresh{}
+	if err := c.getJSON(ctx, c.getSymmetrixIDListURL()+"/"+symID+"/refresh", refresh); err != nil {
+		log.Error("GetArrayLastRefresh failed: " + err.Error())
+		return time.Time{}, err
+	}
+	return millisToTime(refresh.LastRefreshTime), nil
+}
+
+// GetSLOComplianceSummary returns the number of storage groups of an array in each service level compliance state
+func (c *Client) GetSLOComplianceSummary(ctx context.Context, symID string) (*types.SLOComplianceSummary, error) {
+	defer c.TimeSpent("GetSLOComplianceSummary", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	summary := &types.SLOComplianceSummary{}
+	URL := c.urlPrefix() + SLOProvisioningX + SymmetrixX + symID + "/compliance" + XStorageGroup
+	if err := c.getJSON(ctx, URL, summary); err != nil {
+		log.Error("GetSLOComplianceSummary failed: " + err.Error())
+		return nil, err
+	}
+	return summary, nil
+}
+
+// GetUnisphereInfo returns diagnostic information about the connected Unisphere instance:
+// the server version, build date and supported API versions, and the current API load
+// and capacity if the Unisphere instance reports it.
+func (c *Client) GetUnisphereInfo(ctx context.Context) (*types.UnisphereInfo, error) {
+	defer c.TimeSpent("GetUnisphereInfo", time.Now())
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
+	defer cancel()
+	resp, err := c.api.DoAndGetResponseBody(
+		ctx, http.MethodGet, c.getVersionURL(), c.getDefaultHeaders(), nil)
+	if err != nil {
+		log.Error("GetUnisphereInfo failed: " + err.Error())
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err = c.checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	version := &types.Version{}
+	if err = api.DecodeJSON(resp, version); err != nil {
+		return nil, err
+	}
+	info := &types.UnisphereInfo{
+		Version:              version.Version,
+		APIVersion:           version.APIVersion,
+		SupportedAPIVersions: version.SupportedAPIVersions,
+		BuildDate:            version.BuildDate,
+	}
+	if info.APIVersion == "" {
+		info.APIVersion = c.version
+	}
+
+	// Not every Unisphere release reports its load, so a failure here is not fatal
+	usage := &types.UnisphereAPIUsage{}
+	err = c.api.Get(ctx, c.privURLPrefix()+"system/api_usage", c.getDefaultHeaders(), usage)
+	if err != nil {
+		log.Debug("GetUnisphereInfo: API usage not available: " + err.Error())
+	} else {
+		info.APIUsage = usage
+	}
+	return info, nil
+}
+
 // GetJobIDList returns a list of all the jobs in the symmetrix system.
 // If optional statusQuery is something like JobStatusRunning it will search for running jobs.
 func (c *Client) GetJobIDList(ctx context.Context, symID string, statusQuery string) ([]string, error) {
+	return c.GetJobIDListWithFilter(ctx, symID, JobListFilter{Status: statusQuery})
+}
+
+// JobListFilter selects the jobs returned by GetJobIDListWithFilter. The filters are applied by
+// Unisphere, so finding a few jobs does not fetch the IDs of the whole job history of the array.
+type JobListFilter struct {
+	// Status, e.g. JobStatusRunning, selects the jobs with that status
+	Status string
+	// NamePrefix selects the jobs whose name starts with it. Unisphere matches it with its <like>
+	// operator, which is not anchored, so it should not appear elsewhere in the names of jobs.
+	NamePrefix string
+	// ResourceType, e.g. "volume" or "storagegroup", selects the jobs whose resource link is a
+	// resource of that type, as returned by types.Job.GetJobResource
+	ResourceType string
+	// ScheduledAfter selects the jobs scheduled after it
+	ScheduledAfter time.Time
+}
+
+// GetJobIDListWithFilter returns the IDs of the jobs of the symmetrix system selected by filter.
+// The empty filter selects every job.
+func (c *Client) GetJobIDListWithFilter(ctx context.Context, symID string, filter JobListFilter) ([]string, error) {
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
-	url := c.getSymmetrixIDListURL() + "/" + symID + "/" + "job"
-	if statusQuery != "" {
-		url = url + "?status=" + statusQuery
+	query := url.Values{}
+	if filter.Status != "" {
+		query.Set("status", filter.Status)
+	}
+	if filter.NamePrefix != "" {
+		query.Set("name", "<like>"+filter.NamePrefix)
+	}
+	if filter.ResourceType != "" {
+		query.Set("resourceLink", "<like>/"+filter.ResourceType+"/")
+	}
+	if !filter.ScheduledAfter.IsZero() {
+		query.Set("scheduled_date", fmt.Sprintf(">%d", filter.ScheduledAfter.UnixNano()/int64(time.Millisecond)))
+	}
+	URL := c.getSymmetrixIDListURL() + "/" + symID + "/" + "job"
+	if len(query) > 0 {
+		URL = URL + "?" + query.Encode()
 	}
 	jobIDList := &types.JobIDList{}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
-	err := c.api.Get(ctx, url, c.getDefaultHeaders(), jobIDList)
+	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), jobIDList)
 	if err != nil {
 		log.Error("GetJobIDList failed: " + err.Error())
 		return nil, err
@@ -146,7 +412,7 @@ func (c *Client) GetJobByID(ctx context.Context, symID string, jobID string) (*t
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	maxRetry := 6
 	for i := 0; i < maxRetry; i++ {
@@ -167,15 +433,65 @@ func (c *Client) GetJobByID(ctx context.Context, symID string, jobID string) (*t
 	return nil, fmt.Errorf("GetJob still failing after %d retries", maxRetry)
 }
 
+// CancelJob cancels a job which has not started running yet, deleting it from Unisphere.
+func (c *Client) CancelJob(ctx context.Context, symID string, jobID string) error {
+	defer c.TimeSpent("CancelJob", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return err
+	}
+	ctx, cancel := c.getTimeoutContext(ctx, writeOperation)
+	defer cancel()
+	url := c.getSymmetrixIDListURL() + "/" + symID + "/" + "job" + "/" + jobID
+	if err := c.api.Delete(ctx, url, c.getDefaultHeaders(), nil); err != nil {
+		log.Error("CancelJob failed: " + err.Error())
+		return err
+	}
+	log.Info(fmt.Sprintf("Cancelled Symmetrix %s Job %s", symID, jobID))
+	return nil
+}
+
+// JobWaitOptions are the optional settings of WaitOnJobCompletionWithOptions.
+type JobWaitOptions struct {
+	// CancelOnAbort cancels the job with CancelJob if ctx is cancelled or times out while waiting,
+	// instead of leaving it to run without anyone waiting for it.
+	CancelOnAbort bool
+}
+
 // WaitOnJobCompletion waits until a Job reaches a terminal state.
 // The state may be JobStatusSucceeded or JobStatusFailed (it is the caller's responsibility to check.)
 func (c *Client) WaitOnJobCompletion(ctx context.Context, symID string, jobID string) (*types.Job, error) {
+	return c.WaitOnJobCompletionWithOptions(ctx, symID, jobID, JobWaitOptions{})
+}
+
+// WaitOnJobCompletionWithOptions is WaitOnJobCompletion with optional settings. If the job is cancelled
+// because ctx was aborted, the returned error still wraps the error of ctx.
+func (c *Client) WaitOnJobCompletionWithOptions(ctx context.Context, symID string, jobID string, options JobWaitOptions) (*types.Job, error) {
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
+	ctx, cancel := c.getTimeoutContext(ctx, longJobOperation)
+	defer cancel()
+	operation := fmt.Sprintf("WaitOnJobCompletion of Symmetrix %s Job %s", symID, jobID)
+	aborted := func(err error) error {
+		if !options.CancelOnAbort {
+			return err
+		}
+		// ctx is already done, so the job is cancelled with a context of its own
+		if cancelErr := c.CancelJob(context.Background(), symID, jobID); cancelErr != nil {
+			return fmt.Errorf("%w; cancelling the job failed: %s", err, cancelErr.Error())
+		}
+		return fmt.Errorf("%w; the job was cancelled", err)
+	}
 	for i := 0; i < MAXJobRetryCount; i++ {
+		if err := abortedError(ctx, operation); err != nil {
+			return nil, aborted(err)
+		}
 		job, err := c.GetJobByID(ctx, symID, jobID)
 		if err != nil {
+			// the poll fails when ctx is aborted while it is outstanding
+			if abortErr := abortedError(ctx, operation); abortErr != nil {
+				return nil, aborted(abortErr)
+			}
 			return nil, err
 		}
 		log.Debug(c.JobToString(job))
@@ -185,7 +501,13 @@ func (c *Client) WaitOnJobCompletion(ctx context.Context, symID string, jobID st
 		case types.JobStatusFailed:
 			return job, nil
 		}
-		time.Sleep(JobRetrySleepDuration)
+		select {
+		case <-ctx.Done():
+		case <-time.After(JobRetrySleepDuration):
+		}
+	}
+	if err := abortedError(ctx, operation); err != nil {
+		return nil, aborted(err)
 	}
 	return nil, fmt.Errorf("Symmetrix %s Job %s timed out after %d retries", symID, jobID, MAXJobRetryCount)
 }
@@ -213,7 +535,7 @@ func (c *Client) GetDirectorIDList(ctx context.Context, symID string) (*types.Di
 	}
 	directorList := &types.DirectorIDList{}
 	URL := c.getSymmetrixIDListURL() + "/" + symID + "/director"
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), directorList)
 	if err != nil {
@@ -234,7 +556,7 @@ func (c *Client) GetPortList(ctx context.Context, symID string, directorID strin
 	if query != "" {
 		URL = URL + "?" + query
 	}
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), portList)
 	if err != nil {
@@ -252,7 +574,7 @@ func (c *Client) GetPort(ctx context.Context, symID string, directorID string, p
 	}
 	port := &types.Port{}
 	URL := c.getSymmetrixIDListURL() + "/" + symID + "/director/" + directorID + "/port/" + portID
-	ctx, cancel := c.GetTimeoutContext(ctx)
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
 	defer cancel()
 	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), port)
 	if err != nil {
@@ -263,44 +585,84 @@ func (c *Client) GetPort(ctx context.Context, symID string, directorID string, p
 	return port, nil
 }
 
-// GetListOfTargetAddresses returns list of target addresses
+// GetSymmetrixPortList returns a list of the ports of all the directors of an array,
+// filtered by query, e.g. "type=Gige" or "iscsi_target=true".
+func (c *Client) GetSymmetrixPortList(ctx context.Context, symID string, query string) (*types.PortList, error) {
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	portList := &types.PortList{}
+	URL := c.getSymmetrixIDListURL() + "/" + symID + "/port"
+	if query != "" {
+		URL = URL + "?" + query
+	}
+	ctx, cancel := c.getTimeoutContext(ctx, readOperation)
+	defer cancel()
+	err := c.api.Get(ctx, URL, c.getDefaultHeaders(), portList)
+	if err != nil {
+		log.Error("GetSymmetrixPortList failed: " + err.Error())
+		return nil, err
+	}
+
+	return portList, nil
+}
+
+// TargetAddressOptions are the optional settings of GetListOfTargetAddressesWithOptions.
+type TargetAddressOptions struct {
+	// IncludeDownPorts returns the addresses of ports which are offline as well.
+	IncludeDownPorts bool
+}
+
+// GetListOfTargetAddresses returns list of target addresses of the GigE ports which are online
 func (c *Client) GetListOfTargetAddresses(ctx context.Context, symID string) ([]string, error) {
+	return c.GetListOfTargetAddressesWithOptions(ctx, symID, TargetAddressOptions{})
+}
+
+// GetListOfTargetAddressesWithOptions returns list of target addresses of the GigE ports.
+// The ports are listed with a single query and their details are fetched concurrently, up to
+// ClientOptions.MaxConcurrentRequests at a time. The ports whose details cannot be read are skipped.
+func (c *Client) GetListOfTargetAddressesWithOptions(ctx context.Context, symID string, options TargetAddressOptions) ([]string, error) {
+	defer c.TimeSpent("GetListOfTargetAddresses", time.Now())
 	if _, err := c.IsAllowedArray(symID); err != nil {
 		return nil, err
 	}
-	ipAddr := []string{}
-	// Get list of all directors
-	directors, err := c.GetDirectorIDList(ctx, symID)
+	ports, err := c.GetSymmetrixPortList(ctx, symID, "type=Gige")
 	if err != nil {
 		return []string{}, err
 	}
 
-	// for each director, get list of ports with iscsi_target=true
-	for _, d := range directors.DirectorIDs {
-
-		ports, err := c.GetPortList(ctx, symID, d, "type=Gige")
+	// fetch the details of each port, keeping the addresses in the order of the port list
+	addresses := make([][]string, len(ports.SymmetrixPortKey))
+	err = c.forEachConcurrently(ctx, "GetListOfTargetAddresses", len(ports.SymmetrixPortKey), func(ctx context.Context, i int) error {
+		p := ports.SymmetrixPortKey[i]
+		port, err := c.GetPort(ctx, symID, p.DirectorID, p.PortID)
 		if err != nil {
 			// Ignore the error and continue
-			continue
+			return nil
 		}
-
-		// for each port, get the details
-		for _, p := range ports.SymmetrixPortKey {
-			port, err := c.GetPort(ctx, symID, d, p.PortID)
-			if err != nil {
-				// Ignore the error and continue
-				continue
-			}
-			if len(port.SymmetrixPort.IPAddresses) > 0 {
-				ipAddr = append(ipAddr, port.SymmetrixPort.IPAddresses...)
-			}
-
+		if !options.IncludeDownPorts && isPortOffline(&port.SymmetrixPort) {
+			log.Debugf("Skipping port %s:%s as it is offline", p.DirectorID, p.PortID)
+			return nil
 		}
+		addresses[i] = port.SymmetrixPort.IPAddresses
+		return nil
+	})
+	if err != nil {
+		return []string{}, err
 	}
 
+	ipAddr := []string{}
+	for _, a := range addresses {
+		ipAddr = append(ipAddr, a...)
+	}
 	return ipAddr, nil
 }
 
+// isPortOffline returns true if the status of the port is OFF or Offline
+func isPortOffline(port *types.SymmetrixPortType) bool {
+	return strings.EqualFold(port.PortStatus, "OFF") || strings.EqualFold(port.PortStatus, "Offline")
+}
+
 // GetISCSITargets returns list of target addresses
 func (c *Client) GetISCSITargets(ctx context.Context, symID string) ([]ISCSITarget, error) {
 	if _, err := c.IsAllowedArray(symID); err != nil {
@@ -366,8 +728,19 @@ func (c *Client) GetAllowedArrays() []string {
 	return c.allowedArrays
 }
 
-// IsAllowedArray checks to see if we can manipulate the specified array
+// IsAllowedArray checks to see if we can manipulate the specified array.
+// A client created with ClientOptions.SkipAllowedArrayCheck allows every array, and one
+// created with ClientOptions.ArrayAuthorizer asks it instead of checking the allowed arrays.
 func (c *Client) IsAllowedArray(array string) (bool, error) {
+	if c.skipAllowedArrayCheck {
+		return true, nil
+	}
+	if c.arrayAuthorizer != nil {
+		if err := c.arrayAuthorizer(array); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
 	// if no list has been specified, allow all arrays
 	if len(c.allowedArrays) == 0 {
 		return true, nil
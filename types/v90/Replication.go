@@ -112,6 +112,16 @@ type Establish struct {
 	MetroBias bool `json:"metroBias"`
 }
 
+// SetMode action
+type SetMode struct {
+	Mode     string `json:"mode"`
+	Force    bool   `json:"force"`
+	SymForce bool   `json:"symForce"`
+	Star     bool   `json:"star"`
+	Hop2     bool   `json:"hop2"`
+	Bypass   bool   `json:"bypass"`
+}
+
 // ModifySGRDFGroup holds parameters for rdf storage group updates
 type ModifySGRDFGroup struct {
 	Action          string     `json:"action"`
@@ -121,6 +131,7 @@ type ModifySGRDFGroup struct {
 	Failback        *Failback  `json:"failback,omitempty"`
 	Failover        *Failover  `json:"failover,omitempty"`
 	Swap            *Swap      `json:"swap,omitempty"`
+	SetMode         *SetMode   `json:"setMode,omitempty"`
 	ExecutionOption string     `json:"executionOption"`
 }
 
@@ -247,3 +258,13 @@ type StorageGroupRDFG struct {
 	States           []string `json:"states"`
 	Modes            []string `json:"modes"`
 }
+
+// RDFDirList contains the list of RDF directors of an array
+type RDFDirList struct {
+	RDFDirs []string `json:"directorId"`
+}
+
+// RDFPortList contains the list of RDF capable ports of an RDF director
+type RDFPortList struct {
+	RDFPorts []string `json:"portId"`
+}
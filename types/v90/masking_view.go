@@ -173,11 +173,14 @@ type MaskingViewCreateParam struct {
 	StorageGroupSelection    *StorageGroupSelection    `json:"storageGroupSelection,omitempty"`
 	EnableComplianceAlerts   bool                      `json:"enableComplianceAlerts,omitempty"`
 	ExecutionOption          string                    `json:"executionOption,omitempty"`
+	StartingLUNAddress       string                    `json:"starting_lun_address,omitempty"`
 }
 
 // MaskingViewConnection is a connection entry for the massking view associating
 // a volume with the HostLUNAddress, the InitiatID and DirectorPort used for the
 // path, and other attributes.
+// For an NVMe host the InitiatorID is the host NQN, and the namespace ID of the volume
+// and the NQN of the array's NVMe subsystem are set as well.
 type MaskingViewConnection struct {
 	VolumeID       string `json:"volumeID"`
 	HostLUNAddress string `json:"host_lun_address"`
@@ -187,9 +190,34 @@ type MaskingViewConnection struct {
 	DirectorPort   string `json:"dir_port"`
 	LoggedIn       bool   `json:"logged_in"`
 	OnFabric       bool   `json:"on_fabric"`
+	NamespaceID    string `json:"namespace_id,omitempty"`
+	SubsystemNQN   string `json:"subsystem_nqn,omitempty"`
+}
+
+// IsNVMe returns true if the connection is an NVMe path, which exposes the volume as a namespace
+func (c *MaskingViewConnection) IsNVMe() bool {
+	return c.NamespaceID != ""
 }
 
 // MaskingViewConnectionsResult is the result structure for .../maskingview/{id}/connections
 type MaskingViewConnectionsResult struct {
 	MaskingViewConnections []*MaskingViewConnection `json:"maskingViewConnection"`
 }
+
+// MaskingViewConnectionResultList is a page of the connections of a masking view iterator
+type MaskingViewConnectionResultList struct {
+	MaskingViewConnections []*MaskingViewConnection `json:"result"`
+	From                   int                      `json:"from"`
+	To                     int                      `json:"to"`
+}
+
+// MaskingViewConnectionIterator holds the first page of the connections of a masking view, and the
+// iterator of the following ones, as returned by .../maskingview/{id}/connections when the connections
+// do not fit in a page
+type MaskingViewConnectionIterator struct {
+	ResultList     MaskingViewConnectionResultList `json:"resultList"`
+	ID             string                          `json:"id"`
+	Count          int                             `json:"count"`
+	ExpirationTime int64                           `json:"expirationTime"`
+	MaxPageSize    int                             `json:"maxPageSize"`
+}
@@ -35,6 +35,22 @@ const InSG = "inSG"
 // endpoing for filtering the resluts based on their RDF relationship
 const IsRdf = "isRdf"
 
+// Actions which can be performed on a snapshot with ModifyVolumeSnapshot
+const (
+	SnapshotActionLink    = "Link"
+	SnapshotActionUnlink  = "Unlink"
+	SnapshotActionRelink  = "Relink"
+	SnapshotActionRename  = "Rename"
+	SnapshotActionSetMode = "SetMode"
+)
+
+// States of the link of a snapshot to a target volume
+const (
+	SnapshotLinkStateLinked     = "Linked"
+	SnapshotLinkStateCopyInProg = "CopyInProg"
+	SnapshotLinkStateCopied     = "Copied"
+)
+
 // VolumeList contains list of device names
 type VolumeList struct {
 	Name string `json:"name"`
@@ -73,6 +89,20 @@ type ModifyVolumeSnapshot struct {
 	ExecutionOption      string       `json:"executionOption,omitempty"`
 }
 
+// LinkStorageGroupSnapshotParam holds the target of the link of a storage group snapshot
+type LinkStorageGroupSnapshotParam struct {
+	LinkStorageGroupName string `json:"linkStorageGroupName"`
+	Copy                 bool   `json:"copy,omitempty"`
+	Remote               bool   `json:"remote,omitempty"`
+}
+
+// ModifyStorageGroupSnapshot contains input parameters to modify the snapshot of a storage group
+type ModifyStorageGroupSnapshot struct {
+	Action          string                         `json:"action"`
+	Link            *LinkStorageGroupSnapshotParam `json:"link,omitempty"`
+	ExecutionOption string                         `json:"executionOption,omitempty"`
+}
+
 // DeleteVolumeSnapshot contains input parameters to delete the snapshot
 type DeleteVolumeSnapshot struct {
 	DeviceNameListSource []VolumeList `json:"deviceNameListSource"`
@@ -89,6 +119,7 @@ type VolumeSnapshotSource struct {
 	SnapshotName         string          `json:"snapshotName"`
 	Generation           int64           `json:"generation"`
 	TimeStamp            string          `json:"timestamp"`
+	TimeStampUTC         int64           `json:"timestamp_utc,omitempty"`
 	State                string          `json:"state"`
 	ProtectionExpireTime int64           `json:"protectionExpireTime"`
 	GCM                  bool            `json:"gcm"`
@@ -176,12 +207,13 @@ type SymDevice struct {
 
 //Snapshot contains information for a snapshot
 type Snapshot struct {
-	Name       string `json:"name"`
-	Generation int64  `json:"generation"`
-	Linked     bool   `json:"linked"`
-	Restored   bool   `json:"restored"`
-	Timestamp  string `json:"timestamp"`
-	State      string `json:"state"`
+	Name         string `json:"name"`
+	Generation   int64  `json:"generation"`
+	Linked       bool   `json:"linked"`
+	Restored     bool   `json:"restored"`
+	Timestamp    string `json:"timestamp"`
+	TimestampUTC int64  `json:"timestamp_utc,omitempty"`
+	State        string `json:"state"`
 }
 
 // SymVolumeList contains information on private volume get
@@ -192,9 +224,14 @@ type SymVolumeList struct {
 
 // SymmetrixCapability holds replication capabilities
 type SymmetrixCapability struct {
-	SymmetrixID   string `json:"symmetrixId"`
-	SnapVxCapable bool   `json:"snapVxCapable"`
-	RdfCapable    bool   `json:"rdfCapable"`
+	SymmetrixID           string `json:"symmetrixId"`
+	SnapVxCapable         bool   `json:"snapVxCapable"`
+	RdfCapable            bool   `json:"rdfCapable"`
+	VirtualWitnessCapable bool   `json:"virtualWitnessCapable"`
+	// SnapVxMaxSnapshotsPerVolume and SnapVxMaxSnapshotsPerArray are the maximum numbers of SnapVX
+	// snapshots of a source volume and of the array, if the array reports them
+	SnapVxMaxSnapshotsPerVolume int `json:"snapVxMaxSnapshotsPerVolume,omitempty"`
+	SnapVxMaxSnapshotsPerArray  int `json:"snapVxMaxSnapshotsPerArray,omitempty"`
 }
 
 // SymReplicationCapabilities holds whether or not snapshot is licensed
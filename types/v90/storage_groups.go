@@ -44,6 +44,39 @@ type StorageGroup struct {
 	ChildStorageGroup  []string `json:"child_storage_group"`
 	ParentStorageGroup []string `json:"parent_storage_group"`
 	MaskingView        []string `json:"maskingview"`
+	// Data reduction of the storage group, see GetStorageGroupDataReduction
+	Compression           bool    `json:"compression"`
+	CompressionRatio      string  `json:"compressionRatio,omitempty"`
+	CompressionRatioToOne float64 `json:"compression_ratio_to_one"`
+	VPSavedPercent        float64 `json:"vp_saved_percent"`
+	UnreducibleDataGB     float64 `json:"unreducible_data_gb"`
+	// VolumeIDs is only returned when the volumes are expanded, see GetStorageGroupWithVolumes
+	VolumeIDs []string `json:"volumeId,omitempty"`
+}
+
+// StorageGroupDemandReport : capacity demand of the storage groups in a storage resource pool
+type StorageGroupDemandReport struct {
+	StorageGroupDemands []StorageGroupDemand `json:"storageGroupDemand"`
+}
+
+// StorageGroupDemand : allocated and subscribed capacity of a storage group
+type StorageGroupDemand struct {
+	StorageGroupID      string  `json:"storageGroupId"`
+	Emulation           string  `json:"emulation"`
+	SubscribedGB        float64 `json:"subscribed_gb"`
+	AllocatedGB         float64 `json:"allocated_gb"`
+	AllocatedPercent    float64 `json:"allocated_percent"`
+	SnapshotAllocatedGB float64 `json:"snapshot_allocated_gb"`
+}
+
+// TotalCapacity returns the sum of the subscribed and allocated capacity (in GB) of all storage groups in the report,
+// giving the demand placed on the storage resource pool.
+func (r *StorageGroupDemandReport) TotalCapacity() (subscribedGB float64, allocatedGB float64) {
+	for _, demand := range r.StorageGroupDemands {
+		subscribedGB += demand.SubscribedGB
+		allocatedGB += demand.AllocatedGB
+	}
+	return subscribedGB, allocatedGB
 }
 
 // StorageGroupResult holds result of an operation
@@ -203,6 +236,17 @@ type RenameStorageGroupParam struct {
 	NewStorageGroupName string `json:"new_storage_Group_name,omitempty"`
 }
 
+// SnapshotPolicyNamesParam holds the names of snapshot policies
+type SnapshotPolicyNamesParam struct {
+	SnapshotPolicyNames []string `json:"snapshot_policy_name"`
+}
+
+// EditSnapshotPoliciesParam holds the snapshot policies to associate with or disassociate from an SG
+type EditSnapshotPoliciesParam struct {
+	AssociateSnapshotPolicyParam    *SnapshotPolicyNamesParam `json:"associateSnapshotPolicyParam,omitempty"`
+	DisassociateSnapshotPolicyParam *SnapshotPolicyNamesParam `json:"disassociateSnapshotPolicyParam,omitempty"`
+}
+
 // EditStorageGroupActionParam holds parameters to modify an SG
 type EditStorageGroupActionParam struct {
 	MergeStorageGroupParam        *MergeStorageGroupParam        `json:"mergeStorageGroupParam,omitempty"`
@@ -218,6 +262,7 @@ type EditStorageGroupActionParam struct {
 	EditStorageGroupSRPParam      *EditStorageGroupSRPParam      `json:"editStorageGroupSRPParam,omitempty"`
 	RemoveStorageGroupParam       *RemoveStorageGroupParam       `json:"removeStorageGroupParam,omitempty"`
 	RenameStorageGroupParam       *RenameStorageGroupParam       `json:"renameStorageGroupParam,omitempty"`
+	EditSnapshotPoliciesParam     *EditSnapshotPoliciesParam     `json:"editSnapshotPoliciesParam,omitempty"`
 }
 
 // ExecutionOptionSynchronous : execute tasks synchronously
@@ -251,3 +296,17 @@ func (vp *UpdateStorageGroupPayload) SetMetaData(metadata http.Header) {
 type UseExistingStorageGroupParam struct {
 	StorageGroupID string `json:"storageGroupId,omitempty"`
 }
+
+// RemoteSymmetrixError is an error reported by Unisphere for a remote array of a storage group
+// update, e.g. the R2 array when volumes are created in a protected storage group
+type RemoteSymmetrixError struct {
+	SymmetrixID    string `json:"symmetrixId"`
+	StorageGroupID string `json:"storageGroupId,omitempty"`
+	Message        string `json:"message"`
+}
+
+// StorageGroupUpdateResult holds the remote array errors embedded in the response to a
+// successful storage group update
+type StorageGroupUpdateResult struct {
+	RemoteSymmetrixErrors []RemoteSymmetrixError `json:"remoteSymmetrixErrors,omitempty"`
+}
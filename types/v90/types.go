@@ -15,6 +15,10 @@
 package types
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
 	"strings"
 )
 
@@ -23,15 +27,54 @@ type Error struct {
 	Message        string `json:"message"`
 	HTTPStatusCode int    `json:"httpStatusCode"`
 	ErrorCode      int    `json:"errorCode"`
+	// RemoteSymmetrixErrors are the errors of the remote arrays involved in the request, if any
+	RemoteSymmetrixErrors []RemoteSymmetrixError `json:"remoteSymmetrixErrors,omitempty"`
+	// BodySnippet is the start of a response body which could not be decoded, e.g. an HTML error page
+	BodySnippet string `json:"-"`
+	// RequestID is the ID Unisphere gave the failed request, to quote when opening a service request
+	RequestID string `json:"-"`
+	// Header holds the headers of the error response
+	Header http.Header `json:"-"`
 }
 
 func (e Error) Error() string {
 	return e.Message
 }
 
+// ErrNotFound matches, with errors.Is, an Error with the HTTP status 404 Not Found
+var ErrNotFound = errors.New("not found")
+
+// Is returns true if target is ErrNotFound and the request failed with 404 Not Found
+func (e Error) Is(target error) bool {
+	return target == ErrNotFound && e.HTTPStatusCode == http.StatusNotFound
+}
+
 // Version : /unixmax/restapi/system/version
 type Version struct {
-	Version string `json:"version"`
+	Version              string   `json:"version"`
+	APIVersion           string   `json:"api_version,omitempty"`
+	SupportedAPIVersions []string `json:"supported_api_versions,omitempty"`
+	BuildDate            string   `json:"build_date,omitempty"`
+}
+
+// UnisphereAPIUsage : request load and capacity of a Unisphere instance
+// /univmax/restapi/private/{version}/system/api_usage
+type UnisphereAPIUsage struct {
+	ActiveRequests           int     `json:"active_requests"`
+	MaxConcurrentRequests    int     `json:"max_concurrent_requests"`
+	RequestsPerMinute        int     `json:"requests_per_minute"`
+	CPUUtilizationPercent    float64 `json:"cpu_utilization_percent"`
+	MemoryUtilizationPercent float64 `json:"memory_utilization_percent"`
+}
+
+// UnisphereInfo : diagnostic information about the connected Unisphere instance
+type UnisphereInfo struct {
+	Version              string
+	APIVersion           string
+	SupportedAPIVersions []string
+	BuildDate            string
+	// APIUsage is nil if the Unisphere instance does not report its load
+	APIUsage *UnisphereAPIUsage
 }
 
 // SymmetrixIDList : contains list of symIDs
@@ -51,6 +94,41 @@ type Symmetrix struct {
 	DiskCount      int    `json:"disk_count"`
 	CacheSizeMB    int    `json:"cache_size_mb"`
 	DataEncryption string `json:"data_encryption"`
+	// SystemTime is the time of the array, in milliseconds since the epoch
+	SystemTime int64 `json:"system_time,omitempty"`
+}
+
+// ArrayHealth : health scores of an array
+// /univmax/restapi/{version}/system/symmetrix/{id}/health
+type ArrayHealth struct {
+	HealthScoreMetrics []HealthScoreMetric `json:"health_score_metric"`
+	NumFailedDisks     int                 `json:"num_failed_disks"`
+}
+
+// HealthScoreMetric : health score, from 0 to 100, of an area of an array such as CONFIGURATION or CAPACITY
+type HealthScoreMetric struct {
+	Metric      string  `json:"metric"`
+	HealthScore float64 `json:"health_score"`
+	// DataDate is when the score was computed, in milliseconds since the epoch
+	DataDate int64 `json:"data_date"`
+	Expired  bool  `json:"expired"`
+}
+
+// SymmetrixRefresh : last time Unisphere refreshed its data about an array
+// /univmax/restapi/{version}/system/symmetrix/{id}/refresh
+type SymmetrixRefresh struct {
+	// LastRefreshTime is in milliseconds since the epoch
+	LastRefreshTime int64 `json:"lastRefreshTime"`
+}
+
+// SLOComplianceSummary : number of storage groups of an array in each service level compliance state
+// /univmax/restapi/{version}/sloprovisioning/symmetrix/{id}/compliance/storagegroup
+type SLOComplianceSummary struct {
+	StorageGroupCount int `json:"storage_group_count"`
+	Stable            int `json:"stable"`
+	Marginal          int `json:"marginal"`
+	Critical          int `json:"critical"`
+	NoCompliance      int `json:"none"`
 }
 
 // StoragePoolList : list of storage pools in the system
@@ -94,6 +172,54 @@ type SrpEfficiency struct {
 	SanpSavingRatioToOne     float32 `json:"snapshot_savings_ratio_to_one"`
 }
 
+// AlertList : list of alert ids
+type AlertList struct {
+	AlertIDs []string `json:"alertId"`
+}
+
+// Alert : an alert raised by an array
+type Alert struct {
+	AlertID                 string `json:"alertId"`
+	State                   string `json:"state"`
+	Severity                string `json:"severity"`
+	Type                    string `json:"type"`
+	Object                  string `json:"object"`
+	ObjectType              string `json:"object_type"`
+	CreatedDate             string `json:"created_date"`
+	CreatedDateMilliseconds int64  `json:"created_date_milliseconds"`
+	Description             string `json:"description"`
+	Acknowledged            bool   `json:"acknowledged"`
+}
+
+// SRPNotificationSettings : capacity alert thresholds of an SRP, as a percentage of its usable capacity
+type SRPNotificationSettings struct {
+	StoragePoolID            string `json:"srpId,omitempty"`
+	AlertsEnabled            bool   `json:"alerts_enabled"`
+	WarningThresholdPercent  int    `json:"warning_threshold_percent"`
+	CriticalThresholdPercent int    `json:"critical_threshold_percent"`
+}
+
+// PerformanceRegistration : registration of an array for the collection of performance data
+type PerformanceRegistration struct {
+	SymmetrixID            string `json:"symmetrixId"`
+	RealTime               bool   `json:"realtime"`
+	Diagnostic             bool   `json:"diagnostic"`
+	CollectionIntervalMins int    `json:"collectionintervalmins,omitempty"`
+	Message                string `json:"message,omitempty"`
+}
+
+// PerformanceRegistrationList : the performance registrations of an array
+type PerformanceRegistrationList struct {
+	RegistrationDetailsInfo []PerformanceRegistration `json:"registrationDetailsInfo"`
+}
+
+// RegisterPerformanceParam : payload registering an array for the collection of performance data
+type RegisterPerformanceParam struct {
+	SymmetrixID string `json:"symmetrixId"`
+	RealTime    bool   `json:"realtime,omitempty"`
+	Diagnostic  bool   `json:"diagnostic,omitempty"`
+}
+
 // constants of storage units
 const (
 	CapacityUnitTb  = "TB"
@@ -108,6 +234,14 @@ type VolumeAttributeType struct {
 	VolumeSize   string `json:"volume_size"`
 }
 
+// Choices of the volume identifier
+const (
+	// VolumeIdentifierChoiceName sets the identifier to IdentifierName
+	VolumeIdentifierChoiceName = "identifier_name"
+	// VolumeIdentifierChoiceNone removes the identifier
+	VolumeIdentifierChoiceNone = "none"
+)
+
 // VolumeIdentifierType : volume identifier
 type VolumeIdentifierType struct {
 	VolumeIdentifierChoice string `json:"volumeIdentifierChoice,omitempty"`
@@ -193,13 +327,22 @@ type PortGroup struct {
 	NumberMaskingViews int64     `json:"number_of_masking_views"`
 	PortGroupType      string    `json:"type"`
 	MaskingView        []string  `json:"maskingview"`
+	PortGroupProtocol  string    `json:"port_group_protocol,omitempty"`
 }
 
+// Protocols of a port group
+const (
+	PortGroupProtocolFibre   = "SCSI_FC"
+	PortGroupProtocolISCSI   = "iSCSI"
+	PortGroupProtocolNVMeTCP = "NVMe_TCP"
+)
+
 // CreatePortGroupParams - Input params for creating port groups
 type CreatePortGroupParams struct {
-	PortGroupID      string    `json:"portGroupId"`
-	SymmetrixPortKey []PortKey `json:"symmetrixPortKey"`
-	ExecutionOption  string    `json:"executionOption"`
+	PortGroupID       string    `json:"portGroupId"`
+	SymmetrixPortKey  []PortKey `json:"symmetrixPortKey"`
+	ExecutionOption   string    `json:"executionOption"`
+	PortGroupProtocol string    `json:"port_group_protocol,omitempty"`
 }
 
 // InitiatorList : list of initiators
@@ -218,13 +361,55 @@ type Initiator struct {
 	HostGroupIDs         []string  `json:"hostGroup,omitempty"`
 	LoggedIn             bool      `json:"logged_in"`
 	OnFabric             bool      `json:"on_fabric"`
+	PortFlagsOverride    bool      `json:"port_flags_override,omitempty"`
+	EnabledFlags         string    `json:"enabled_flags,omitempty"`
+	DisabledFlags        string    `json:"disabled_flags,omitempty"`
 	FlagsInEffect        string    `json:"flags_in_effect"`
 	NumberVols           int64     `json:"num_of_vols"`
 	NumberHostGroups     int64     `json:"num_of_host_groups"`
 	NumberMaskingViews   int64     `json:"number_of_masking_views"`
 	NumberPowerPathHosts int64     `json:"num_of_powerpath_hosts"`
+	// Alias is the alias of the HBA, e.g. "node1/10000090fa66060a"
+	Alias string `json:"alias,omitempty"`
+}
+
+// UnmarshalJSON decodes an Initiator. Some Unisphere versions give the host group of an
+// initiator as a single ID rather than a list, both are decoded into HostGroupIDs.
+func (i *Initiator) UnmarshalJSON(data []byte) error {
+	type initiator Initiator
+	aux := struct {
+		*initiator
+		HostGroupIDs json.RawMessage `json:"hostGroup,omitempty"`
+	}{initiator: (*initiator)(i)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	i.HostGroupIDs = nil
+	hostGroups := bytes.TrimSpace(aux.HostGroupIDs)
+	switch {
+	case len(hostGroups) == 0 || bytes.Equal(hostGroups, []byte("null")):
+		return nil
+	case hostGroups[0] == '"':
+		var hostGroupID string
+		if err := json.Unmarshal(hostGroups, &hostGroupID); err != nil {
+			return err
+		}
+		if hostGroupID != "" {
+			i.HostGroupIDs = []string{hostGroupID}
+		}
+		return nil
+	default:
+		return json.Unmarshal(hostGroups, &i.HostGroupIDs)
+	}
 }
 
+// Types of a host, given by the protocol of its initiators
+const (
+	HostTypeFibre = "Fibre"
+	HostTypeISCSI = "iSCSI"
+	HostTypeNVMe  = "NVMe"
+)
+
 // HostList : list of hosts
 type HostList struct {
 	HostIDs []string `json:"hostId"`
@@ -243,14 +428,52 @@ type Host struct {
 	HostType           string   `json:"type"`
 	Initiators         []string `json:"initiator"`
 	MaskingviewIDs     []string `json:"maskingview"`
+	HostGroupIDs       []string `json:"hostgroup,omitempty"`
 	NumPowerPathHosts  int64    `json:"num_of_powerpath_hosts"`
 }
 
+// HostGroupList : list of host groups
+type HostGroupList struct {
+	HostGroupIDs []string `json:"hostGroupId"`
+}
+
+// HostGroupHost : a host of a host group and its initiators
+type HostGroupHost struct {
+	HostID     string   `json:"hostId"`
+	Initiators []string `json:"initiator"`
+}
+
+// HostGroup : Information about a host group. The flags of a host group apply to its
+// hosts, unless the flags of a host override them.
+type HostGroup struct {
+	HostGroupID        string          `json:"hostGroupId"`
+	NumberMaskingViews int64           `json:"num_of_masking_views"`
+	NumberInitiators   int64           `json:"num_of_initiators"`
+	NumberHosts        int64           `json:"num_of_hosts"`
+	PortFlagsOverride  bool            `json:"port_flags_override"`
+	ConsistentLun      bool            `json:"consistent_lun"`
+	EnabledFlags       string          `json:"enabled_flags"`
+	DisabledFlags      string          `json:"disabled_flags"`
+	HostGroupType      string          `json:"type"`
+	Hosts              []HostGroupHost `json:"host"`
+	MaskingviewIDs     []string        `json:"maskingview"`
+}
+
 // DirectorIDList : list of directors
 type DirectorIDList struct {
 	DirectorIDs []string `json:"directorId"`
 }
 
+// Director : information about a director
+type Director struct {
+	DirectorID         string `json:"directorId"`
+	DirectorNumber     int64  `json:"director_number,omitempty"`
+	DirectorSlotNumber int64  `json:"director_slot_number,omitempty"`
+	Availability       string `json:"availability,omitempty"`
+	NumberOfPorts      int64  `json:"num_of_ports,omitempty"`
+	NumberOfCores      int64  `json:"num_of_cores,omitempty"`
+}
+
 // PortList : list of ports
 type PortList struct {
 	SymmetrixPortKey []PortKey `json:"symmetrixPortKey"`
@@ -258,13 +481,62 @@ type PortList struct {
 
 // SymmetrixPortType : type of symmetrix port
 type SymmetrixPortType struct {
-	ISCSITarget bool     `json:"iscsi_target,omitempty"`
-	IPAddresses []string `json:"ip_addresses,omitempty"`
-	Identifier  string   `json:"identifier,omitempty"`
-	Type        string   `json:"type,omitempty"`
+	SymmetrixPortKey      *PortKey `json:"symmetrixPortKey,omitempty"`
+	PortStatus            string   `json:"port_status,omitempty"`
+	DirectorStatus        string   `json:"director_status,omitempty"`
+	NumberOfCores         int64    `json:"num_of_cores,omitempty"`
+	NegotiatedSpeed       string   `json:"negotiated_speed,omitempty"`
+	MaxSpeed              string   `json:"max_speed,omitempty"`
+	NumberOfPortGroups    int64    `json:"num_of_port_groups,omitempty"`
+	NumberOfMaskingViews  int64    `json:"num_of_masking_views,omitempty"`
+	NumberOfMappedVolumes int64    `json:"num_of_mapped_vols,omitempty"`
+	VCMState              string   `json:"vcm_state,omitempty"`
+	AclX                  bool     `json:"aclx,omitempty"`
+	PortGroups            []string `json:"portgroup,omitempty"`
+	MaskingViews          []string `json:"maskingview,omitempty"`
+	ISCSITarget           bool     `json:"iscsi_target,omitempty"`
+	IPAddresses           []string `json:"ip_addresses,omitempty"`
+	Identifier            string   `json:"identifier,omitempty"`
+	Type                  string   `json:"type,omitempty"`
 }
 
 // Port is a minimal represation of a Symmetrix Port for iSCSI target purpose
 type Port struct {
 	SymmetrixPort SymmetrixPortType `json:"symmetrixPort"`
 }
+
+// IPInterfaceList : list of the IP interfaces of a port
+type IPInterfaceList struct {
+	IPInterfaceIDs []string `json:"ipInterfaceId"`
+}
+
+// IPInterface : an IP interface of a port of an SE director, on which iSCSI targets
+// advertise their portals. A VLANID of 0 is an untagged interface.
+type IPInterface struct {
+	IPInterfaceID  string `json:"ip_interface_id"`
+	IPAddress      string `json:"ip_address"`
+	IPPrefixLength int    `json:"ip_prefix_length"`
+	NetworkID      int    `json:"network_id"`
+	VLANID         int    `json:"vlan_id"`
+	MTU            int    `json:"mtu,omitempty"`
+}
+
+// CreateIPInterfaceParam : parameters to create an IP interface on a port
+type CreateIPInterfaceParam struct {
+	IPAddress      string `json:"ip_address"`
+	IPPrefixLength int    `json:"ip_prefix_length"`
+	NetworkID      int    `json:"network_id"`
+	VLANID         int    `json:"vlan_id,omitempty"`
+	MTU            int    `json:"mtu,omitempty"`
+}
+
+// EditPortActionParam : the change made to a port
+type EditPortActionParam struct {
+	CreateIPInterfaceParam *CreateIPInterfaceParam `json:"createIPInterfaceParam,omitempty"`
+}
+
+// EditPortParam : payload to edit a port
+type EditPortParam struct {
+	EditPortActionParam EditPortActionParam `json:"editPortActionParam"`
+	ExecutionOption     string              `json:"executionOption"`
+}
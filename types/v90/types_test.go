@@ -0,0 +1,64 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func Test_InitiatorHostGroups(t *testing.T) {
+	var tests = []struct {
+		name       string
+		json       string
+		hostGroups []string
+		wantErr    bool
+	}{
+		{"list", `{"initiatorId": "FA-1D:4:10000090fa66060a", "hostGroup": ["hg1", "hg2"], "logged_in": true}`, []string{"hg1", "hg2"}, false},
+		{"single ID", `{"initiatorId": "FA-1D:4:10000090fa66060a", "hostGroup": "hg1", "logged_in": true}`, []string{"hg1"}, false},
+		{"empty ID", `{"initiatorId": "FA-1D:4:10000090fa66060a", "hostGroup": "", "logged_in": true}`, nil, false},
+		{"null", `{"initiatorId": "FA-1D:4:10000090fa66060a", "hostGroup": null, "logged_in": true}`, nil, false},
+		{"absent", `{"initiatorId": "FA-1D:4:10000090fa66060a", "logged_in": true}`, nil, false},
+		{"bad type", `{"initiatorId": "FA-1D:4:10000090fa66060a", "hostGroup": 1}`, nil, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			initiator := &Initiator{}
+			err := json.Unmarshal([]byte(tt.json), initiator)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v", tt.json, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(initiator.HostGroupIDs, tt.hostGroups) {
+				t.Errorf("HostGroupIDs = %v; expected %v", initiator.HostGroupIDs, tt.hostGroups)
+			}
+			if initiator.InitiatorID != "FA-1D:4:10000090fa66060a" || !initiator.LoggedIn {
+				t.Errorf("the other fields were not decoded: %+v", initiator)
+			}
+			encoded, err := json.Marshal(initiator)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded := &Initiator{}
+			if err = json.Unmarshal(encoded, decoded); err != nil || !reflect.DeepEqual(decoded, initiator) {
+				t.Errorf("round trip of %s gave %+v, %v", encoded, decoded, err)
+			}
+		})
+	}
+}
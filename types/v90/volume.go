@@ -65,11 +65,38 @@ type Volume struct {
 	Message          string                 `json:"message"`
 	SnapSource       bool                   `json:"snapvx_source"`
 	SnapTarget       bool                   `json:"snapvx_target"`
+	// Fields returned by newer Unisphere versions
+	StorageGroups     []VolumeStorageGroup `json:"storage_groups,omitempty"`
+	UnreducibleDataGB float64              `json:"unreducible_data_gb,omitempty"`
+	MobilityIDEnabled bool                 `json:"mobility_id_enabled,omitempty"`
+	NGUID             string               `json:"nguid,omitempty"`
+}
+
+// VolumeStorageGroup is a storage group of a volume, as listed by newer Unisphere
+// versions in place of storageGroupId
+type VolumeStorageGroup struct {
+	StorageGroupName       string `json:"storage_group_name"`
+	ParentStorageGroupName string `json:"parent_storage_group_name,omitempty"`
+}
+
+// StorageGroupNames returns the storage groups of the volume, from storageGroupId or,
+// if Unisphere did not return it, from storage_groups
+func (v *Volume) StorageGroupNames() []string {
+	if len(v.StorageGroupIDList) > 0 || len(v.StorageGroups) == 0 {
+		return v.StorageGroupIDList
+	}
+	names := make([]string, 0, len(v.StorageGroups))
+	for _, sg := range v.StorageGroups {
+		names = append(names, sg.StorageGroupName)
+	}
+	return names
 }
 
 // RDFGroupID contains the group number
 type RDFGroupID struct {
 	RDFGroupNumber int `json:"rdf_group_number"`
+	// Label is only returned by newer Unisphere versions
+	Label string `json:"label,omitempty"`
 }
 
 // FreeVolumeParam : boolean value representing data to be freed
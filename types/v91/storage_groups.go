@@ -215,6 +215,17 @@ type RenameStorageGroupParam struct {
 	NewStorageGroupName string `json:"new_storage_Group_name,omitempty"`
 }
 
+// SnapshotPolicyNamesParam holds the names of snapshot policies
+type SnapshotPolicyNamesParam struct {
+	SnapshotPolicyNames []string `json:"snapshot_policy_name"`
+}
+
+// EditSnapshotPoliciesParam holds the snapshot policies to associate with or disassociate from an SG
+type EditSnapshotPoliciesParam struct {
+	AssociateSnapshotPolicyParam    *SnapshotPolicyNamesParam `json:"associateSnapshotPolicyParam,omitempty"`
+	DisassociateSnapshotPolicyParam *SnapshotPolicyNamesParam `json:"disassociateSnapshotPolicyParam,omitempty"`
+}
+
 // EditStorageGroupActionParam holds parameters to modify an SG
 type EditStorageGroupActionParam struct {
 	MergeStorageGroupParam        *MergeStorageGroupParam        `json:"mergeStorageGroupParam,omitempty"`
@@ -230,6 +241,7 @@ type EditStorageGroupActionParam struct {
 	EditStorageGroupSRPParam      *EditStorageGroupSRPParam      `json:"editStorageGroupSRPParam,omitempty"`
 	RemoveStorageGroupParam       *RemoveStorageGroupParam       `json:"removeStorageGroupParam,omitempty"`
 	RenameStorageGroupParam       *RenameStorageGroupParam       `json:"renameStorageGroupParam,omitempty"`
+	EditSnapshotPoliciesParam     *EditSnapshotPoliciesParam     `json:"editSnapshotPoliciesParam,omitempty"`
 }
 
 // ExecutionOptionSynchronous : execute tasks synchronously
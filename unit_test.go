@@ -85,6 +85,9 @@ func TestMain(m *testing.M) {
 	runOptions.Paths = testPaths
 	runOptions.Tags = testTags
 
+	// The mock server does not use TLS
+	os.Setenv("X_CSI_UNISPHERE_ALLOW_HTTP", "true")
+
 	// Start the mock server.
 	handler := mock.GetHandler()
 	mockServer = httptest.NewServer(handler)
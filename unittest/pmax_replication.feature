@@ -5,6 +5,74 @@ Feature: PMAX replication test
     When I excute the capabilities on the symmetrix array
     Then the error message contains "none"
 
+  Scenario Outline: Test cases for GetArrayCapabilities
+    Given a valid connection
+    And I have a new client with capability refresh interval "1h"
+    And I have an allowed list of <arrays>
+    And the mock reports <vvol> as vVol capable
+    And the mock reports <file> as file capable
+    And I induce error <induced>
+    When I call GetArrayCapabilities "000197900046"
+    Then the error message contains <errormsg>
+    And the array capabilities are <capabilities> if no error
+
+    Examples:
+      | vvol                        | file           | induced                  | errormsg                        | capabilities           | arrays         |
+      | ""                          | ""             | "none"                   | "none"                          | "snapvx,rdf"           | ""             |
+      | "000197900046"              | ""             | "none"                   | "none"                          | "snapvx,rdf,vvol"      | ""             |
+      | "000197900047,000197900046" | "000197900046" | "none"                   | "none"                          | "snapvx,rdf,vvol,file" | ""             |
+      | "000197900047"              | "unsupported"  | "none"                   | "none"                          | "snapvx,rdf"           | ""             |
+      | "unsupported"               | "unsupported"  | "SnapshotNotLicensed"    | "none"                          | "rdf"                  | ""             |
+      | ""                          | ""             | "UnisphereMismatchError" | "are not reported by Unisphere" | ""                     | ""             |
+      | ""                          | ""             | "InvalidResponse"        | "induced error"                 | ""                     | ""             |
+      | ""                          | ""             | "httpStatus500"          | "Internal Error"                | ""                     | ""             |
+      | ""                          | ""             | "none"                   | "ignored as it is not managed"  | ""                     | "000197900047" |
+
+  Scenario: GetArrayCapabilities caches the capabilities of the array
+    Given a valid connection
+    And I have a new client with capability refresh interval "1h"
+    And I call GetArrayCapabilities "000197900046"
+    And the array capabilities are "snapvx,rdf" if no error
+    And the mock reports "000197900046" as vVol capable
+    When I call GetArrayCapabilities "000197900046"
+    Then the error message contains "none"
+    And the array capabilities are "snapvx,rdf" if no error
+    And the mock served 1 capability requests
+
+  Scenario: ForceRefreshArrayCapabilities reads the capabilities again
+    Given a valid connection
+    And I have a new client with capability refresh interval "1h"
+    And I call GetArrayCapabilities "000197900046"
+    And the mock reports "000197900046" as file capable
+    And I call ForceRefreshArrayCapabilities "000197900046"
+    And the array capabilities are "snapvx,rdf,file" if no error
+    When I call GetArrayCapabilities "000197900046"
+    Then the error message contains "none"
+    And the array capabilities are "snapvx,rdf,file" if no error
+    And the mock served 2 capability requests
+
+  Scenario: GetArrayCapabilities refreshes the capabilities after the refresh interval
+    Given a valid connection
+    And I have a new client with capability refresh interval "50ms"
+    And I call GetArrayCapabilities "000197900046"
+    And I induce error "SnapshotNotLicensed"
+    And I wait "100ms"
+    When I call GetArrayCapabilities "000197900046"
+    Then the error message contains "none"
+    And the array capabilities are "rdf" if no error
+    And the mock served 2 capability requests
+
+  Scenario: GetArrayCapabilities does not cache the failures
+    Given a valid connection
+    And I have a new client with capability refresh interval "1h"
+    And I induce error "InvalidResponse"
+    And I call GetArrayCapabilities "000197900046"
+    And the error message contains "induced error"
+    And I induce error "none"
+    When I call GetArrayCapabilities "000197900046"
+    Then the error message contains "none"
+    And the array capabilities are "snapvx,rdf" if no error
+
   Scenario Outline: Create a snapshot on a source volume
     Given a valid connection
     And I have 5 volumes 
@@ -37,6 +105,50 @@ Feature: PMAX replication test
       | ""               |  ""        |  "ignored as it is not managed"   | "none"              | "ignored" |
 
 
+  Scenario Outline: List volumes with snapshots through the public or the private endpoint
+    Given a valid connection
+    And I have a new client with API version <version>
+    And I have 2 volumes
+    And I call CreateSnapshot with "00001,00002" and snapshot "snapshot1" on it
+    And the mock <public> the public snapshot volume list
+    And the mock <private> the private routes
+    When I call GetSnapVolumeList with <queryKey> and <queryValue>
+    Then the error message contains <errormsg>
+    And I should get a list of volumes having snapshots if no error
+
+    Examples:
+      | version | public | private | queryKey         | queryValue | errormsg    |
+      | "91"    | has    | blocks  | ""               | ""         | "none"      |
+      | "91"    | has    | blocks  | "includeDetails" | "true"     | "none"      |
+      | "91"    | lacks  | allows  | ""               | ""         | "none"      |
+      | "91"    | lacks  | blocks  | ""               | ""         | "Forbidden" |
+      | "90"    | has    | allows  | ""               | ""         | "none"      |
+      | "90"    | has    | blocks  | ""               | ""         | "Forbidden" |
+
+  Scenario: The private snapshot volume list is used once the public one is missing
+    Given a valid connection
+    And I have a new client with API version "91"
+    And I have 2 volumes
+    And I call CreateSnapshot with "00001,00002" and snapshot "snapshot1" on it
+    And the mock lacks the public snapshot volume list
+    And I call GetSnapVolumeList with "" and ""
+    And the error message contains "none"
+    And the mock has the public snapshot volume list
+    And the mock blocks the private routes
+    When I call GetSnapVolumeList with "" and ""
+    Then the error message contains "Forbidden"
+
+  Scenario: The public snapshot volume list is kept after listing an unknown array
+    Given a valid connection
+    And I have a new client with API version "91"
+    And I have 2 volumes
+    And I call CreateSnapshot with "00001,00002" and snapshot "snapshot1" on it
+    And I call GetSnapVolumeList on array "000000000001"
+    And the error message contains "Symmetrix not found"
+    And the mock blocks the private routes
+    When I call GetSnapVolumeList with "" and ""
+    Then the error message contains "none"
+
   Scenario Outline: List all Snapshot for a volume
     Given a valid connection
     And I have an allowed list of <arrays>
@@ -214,6 +326,214 @@ Feature: PMAX replication test
     |    "00001"       |   "00003"       | "snapshot1" |  "Unlink"  |  "already in desired state" |
     |    "00001,00003" |   "00004,00004" | "snapshot1" |  "Unlink"  |  "already in desired state" |
  
+  Scenario Outline: Linking and unlinking a snapshot with the typed wrappers
+    Given a valid connection
+    And I have an allowed list of <arrays>
+    And I have 4 volumes
+    And I call CreateSnapshot with "00001,00002" and snapshot "snapshot1" on it
+    When I call <action> with <source>, <target>, <snapID>, 0 and copy <copy>
+    Then the error message contains <errormsg>
+    And I should get a valid response if no error
+
+    Examples:
+    | action           | source        | target        | snapID      | copy    | errormsg                          | arrays    |
+    | LinkSnapshot     | "00001"       | "00003"       | "snapshot1" | "false" | "none"                            |    ""     |
+    | LinkSnapshot     | "00001,00002" | "00003,00004" | "snapshot1" | "true"  | "none"                            |    ""     |
+    | LinkSnapshot     | "00001,00002" | "00003"       | "snapshot1" | "false" | "1 target volumes given for 2"    |    ""     |
+    | LinkSnapshot     | ""            | "00003"       | "snapshot1" | "false" | "source volume name must not be"  |    ""     |
+    | LinkSnapshot     | "00001"       | ""            | "snapshot1" | "false" | "target volume name must not be"  |    ""     |
+    | LinkSnapshot     | "00001"       | "00003"       | ""          | "false" | "snapshot name must be supplied"  |    ""     |
+    | LinkSnapshot     | "00003"       | "00004"       | "snapshot1" | "false" | "no snapshot information"         |    ""     |
+    | LinkSnapshot     | "00001"       | "00003"       | "snapshot1" | "false" | "ignored as it is not managed"    | "ignored" |
+    | UnlinkSnapshot   | "00001"       | "00003"       | "snapshot1" | "false" | "already in desired state"        |    ""     |
+    | UnlinkSnapshot   | "00001"       | ""            | "snapshot1" | "false" | "target volume name must not be"  |    ""     |
+
+  Scenario Outline: Relinking a snapshot and setting its copy mode with the typed wrappers
+    Given a valid connection
+    And I have an allowed list of <arrays>
+    And I have 4 volumes
+    And I induce error <induced>
+    And I call CreateSnapshot with "00001,00002" and snapshot "snapshot1" on it
+    And I call LinkSnapshot with "00001", "00003", "snapshot1", 0 and copy "false"
+    When I call <action> with <source>, <target>, "snapshot1", 0 and copy <copy>
+    Then the error message contains <errormsg>
+    And the snapshot "snapshot1" link from <source> to <target> has copy <copy> if no error
+
+    Examples:
+    | action            | source  | target  | copy    | errormsg                         | induced             | arrays    |
+    | SetSnapshotMode   | "00001" | "00003" | "true"  | "none"                           | "none"              |    ""     |
+    | SetSnapshotMode   | "00001" | "00003" | "false" | "none"                           | "none"              |    ""     |
+    | SetSnapshotMode   | "00002" | "00004" | "true"  | "devices not in the linked state"| "none"              |    ""     |
+    | RelinkSnapshot    | "00001" | "00003" | "true"  | "none"                           | "none"              |    ""     |
+    | RelinkSnapshot    | "00001" | "00004" | "false" | "devices not in the linked state"| "none"              |    ""     |
+    | RelinkSnapshot    | "00001" | "00003" | "false" | "induced error"                  | "LinkSnapshotError" |    ""     |
+    | UnlinkSnapshot    | "00001" | "00004" | "false" | "already in desired state"       | "none"              |    ""     |
+    | SetSnapshotMode   | "00001" | "00003" | "true"  | "ignored as it is not managed"   | "none"              | "ignored" |
+
+  Scenario Outline: Waiting for a linked snapshot target to be defined
+    Given a valid connection
+    And I have an allowed list of <arrays>
+    And I have 4 volumes
+    And I call CreateSnapshot with "00001" and snapshot "snapshot1" on it
+    And snapshot link targets are defined after <polls> polls
+    And I call LinkSnapshot with "00001", "00002", "snapshot1", 0 and copy "false"
+    And I induce error <induced>
+    When I call WaitForSnapshotLinkDefined with <source>, "snapshot1", <target> and timeout <timeout>
+    Then the error message contains <errormsg>
+
+    Examples:
+    | source  | target  | polls | timeout | errormsg                         | induced             | arrays    |
+    | "00001" | "00002" | 1     | 1000    | "none"                           | "none"              |    ""     |
+    | "00001" | "00002" | 4     | 1000    | "none"                           | "none"              |    ""     |
+    | "00001" | "00002" | 0     | 200     | "timed out waiting for link"     | "none"              |    ""     |
+    | "00001" | "00003" | 1     | 1000    | "is not linked to volume 00003"  | "none"              |    ""     |
+    | "00002" | "00002" | 1     | 1000    | "is not linked to volume 00002"  | "none"              |    ""     |
+    | "00007" | "00002" | 1     | 1000    | "cannot be found"                | "none"              |    ""     |
+    | "00001" | "00002" | 1     | 1000    | "ignored as it is not managed"   | "none"              | "ignored" |
+
+  Scenario Outline: Creating a volume from a snapshot
+    Given a valid connection
+    And I have an allowed list of <arrays>
+    And I have 4 volumes
+    And I call CreateSnapshot with "00001" and snapshot "snapshot1" on it
+    And I call LinkSnapshot with "00001", "00002", "snapshot1", 0 and copy "false"
+    And I induce error <induced>
+    When I call CreateVolumeFromSnapshot with <source>, snapshot "snapshot1", name <volname>, size <size> and copy <copy>
+    Then the error message contains <errormsg>
+    And the new volume has size <expsize> and is linked to snapshot "snapshot1" of <source> with copy <copy> if no error
+
+    Examples:
+    | source  | volname    | size | copy    | expsize | errormsg                             | induced             | arrays    |
+    | "00001" | "NewVol"   | 0    | "false" | 7       | "none"                               | "none"              |    ""     |
+    | "00001" | "NewVol"   | 10   | "true"  | 10      | "none"                               | "none"              |    ""     |
+    | "00001" | "Vol00002" | 7    | "false" | 7       | "none"                               | "none"              |    ""     |
+    | "00001" | "Vol00003" | 0    | "true"  | 7       | "none"                               | "none"              |    ""     |
+    | "00001" | "NewVol"   | 5    | "false" | 5       | "is smaller than the size 7 CYL"     | "none"              |    ""     |
+    | "00001" | "Vol00003" | 10   | "false" | 10      | "already exists with size 7 CYL"     | "none"              |    ""     |
+    | "00003" | "NewVol"   | 0    | "false" | 7       | "couldn't link snapshot (snapshot1)" | "none"              |    ""     |
+    | "00009" | "NewVol"   | 0    | "false" | 7       | "cannot be found"                    | "none"              |    ""     |
+    | "00001" | "NewVol"   | 0    | "false" | 7       | "induced error"                      | "LinkSnapshotError" |    ""     |
+    | "00001" | "NewVol"   | 0    | "false" | 7       | "ignored as it is not managed"       | "none"              | "ignored" |
+
+  Scenario Outline: Linking the snapshot of a storage group to a new storage group
+    Given a valid connection
+    And I have an allowed list of <arrays>
+    And I have a StorageGroup "SrcSG" with volumes "00011,00012,00013"
+    And I have a StorageGroup "EmptySG" with volumes ""
+    And I call CreateSnapshot with "00011,00012,00013" and snapshot "snapshot1" on it
+    And I induce error <induced>
+    When I call LinkSnapshotToNewStorageGroup <snapshot> of <source> to <target> with copy <copy>
+    Then the error message contains <errormsg>
+    And the snapshot <snapshot> of volumes "00011,00012,00013" is linked to the volumes of <target> with copy <copy> if no error
+
+    Examples:
+    | snapshot    | source    | target  | copy    | errormsg                                   | induced                   | arrays    |
+    | "snapshot1" | "SrcSG"   | "TgtSG" | "false" | "none"                                     | "none"                    | ""        |
+    | "snapshot1" | "SrcSG"   | "TgtSG" | "true"  | "none"                                     | "none"                    | ""        |
+    | "snapshot2" | "SrcSG"   | "TgtSG" | "false" | "couldn't link snapshot (snapshot2)"       | "none"                    | ""        |
+    | ""          | "SrcSG"   | "TgtSG" | "false" | "snapshot name must be supplied"           | "none"                    | ""        |
+    | "snapshot1" | "SrcSG"   | "SrcSG" | "false" | "must be supplied and differ"              | "none"                    | ""        |
+    | "snapshot1" | "EmptySG" | "TgtSG" | "false" | "has no volumes to link snapshot"          | "none"                    | ""        |
+    | "snapshot1" | "NoSG"    | "TgtSG" | "false" | "not found"                                | "none"                    | ""        |
+    | "snapshot1" | "SrcSG"   | "TgtSG" | "false" | "couldn't create the target storage group" | "CreateStorageGroupError" | ""        |
+    | "snapshot1" | "SrcSG"   | "TgtSG" | "false" | "induced error"                            | "LinkSnapshotError"       | ""        |
+    | "snapshot1" | "SrcSG"   | "TgtSG" | "false" | "ignored as it is not managed"             | "none"                    | "ignored" |
+
+  Scenario: Linking the snapshot of a storage group to an existing empty storage group
+    Given a valid connection
+    And I have a StorageGroup "SrcSG" with volumes "00011,00012"
+    And I have a StorageGroup "TgtSG" with volumes ""
+    And I call CreateSnapshot with "00011,00012" and snapshot "snapshot1" on it
+    When I call LinkSnapshotToNewStorageGroup "snapshot1" of "SrcSG" to "TgtSG" with copy "false"
+    Then the error message contains "none"
+    And the snapshot "snapshot1" of volumes "00011,00012" is linked to the volumes of "TgtSG" with copy "false" if no error
+
+  Scenario: Linking the snapshot of a storage group to a storage group which is not empty
+    Given a valid connection
+    And I have a StorageGroup "SrcSG" with volumes "00011,00012"
+    And I call CreateSnapshot with "00011,00012" and snapshot "snapshot1" on it
+    And I induce error "LinkSnapshotError"
+    When I call LinkSnapshotToNewStorageGroup "snapshot1" of "SrcSG" to "TgtSG" with copy "false"
+    Then the error message contains "induced error"
+    When I induce error "none"
+    And I call LinkSnapshotToNewStorageGroup "snapshot1" of "SrcSG" to "TgtSG" with copy "false"
+    Then the error message contains "the target storage group TgtSG is not empty"
+
+  Scenario Outline: Cloning a volume through a temporary snapshot
+    Given a valid connection
+    And I have an allowed list of <arrays>
+    And I have 4 volumes
+    And I induce error <induced>
+    And snapshot link copies complete after <polls> polls
+    When I call CloneVolumeFull of <source> with name "Clone1", size <size> and auto cleanup <auto>
+    Then the error message contains <errormsg>
+    And I finalize the clone if no error
+    And the error message contains <finalizeerr>
+    And the clone snapshot of <source> is terminated if no error
+
+    Examples:
+    | source  | size | auto    | polls | errormsg                       | finalizeerr                                | induced               | arrays    |
+    | "00001" | 0    | "false" | 0     | "none"                         | "none"                                     | "none"                |    ""     |
+    | "00001" | 10   | "true"  | 0     | "none"                         | "none"                                     | "none"                |    ""     |
+    | "00001" | 0    | "true"  | 3     | "none"                         | "none"                                     | "none"                |    ""     |
+    | "00001" | 0    | "false" | 3     | "none"                         | "none"                                     | "none"                |    ""     |
+    | "00001" | 0    | "true"  | -1    | "none"                         | "timed out waiting for link"               | "none"                |    ""     |
+    | "00001" | 0    | "false" | 0     | "none"                         | "couldn't terminate snapshot (gpmx_clone_" | "DeleteSnapshotError" |    ""     |
+    | "00001" | 5    | "false" | 0     | "is smaller than the size"     | "is smaller than the size"                 | "none"                |    ""     |
+    | "00009" | 0    | "false" | 0     | "cannot be found"              | "cannot be found"                          | "none"                |    ""     |
+    | "00001" | 0    | "false" | 0     | "induced error"                | "induced error"                            | "LinkSnapshotError"   |    ""     |
+    | "00001" | 0    | "false" | 0     | "ignored as it is not managed" | "ignored as it is not managed"             | "none"                | "ignored" |
+
+  Scenario: Cancelling the background finalization of a clone
+    Given a valid connection
+    And I have 4 volumes
+    And snapshot link copies complete after -1 polls
+    When I call CloneVolumeFull of "00001" with name "Clone1", size 0 and auto cleanup "true"
+    And I cancel the clone if no error
+    And I finalize the clone if no error
+    Then the error message contains "context canceled"
+
+  Scenario Outline: Recovering the temporary snapshots of clones
+    Given a valid connection
+    And I have an allowed list of <arrays>
+    And I have 4 volumes
+    And new snapshots are 7200 seconds old
+    And I call CloneVolumeFull of "00001" with name "Clone1", size 0 and auto cleanup "false"
+    And I call CreateSnapshot with "00003" and snapshot "gpmx_clone_orphan" on it
+    And new snapshots are 0 seconds old
+    And I call CreateSnapshot with "00004" and snapshot "gpmx_clone_recent" on it
+    And snapshot link copies complete after -1 polls
+    And I call CloneVolumeFull of "00002" with name "Clone2", size 0 and auto cleanup "false"
+    And I call CreateSnapshot with "00004" and snapshot "snapshot1" on it
+    And I induce error <induced>
+    When I call RecoverCloneSnapshots with grace period <grace> seconds
+    Then the error message contains <errormsg>
+    And the recovered clone snapshots are <recovered> if no error
+
+    Examples:
+    | recovered                                                                  | grace | errormsg                       | induced             | arrays    |
+    | "00001/gpmx_clone_Clone1,00003/gpmx_clone_orphan"                          | 3600  | "none"                         | "none"              |    ""     |
+    | "00001/gpmx_clone_Clone1,00003/gpmx_clone_orphan,00004/gpmx_clone_recent"  | 0     | "none"                         | "none"              |    ""     |
+    | ""                                                                         | 3600  | "induced error"                | "GetSymVolumeError" |    ""     |
+    | ""                                                                         | 3600  | "couldn't recover all clone"   | "GetVolSnapsError"  |    ""     |
+    | ""                                                                         | 3600  | "ignored as it is not managed" | "none"              | "ignored" |
+
+  Scenario Outline: Renaming a snapshot with the typed wrapper
+    Given a valid connection
+    And I have 3 volumes
+    And I call CreateSnapshot with "00001" and snapshot "snapshot1" on it
+    When I call RenameSnapshot with <source>, <snapID>, <newSnapID> and <genID>
+    Then the error message contains <errormsg>
+    And I should get a valid response if no error
+
+    Examples:
+    | source  | snapID      | newSnapID      | genID | errormsg                                 |
+    | "00001" | "snapshot1" | "snapshot_csi" | 0     | "none"                                   |
+    | "00002" | "snapshot1" | "snapshot_csi" | 0     | "no snapshot information"                |
+    | "00001" | "snapshot1" | ""             | 0     | "a new, different snapshot name"         |
+    | "00001" | "snapshot1" | "snapshot1"    | 0     | "a new, different snapshot name"         |
+    | "00001" | "snapshot1" | "snapshot_csi" | -1    | "invalid generation -1"                  |
+
   Scenario Outline: Delete a snapshot
     Given a valid connection
     And I have an allowed list of <arrays>
@@ -280,4 +600,103 @@ Feature: PMAX replication test
     | "00004" | "none"                         |   ""      | "none"                   |
     | "00007" | "cannot be found"              |   ""      | "none"                   |
     | "00001" | "ignored as it is not managed" | "ignored" | "none"                   |
-    | "00001" | "induced error"                |   ""      | "GetPrivVolumeByIDError" |
\ No newline at end of file
+    | "00001" | "induced error"                |   ""      | "GetPrivVolumeByIDError" |
+
+  Scenario Outline: Listing private volumes by WWN across the pages of the iterator
+    Given a valid connection
+    And I have 25 volumes
+    And I have an allowed list of <arrays>
+    And I call CreateSnapshot with "00001" and snapshot "snapshot1" on it
+    And I induce error <induced>
+    When I call GetPrivVolumeList with WWN <wwn> and like <like>
+    Then the error message contains <errormsg>
+    And I get <count> distinct private volumes if no error
+
+    Examples:
+    | wwn                                | like    | count | errormsg                                 | arrays    | induced                      |
+    | ""                                 | "false" | 25    | "none"                                   |   ""      | "none"                       |
+    | "60000970000197900046533030300012" | "false" | 1     | "none"                                   |   ""      | "none"                       |
+    | "60000970000197900046533030300099" | "false" | 0     | "none"                                   |   ""      | "none"                       |
+    | "303030001"                        | "true"  | 10    | "none"                                   |   ""      | "none"                       |
+    | "6533030300"                       | "true"  | 25    | "none"                                   |   ""      | "none"                       |
+    | ""                                 | "false" | 25    | "returned 5 volumes from 11 to 20"       |   ""      | "PrivVolumePartialPageError" |
+    | "303030001"                        | "true"  | 10    | "none"                                   |   ""      | "PrivVolumePartialPageError" |
+    | ""                                 | "false" | 25    | "induced error"                          |   ""      | "GetPrivateVolumeIterator"   |
+    | ""                                 | "false" | 25    | "ignored as it is not managed"           | "ignored" | "none"                       |
+  Scenario Outline: Testing BuildSnapshotTopology
+    Given a valid connection
+    And I have 5 volumes
+    And I have an allowed list of <arrays>
+    And I call CreateSnapshot with "00001" and snapshot "snapshot1" on it
+    And I call ModifySnapshot with "00001,00001", "00002,00004", "snapshot1", "", 0 and "Link"
+    And I call CreateSnapshot with "00002" and snapshot "snapshot2" on it
+    And I call ModifySnapshot with "00002", "00003", "snapshot2", "", 0 and "Link"
+    And I induce error <induced>
+    When I call BuildSnapshotTopology with <volIDs>
+    Then the error message contains <errormsg>
+    And the snapshot topology has volumes <volumes>
+    And the snapshot topology origin of "00003" is <origin>
+
+    Examples:
+    | volIDs        | volumes                   | origin                               | errormsg                       | arrays    | induced                  |
+    | "00003"       | "00001,00002,00003,00004" | "00002:snapshot2,00001:snapshot1"    | "none"                         | ""        | "none"                   |
+    | "00001"       | "00001,00002,00003,00004" | "00002:snapshot2,00001:snapshot1"    | "none"                         | ""        | "none"                   |
+    | "00005"       | "00005"                   | ""                                   | "none"                         | ""        | "none"                   |
+    | "00003,00007" | "00001,00002,00003,00004" | "00002:snapshot2,00001:snapshot1"    | "failed to read 1 volumes"     | ""        | "none"                   |
+    | "00003"       | ""                        | ""                                   | "induced error"                | ""        | "GetPrivVolumeByIDError" |
+
+  Scenario: BuildSnapshotTopology on an array which is not managed
+    Given a valid connection
+    And I have an allowed list of "ignored"
+    When I call BuildSnapshotTopology with "00001"
+    Then the error message contains "ignored as it is not managed"
+
+  Scenario: BuildSnapshotTopology lists the targets of a snapshot
+    Given a valid connection
+    And I have 4 volumes
+    And I call CreateSnapshot with "00001" and snapshot "snapshot1" on it
+    And I call ModifySnapshot with "00001,00001", "00002,00003", "snapshot1", "", 0 and "Link"
+    When I call BuildSnapshotTopology with "00001"
+    Then the error message contains "none"
+    And the snapshot topology targets of "00001" snapshot "snapshot1" are "00002,00003"
+    And the snapshot topology origin of "00003" is "00001:snapshot1"
+
+  Scenario Outline: Snapshot limits enforced by the array
+    Given a valid connection
+    And I have 3 volumes
+    And the mock allows 3 snapshots per volume
+    And I call CreateSnapshot with "00001,00002" and snapshot "snapshot1" on it
+    And I call CreateSnapshot with "00001" and snapshot "snapshot2" on it
+    When I call CreateSnapshot with <volIDs> and snapshot <snapID> on it
+    Then the error message contains <errormsg>
+    And the snapshot limit is reached <reached>
+
+    Examples:
+    | volIDs        | snapID      | errormsg                   | reached |
+    | "00002"       | "snapshot3" | "none"                     | "false" |
+    | "00001"       | "snapshot3" | "maximum number of snapsh" | "true"  |
+    | "00002,00001" | "snapshot3" | "maximum number of snapsh" | "true"  |
+    | "00001"       | "snapshot2" | "none"                     | "false" |
+
+  Scenario Outline: Snapshot headroom and limits checked by the client
+    Given a valid connection
+    And I have 3 volumes
+    And I call CreateSnapshot with "00001,00002" and snapshot "snapshot1" on it
+    And I call CreateSnapshot with "00001" and snapshot "snapshot2" on it
+    And the array allows <perVolume> snapshots per volume and <perArray> per array
+    And I have a new client checking snapshot limits
+    When I call GetSnapshotHeadroom with volume <volID>
+    Then the error message contains "none"
+    And the snapshot headroom is <available> with <volSnaps> volume snapshots and <arraySnaps> array snapshots if no error
+    And I call CreateSnapshot with <volID> and snapshot "snapshot3" on it
+    And the error message contains <errormsg>
+    And the snapshot limit is reached <reached>
+
+    Examples:
+    | perVolume | perArray | volID   | available | volSnaps | arraySnaps | errormsg           | reached |
+    | 0         | 0        | "00001" | 253       | 3        | 5          | "none"             | "false" |
+    | 3         | 0        | "00001" | 0         | 3        | 5          | "volume 00001 has" | "true"  |
+    | 3         | 0        | "00002" | 1         | 2        | 5          | "none"             | "false" |
+    | 5         | 5        | "00002" | 0         | 2        | 5          | "array"            | "true"  |
+    | 5         | 7        | "00003" | 2         | 0        | 5          | "none"             | "false" |
+
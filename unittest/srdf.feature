@@ -83,6 +83,21 @@ Feature: PMAX SRDF test
     |     "none"      |    "ignored as it is not managed" |  "ignored"  |
     | "httpStatus500" |          "Internal Error"         |      ""     |
 
+  @srdf
+  Scenario Outline: Create a volume in a protected storage-group with remote array errors
+    Given a valid connection
+    And I induce error <induced>
+    When I call CreateVolumeInProtectedStorageGroupS with name "IntgRemote"
+    Then the error message contains <errormsg>
+    And the storage group update failed on <failed>
+
+    Examples:
+    |             induced             |                   errormsg                      |  failed   |
+    |             "none"              |                    "none"                       |  "none"   |
+    | "UpdateRemoteStorageGroupError" | "Error updating remote Storage Group"           |  "remote" |
+    |  "UpdateLocalAndRemoteSGError"  | "Error updating Storage Group: induced error"   |  "both"   |
+    |    "UpdateStorageGroupError"    | "Error updating Storage Group: induced error"   |  "none"   |
+
   @srdf
   Scenario Outline: Add volumes to protected storage-group
     Given a valid connection
@@ -151,3 +166,150 @@ Feature: PMAX SRDF test
   |     "none"      |     "not a supported action"      |      ""     |   "Dance"   |
   |     "none"      |    "ignored as it is not managed" |  "ignored"  |  "Suspend"  |
   | "httpStatus500" |          "Internal Error"         |      ""     |  "Suspend"  |
+
+  @srdf
+  Scenario Outline: Create RDF pairs in an existing RDF group
+    Given a valid connection
+    And I have an allowed list of <arrays>
+    And I induce error <induced>
+    And I have <vols> volumes
+    When I call CreateRDFPairInGroup with RDF group <rdfg>, mode <mode> and remote storage group <remoteSG>
+    Then the error message contains <errormsg>
+    And the RDF pair job status is <status>
+    And the RDF pair of "00001" is "SyncInProg" if no error
+    And the storage group <remoteSG> has volumes <remoteVols> if no error
+
+  Examples:
+  |       induced        |  vols  |  rdfg  |   mode    |      remoteSG       |   remoteVols    |              errormsg                         |   status    |  arrays     |
+  |       "none"         |   2    |   13   |  "ASYNC"  |  "CSI-Test-SG-2"    |  "00001,00002"  |               "none"                          | "SUCCEEDED" |      ""     |
+  |       "none"         |   1    |   13   |  "METRO"  |  ""                 |  ""             |               "none"                          | "SUCCEEDED" |      ""     |
+  |       "none"         |   1    |   13   |  "STAR"   |  ""                 |  ""             |         "not a supported RDF mode"            |  ""         |      ""     |
+  |       "none"         |   0    |   13   |  "ASYNC"  |  ""                 |  ""             |  "at least one volume id has to be specified" |  ""         |      ""     |
+  |       "none"         |   1    |   14   |  "ASYNC"  |  ""                 |  ""             |          "RA group is not valid"              |  ""         |      ""     |
+  | "CreateRDFPairError" |   1    |   13   |  "SYNC"   |  ""                 |  ""             |             "job failed"                      |  "FAILED"   |      ""     |
+  |       "none"         |   1    |   13   |  "ASYNC"  |  ""                 |  ""             |     "ignored as it is not managed"            |  ""         |  "ignored"  |
+
+  @srdf
+  Scenario Outline: Delete an RDF pair
+    Given a valid connection
+    And I have an allowed list of <arrays>
+    And I have 2 volumes
+    And I call CreateRDFPairInGroup with RDF group 13, mode "ASYNC" and remote storage group ""
+    And I induce error <induced>
+    When I call DeleteRDFPair for <volID>
+    Then the error message contains <errormsg>
+    And the volume <volID> has no RDF pair if no error
+
+  Examples:
+  |       induced        |  volID   |            errormsg               |  arrays     |
+  |       "none"         | "00001"  |              "none"               |      ""     |
+  |       "none"         | "00003"  |       "not an RDF device"         |      ""     |
+  | "DeleteRDFPairError" | "00002"  |          "induced error"          |      ""     |
+  |       "none"         | "00001"  |    "ignored as it is not managed" |  "ignored"  |
+
+  @srdf
+  Scenario Outline: List RDF directors
+    Given a valid connection
+    And I have an allowed list of <arrays>
+    And I induce error <induced>
+    And the RDF director "RF-2F" is offline
+    When I call GetRDFDirectorList with online <online>
+    Then the error message contains <errormsg>
+    And I get RDF directors <directors> if no error
+
+  Examples:
+  |        induced        |            errormsg               |  arrays     |  online  |    directors     |
+  |        "none"         |              "none"               |      ""     |  "false" |  "RF-1F,RF-2F"   |
+  |        "none"         |              "none"               |      ""     |  "true"  |  "RF-1F"         |
+  | "GetRDFDirectorError" |          "induced error"          |      ""     |  "false" |  ""              |
+  |        "none"         |    "ignored as it is not managed" |  "ignored"  |  "false" |  ""              |
+
+  @srdf
+  Scenario Outline: List RDF ports of a director
+    Given a valid connection
+    And I have an allowed list of <arrays>
+    And I induce error <induced>
+    And the RDF port "RF-1F":"1" is offline
+    When I call GetRDFPortList for <director> with online <online>
+    Then the error message contains <errormsg>
+    And I get RDF ports <ports> if no error
+
+  Examples:
+  |      induced      |            errormsg               |  arrays     |  director  |  online  |  ports  |
+  |      "none"       |              "none"               |      ""     |  "RF-1F"   |  "false" |  "0,1"  |
+  |      "none"       |              "none"               |      ""     |  "RF-1F"   |  "true"  |  "0"    |
+  |      "none"       |              "none"               |      ""     |  "RF-2F"   |  "true"  |  "0,1"  |
+  |      "none"       |          "cannot be found"        |      ""     |  "SE-1E"   |  "false" |  ""     |
+  | "GetRDFPortError" |          "induced error"          |      ""     |  "RF-1F"   |  "false" |  ""     |
+  |      "none"       |    "ignored as it is not managed" |  "ignored"  |  "RF-1F"   |  "false" |  ""     |
+
+  @srdf
+  Scenario Outline: Create a protected storage group
+    Given a valid connection
+    And I have an allowed list of <arrays>
+    And I induce error <induced>
+    When I call CreateProtectedStorageGroup "CSI-Protected-SG" with snapshot policies <policies> and SRDF <srdf>
+    Then the error message contains <errormsg>
+    And the storage group "CSI-Protected-SG" exists <exists> with snapshot policies <policies> and SRDF <srdf>
+
+  Examples:
+  |          induced          |  policies        |  srdf    |            errormsg                      |  exists  |  arrays     |
+  |          "none"           |  "Daily,Weekly"  |  "true"  |              "none"                      |  "true"  |      ""     |
+  |          "none"           |  "Daily"         |  "false" |              "none"                      |  "true"  |      ""     |
+  |          "none"           |  ""              |  "true"  |              "none"                      |  "true"  |      ""     |
+  |          "none"           |  ""              |  "false" |              "none"                      |  "true"  |      ""     |
+  |  "CreateSGReplicaError"   |  "Daily"         |  "true"  |  "failed to protect storage group"       |  "false" |      ""     |
+  | "EditSnapshotPolicyError" |  "Daily"         |  "true"  |  "failed to associate snapshot policies" |  "false" |      ""     |
+  | "CreateStorageGroupError" |  "Daily"         |  "true"  |            "induced error"               |  "false" |      ""     |
+  |          "none"           |  "Daily"         |  "true"  |   "ignored as it is not managed"         |  "false" |  "ignored"  |
+
+  @srdf
+  Scenario Outline: Roll back the remote storage group of a protected storage group
+    Given a valid connection
+    And I induce error <induced>
+    And the SRDF protection fails after creating the remote storage group
+    When I call CreateProtectedStorageGroup "CSI-Protected-SG" with SRDF to the remote storage group "CSI-Protected-SG-R2"
+    Then the error message contains <errormsg>
+    And the rollback left behind <leftbehind>
+    And the storage group "CSI-Protected-SG-R2" exists <exists> with snapshot policies "" and SRDF "false"
+
+  Examples:
+  |          induced          |             errormsg               |                                                  leftbehind                                                   |  exists  |
+  |          "none"           |  "failed to protect storage group" |                                                      ""                                                       |  "false" |
+  | "DeleteStorageGroupError" |  "the rollback left behind"        |  "storage group CSI-Protected-SG-R2 on array 000000000013,storage group CSI-Protected-SG on array 000197900046"  |  "true"  |
+
+  @srdf
+  Scenario Outline: Change the SRDF mode of a protected storage group
+    Given a valid connection
+    And I have an allowed list of <arrays>
+    And I induce error <induced>
+    And the SRDF pairs are in mode <from> and state <state>
+    When I call SetRDFMode <to>
+    Then the error message contains <errormsg>
+    And the SRDF pairs have mode <mode> and state <endstate>
+
+  Examples:
+  | induced           | from           | state          | to      | errormsg                                    | mode           | endstate       | arrays    |
+  | "none"            | "Asynchronous" | "Consistent"   | "SYNC"  | "none"                                      | "Synchronous"  | "Synchronized" | ""        |
+  | "none"            | "Synchronous"  | "Synchronized" | "ASYNC" | "none"                                      | "Asynchronous" | "Consistent"   | ""        |
+  | "none"            | "Synchronous"  | "Suspended"    | "ASYNC" | "none"                                      | "Asynchronous" | "Suspended"    | ""        |
+  | "none"            | "Asynchronous" | "Consistent"   | "ASYNC" | "none"                                      | "Asynchronous" | "Consistent"   | ""        |
+  | "none"            | "Asynchronous" | "Consistent"   | "STAR"  | "not a supported SRDF mode"                 | "Asynchronous" | "Consistent"   | ""        |
+  | "none"            | "Active"       | "ActiveBias"   | "SYNC"  | "from METRO to SYNC: delete the SRDF/Metro" | "Active"       | "ActiveBias"   | ""        |
+  | "none"            | "Asynchronous" | "Consistent"   | "METRO" | "from ASYNC to METRO: delete the pairs"     | "Asynchronous" | "Consistent"   | ""        |
+  | "none"            | "Asynchronous" | "Failed Over"  | "SYNC"  | "Failback first"                            | "Asynchronous" | "Failed Over"  | ""        |
+  | "none"            | "Asynchronous" | "Partitioned"  | "SYNC"  | "restore the SRDF links first"              | "Asynchronous" | "Partitioned"  | ""        |
+  | "SetRDFModeError" | "Asynchronous" | "Consistent"   | "SYNC"  | "its pairs are left suspended"              | "Asynchronous" | "Suspended"    | ""        |
+  | "httpStatus500"   | "Asynchronous" | "Consistent"   | "SYNC"  | "Internal Error"                            | "Asynchronous" | "Consistent"   | ""        |
+  | "none"            | "Asynchronous" | "Consistent"   | "SYNC"  | "ignored as it is not managed"              | "Asynchronous" | "Consistent"   | "ignored" |
+
+  @srdf
+  Scenario: A failed over storage group requires a failback before its SRDF mode changes
+    Given a valid connection
+    And the SRDF pairs are in mode "Asynchronous" and state "Failed Over"
+    When I call SetRDFMode "SYNC"
+    Then the error requires the step "Failback"
+    When I call ExecuteAction "Failback"
+    And I call SetRDFMode "SYNC"
+    Then the error message contains "none"
+    And the SRDF pairs have mode "Synchronous" and state "Synchronized"
@@ -0,0 +1,231 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	types "github.com/dell/gopowermax/types/v90"
+	log "github.com/sirupsen/logrus"
+)
+
+// UnmapOptions decide what UnmapVolumeFromHostWithOptions does with the masking views and storage groups
+// it leaves without volumes
+type UnmapOptions struct {
+	// DeleteEmptyMaskingViews deletes a masking view before removing its last volume, which the array
+	// does not allow. Without it, unmapping the last volume of a masking view fails.
+	DeleteEmptyMaskingViews bool
+	// DeleteEmptyStorageGroups deletes the storage groups the volume is removed from once they are empty
+	// and in no masking view, along with the parent storage group of a deleted masking view
+	DeleteEmptyStorageGroups bool
+}
+
+// UnmapResult lists the changes made by UnmapVolumeFromHost
+type UnmapResult struct {
+	// StorageGroups are the storage groups the volume was removed from
+	StorageGroups []string
+	// DeletedMaskingViews are the masking views deleted as the volume was their last one
+	DeletedMaskingViews []string
+	// DeletedStorageGroups are the storage groups deleted as they were left empty
+	DeletedStorageGroups []string
+}
+
+// SharedStorageGroupError is returned by UnmapVolumeFromHost when the volume is exposed to the host through
+// a storage group which is in other masking views too: removing the volume from it would unmap it from the
+// hosts of those masking views as well
+type SharedStorageGroupError struct {
+	StorageGroupID string
+	MaskingViewIDs []string
+}
+
+func (e *SharedStorageGroupError) Error() string {
+	return fmt.Sprintf("storage group %s is shared with masking view(s) %s", e.StorageGroupID, strings.Join(e.MaskingViewIDs, ", "))
+}
+
+// UnmapVolumeFromHost removes a volume from the storage groups which expose it to a host through the host's
+// masking views, with the default UnmapOptions: unmapping the last volume of a masking view fails.
+func (c *Client) UnmapVolumeFromHost(ctx context.Context, symID string, volumeID string, hostID string) (*UnmapResult, error) {
+	return c.UnmapVolumeFromHostWithOptions(ctx, symID, volumeID, hostID, UnmapOptions{})
+}
+
+// UnmapVolumeFromHostWithOptions removes a volume from the storage groups which expose it to a host through the
+// host's masking views. The storage group of a masking view may be a parent storage group, in which case the volume
+// is removed from the child storage groups holding it. Unmapping a volume which is not exposed to the host is not
+// an error. A *SharedStorageGroupError is returned, before any change to the masking view, if the volume would
+// have to be removed from a storage group which other masking views use. On failure, the result lists the
+// changes made before it.
+func (c *Client) UnmapVolumeFromHostWithOptions(ctx context.Context, symID string, volumeID string, hostID string, options UnmapOptions) (*UnmapResult, error) {
+	defer c.TimeSpent("UnmapVolumeFromHost", time.Now())
+	if _, err := c.IsAllowedArray(symID); err != nil {
+		return nil, err
+	}
+	result := &UnmapResult{
+		StorageGroups:        make([]string, 0),
+		DeletedMaskingViews:  make([]string, 0),
+		DeletedStorageGroups: make([]string, 0),
+	}
+	host, err := c.GetHostByID(ctx, symID, hostID)
+	if err != nil {
+		return nil, err
+	}
+	for _, maskingViewID := range host.MaskingviewIDs {
+		if err := c.unmapVolumeFromMaskingView(ctx, symID, volumeID, maskingViewID, options, result); err != nil {
+			log.Error(fmt.Sprintf("UnmapVolumeFromHost of volume %s from host %s failed: %s", volumeID, hostID, err.Error()))
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// unmapVolumeFromMaskingView removes the volume from the storage groups of the masking view holding it
+func (c *Client) unmapVolumeFromMaskingView(ctx context.Context, symID, volumeID, maskingViewID string, options UnmapOptions, result *UnmapResult) error {
+	volume, err := c.GetVolumeByID(ctx, symID, volumeID)
+	if err != nil {
+		return err
+	}
+	maskingView, err := c.GetMaskingViewByID(ctx, symID, maskingViewID)
+	if err != nil {
+		return err
+	}
+	storageGroup, err := c.GetStorageGroup(ctx, symID, maskingView.StorageGroupID)
+	if err != nil {
+		return err
+	}
+	cascaded := len(storageGroup.ChildStorageGroup) > 0
+	members := []string{storageGroup.StorageGroupID}
+	if cascaded {
+		members = storageGroup.ChildStorageGroup
+	}
+	targets := make([]string, 0)
+	for _, sgID := range volume.StorageGroupNames() {
+		if stringInSlice(sgID, members) {
+			targets = append(targets, sgID)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	for _, sgID := range targets {
+		target := storageGroup
+		if sgID != storageGroup.StorageGroupID {
+			if target, err = c.GetStorageGroup(ctx, symID, sgID); err != nil {
+				return err
+			}
+		}
+		others, err := c.otherMaskingViews(ctx, symID, target, maskingViewID)
+		if err != nil {
+			return err
+		}
+		if len(others) > 0 {
+			return &SharedStorageGroupError{StorageGroupID: sgID, MaskingViewIDs: others}
+		}
+	}
+
+	// The array refuses to remove the last volume of a masking view, which must be deleted first
+	last := true
+	for _, sgID := range members {
+		volumeIDs, err := c.GetVolumeIDListInStorageGroup(ctx, symID, sgID)
+		if err != nil {
+			return err
+		}
+		for _, id := range volumeIDs {
+			if id != volumeID {
+				last = false
+			}
+		}
+	}
+	if last {
+		if !options.DeleteEmptyMaskingViews {
+			return fmt.Errorf("volume %s is the last volume of masking view %s, which must be deleted to unmap it", volumeID, maskingViewID)
+		}
+		if err := c.DeleteMaskingView(ctx, symID, maskingViewID); err != nil {
+			return err
+		}
+		result.DeletedMaskingViews = append(result.DeletedMaskingViews, maskingViewID)
+	}
+	for _, sgID := range targets {
+		if _, err := c.RemoveVolumesFromStorageGroup(ctx, symID, sgID, false, volumeID); err != nil {
+			return err
+		}
+		result.StorageGroups = append(result.StorageGroups, sgID)
+	}
+	if !options.DeleteEmptyStorageGroups {
+		return nil
+	}
+
+	if cascaded && last {
+		// The children of an empty parent storage group are detached so that they can all be deleted
+		payload := &types.UpdateStorageGroupPayload{
+			EditStorageGroupActionParam: types.EditStorageGroupActionParam{
+				RemoveStorageGroupParam: &types.RemoveStorageGroupParam{
+					StorageGroupIDs: members,
+				},
+			},
+			ExecutionOption: types.ExecutionOptionSynchronous,
+		}
+		if err := c.UpdateStorageGroupS(ctx, symID, storageGroup.StorageGroupID, payload); err != nil {
+			return err
+		}
+		targets = append([]string{storageGroup.StorageGroupID}, members...)
+	}
+	for _, sgID := range targets {
+		if err := c.deleteStorageGroupIfUnused(ctx, symID, sgID, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// otherMaskingViews returns the masking views other than maskingViewID which expose the volumes of the storage
+// group, directly or through its parent storage groups
+func (c *Client) otherMaskingViews(ctx context.Context, symID string, storageGroup *types.StorageGroup, maskingViewID string) ([]string, error) {
+	maskingViewIDs := append([]string{}, storageGroup.MaskingView...)
+	for _, parentID := range storageGroup.ParentStorageGroup {
+		parent, err := c.GetStorageGroup(ctx, symID, parentID)
+		if err != nil {
+			return nil, err
+		}
+		maskingViewIDs = append(maskingViewIDs, parent.MaskingView...)
+	}
+	others := make([]string, 0)
+	for _, mvID := range maskingViewIDs {
+		if mvID != maskingViewID && !stringInSlice(mvID, others) {
+			others = append(others, mvID)
+		}
+	}
+	sort.Strings(others)
+	return others, nil
+}
+
+// deleteStorageGroupIfUnused deletes the storage group if it has no volumes, no masking view and no relatives
+func (c *Client) deleteStorageGroupIfUnused(ctx context.Context, symID, storageGroupID string, result *UnmapResult) error {
+	storageGroup, err := c.GetStorageGroup(ctx, symID, storageGroupID)
+	if err != nil {
+		return err
+	}
+	if storageGroup.NumOfVolumes > 0 || storageGroup.NumOfMaskingViews > 0 ||
+		len(storageGroup.ChildStorageGroup) > 0 || len(storageGroup.ParentStorageGroup) > 0 {
+		return nil
+	}
+	if err := c.DeleteStorageGroup(ctx, symID, storageGroupID); err != nil {
+		return err
+	}
+	result.DeletedStorageGroups = append(result.DeletedStorageGroups, storageGroupID)
+	return nil
+}
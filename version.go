@@ -0,0 +1,173 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// APIVersionInfo describes an API version supported by the library
+type APIVersionInfo struct {
+	// Version is the API version, e.g. "91"
+	Version string
+	// Unisphere is the first Unisphere release serving the API version, e.g. "9.1"
+	Unisphere string
+	// VersionEndpoint is the path, under RESTPrefix, of the endpoint returning the Unisphere version
+	VersionEndpoint string
+}
+
+// supportedAPIVersions are the API versions supported by the library, oldest first
+var supportedAPIVersions = []APIVersionInfo{
+	{Version: APIVersion90, Unisphere: "9.0", VersionEndpoint: APIVersion90 + "/system/version"},
+	{Version: APIVersion91, Unisphere: "9.1", VersionEndpoint: "version"},
+}
+
+var (
+	apiVersionRegexp       = regexp.MustCompile(`^[0-9]{2}$`)
+	unisphereVersionRegexp = regexp.MustCompile(`^([0-9]+)\.([0-9]+)(\.[0-9]+)*$`)
+)
+
+// APIVersionError is returned for an API version which cannot be used. Guidance tells what to configure instead.
+type APIVersionError struct {
+	Version  string
+	Reason   string
+	Guidance string
+}
+
+func (e *APIVersionError) Error() string {
+	return fmt.Sprintf("API version %q %s: %s", e.Version, e.Reason, e.Guidance)
+}
+
+// SupportedVersions returns the API versions supported by the library, oldest first
+func SupportedVersions() []APIVersionInfo {
+	versions := make([]APIVersionInfo, len(supportedAPIVersions))
+	copy(versions, supportedAPIVersions)
+	return versions
+}
+
+// supportedVersionList returns the API versions supported by the library, for the guidance of the errors
+func supportedVersionList() string {
+	versions := make([]string, 0, len(supportedAPIVersions))
+	for _, v := range supportedAPIVersions {
+		versions = append(versions, v.Version)
+	}
+	return strings.Join(versions, ", ")
+}
+
+// apiVersionOf returns the API version of a version string, either an API version such as "91"
+// or a Unisphere release such as "9.1" or "V9.1.0.2", or "" if it is neither
+func apiVersionOf(version string) string {
+	version = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(version), "V"), "v")
+	if apiVersionRegexp.MatchString(version) {
+		return version
+	}
+	if match := unisphereVersionRegexp.FindStringSubmatch(version); match != nil {
+		return match[1] + match[2]
+	}
+	return ""
+}
+
+// isNewerAPIVersion returns true if the API version a is newer than b, e.g. "100" than "91"
+func isNewerAPIVersion(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+	return a > b
+}
+
+// ParseAPIVersion returns the API version of a version string, either an API version such as "91"
+// or a Unisphere release such as "9.1" or "V9.1.0.2". It fails with an APIVersionError if the
+// string is not a version or if the library does not support the API version.
+func ParseAPIVersion(version string) (string, error) {
+	apiVersion := apiVersionOf(version)
+	if apiVersion == "" {
+		return "", &APIVersionError{
+			Version:  version,
+			Reason:   "is not a valid version",
+			Guidance: fmt.Sprintf("use an API version such as %s, or a Unisphere release such as 9.1", APIVersion91),
+		}
+	}
+	for _, v := range supportedAPIVersions {
+		if v.Version == apiVersion {
+			return apiVersion, nil
+		}
+	}
+	guidance := fmt.Sprintf("use one of the API versions %s", supportedVersionList())
+	if isNewerAPIVersion(apiVersion, supportedAPIVersions[len(supportedAPIVersions)-1].Version) {
+		guidance += ", which the newer Unisphere releases still serve, or upgrade gopowermax"
+	}
+	return "", &APIVersionError{
+		Version:  version,
+		Reason:   "is not supported by gopowermax",
+		Guidance: guidance,
+	}
+}
+
+// CheckAPIVersion checks that the API version of the client is supported by both the library and the
+// Unisphere server, so that a misconfiguration fails at startup rather than on the first call using it.
+// Unsupported versions fail with an APIVersionError naming the versions to use instead.
+func (c *Client) CheckAPIVersion(ctx context.Context) error {
+	apiVersion, err := ParseAPIVersion(c.version)
+	if err != nil {
+		return err
+	}
+	info, err := c.GetUnisphereInfo(ctx)
+	if errors.Is(err, ErrNotFound) {
+		return &APIVersionError{
+			Version:  c.version,
+			Reason:   "is not served by Unisphere, which has no version endpoint for it",
+			Guidance: fmt.Sprintf("check the endpoint, or use API version %s for a Unisphere release older than 9.1", APIVersion90),
+		}
+	} else if err != nil {
+		return fmt.Errorf("checking API version %s against Unisphere failed: %w", apiVersion, err)
+	}
+
+	if len(info.SupportedAPIVersions) > 0 {
+		usable := make([]string, 0)
+		for _, v := range supportedAPIVersions {
+			if stringInSlice(v.Version, info.SupportedAPIVersions) {
+				usable = append(usable, v.Version)
+			}
+		}
+		if stringInSlice(apiVersion, usable) {
+			return nil
+		}
+		guidance := fmt.Sprintf("Unisphere serves %s and gopowermax supports %s, upgrade gopowermax",
+			strings.Join(info.SupportedAPIVersions, ", "), supportedVersionList())
+		if len(usable) > 0 {
+			guidance = fmt.Sprintf("use one of the API versions %s", strings.Join(usable, ", "))
+		}
+		return &APIVersionError{
+			Version:  c.version,
+			Reason:   fmt.Sprintf("is not supported by Unisphere %s", info.Version),
+			Guidance: guidance,
+		}
+	}
+
+	// Releases which do not list the API versions they serve serve those up to their own
+	serverVersion := apiVersionOf(info.Version)
+	if serverVersion != "" && isNewerAPIVersion(apiVersion, serverVersion) {
+		return &APIVersionError{
+			Version:  c.version,
+			Reason:   fmt.Sprintf("is newer than Unisphere %s", info.Version),
+			Guidance: fmt.Sprintf("use API version %s or upgrade Unisphere", serverVersion),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_ParseAPIVersion(t *testing.T) {
+	var tests = []struct {
+		name     string
+		version  string
+		expected string
+		reason   string
+	}{
+		{"API version", "91", "91", ""},
+		{"Unisphere release", "9.0", "90", ""},
+		{"Unisphere version", "V9.1.0.2", "91", ""},
+		{"padded", " 90 ", "90", ""},
+		{"unsupported", "92", "", "is not supported by gopowermax"},
+		{"unsupported release", "10.0", "", "is not supported by gopowermax"},
+		{"not a version", "latest", "", "is not a valid version"},
+		{"empty", "", "", "is not a valid version"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			apiVersion, err := ParseAPIVersion(tt.version)
+			if apiVersion != tt.expected {
+				t.Errorf("ParseAPIVersion(%s) = %s; expected %s", tt.version, apiVersion, tt.expected)
+			}
+			var versionError *APIVersionError
+			if tt.reason == "" && err != nil {
+				t.Errorf("ParseAPIVersion(%s) failed: %v", tt.version, err)
+			} else if tt.reason != "" && (!errors.As(err, &versionError) || versionError.Reason != tt.reason) {
+				t.Errorf("ParseAPIVersion(%s) = %v; expected an APIVersionError which %s", tt.version, err, tt.reason)
+			}
+		})
+	}
+}
+
+func Test_SupportedVersions(t *testing.T) {
+	versions := SupportedVersions()
+	for _, v := range versions {
+		if apiVersion, err := ParseAPIVersion(v.Unisphere); err != nil || apiVersion != v.Version {
+			t.Errorf("the Unisphere release %s of API version %s parses as %s, %v", v.Unisphere, v.Version, apiVersion, err)
+		}
+	}
+	versions[0].Version = "00"
+	if SupportedVersions()[0].Version == "00" {
+		t.Errorf("SupportedVersions returned the table of the library instead of a copy")
+	}
+}
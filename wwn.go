@@ -0,0 +1,117 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The native WWN of a PowerMax volume is the NAA prefix, the 12 digit array ID, the
+// 'S' character and the 5 character device ID, each character being hex encoded.
+const (
+	volumeWWNPrefix    = "60000970"
+	volumeWWNSeparator = "53"
+	volumeWWNLength    = len(volumeWWNPrefix) + 12 + len(volumeWWNSeparator) + 2*5
+)
+
+var (
+	fcWWNRegex    = regexp.MustCompile(`^[0-9a-f]{16}$`)
+	symIDRegex    = regexp.MustCompile(`^[0-9]{12}$`)
+	deviceIDRegex = regexp.MustCompile(`^[0-9A-F]{5}$`)
+	iqnRegex      = regexp.MustCompile(`^iqn\.[0-9]{4}-[0-9]{2}\.[a-z0-9]+([.-][a-z0-9]+)*(:[^\s]+)?$`)
+	euiRegex      = regexp.MustCompile(`^eui\.[0-9A-Fa-f]{16}$`)
+	nqnRegex      = regexp.MustCompile(`^nqn\.[0-9]{4}-[0-9]{2}\.[a-z0-9]+([.-][a-z0-9]+)*:[^\s]+$`)
+)
+
+// maxISCSINameLength is the maximum length of an iSCSI or NVMe qualified name
+const maxISCSINameLength = 223
+
+// NormalizeFCWWN returns the FC WWN in the form used by Unisphere, i.e. 16 lower case hex
+// digits without colons, e.g. "10:00:00:90:FA:66:06:0A" becomes "10000090fa66060a".
+// A "0x" prefix is accepted as well.
+func NormalizeFCWWN(wwn string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(wwn))
+	normalized = strings.TrimPrefix(normalized, "0x")
+	normalized = strings.Replace(normalized, ":", "", -1)
+	if !fcWWNRegex.MatchString(normalized) {
+		return "", fmt.Errorf("Invalid FC WWN %s, it must have 16 hex digits", wwn)
+	}
+	return normalized, nil
+}
+
+// FormatFCWWN returns the FC WWN as colon separated lower case bytes, e.g. "10:00:00:90:fa:66:06:0a".
+func FormatFCWWN(wwn string) (string, error) {
+	normalized, err := NormalizeFCWWN(wwn)
+	if err != nil {
+		return "", err
+	}
+	bytes := make([]string, 0, len(normalized)/2)
+	for i := 0; i < len(normalized); i += 2 {
+		bytes = append(bytes, normalized[i:i+2])
+	}
+	return strings.Join(bytes, ":"), nil
+}
+
+// IsValidIQN returns true if iqn is an iSCSI qualified name, e.g. "iqn.1993-08.org.debian:01:8f21cc8ad2a7",
+// or an EUI-64 name, e.g. "eui.0123456789ABCDEF".
+func IsValidIQN(iqn string) bool {
+	if len(iqn) > maxISCSINameLength {
+		return false
+	}
+	return iqnRegex.MatchString(iqn) || euiRegex.MatchString(iqn)
+}
+
+// IsValidNQN returns true if nqn is an NVMe qualified name, e.g.
+// "nqn.2014-08.org.nvmexpress:uuid:f81d4fae-7dec-11d0-a765-00a0c91e6bf6".
+func IsValidNQN(nqn string) bool {
+	if len(nqn) > maxISCSINameLength {
+		return false
+	}
+	return nqnRegex.MatchString(nqn)
+}
+
+// VolumeWWN returns the native WWN of the volume with the 5 digit deviceID on the array symID.
+func VolumeWWN(symID, deviceID string) (string, error) {
+	if !symIDRegex.MatchString(symID) {
+		return "", fmt.Errorf("Invalid array ID %s, it must have 12 digits", symID)
+	}
+	deviceID = strings.ToUpper(deviceID)
+	if !deviceIDRegex.MatchString(deviceID) {
+		return "", fmt.Errorf("Invalid device ID %s, it must have 5 hex digits", deviceID)
+	}
+	return volumeWWNPrefix + symID + volumeWWNSeparator + hex.EncodeToString([]byte(deviceID)), nil
+}
+
+// DeviceIDFromVolumeWWN returns the array ID and the 5 digit device ID encoded in the native
+// WWN of a volume. The WWN is case insensitive and may contain colons.
+func DeviceIDFromVolumeWWN(wwn string) (symID string, deviceID string, err error) {
+	normalized := strings.ToLower(strings.Replace(strings.TrimSpace(wwn), ":", "", -1))
+	if len(normalized) != volumeWWNLength || !strings.HasPrefix(normalized, volumeWWNPrefix) {
+		return "", "", fmt.Errorf("Invalid volume WWN %s, it is not a PowerMax WWN", wwn)
+	}
+	symID = normalized[len(volumeWWNPrefix) : len(volumeWWNPrefix)+12]
+	rest := normalized[len(volumeWWNPrefix)+12:]
+	if !symIDRegex.MatchString(symID) || !strings.HasPrefix(rest, volumeWWNSeparator) {
+		return "", "", fmt.Errorf("Invalid volume WWN %s, it is not a PowerMax WWN", wwn)
+	}
+	decoded, err := hex.DecodeString(rest[len(volumeWWNSeparator):])
+	if err != nil || !deviceIDRegex.MatchString(string(decoded)) {
+		return "", "", fmt.Errorf("Invalid volume WWN %s, it does not encode a device ID", wwn)
+	}
+	return symID, string(decoded), nil
+}
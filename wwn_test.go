@@ -0,0 +1,132 @@
+/*
+ Copyright © 2020 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pmax
+
+import "testing"
+
+func Test_NormalizeFCWWN(t *testing.T) {
+	var tests = []struct {
+		name      string
+		wwn       string
+		expected  string
+		formatted string
+		wantErr   bool
+	}{
+		{"lower case", "10000090fa66060a", "10000090fa66060a", "10:00:00:90:fa:66:06:0a", false},
+		{"upper case with colons", "10:00:00:90:FA:66:06:0A", "10000090fa66060a", "10:00:00:90:fa:66:06:0a", false},
+		{"hex prefix", "0x10000090FA66060A", "10000090fa66060a", "10:00:00:90:fa:66:06:0a", false},
+		{"too short", "10000090fa6606", "", "", true},
+		{"not hex", "10000090fa66060g", "", "", true},
+		{"empty", "", "", "", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			normalized, err := NormalizeFCWWN(tt.wwn)
+			if (err != nil) != tt.wantErr || normalized != tt.expected {
+				t.Errorf("NormalizeFCWWN(%s) = %s, %v; expected %s", tt.wwn, normalized, err, tt.expected)
+			}
+			formatted, err := FormatFCWWN(tt.wwn)
+			if (err != nil) != tt.wantErr || formatted != tt.formatted {
+				t.Errorf("FormatFCWWN(%s) = %s, %v; expected %s", tt.wwn, formatted, err, tt.formatted)
+			}
+		})
+	}
+}
+
+func Test_IsValidIQNAndNQN(t *testing.T) {
+	var tests = []struct {
+		name string
+		id   string
+		iqn  bool
+		nqn  bool
+	}{
+		{"iqn", "iqn.1993-08.org.debian:01:8f21cc8ad2a7", true, false},
+		{"iqn without suffix", "iqn.1992-04.com.emc", true, false},
+		{"eui", "eui.0123456789ABCDEF", true, false},
+		{"iqn with bad date", "iqn.93-08.org.debian:01:8f21cc8ad2a7", false, false},
+		{"nqn", "nqn.2014-08.org.nvmexpress:uuid:f81d4fae-7dec-11d0-a765-00a0c91e6bf6", false, true},
+		{"nqn without suffix", "nqn.2014-08.org.nvmexpress", false, false},
+		{"fc wwn", "10000090fa66060a", false, false},
+		{"empty", "", false, false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if IsValidIQN(tt.id) != tt.iqn {
+				t.Errorf("IsValidIQN(%s) expected %v", tt.id, tt.iqn)
+			}
+			if IsValidNQN(tt.id) != tt.nqn {
+				t.Errorf("IsValidNQN(%s) expected %v", tt.id, tt.nqn)
+			}
+		})
+	}
+}
+
+func Test_VolumeWWN(t *testing.T) {
+	var tests = []struct {
+		name     string
+		symID    string
+		deviceID string
+		wwn      string
+		wantErr  bool
+	}{
+		{"device", "000197900046", "00115", "60000970000197900046533030313135", false},
+		{"hex device", "000197900046", "0A01F", "60000970000197900046533041303146", false},
+		{"bad array", "97900046", "00115", "", true},
+		{"bad device", "000197900046", "0011", "", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			wwn, err := VolumeWWN(tt.symID, tt.deviceID)
+			if (err != nil) != tt.wantErr || wwn != tt.wwn {
+				t.Errorf("VolumeWWN(%s, %s) = %s, %v; expected %s", tt.symID, tt.deviceID, wwn, err, tt.wwn)
+			}
+			if tt.wantErr {
+				return
+			}
+			symID, deviceID, err := DeviceIDFromVolumeWWN(wwn)
+			if err != nil || symID != tt.symID || deviceID != tt.deviceID {
+				t.Errorf("DeviceIDFromVolumeWWN(%s) = %s, %s, %v; expected %s, %s", wwn, symID, deviceID, err, tt.symID, tt.deviceID)
+			}
+		})
+	}
+}
+
+func Test_DeviceIDFromVolumeWWN(t *testing.T) {
+	var tests = []struct {
+		name     string
+		wwn      string
+		symID    string
+		deviceID string
+		wantErr  bool
+	}{
+		{"upper case", "60000970000197900046533030313135", "000197900046", "00115", false},
+		{"colons", "60:00:09:70:00:01:97:90:00:46:53:30:30:31:31:35", "000197900046", "00115", false},
+		{"other vendor", "60000980000197900046533030313135", "", "", true},
+		{"too short", "600009700001979000465330303131", "", "", true},
+		{"not a device ID", "60000970000197900046537a7a7a7a7a", "", "", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			symID, deviceID, err := DeviceIDFromVolumeWWN(tt.wwn)
+			if (err != nil) != tt.wantErr || symID != tt.symID || deviceID != tt.deviceID {
+				t.Errorf("DeviceIDFromVolumeWWN(%s) = %s, %s, %v; expected %s, %s", tt.wwn, symID, deviceID, err, tt.symID, tt.deviceID)
+			}
+		})
+	}
+}